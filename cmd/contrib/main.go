@@ -512,6 +512,7 @@ func {{ .PlatformNameUpperCamelCase }}CLI() schema.Executable {
 		DocsURL:   sdk.URL("https://{{ .Name }}.com/docs/cli"), // TODO: Replace with actual URL
 		NeedsAuth: needsauth.IfAll(
 			needsauth.NotForHelpOrVersion(),
+			needsauth.NotForShellCompletion(),
 			needsauth.NotWithoutArgs(),
 		),
 		{{- if .CredentialName }}
@@ -2,9 +2,12 @@ package aws
 
 import (
 	"context"
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"gopkg.in/ini.v1"
 
@@ -83,6 +86,75 @@ func TryCredentialsFile() sdk.Importer {
 	})
 }
 
+// ssoCachedToken is the access token that `aws sso login` caches in ~/.aws/sso/cache.
+type ssoCachedToken struct {
+	StartURL    string `json:"startUrl"`
+	Region      string `json:"region"`
+	AccessToken string `json:"accessToken"`
+	ExpiresAt   string `json:"expiresAt"`
+	SessionName string `json:"sessionName"`
+}
+
+// TrySSOTokenCache looks for the access tokens that `aws sso login` caches in ~/.aws/sso/cache. Expired tokens are
+// skipped. The directory also contains client registrations, which don't have an access token and are skipped too.
+// The candidates have an access token instead of an access key, so this importer is not part of AccessKey's importer.
+func TrySSOTokenCache() sdk.Importer {
+	return importer.TryAllFilesMatching("~/.aws/sso/cache/*.json", func(ctx context.Context, contents importer.FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+		var token ssoCachedToken
+		if err := contents.ToJSON(&token); err != nil {
+			out.AddError(err)
+			return
+		}
+		if token.AccessToken == "" || token.StartURL == "" {
+			return
+		}
+
+		expiresAt, err := parseSSOExpiry(token.ExpiresAt)
+		if err != nil {
+			out.AddError(err)
+			return
+		}
+
+		nameHint := token.SessionName
+		if nameHint == "" {
+			if startURL, err := url.Parse(token.StartURL); err == nil {
+				// The start URL is https://<portal>.awsapps.com/start, so the subdomain identifies the portal.
+				nameHint, _, _ = strings.Cut(startURL.Hostname(), ".")
+			}
+		}
+		if !expiresAt.After(time.Now()) {
+			out.AddNote(fmt.Sprintf("Skipped SSO token for %s: it expired at %s", nameHint, expiresAt.Format(time.RFC3339)))
+			return
+		}
+
+		fields := map[sdk.FieldName]string{
+			fieldname.AccessToken: token.AccessToken,
+			fieldname.StartURL:    token.StartURL,
+		}
+		if token.Region != "" {
+			fields[fieldname.Region] = token.Region
+		}
+		out.AddCandidate(sdk.ImportCandidate{
+			Fields:    fields,
+			NameHint:  importer.SanitizeNameHint(nameHint),
+			ExpiresAt: &expiresAt,
+		})
+	})
+}
+
+// parseSSOExpiry parses the expiry of a cached SSO token. Older versions of the AWS CLI wrote it with a "UTC" suffix
+// instead of in RFC 3339 format.
+func parseSSOExpiry(expiresAt string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, expiresAt); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02T15:04:05UTC", expiresAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiresAt '%s' in SSO token cache", expiresAt)
+	}
+	return t, nil
+}
+
 // Backend types from AWS Vault and their respective user-friendly display names
 // Details can be found at https://pkg.go.dev/github.com/99designs/keyring@v1.2.2#section-readme
 var backendNames = map[keyring.BackendType]string{
@@ -0,0 +1,78 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+)
+
+func TestSSOTokenCacheImporter(t *testing.T) {
+	expiresAt := time.Date(2099, 1, 1, 12, 0, 0, 0, time.UTC)
+	legacyExpiresAt := time.Date(2099, 6, 30, 8, 15, 0, 0, time.UTC)
+
+	plugintest.TestImporter(t, TrySSOTokenCache(), map[string]plugintest.ImportCase{
+		"token cache": {
+			Files: map[string]string{
+				"~/.aws/sso/cache/d033e22ae348aeb5660fc2140aec35850c4da997.json": plugintest.LoadFixture(t, "sso-cache-token.json"),
+				"~/.aws/sso/cache/4d4b9c1a0bd1b8e6e1d5c2a9a0b5a3f5e6c7d8e9.json": plugintest.LoadFixture(t, "sso-cache-legacy-token.json"),
+			},
+			ExpectedCandidates: []sdk.ImportCandidate{
+				{
+					Fields: map[sdk.FieldName]string{
+						fieldname.AccessToken: "aoaAAAAAGSwUh0example",
+						fieldname.StartURL:    "https://my-sso-portal.awsapps.com/start",
+						fieldname.Region:      "us-east-1",
+					},
+					NameHint:  "my-sso",
+					ExpiresAt: &expiresAt,
+				},
+				{
+					Fields: map[sdk.FieldName]string{
+						fieldname.AccessToken: "aoaAAAAAGSwUh0legacy",
+						fieldname.StartURL:    "https://legacy-portal.awsapps.com/start",
+						fieldname.Region:      "eu-west-1",
+					},
+					NameHint:  "legacy-portal",
+					ExpiresAt: &legacyExpiresAt,
+				},
+			},
+		},
+		"expired token and client registration": {
+			Files: map[string]string{
+				"~/.aws/sso/cache/1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b.json": plugintest.LoadFixture(t, "sso-cache-expired-token.json"),
+				"~/.aws/sso/cache/botocore-client-id-us-east-1.json":             plugintest.LoadFixture(t, "sso-cache-registration.json"),
+			},
+			ExpectedOutput: &sdk.ImportOutput{
+				Attempts: []*sdk.ImportAttempt{
+					{
+						Source: sdk.ImportSource{Files: []string{"~/.aws/sso/cache/1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b.json"}},
+						Diagnostics: sdk.Diagnostics{
+							Notes: []sdk.Note{{Message: "Skipped SSO token for old-session: it expired at 2021-03-01T09:00:00Z"}},
+						},
+					},
+					{
+						Source: sdk.ImportSource{Files: []string{"~/.aws/sso/cache/botocore-client-id-us-east-1.json"}},
+					},
+				},
+			},
+		},
+		"malformed expiry": {
+			Files: map[string]string{
+				"~/.aws/sso/cache/token.json": `{"startUrl": "https://my-sso-portal.awsapps.com/start", "accessToken": "token", "expiresAt": "tomorrow"}`,
+			},
+			ExpectedOutput: &sdk.ImportOutput{
+				Attempts: []*sdk.ImportAttempt{
+					{
+						Source: sdk.ImportSource{Files: []string{"~/.aws/sso/cache/token.json"}},
+						Diagnostics: sdk.Diagnostics{
+							Errors: []sdk.Error{{Message: "invalid expiresAt 'tomorrow' in SSO token cache"}},
+						},
+					},
+				},
+			},
+		},
+	})
+}
@@ -36,31 +36,32 @@ func PersonalAccessToken() schema.CredentialType {
 		}),
 		Importer: importer.TryAll(
 			importer.TryAllEnvVars(fieldname.Token, "DIGITALOCEAN_ACCESS_TOKEN"),
-			importer.MacOnly(
-				TryDigitalOceanConfigFile("~/Library/Application Support/doctl/config.yaml"),
-			),
+			TryDigitalOceanConfigFile(),
 		),
 	}
 }
 
-func TryDigitalOceanConfigFile(path string) sdk.Importer {
-	return importer.TryFile(path, func(ctx context.Context, contents importer.FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
-		var config Config
-		if err := contents.ToYAML(&config); err != nil {
-			out.AddError(err)
-			return
-		}
+// TryDigitalOceanConfigFile imports the token from doctl's config file, which is stored in the user's config directory.
+func TryDigitalOceanConfigFile() sdk.Importer {
+	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+		importer.TryFile(in.FromConfigDir("doctl", "config.yaml"), func(ctx context.Context, contents importer.FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+			var config Config
+			if err := contents.ToYAML(&config); err != nil {
+				out.AddError(err)
+				return
+			}
 
-		if config.AccessToken == "" {
-			return
-		}
+			if config.AccessToken == "" {
+				return
+			}
 
-		out.AddCandidate(sdk.ImportCandidate{
-			Fields: map[sdk.FieldName]string{
-				fieldname.Token: config.AccessToken,
-			},
-		})
-	})
+			out.AddCandidate(sdk.ImportCandidate{
+				Fields: map[sdk.FieldName]string{
+					fieldname.Token: config.AccessToken,
+				},
+			})
+		})(ctx, in, out)
+	}
 }
 
 type Config struct {
@@ -35,6 +35,53 @@ func TestPersonalAccessTokenImporter(t *testing.T) {
 				},
 			},
 		},
+		"config file linux": {
+			OS: "linux",
+			Files: map[string]string{
+				"~/.config/doctl/config.yaml": plugintest.LoadFixture(t, "config.yaml"),
+			},
+			ExpectedCandidates: []sdk.ImportCandidate{
+				{
+					Fields: map[sdk.FieldName]string{
+						fieldname.Token: "dop_v1_tr33mpd5m8q9t3ncisqbceydi8dd2n60pl1yiycg97z25fkqffp8j6ycjexample",
+					},
+				},
+			},
+		},
+		"config file windows": {
+			OS: "windows",
+			Files: map[string]string{
+				"~/AppData/Roaming/doctl/config.yaml": plugintest.LoadFixture(t, "config.yaml"),
+			},
+			ExpectedCandidates: []sdk.ImportCandidate{
+				{
+					Fields: map[sdk.FieldName]string{
+						fieldname.Token: "dop_v1_tr33mpd5m8q9t3ncisqbceydi8dd2n60pl1yiycg97z25fkqffp8j6ycjexample",
+					},
+				},
+			},
+		},
+		"config file in XDG config dir": {
+			OS:        "darwin",
+			ConfigDir: "~/.xdg/config",
+			Files: map[string]string{
+				"~/.xdg/config/doctl/config.yaml": plugintest.LoadFixture(t, "config.yaml"),
+			},
+			ExpectedCandidates: []sdk.ImportCandidate{
+				{
+					Fields: map[sdk.FieldName]string{
+						fieldname.Token: "dop_v1_tr33mpd5m8q9t3ncisqbceydi8dd2n60pl1yiycg97z25fkqffp8j6ycjexample",
+					},
+				},
+			},
+		},
+		"config file on other OS": {
+			OS: "linux",
+			Files: map[string]string{
+				"~/Library/Application Support/doctl/config.yaml": plugintest.LoadFixture(t, "config.yaml"),
+			},
+			ExpectedCandidates: []sdk.ImportCandidate{},
+		},
 	})
 }
 
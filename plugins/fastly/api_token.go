@@ -34,12 +34,7 @@ func APIToken() schema.CredentialType {
 		DefaultProvisioner: provision.EnvVars(defaultEnvVarMapping),
 		Importer: importer.TryAll(
 			importer.TryAllEnvVars(fieldname.Token, "FASTLY_API_TOKEN"),
-			importer.MacOnly(
-				TryFastlyConfigFile("~/Library/Application Support/fastly/config.toml"),
-			),
-			importer.LinuxOnly(
-				TryFastlyConfigFile("~/.config/fastly/config.toml"),
-			),
+			TryFastlyConfigFile(),
 		),
 	}
 }
@@ -48,23 +43,27 @@ var defaultEnvVarMapping = map[string]sdk.FieldName{
 	"FASTLY_API_TOKEN": fieldname.Token,
 }
 
-func TryFastlyConfigFile(path string) sdk.Importer {
-	return importer.TryFile(path, func(ctx context.Context, contents importer.FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
-		var config Config
-		if err := contents.ToTOML(&config); err != nil {
-			out.AddError(err)
-			return
-		}
+// TryFastlyConfigFile imports the tokens of all profiles in the Fastly CLI's config file, which is stored in the user's
+// config directory.
+func TryFastlyConfigFile() sdk.Importer {
+	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+		importer.TryFile(in.FromConfigDir("fastly", "config.toml"), func(ctx context.Context, contents importer.FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+			var config Config
+			if err := contents.ToTOML(&config); err != nil {
+				out.AddError(err)
+				return
+			}
 
-		for profileName, configProfile := range config.Profile {
-			out.AddCandidate(sdk.ImportCandidate{
-				Fields: map[sdk.FieldName]string{
-					fieldname.Token: configProfile.Token,
-				},
-				NameHint: importer.SanitizeNameHint(profileName),
-			})
-		}
-	})
+			for profileName, configProfile := range config.Profile {
+				out.AddCandidate(sdk.ImportCandidate{
+					Fields: map[sdk.FieldName]string{
+						fieldname.Token: configProfile.Token,
+					},
+					NameHint: importer.SanitizeNameHint(profileName),
+				})
+			}
+		})(ctx, in, out)
+	}
 }
 
 type ConfigProfile struct {
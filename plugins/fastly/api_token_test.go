@@ -89,5 +89,58 @@ func TestAPITokenImporter(t *testing.T) {
 				},
 			},
 		},
+		"config file on Windows": {
+			OS: "windows",
+			Files: map[string]string{
+				"~/AppData/Roaming/fastly/config.toml": plugintest.LoadFixture(t, "config.toml"),
+			},
+			ExpectedCandidates: []sdk.ImportCandidate{
+				{
+					Fields: map[sdk.FieldName]string{
+						fieldname.Token: "4Oncq0V723ZO8HIqUgOTB77dsEXAMPLE",
+					},
+					NameHint: "first",
+				},
+				{
+					Fields: map[sdk.FieldName]string{
+						fieldname.Token: "NyK5NwkqXpuf74Le0omvFVUtZEXAMPLE",
+					},
+					NameHint: "second",
+				},
+				{
+					Fields: map[sdk.FieldName]string{
+						fieldname.Token: "L2IofeJGtvwy1fDSKNj5dEIRgEXAMPLE",
+					},
+					NameHint: "third",
+				},
+			},
+		},
+		"config file in XDG config dir": {
+			OS:        "linux",
+			ConfigDir: "~/.xdg/config",
+			Files: map[string]string{
+				"~/.xdg/config/fastly/config.toml": plugintest.LoadFixture(t, "config.toml"),
+			},
+			ExpectedCandidates: []sdk.ImportCandidate{
+				{
+					Fields: map[sdk.FieldName]string{
+						fieldname.Token: "4Oncq0V723ZO8HIqUgOTB77dsEXAMPLE",
+					},
+					NameHint: "first",
+				},
+				{
+					Fields: map[sdk.FieldName]string{
+						fieldname.Token: "NyK5NwkqXpuf74Le0omvFVUtZEXAMPLE",
+					},
+					NameHint: "second",
+				},
+				{
+					Fields: map[sdk.FieldName]string{
+						fieldname.Token: "L2IofeJGtvwy1fDSKNj5dEIRgEXAMPLE",
+					},
+					NameHint: "third",
+				},
+			},
+		},
 	})
 }
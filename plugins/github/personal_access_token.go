@@ -41,7 +41,9 @@ func PersonalAccessToken() schema.CredentialType {
 		DefaultProvisioner: provision.EnvVars(defaultEnvVarMapping),
 		Importer: importer.TryAll(
 			importer.TryEnvVarPair(defaultEnvVarMapping),
-			importer.TryAllEnvVars(fieldname.Token, "GH_TOKEN", "GITHUB_PAT"),
+			importer.TryAllEnvVars(fieldname.Token, "GH_TOKEN"),
+			// GITHUB_PAT is not read by the GitHub CLI itself, so it's less likely to hold the token that's used with gh.
+			importer.WithConfidence(sdk.ConfidenceLow, importer.TryAllEnvVars(fieldname.Token, "GITHUB_PAT")),
 			importer.TryEnvVarPair(map[string]sdk.FieldName{
 				"GH_HOST":             fieldname.Host,
 				"GH_ENTERPRISE_TOKEN": fieldname.Token,
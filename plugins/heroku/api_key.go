@@ -62,11 +62,17 @@ func TryNetrcFile() sdk.Importer {
 				}
 				if login != "" && password != "" && machine != "" {
 					if machine == "api.heroku.com" || machine == "git.heroku.com" {
+						// The Heroku CLI uses the api.heroku.com entry, the git.heroku.com entry is only used for git pushes.
+						confidence := sdk.ConfidenceHigh
+						if machine == "git.heroku.com" {
+							confidence = sdk.ConfidenceMedium
+						}
 						out.AddCandidate(sdk.ImportCandidate{
 							NameHint: importer.SanitizeNameHint(login),
 							Fields: map[sdk.FieldName]string{
 								fieldname.APIKey: password,
 							},
+							Confidence: confidence,
 						})
 					}
 					machine, login, password = "", "", ""
@@ -32,12 +32,14 @@ func TestAPIKeyImporter(t *testing.T) {
 					Fields: map[sdk.FieldName]string{
 						fieldname.APIKey: "dh7k7m662pqglxaybr1p0gpg1cu33example",
 					},
+					Confidence: sdk.ConfidenceHigh,
 				},
 				{
 					NameHint: "wendy@appleseed.com",
 					Fields: map[sdk.FieldName]string{
 						fieldname.APIKey: "dh7k7m662pqglxaybr1p0gpg1cu33example",
 					},
+					Confidence: sdk.ConfidenceMedium,
 				},
 			},
 		},
@@ -39,9 +39,14 @@ func Get(pluginName string) (schema.Plugin, error) {
 func GetByExecutable(executableQuery string) (schema.Plugin, schema.Executable, error) {
 	for _, p := range registry {
 		for _, e := range p.Executables {
-			if strings.EqualFold(executableQuery, e.Command()) || strings.EqualFold(executableQuery, e.Name) {
+			if strings.EqualFold(executableQuery, e.Name) {
 				return p, e, nil
 			}
+			for _, command := range e.Commands() {
+				if strings.EqualFold(executableQuery, command) {
+					return p, e, nil
+				}
+			}
 		}
 	}
 	return schema.Plugin{}, schema.Executable{}, fmt.Errorf("unknown plugin: %s", executableQuery)
@@ -19,6 +19,10 @@ func TestValidatePlugins(t *testing.T) {
 	}
 }
 
+func TestAllExecutablesHaveUniqueCommands(t *testing.T) {
+	assert.Empty(t, schema.DuplicateCommands(registry...))
+}
+
 func TestAllPluginsHaveUniqueNames(t *testing.T) {
 	var pluginNames []string
 	for _, p := range registry {
@@ -3,6 +3,7 @@ package stripe
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/1Password/shell-plugins/sdk"
 	"github.com/1Password/shell-plugins/sdk/importer"
@@ -94,7 +95,8 @@ func TryStripeConfigFile() sdk.Importer {
 						fieldname.Key:  config.LiveModeAPIKey,
 						fieldname.Mode: ModeLive,
 					},
-					NameHint: nameHint,
+					NameHint:  nameHint,
+					ExpiresAt: parseKeyExpiry(config.LiveModeKeyExpiresAt),
 				})
 			}
 			if strings.HasPrefix(config.TestModeAPIKey, "sk_") {
@@ -109,9 +111,20 @@ func TryStripeConfigFile() sdk.Importer {
 						fieldname.Key:  config.TestModeAPIKey,
 						fieldname.Mode: ModeTest,
 					},
-					NameHint: nameHint,
+					NameHint:  nameHint,
+					ExpiresAt: parseKeyExpiry(config.TestModeKeyExpiresAt),
 				})
 			}
 		}
 	})
 }
+
+// parseKeyExpiry parses the expiry date that the Stripe CLI stores next to its keys, e.g. "2023-02-28". Returns nil
+// if the date is absent or can't be parsed.
+func parseKeyExpiry(date string) *time.Time {
+	expiresAt, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil
+	}
+	return &expiresAt
+}
@@ -2,6 +2,7 @@ package stripe
 
 import (
 	"testing"
+	"time"
 
 	"github.com/1Password/shell-plugins/sdk"
 	"github.com/1Password/shell-plugins/sdk/plugintest"
@@ -9,6 +10,8 @@ import (
 )
 
 func TestSecretKeyImporter(t *testing.T) {
+	keyExpiry := time.Date(2023, 2, 28, 0, 0, 0, 0, time.UTC)
+
 	plugintest.TestImporter(t, SecretKey().Importer, map[string]plugintest.ImportCase{
 		"environment": {
 			Environment: map[string]string{
@@ -39,12 +42,14 @@ func TestSecretKeyImporter(t *testing.T) {
 						fieldname.Key:  "sk_uKVoEC2LqU1aXSbKM1ptxFB3QxWiSTMTnbr0CGvkEBMFOs2vetsHc148WMhtrVRAAsP4fcRd35Fz7ykqbhLoa04ZoA7AcRKvUEXAMPLE",
 						fieldname.Mode: ModeTest,
 					},
+					ExpiresAt: &keyExpiry,
 				},
 				{
 					Fields: map[sdk.FieldName]string{
 						fieldname.Key:  "sk_TEm8TYekzqaEKmSIDRb4PXJQAoq94iL6PZx4C1RQlr1Ls5kn67RVRJjhBfmejEX8OS4T7GxCWBnqBuIG20SzdEwopINxyEL05EXAMPLE",
 						fieldname.Mode: ModeLive,
 					},
+					ExpiresAt: &keyExpiry,
 				},
 				{
 					NameHint: "custom - test",
@@ -52,6 +57,7 @@ func TestSecretKeyImporter(t *testing.T) {
 						fieldname.Key:  "sk_9Q9YiSK3uWDqSiNYLakhI6s3f6uHQekczqqdfpRsOI0Zwc6ozOMNAzNfVSNlhnA6IipOakrnF8gdhJ5sC97acFy9d0UbhKe2WEXAMPLE",
 						fieldname.Mode: ModeTest,
 					},
+					ExpiresAt: &keyExpiry,
 				},
 				{
 					NameHint: "custom",
@@ -59,6 +65,7 @@ func TestSecretKeyImporter(t *testing.T) {
 						fieldname.Key:  "sk_UYmt7xpmCZhXgJQypQer6twgdE9pxJsdUWeHcwcce9PKQQPIw1uEMvnWc03GxNOl96mX98Jz9a5Xf9urKYG1Ni2LDk425S2LWEXAMPLE",
 						fieldname.Mode: ModeLive,
 					},
+					ExpiresAt: &keyExpiry,
 				},
 			},
 		},
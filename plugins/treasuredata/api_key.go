@@ -36,9 +36,7 @@ func APIKey() schema.CredentialType {
 			importer.TryEnvVarPair(map[string]sdk.FieldName{
 				"TREASURE_DATA_API_KEY": fieldname.APIKey,
 			}),
-			TryTreasureDataConfigFile(os.Getenv("TREASURE_DATA_CONFIG_PATH")),
-			TryTreasureDataConfigFile(os.Getenv("TD_CONFIG_PATH")),
-			TryTreasureDataConfigFile("~/.td/td.conf"),
+			TryTreasureDataConfigFile(),
 		)}
 }
 
@@ -46,8 +44,18 @@ var defaultEnvVarMapping = map[string]sdk.FieldName{
 	"TD_API_KEY": fieldname.APIKey,
 }
 
-func TryTreasureDataConfigFile(path string) sdk.Importer {
-	return importer.TryFile(path, func(ctx context.Context, contents importer.FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+// TryTreasureDataConfigFile looks for the API key in the config file at the path set in TREASURE_DATA_CONFIG_PATH or
+// TD_CONFIG_PATH, or in ~/.td/td.conf.
+func TryTreasureDataConfigFile() sdk.Importer {
+	locate := func(ctx context.Context, in sdk.ImportInput) ([]string, error) {
+		return []string{
+			os.Getenv("TREASURE_DATA_CONFIG_PATH"),
+			os.Getenv("TD_CONFIG_PATH"),
+			"~/.td/td.conf",
+		}, nil
+	}
+
+	return importer.Chain(locate, func(ctx context.Context, contents importer.FileContents, path string, in sdk.ImportInput, out *sdk.ImportAttempt) {
 		credentialsFile, err := contents.ToINI()
 		if err != nil {
 			out.AddError(err)
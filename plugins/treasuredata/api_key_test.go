@@ -61,6 +61,21 @@ func TestAPIKeyImporter(t *testing.T) {
 				},
 			},
 		},
+		"TD config file from TD_CONFIG_PATH": {
+			Environment: map[string]string{
+				"TD_CONFIG_PATH": "/work/td.conf",
+			},
+			Files: map[string]string{
+				"/work/td.conf": plugintest.LoadFixture(t, "td.conf"),
+			},
+			ExpectedCandidates: []sdk.ImportCandidate{
+				{
+					Fields: map[sdk.FieldName]string{
+						fieldname.APIKey: "1/this12is34an56exampleb13645b6apikey229fa48",
+					},
+				},
+			},
+		},
 	})
 }
 
@@ -0,0 +1,72 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// CredentialProvisioner is the provisioner to use for a single credential of an executable.
+type CredentialProvisioner struct {
+	Credential  CredentialName
+	Provisioner Provisioner
+
+	// RequiredFields contains the names of the fields of the credential that are not optional, which get passed to the
+	// provisioner as ProvisionInput.RequiredFields.
+	RequiredFields []FieldName
+
+	// FieldDefaults contains the default values of the fields of the credential, which get passed to the provisioner as
+	// ProvisionInput.FieldDefaults.
+	FieldDefaults map[FieldName]string
+
+	// FieldSets contains the alternative sets of fields of the credential, which get passed to the provisioner as
+	// ProvisionInput.FieldSets.
+	FieldSets [][]FieldName
+}
+
+// ProvisionCredentials provisions multiple credentials for a single executable. The provisioner of each credential
+// gets called in order with its own input, which only contains the item fields of that credential, so provisioners
+// of credentials that have fields with the same name can't read each other's values. All provisioners add to the same
+// output. If a provisioner sets an environment variable to a different value than a provisioner of another credential
+// did before, an error is reported, since one would otherwise silently clobber the other.
+func ProvisionCredentials(ctx context.Context, in ProvisionInput, provisioners []CredentialProvisioner, itemFields map[CredentialName]map[FieldName]string, out *ProvisionOutput) {
+	envVarOwners := make(map[string]CredentialName)
+	for _, p := range provisioners {
+		credentialIn := in
+		credentialIn.CredentialName = p.Credential
+		credentialIn.ItemFields = itemFields[p.Credential]
+		credentialIn.RequiredFields = p.RequiredFields
+		credentialIn.FieldDefaults = p.FieldDefaults
+		credentialIn.FieldSets = p.FieldSets
+
+		before := make(map[string]string, len(out.Environment))
+		for name, value := range out.Environment {
+			before[name] = value
+		}
+
+		p.Provisioner.Provision(ctx, credentialIn, out)
+
+		for _, name := range sortedEnvVarNames(out.Environment) {
+			value := out.Environment[name]
+			previous, existed := before[name]
+			if existed && previous == value {
+				continue
+			}
+
+			if owner, ok := envVarOwners[name]; ok && owner != p.Credential {
+				out.AddError(fmt.Errorf("environment variable %s is provisioned by both %s and %s", name, owner, p.Credential))
+				continue
+			}
+			envVarOwners[name] = p.Credential
+		}
+	}
+}
+
+func sortedEnvVarNames(env map[string]string) []string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
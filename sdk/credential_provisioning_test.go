@@ -0,0 +1,77 @@
+package sdk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testEnvVarProvisioner provisions the Token field as the specified environment variable.
+type testEnvVarProvisioner struct {
+	Provisioner
+
+	envVarName string
+}
+
+func (p testEnvVarProvisioner) Provision(ctx context.Context, in ProvisionInput, out *ProvisionOutput) {
+	out.AddEnvVar(p.envVarName, string(in.CredentialName)+":"+in.ItemFields["Token"])
+}
+
+func TestProvisionCredentials(t *testing.T) {
+	itemFields := map[CredentialName]map[FieldName]string{
+		"API Token":             {"Token": "tkn_123"},
+		"Personal Access Token": {"Token": "ghp_456"},
+	}
+
+	t.Run("scopes item fields per credential", func(t *testing.T) {
+		out := ProvisionOutput{Environment: make(map[string]string)}
+		ProvisionCredentials(context.Background(), ProvisionInput{}, []CredentialProvisioner{
+			{Credential: "API Token", Provisioner: testEnvVarProvisioner{envVarName: "EXAMPLE_TOKEN"}},
+			{Credential: "Personal Access Token", Provisioner: testEnvVarProvisioner{envVarName: "GITHUB_TOKEN"}},
+		}, itemFields, &out)
+
+		assert.Equal(t, map[string]string{
+			"EXAMPLE_TOKEN": "API Token:tkn_123",
+			"GITHUB_TOKEN":  "Personal Access Token:ghp_456",
+		}, out.Environment)
+		assert.Empty(t, out.Diagnostics.Errors)
+	})
+
+	t.Run("reports env var collisions", func(t *testing.T) {
+		out := ProvisionOutput{Environment: make(map[string]string)}
+		ProvisionCredentials(context.Background(), ProvisionInput{}, []CredentialProvisioner{
+			{Credential: "API Token", Provisioner: testEnvVarProvisioner{envVarName: "TOKEN"}},
+			{Credential: "Personal Access Token", Provisioner: testEnvVarProvisioner{envVarName: "TOKEN"}},
+		}, itemFields, &out)
+
+		assert.Equal(t, []Error{{Message: "environment variable TOKEN is provisioned by both API Token and Personal Access Token"}}, out.Diagnostics.Errors)
+	})
+
+	t.Run("passes required fields per credential", func(t *testing.T) {
+		requiredFields := make(map[CredentialName][]FieldName)
+		record := testRequiredFieldsProvisioner{requiredFields: requiredFields}
+
+		out := ProvisionOutput{Environment: make(map[string]string)}
+		ProvisionCredentials(context.Background(), ProvisionInput{RequiredFields: []FieldName{"Password"}}, []CredentialProvisioner{
+			{Credential: "API Token", Provisioner: record, RequiredFields: []FieldName{"Token"}},
+			{Credential: "Personal Access Token", Provisioner: record},
+		}, itemFields, &out)
+
+		assert.Equal(t, map[CredentialName][]FieldName{
+			"API Token":             {"Token"},
+			"Personal Access Token": nil,
+		}, requiredFields)
+	})
+}
+
+// testRequiredFieldsProvisioner records the required fields that it gets passed for each credential.
+type testRequiredFieldsProvisioner struct {
+	Provisioner
+
+	requiredFields map[CredentialName][]FieldName
+}
+
+func (p testRequiredFieldsProvisioner) Provision(ctx context.Context, in ProvisionInput, out *ProvisionOutput) {
+	p.requiredFields[in.CredentialName] = in.RequiredFields
+}
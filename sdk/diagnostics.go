@@ -1,9 +1,21 @@
 package sdk
 
 type Diagnostics struct {
-	Errors []Error
+	Errors   []Error
+	Warnings []Warning
+	Notes    []Note
 }
 
 type Error struct {
 	Message string
 }
+
+// Warning reports something noteworthy that does not cause the operation to fail.
+type Warning struct {
+	Message string
+}
+
+// Note reports something informational that doesn't need the user's attention, e.g. a source that was skipped.
+type Note struct {
+	Message string
+}
@@ -0,0 +1,45 @@
+package sdk
+
+import (
+	"path/filepath"
+)
+
+// userConfigDir resolves the user's config directory for the specified OS. Unlike os.UserConfigDir, it respects
+// XDG_CONFIG_HOME on every OS, since many cross-platform CLIs do, and it falls back to paths relative to the
+// specified home directory. As required by the XDG Base Directory spec, relative paths in XDG variables are ignored.
+func userConfigDir(goos string, getenv func(string) string, homeDir string) string {
+	if dir := getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return dir
+	}
+
+	switch goos {
+	case "darwin":
+		return filepath.Join(homeDir, "Library", "Application Support")
+	case "windows":
+		if dir := getenv("AppData"); dir != "" {
+			return dir
+		}
+		return filepath.Join(homeDir, "AppData", "Roaming")
+	default:
+		return filepath.Join(homeDir, ".config")
+	}
+}
+
+// userCacheDir resolves the user's cache directory for the specified OS, in the same way as userConfigDir.
+func userCacheDir(goos string, getenv func(string) string, homeDir string) string {
+	if dir := getenv("XDG_CACHE_HOME"); filepath.IsAbs(dir) {
+		return dir
+	}
+
+	switch goos {
+	case "darwin":
+		return filepath.Join(homeDir, "Library", "Caches")
+	case "windows":
+		if dir := getenv("LocalAppData"); dir != "" {
+			return dir
+		}
+		return filepath.Join(homeDir, "AppData", "Local")
+	default:
+		return filepath.Join(homeDir, ".cache")
+	}
+}
@@ -0,0 +1,126 @@
+package sdk
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserDirs(t *testing.T) {
+	home := filepath.Join("/", "home", "wendy")
+
+	for name, tc := range map[string]struct {
+		goos     string
+		env      map[string]string
+		config   string
+		cacheDir string
+	}{
+		"linux": {
+			goos:     "linux",
+			config:   filepath.Join(home, ".config"),
+			cacheDir: filepath.Join(home, ".cache"),
+		},
+		"darwin": {
+			goos:     "darwin",
+			config:   filepath.Join(home, "Library", "Application Support"),
+			cacheDir: filepath.Join(home, "Library", "Caches"),
+		},
+		"windows": {
+			goos:     "windows",
+			config:   filepath.Join(home, "AppData", "Roaming"),
+			cacheDir: filepath.Join(home, "AppData", "Local"),
+		},
+		"windows with AppData": {
+			goos: "windows",
+			env: map[string]string{
+				"AppData":      "D:\\Roaming",
+				"LocalAppData": "D:\\Local",
+			},
+			config:   "D:\\Roaming",
+			cacheDir: "D:\\Local",
+		},
+		"linux with XDG": {
+			goos: "linux",
+			env: map[string]string{
+				"XDG_CONFIG_HOME": filepath.Join("/", "xdg", "config"),
+				"XDG_CACHE_HOME":  filepath.Join("/", "xdg", "cache"),
+			},
+			config:   filepath.Join("/", "xdg", "config"),
+			cacheDir: filepath.Join("/", "xdg", "cache"),
+		},
+		"darwin with XDG": {
+			goos: "darwin",
+			env: map[string]string{
+				"XDG_CONFIG_HOME": filepath.Join("/", "xdg", "config"),
+			},
+			config:   filepath.Join("/", "xdg", "config"),
+			cacheDir: filepath.Join(home, "Library", "Caches"),
+		},
+		"relative XDG is ignored": {
+			goos: "linux",
+			env: map[string]string{
+				"XDG_CONFIG_HOME": "config",
+				"XDG_CACHE_HOME":  "cache",
+			},
+			config:   filepath.Join(home, ".config"),
+			cacheDir: filepath.Join(home, ".cache"),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			getenv := func(key string) string {
+				return tc.env[key]
+			}
+			assert.Equal(t, tc.config, userConfigDir(tc.goos, getenv, home))
+			assert.Equal(t, tc.cacheDir, userCacheDir(tc.goos, getenv, home))
+		})
+	}
+}
+
+func TestProvisionInputFromConfigDir(t *testing.T) {
+	configHome := filepath.Join(t.TempDir(), "config")
+	cacheHome := filepath.Join(t.TempDir(), "cache")
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+
+	in := ProvisionInput{HomeDir: t.TempDir()}
+	assert.Equal(t, filepath.Join(configHome, "gh", "hosts.yml"), in.FromConfigDir("gh", "hosts.yml"))
+	assert.Equal(t, filepath.Join(cacheHome, "gh"), in.FromCacheDir("gh"))
+}
+
+func TestProvisionInputDirs(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("AppData", "")
+	t.Setenv("LocalAppData", "")
+	home := filepath.Join("/", "home", "wendy")
+
+	for _, scenario := range []struct {
+		os     string
+		config string
+		cache  string
+	}{
+		{os: "linux", config: filepath.Join(home, ".config", "tool"), cache: filepath.Join(home, ".cache", "tool")},
+		{os: "darwin", config: filepath.Join(home, "Library", "Application Support", "tool"), cache: filepath.Join(home, "Library", "Caches", "tool")},
+		{os: "windows", config: filepath.Join(home, "AppData", "Roaming", "tool"), cache: filepath.Join(home, "AppData", "Local", "tool")},
+	} {
+		t.Run(scenario.os, func(t *testing.T) {
+			in := ProvisionInput{HomeDir: home, OS: scenario.os}
+			assert.Equal(t, scenario.config, in.FromConfigDir("tool"))
+			assert.Equal(t, scenario.cache, in.FromCacheDir("tool"))
+		})
+	}
+
+	t.Run("set by host", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", filepath.Join("/", "plugin", "config"))
+		t.Setenv("XDG_CACHE_HOME", filepath.Join("/", "plugin", "cache"))
+		in := ProvisionInput{
+			HomeDir:   home,
+			OS:        "linux",
+			ConfigDir: filepath.Join("/", "xdg", "config"),
+			CacheDir:  filepath.Join("/", "xdg", "cache"),
+		}
+		assert.Equal(t, filepath.Join("/", "xdg", "config", "tool"), in.FromConfigDir("tool"))
+		assert.Equal(t, filepath.Join("/", "xdg", "cache", "tool"), in.FromCacheDir("tool"))
+	})
+}
@@ -1,6 +1,8 @@
 package example
 
 import (
+	"context"
+
 	"github.com/1Password/shell-plugins/sdk"
 	"github.com/1Password/shell-plugins/sdk/importer"
 	"github.com/1Password/shell-plugins/sdk/provision"
@@ -12,13 +14,14 @@ import (
 func APIToken() schema.CredentialType {
 	return schema.CredentialType{
 		Name:          credname.APIToken,
-		DocsURL:       sdk.URL("http://example.com/docs/api_token"),
-		ManagementURL: sdk.URL("http://dashboard.example.com/user/security/tokens"),
+		DocsURL:       sdk.URL("https://example.com/docs/api_token"),
+		ManagementURL: sdk.URL("https://dashboard.example.com/user/security/tokens"),
 		Fields: []schema.CredentialField{
 			{
 				Name:                fieldname.AccountID,
-				MarkdownDescription: "The Example API account ID.",
+				MarkdownDescription: "The Example API account ID. Only needed for tokens that have access to multiple accounts.",
 				Secret:              false,
+				Optional:            true,
 				Composition: &schema.ValueComposition{
 					Length: 12,
 					Charset: schema.Charset{
@@ -53,6 +56,45 @@ func APIToken() schema.CredentialType {
 				"EXAMPLE_ACCOUNT_ID": fieldname.AccountID,
 				"EXAMPLE_TOKEN":      fieldname.Token,
 			}),
+			TryExampleConfigFile(),
 		),
 	}
 }
+
+// TryExampleConfigFile imports the API token from the Example CLI config file. The config file can also contain a
+// secret key, which is a different credential type, so it gets added as a candidate for that credential type instead.
+func TryExampleConfigFile() sdk.Importer {
+	return importer.TryFile("~/.example/config.json", func(ctx context.Context, contents importer.FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+		var config Config
+		if err := contents.ToJSON(&config); err != nil {
+			out.AddError(err)
+			return
+		}
+
+		if config.APIToken != "" {
+			fields := map[sdk.FieldName]string{
+				fieldname.Token: config.APIToken,
+			}
+			if config.AccountID != "" {
+				fields[fieldname.AccountID] = config.AccountID
+			}
+			out.AddCandidate(sdk.ImportCandidate{
+				Fields: fields,
+			})
+		}
+
+		if config.SecretKey != "" {
+			out.AddCandidateFor(credname.SecretKey, sdk.ImportCandidate{
+				Fields: map[sdk.FieldName]string{
+					fieldname.Key: config.SecretKey,
+				},
+			})
+		}
+	})
+}
+
+type Config struct {
+	AccountID string `json:"account_id"`
+	APIToken  string `json:"api_token"`
+	SecretKey string `json:"secret_key"`
+}
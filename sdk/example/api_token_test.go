@@ -0,0 +1,87 @@
+package example
+
+import (
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/credname"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+)
+
+func TestAPITokenImporter(t *testing.T) {
+	plugintest.TestImporter(t, APIToken().Importer, map[string]plugintest.ImportCase{
+		"config file with API token and secret key": {
+			Files: map[string]string{
+				"~/.example/config.json": plugintest.LoadFixture(t, "config.json"),
+			},
+			ExpectedCandidates: []sdk.ImportCandidate{
+				{
+					Fields: map[sdk.FieldName]string{
+						fieldname.AccountID: "123456789012",
+						fieldname.Token:     "tkn_ABCDEFGHIJ0123456789KLMNOPQRSEXAMPLE",
+					},
+				},
+				{
+					Fields: map[sdk.FieldName]string{
+						fieldname.Key: "sk_abcdefghij0123456789klexample",
+					},
+					CredentialType: credname.SecretKey,
+				},
+			},
+		},
+		"config file without account ID": {
+			Files: map[string]string{
+				"~/.example/config.json": `{"api_token": "tkn_ABCDEFGHIJ0123456789KLMNOPQRSEXAMPLE"}`,
+			},
+			ExpectedCandidates: []sdk.ImportCandidate{
+				{
+					Fields: map[sdk.FieldName]string{
+						fieldname.Token: "tkn_ABCDEFGHIJ0123456789KLMNOPQRSEXAMPLE",
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestAPITokenProvisioner(t *testing.T) {
+	plugintest.TestProvisioner(t, APIToken().DefaultProvisioner, map[string]plugintest.ProvisionCase{
+		"token with account ID": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.AccountID: "123456789012",
+				fieldname.Token:     "tkn_ABCDEFGHIJ0123456789KLMNOPQRSEXAMPLE",
+			},
+			RequiredFields: APIToken().RequiredFields(),
+			ExpectedOutput: sdk.ProvisionOutput{
+				Environment: map[string]string{
+					"EXAMPLE_ACCOUNT_ID": "123456789012",
+					"EXAMPLE_API_TOKEN":  "tkn_ABCDEFGHIJ0123456789KLMNOPQRSEXAMPLE",
+				},
+			},
+		},
+		"token without account ID": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Token: "tkn_ABCDEFGHIJ0123456789KLMNOPQRSEXAMPLE",
+			},
+			RequiredFields: APIToken().RequiredFields(),
+			ExpectedOutput: sdk.ProvisionOutput{
+				Environment: map[string]string{
+					"EXAMPLE_API_TOKEN": "tkn_ABCDEFGHIJ0123456789KLMNOPQRSEXAMPLE",
+				},
+			},
+		},
+		"missing token": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.AccountID: "123456789012",
+			},
+			RequiredFields: APIToken().RequiredFields(),
+			ExpectedOutput: sdk.ProvisionOutput{
+				Environment: map[string]string{
+					"EXAMPLE_ACCOUNT_ID": "123456789012",
+				},
+				Diagnostics: sdk.Diagnostics{Errors: []sdk.Error{{Message: "cannot set environment variable EXAMPLE_API_TOKEN: the item is missing the required field Token"}}},
+			},
+		},
+	})
+}
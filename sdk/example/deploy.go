@@ -0,0 +1,41 @@
+package example
+
+import (
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/needsauth"
+	"github.com/1Password/shell-plugins/sdk/provision"
+	"github.com/1Password/shell-plugins/sdk/schema"
+	"github.com/1Password/shell-plugins/sdk/schema/credname"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+)
+
+// ExampleDeployCLI is an example of an executable that uses multiple credentials: the Example API token and a GitHub
+// personal access token from the GitHub plugin. Both credentials have a Token field, but each provisioner only gets
+// to see the fields of its own credential.
+//
+// Deployments from a pipeline are expected to bring their own credentials, so authentication is only required when the
+// CLI is run interactively.
+func ExampleDeployCLI() schema.Executable {
+	return schema.Executable{
+		Name:    "Example Deploy CLI",
+		Runs:    []string{"example-deploy"},
+		DocsURL: sdk.URL("https://example.com/docs/deploy"),
+		NeedsAuth: needsauth.All(
+			needsauth.NotForHelpOrVersion(),
+			needsauth.OnlyWhenInteractive(),
+		),
+		Uses: []schema.CredentialUsage{
+			{
+				Name: credname.APIToken,
+			},
+			{
+				Name:        credname.PersonalAccessToken,
+				Plugin:      "github",
+				Description: "Used to fetch the source code of the deployment.",
+				Provisioner: provision.EnvVars(map[string]sdk.FieldName{
+					"EXAMPLE_DEPLOY_GITHUB_TOKEN": fieldname.Token,
+				}),
+			},
+		},
+	}
+}
@@ -11,7 +11,8 @@ func ExampleCLI() schema.Executable {
 	return schema.Executable{
 		Name:      "Example CLI",
 		Runs:      []string{"example"},
-		DocsURL:   sdk.URL("http://example.com/docs/cli"),
+		Aliases:   [][]string{{"example-cli"}},
+		DocsURL:   sdk.URL("https://example.com/docs/cli"),
 		NeedsAuth: needsauth.NotForHelpOrVersion(),
 		Uses: []schema.CredentialUsage{
 			{
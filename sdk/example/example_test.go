@@ -0,0 +1,19 @@
+package example
+
+import (
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+)
+
+func TestExampleCLINeedsAuth(t *testing.T) {
+	plugintest.TestExecutableNeedsAuth(t, ExampleCLI(), map[string]bool{
+		"":                     true,
+		"projects list":        true,
+		"projects list --help": false,
+		"help projects":        false,
+		"-v":                   false,
+		"version":              false,
+		"projects list | ...":  true,
+	})
+}
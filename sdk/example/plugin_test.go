@@ -1,14 +1,39 @@
 package example
 
 import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+var update = flag.Bool("update", false, "update the golden files in test-fixtures")
+
 func TestPlugin(t *testing.T) {
 	_, report := New().Validate()
 	for _, c := range report.Checks {
 		assert.True(t, c.Assertion)
 	}
 }
+
+func TestManifest(t *testing.T) {
+	manifest, err := json.MarshalIndent(New().ToManifest(), "", "  ")
+	require.NoError(t, err)
+	manifest = append(manifest, '\n')
+	assert.True(t, json.Valid(manifest))
+
+	if *update {
+		require.NoError(t, os.WriteFile(filepath.Join("test-fixtures", "manifest.json"), manifest, 0600))
+	}
+	assert.Equal(t, plugintest.LoadFixture(t, "manifest.json"), string(manifest))
+
+	var decoded schema.Manifest
+	require.NoError(t, json.Unmarshal(manifest, &decoded))
+	assert.Equal(t, New().ToManifest(), decoded)
+}
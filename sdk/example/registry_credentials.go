@@ -0,0 +1,70 @@
+package example
+
+import (
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/importer"
+	"github.com/1Password/shell-plugins/sdk/provision"
+	"github.com/1Password/shell-plugins/sdk/schema"
+	"github.com/1Password/shell-plugins/sdk/schema/credname"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+)
+
+// RegistryCredentials is an example of a credential type with alternative sets of fields: the Example package
+// registry accepts either a token or a username and password. It's not part of the example plugin, since plugins can
+// only define a single credential type.
+func RegistryCredentials() schema.CredentialType {
+	return schema.CredentialType{
+		Name:          credname.RegistryCredentials,
+		DocsURL:       sdk.URL("https://example.com/docs/registry"),
+		ManagementURL: sdk.URL("https://dashboard.example.com/user/security/registry"),
+		Fields: []schema.CredentialField{
+			{
+				Name:                fieldname.Token,
+				MarkdownDescription: "The token used to authenticate to the Example registry.",
+				Secret:              true,
+				Composition: &schema.ValueComposition{
+					Length: 40,
+					Prefix: "reg_",
+					Charset: schema.Charset{
+						Lowercase: true,
+						Digits:    true,
+					},
+				},
+			},
+			{
+				Name:                fieldname.Username,
+				MarkdownDescription: "The username used to authenticate to the Example registry, if no token is used.",
+			},
+			{
+				Name:                fieldname.Password,
+				MarkdownDescription: "The password used to authenticate to the Example registry, if no token is used.",
+				Secret:              true,
+			},
+			{
+				Name:                fieldname.Host,
+				MarkdownDescription: "The host of the Example registry, for self-hosted registries.",
+				Optional:            true,
+				DefaultValue:        "registry.example.com",
+			},
+		},
+		FieldSets: [][]sdk.FieldName{
+			{fieldname.Token},
+			{fieldname.Username, fieldname.Password},
+		},
+		DefaultProvisioner: provision.EnvVars(map[string]sdk.FieldName{
+			"EXAMPLE_REGISTRY_TOKEN":    fieldname.Token,
+			"EXAMPLE_REGISTRY_USERNAME": fieldname.Username,
+			"EXAMPLE_REGISTRY_PASSWORD": fieldname.Password,
+			"EXAMPLE_REGISTRY_HOST":     fieldname.Host,
+		}),
+		Importer: importer.TryEnvVarPairs(
+			map[string]sdk.FieldName{
+				"EXAMPLE_REGISTRY_TOKEN": fieldname.Token,
+			},
+			map[string]sdk.FieldName{
+				"EXAMPLE_REGISTRY_USERNAME": fieldname.Username,
+				"EXAMPLE_REGISTRY_PASSWORD": fieldname.Password,
+			},
+		),
+	}
+}
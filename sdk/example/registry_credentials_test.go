@@ -0,0 +1,106 @@
+package example
+
+import (
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryCredentials(t *testing.T) {
+	_, report := RegistryCredentials().Validate()
+	for _, c := range report.Checks {
+		assert.True(t, c.Assertion, c.Description)
+	}
+}
+
+func TestRegistryCredentialsImporter(t *testing.T) {
+	plugintest.TestImporter(t, RegistryCredentials().Importer, map[string]plugintest.ImportCase{
+		"token": {
+			Environment: map[string]string{
+				"EXAMPLE_REGISTRY_TOKEN": "reg_abcdefghij0123456789klmnopqrsexample",
+			},
+			ExpectedCandidates: []sdk.ImportCandidate{
+				{
+					Fields: map[sdk.FieldName]string{
+						fieldname.Token: "reg_abcdefghij0123456789klmnopqrsexample",
+					},
+				},
+			},
+		},
+		"username and password": {
+			Environment: map[string]string{
+				"EXAMPLE_REGISTRY_USERNAME": "wendy",
+				"EXAMPLE_REGISTRY_PASSWORD": "hunter2",
+			},
+			ExpectedCandidates: []sdk.ImportCandidate{
+				{
+					Fields: map[sdk.FieldName]string{
+						fieldname.Username: "wendy",
+						fieldname.Password: "hunter2",
+					},
+				},
+			},
+		},
+		"username without password": {
+			Environment: map[string]string{
+				"EXAMPLE_REGISTRY_USERNAME": "wendy",
+			},
+			ExpectedCandidates: []sdk.ImportCandidate{},
+		},
+	})
+}
+
+func TestRegistryCredentialsProvisioner(t *testing.T) {
+	credential := RegistryCredentials()
+	plugintest.TestProvisioner(t, credential.DefaultProvisioner, map[string]plugintest.ProvisionCase{
+		"token": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Token: "reg_abcdefghij0123456789klmnopqrsexample",
+			},
+			RequiredFields: credential.RequiredFields(),
+			FieldDefaults:  credential.FieldDefaults(),
+			FieldSets:      credential.FieldSets,
+			ExpectedOutput: sdk.ProvisionOutput{
+				Environment: map[string]string{
+					"EXAMPLE_REGISTRY_TOKEN": "reg_abcdefghij0123456789klmnopqrsexample",
+					"EXAMPLE_REGISTRY_HOST":  "registry.example.com",
+				},
+			},
+		},
+		"username and password": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Username: "wendy",
+				fieldname.Password: "hunter2",
+				fieldname.Host:     "registry.internal.example.com",
+			},
+			RequiredFields: credential.RequiredFields(),
+			FieldDefaults:  credential.FieldDefaults(),
+			FieldSets:      credential.FieldSets,
+			ExpectedOutput: sdk.ProvisionOutput{
+				Environment: map[string]string{
+					"EXAMPLE_REGISTRY_USERNAME": "wendy",
+					"EXAMPLE_REGISTRY_PASSWORD": "hunter2",
+					"EXAMPLE_REGISTRY_HOST":     "registry.internal.example.com",
+				},
+			},
+		},
+		"token and a leftover username": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Token:    "reg_abcdefghij0123456789klmnopqrsexample",
+				fieldname.Username: "wendy",
+			},
+			RequiredFields: credential.RequiredFields(),
+			FieldDefaults:  credential.FieldDefaults(),
+			FieldSets:      credential.FieldSets,
+			ExpectedOutput: sdk.ProvisionOutput{
+				Environment: map[string]string{
+					"EXAMPLE_REGISTRY_TOKEN": "reg_abcdefghij0123456789klmnopqrsexample",
+					"EXAMPLE_REGISTRY_HOST":  "registry.example.com",
+				},
+			},
+		},
+	})
+}
@@ -0,0 +1,42 @@
+package example
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+)
+
+type sandboxProvisioner struct {
+}
+
+// SandboxProvisioner provisions the API token as a config file in a sandbox directory in the temp dir, and runs the
+// executable from that directory. This is useful for executables that only read their config from the working directory.
+func SandboxProvisioner() sdk.Provisioner {
+	return sandboxProvisioner{}
+}
+
+func (p sandboxProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	token, ok := in.FieldValue(fieldname.Token)
+	if !ok {
+		out.AddError(fmt.Errorf("no value present in the item for field '%s'", fieldname.Token))
+		return
+	}
+
+	sandboxDir := in.FromTempDir("sandbox")
+	out.AddDirectory(sandboxDir, sdk.OutputDirectory{
+		OnlyAllowCurrentProcess: true,
+	})
+	out.AddSecretFile(filepath.Join(sandboxDir, ".examplerc"), []byte(fmt.Sprintf("token=%s\n", token)))
+	out.SetWorkingDir(sandboxDir)
+}
+
+func (p sandboxProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	// Nothing to do here: deleting the files gets taken care of.
+}
+
+func (p sandboxProvisioner) Description() string {
+	return "Provision config file in a sandbox working directory"
+}
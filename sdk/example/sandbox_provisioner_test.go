@@ -0,0 +1,59 @@
+package example
+
+import (
+	"context"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSandboxProvisioner(t *testing.T) {
+	plugintest.TestProvisioner(t, SandboxProvisioner(), map[string]plugintest.ProvisionCase{
+		"default": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Token: "tkn_ABC123",
+			},
+			CommandLine: []string{"example", "deploy"},
+			ExpectedOutput: sdk.ProvisionOutput{
+				CommandLine: []string{"example", "deploy"},
+				Directories: map[string]sdk.OutputDirectory{
+					"/tmp/sandbox": {OnlyAllowCurrentProcess: true},
+				},
+				Files: map[string]sdk.OutputFile{
+					"/tmp/sandbox/.examplerc": {Contents: []byte("token=tkn_ABC123\n")},
+				},
+				WorkingDirectory: "/tmp/sandbox",
+			},
+		},
+	})
+}
+
+func TestSandboxProvisionerConflictingWorkingDir(t *testing.T) {
+	out := sdk.ProvisionOutput{
+		Environment: make(map[string]string),
+		Files:       make(map[string]sdk.OutputFile),
+	}
+	out.SetWorkingDir("/home/wendy/project")
+
+	SandboxProvisioner().Provision(context.Background(), sdk.ProvisionInput{
+		TempDir:    "/tmp",
+		ItemFields: map[sdk.FieldName]string{fieldname.Token: "tkn_ABC123"},
+	}, &out)
+
+	assert.Equal(t, "/home/wendy/project", out.WorkingDirectory)
+	assert.Equal(t, []sdk.Error{{Message: "conflicting working directories: /home/wendy/project and /tmp/sandbox"}}, out.Diagnostics.Errors)
+}
+
+func TestCheckWorkingDir(t *testing.T) {
+	out := sdk.ProvisionOutput{}
+	assert.NoError(t, out.CheckWorkingDir())
+
+	out.WorkingDirectory = t.TempDir()
+	assert.NoError(t, out.CheckWorkingDir())
+
+	out.WorkingDirectory = out.WorkingDirectory + "/missing"
+	assert.Error(t, out.CheckWorkingDir())
+}
@@ -0,0 +1,40 @@
+package example
+
+import (
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/importer"
+	"github.com/1Password/shell-plugins/sdk/provision"
+	"github.com/1Password/shell-plugins/sdk/schema"
+	"github.com/1Password/shell-plugins/sdk/schema/credname"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+)
+
+// SecretKey is an example of a credential type for which the executable has a native 1Password integration: the
+// Example CLI resolves op:// references in its config file itself, so the plugin only provides the schema and importer.
+// It's not part of the example plugin, since plugins can only define a single credential type.
+func SecretKey() schema.CredentialType {
+	return schema.CredentialType{
+		Name:          credname.SecretKey,
+		DocsURL:       sdk.URL("https://example.com/docs/secret_key"),
+		ManagementURL: sdk.URL("https://dashboard.example.com/user/security/keys"),
+		Fields: []schema.CredentialField{
+			{
+				Name:                fieldname.Key,
+				MarkdownDescription: "The secret key used to sign requests to the Example API.",
+				Secret:              true,
+				Composition: &schema.ValueComposition{
+					Length: 32,
+					Prefix: "sk_",
+					Charset: schema.Charset{
+						Lowercase: true,
+						Digits:    true,
+					},
+				},
+			},
+		},
+		DefaultProvisioner: provision.None("the Example CLI resolves op:// references in its config file itself"),
+		Importer: importer.TryEnvVarPair(map[string]sdk.FieldName{
+			"EXAMPLE_SECRET_KEY": fieldname.Key,
+		}),
+	}
+}
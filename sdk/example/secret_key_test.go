@@ -0,0 +1,28 @@
+package example
+
+import (
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretKey(t *testing.T) {
+	_, report := SecretKey().Validate()
+	for _, c := range report.Checks {
+		assert.True(t, c.Assertion, c.Description)
+	}
+}
+
+func TestSecretKeyProvisioner(t *testing.T) {
+	plugintest.TestProvisioner(t, SecretKey().DefaultProvisioner, map[string]plugintest.ProvisionCase{
+		"provisions nothing": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Key: "sk_0123456789abcdef0123456789abcd",
+			},
+			ExpectedOutput: sdk.ProvisionOutput{},
+		},
+	})
+}
@@ -0,0 +1,70 @@
+package sdk
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"sort"
+)
+
+// ExistingItems identifies the items that the user already saved for a credential type, without revealing their
+// values to the plugin. The host picks a random salt for every import and sends the salted hash of the fields of every
+// saved item, as computed by HashFields.
+type ExistingItems struct {
+	Salt   []byte
+	Hashes [][]byte
+}
+
+// HashFields returns the HMAC-SHA256 of the fields, keyed with the salt. The fields are encoded in order of their
+// name, with every name and value prefixed by its length, so that different field sets can't encode the same way.
+func (e *ExistingItems) HashFields(fields map[FieldName]string) []byte {
+	names := make([]FieldName, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+
+	mac := hmac.New(sha256.New, e.Salt)
+	writeString := func(s string) {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(s)))
+		mac.Write(length[:])
+		mac.Write([]byte(s))
+	}
+	for _, name := range names {
+		writeString(string(name))
+		writeString(fields[name])
+	}
+	return mac.Sum(nil)
+}
+
+// Contains returns whether the fields of the candidate match any of the existing items. It compares the hash of the
+// candidate against every existing hash in constant time, so that the time it takes doesn't reveal which item matched.
+func (e *ExistingItems) Contains(candidate ImportCandidate) bool {
+	hash := e.HashFields(candidate.Fields)
+	found := 0
+	for _, existing := range e.Hashes {
+		found |= subtle.ConstantTimeCompare(hash, existing)
+	}
+	return found == 1
+}
+
+// RemoveExisting removes the candidates that match items the user already saved, and adds the number of removed
+// candidates to SkippedExisting. It does nothing if existing is nil.
+func (out *ImportOutput) RemoveExisting(existing *ExistingItems) {
+	if existing == nil {
+		return
+	}
+	for _, attempt := range out.Attempts {
+		candidates := attempt.Candidates[:0]
+		for _, candidate := range attempt.Candidates {
+			if existing.Contains(candidate) {
+				out.SkippedExisting++
+				continue
+			}
+			candidates = append(candidates, candidate)
+		}
+		attempt.Candidates = candidates
+	}
+}
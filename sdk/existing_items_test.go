@@ -0,0 +1,61 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportOutputRemoveExisting(t *testing.T) {
+	salt := []byte("salt")
+	saved := &ExistingItems{Salt: salt}
+	saved.Hashes = [][]byte{
+		saved.HashFields(map[FieldName]string{"Token": "saved-token"}),
+		saved.HashFields(map[FieldName]string{"Username": "user", "Password": "saved-password"}),
+	}
+
+	out := ImportOutput{}
+	env := out.NewAttempt(ImportSource{Env: []string{"TOOL_TOKEN"}})
+	env.AddCandidate(ImportCandidate{Fields: map[FieldName]string{"Token": "saved-token"}})
+	config := out.NewAttempt(ImportSource{Files: []string{"~/.tool/config.json"}})
+	config.AddCandidate(ImportCandidate{Fields: map[FieldName]string{"Password": "saved-password", "Username": "user"}, NameHint: "work"})
+	config.AddCandidate(ImportCandidate{Fields: map[FieldName]string{"Token": "new-token"}})
+	config.AddCandidate(ImportCandidate{Fields: map[FieldName]string{"Username": "user", "Password": "other-password"}})
+	config.AddCandidate(ImportCandidate{Fields: map[FieldName]string{"Token": "saved-token", "Host": "example.com"}})
+
+	out.RemoveExisting(saved)
+
+	assert.Equal(t, 2, out.SkippedExisting)
+	assert.Empty(t, env.Candidates)
+	assert.Equal(t, []ImportCandidate{
+		{Fields: map[FieldName]string{"Token": "new-token"}},
+		{Fields: map[FieldName]string{"Username": "user", "Password": "other-password"}},
+		{Fields: map[FieldName]string{"Token": "saved-token", "Host": "example.com"}},
+	}, config.Candidates)
+}
+
+func TestImportOutputRemoveExistingNil(t *testing.T) {
+	out := ImportOutput{}
+	out.NewAttempt(ImportSource{Env: []string{"TOOL_TOKEN"}}).AddCandidate(ImportCandidate{Fields: map[FieldName]string{"Token": "token"}})
+
+	out.RemoveExisting(nil)
+
+	assert.Len(t, out.AllCandidates(), 1)
+	assert.Zero(t, out.SkippedExisting)
+}
+
+func TestExistingItemsHashFields(t *testing.T) {
+	items := &ExistingItems{Salt: []byte("salt")}
+	hash := items.HashFields(map[FieldName]string{"Token": "abc"})
+
+	assert.Len(t, hash, 32)
+	assert.Equal(t, hash, items.HashFields(map[FieldName]string{"Token": "abc"}))
+	assert.NotEqual(t, hash, (&ExistingItems{Salt: []byte("other salt")}).HashFields(map[FieldName]string{"Token": "abc"}))
+
+	// Moving characters between a field's name and value must not result in the same hash.
+	assert.NotEqual(t, hash, items.HashFields(map[FieldName]string{"Toke": "nabc"}))
+	assert.NotEqual(t,
+		items.HashFields(map[FieldName]string{"A": "b", "C": "d"}),
+		items.HashFields(map[FieldName]string{"A": "bC", "": "d"}),
+	)
+}
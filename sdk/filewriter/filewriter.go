@@ -0,0 +1,202 @@
+// Package filewriter writes the files and directories of a provision output to disk, and removes them again once the
+// executable exits. It implements the file conventions described on sdk.OutputFile and sdk.OutputDirectory.
+package filewriter
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/internal/acl"
+)
+
+const (
+	defaultFileMode fs.FileMode = 0600
+	defaultDirMode  fs.FileMode = 0700
+)
+
+// restrictor is used to restrict access to files and directories that should only be accessible by the current user.
+var restrictor = acl.Default()
+
+// Written keeps track of the files and directories that got written for a provision output, so that they can be
+// removed afterwards.
+type Written struct {
+	files []string
+
+	// dirs contains the directories that did not exist before, in the order they got created.
+	dirs []string
+
+	// Warnings contains the problems that did not prevent writing, such as failing to restrict access to a file on
+	// platforms where that requires more than setting the file mode.
+	Warnings []sdk.Warning
+}
+
+// Write writes the directories and files of the provision output to disk. Directories that already exist are left
+// untouched. If writing fails, everything that got written up to that point is removed again.
+func Write(out sdk.ProvisionOutput) (*Written, error) {
+	w := &Written{}
+
+	// Create parent directories before their children.
+	var dirPaths []string
+	for path := range out.Directories {
+		dirPaths = append(dirPaths, path)
+	}
+	sort.Slice(dirPaths, func(i, j int) bool {
+		return len(dirPaths[i]) < len(dirPaths[j])
+	})
+
+	for _, path := range dirPaths {
+		dir := out.Directories[path]
+		err := w.mkdirAll(path, fileMode(dir.FileMode, defaultDirMode, dir.OnlyAllowCurrentProcess), out.Directories)
+		if err != nil {
+			return nil, w.failed(err)
+		}
+	}
+
+	var filePaths []string
+	for path := range out.Files {
+		filePaths = append(filePaths, path)
+	}
+	sort.Strings(filePaths)
+
+	for _, path := range filePaths {
+		file := out.Files[path]
+		err := w.mkdirAll(filepath.Dir(path), defaultDirMode, out.Directories)
+		if err != nil {
+			return nil, w.failed(err)
+		}
+
+		err = w.writeFile(path, file.Contents, fileMode(file.FileMode, defaultFileMode, file.OnlyAllowCurrentProcess))
+		if err != nil {
+			return nil, w.failed(err)
+		}
+	}
+
+	return w, nil
+}
+
+// Remove removes all written files, and all created directories if they're empty.
+func (w *Written) Remove() error {
+	var errs []error
+	for _, path := range w.files {
+		err := os.Remove(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+
+	// Remove children before their parents. Directories that are not empty are left in place, since they contain
+	// files that were not provisioned.
+	for i := len(w.dirs) - 1; i >= 0; i-- {
+		entries, err := os.ReadDir(w.dirs[i])
+		if err != nil || len(entries) > 0 {
+			continue
+		}
+		err = os.Remove(w.dirs[i])
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("removing provisioned files: %v", errs)
+	}
+	return nil
+}
+
+// mkdirAll creates the directory and any missing parents, keeping track of the directories it creates. Missing parents
+// that are declared in dirs get created with their declared mode.
+func (w *Written) mkdirAll(path string, mode fs.FileMode, dirs map[string]sdk.OutputDirectory) error {
+	info, err := os.Stat(path)
+	if err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("%s exists, but is not a directory", path)
+		}
+		return nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	parent := filepath.Dir(path)
+	if parent != path {
+		parentMode := defaultDirMode
+		if dir, ok := dirs[parent]; ok {
+			parentMode = fileMode(dir.FileMode, defaultDirMode, dir.OnlyAllowCurrentProcess)
+		}
+		if err := w.mkdirAll(parent, parentMode, dirs); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Mkdir(path, mode); err != nil {
+		return err
+	}
+	w.dirs = append(w.dirs, path)
+
+	// Apply the mode explicitly, since the mode passed to os.Mkdir is subject to the umask.
+	if err := os.Chmod(path, mode); err != nil {
+		return err
+	}
+
+	w.restrict(path, mode)
+	return nil
+}
+
+func (w *Written) writeFile(path string, contents []byte, mode fs.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	w.files = append(w.files, path)
+
+	_, err = f.Write(contents)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	// Apply the mode explicitly, since the file may already have existed or the mode is subject to the umask.
+	if err := os.Chmod(path, mode); err != nil {
+		return err
+	}
+
+	w.restrict(path, mode)
+	return nil
+}
+
+// restrict restricts access to the current user if the mode doesn't grant access to anyone else, for platforms where
+// the mode alone is not enough. If that fails, the file or directory is still usable, so a warning gets reported.
+func (w *Written) restrict(path string, mode fs.FileMode) {
+	if mode&0077 != 0 {
+		return
+	}
+
+	if err := restrictor.RestrictToCurrentUser(path); err != nil {
+		w.Warnings = append(w.Warnings, sdk.Warning{
+			Message: fmt.Sprintf("could not restrict access to %s to the current user: %s", path, err),
+		})
+	}
+}
+
+func (w *Written) failed(err error) error {
+	if removeErr := w.Remove(); removeErr != nil {
+		return fmt.Errorf("%s; %s", err, removeErr)
+	}
+	return err
+}
+
+func fileMode(mode fs.FileMode, defaultMode fs.FileMode, onlyAllowCurrentProcess bool) fs.FileMode {
+	if mode == 0 {
+		mode = defaultMode
+	}
+	if onlyAllowCurrentProcess {
+		mode &= 0700
+	}
+	return mode
+}
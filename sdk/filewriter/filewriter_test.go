@@ -0,0 +1,163 @@
+package filewriter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/internal/acl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteNestedPaths(t *testing.T) {
+	root := t.TempDir()
+	sshDir := filepath.Join(root, "home", ".ssh")
+
+	out := sdk.ProvisionOutput{
+		Files: map[string]sdk.OutputFile{
+			filepath.Join(sshDir, "id_ed25519"):             {Contents: []byte("private key")},
+			filepath.Join(sshDir, "config"):                 {Contents: []byte("config"), FileMode: 0644},
+			filepath.Join(root, "gcloud", "configs", "dev"): {Contents: []byte("dev"), FileMode: 0644, OnlyAllowCurrentProcess: true},
+		},
+		Directories: map[string]sdk.OutputDirectory{
+			sshDir: {FileMode: 0750, OnlyAllowCurrentProcess: true},
+		},
+	}
+
+	written, err := Write(out)
+	require.NoError(t, err)
+
+	assertMode(t, filepath.Join(root, "home"), 0700)
+	assertMode(t, sshDir, 0700)
+	assertMode(t, filepath.Join(sshDir, "id_ed25519"), 0600)
+	assertMode(t, filepath.Join(sshDir, "config"), 0644)
+	assertMode(t, filepath.Join(root, "gcloud"), 0700)
+	assertMode(t, filepath.Join(root, "gcloud", "configs", "dev"), 0600)
+
+	contents, err := os.ReadFile(filepath.Join(sshDir, "id_ed25519"))
+	require.NoError(t, err)
+	assert.Equal(t, "private key", string(contents))
+
+	require.NoError(t, written.Remove())
+	entries, err := os.ReadDir(root)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestWritePreExistingDirectories(t *testing.T) {
+	root := t.TempDir()
+	configDir := filepath.Join(root, ".config")
+	require.NoError(t, os.Mkdir(configDir, 0755))
+	require.NoError(t, os.Chmod(configDir, 0755))
+
+	out := sdk.ProvisionOutput{
+		Files: map[string]sdk.OutputFile{
+			filepath.Join(configDir, "tool", "token"): {Contents: []byte("token")},
+		},
+		Directories: map[string]sdk.OutputDirectory{
+			configDir: {FileMode: 0700},
+		},
+	}
+
+	written, err := Write(out)
+	require.NoError(t, err)
+
+	// Pre-existing directories are left untouched.
+	assertMode(t, configDir, 0755)
+	assertMode(t, filepath.Join(configDir, "tool"), 0700)
+
+	// Created directories that are no longer empty are not removed.
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, "tool", "settings"), []byte("{}"), 0644))
+
+	require.NoError(t, written.Remove())
+	_, err = os.Stat(filepath.Join(configDir, "tool", "token"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(configDir, "tool", "settings"))
+	assert.NoError(t, err)
+	assertMode(t, configDir, 0755)
+}
+
+func assertMode(t *testing.T, path string, expected os.FileMode) {
+	t.Helper()
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, expected, info.Mode().Perm(), path)
+}
+
+type fakeRestrictor struct {
+	restricted []string
+	err        error
+}
+
+func (r *fakeRestrictor) RestrictToCurrentUser(path string) error {
+	r.restricted = append(r.restricted, path)
+	return r.err
+}
+
+func TestWriteRestrictsAccessToCurrentUser(t *testing.T) {
+	fake := &fakeRestrictor{}
+	restrictor = fake
+	defer func() { restrictor = acl.Default() }()
+
+	root := t.TempDir()
+	out := sdk.ProvisionOutput{
+		Files: map[string]sdk.OutputFile{
+			filepath.Join(root, "secrets", "token"): {Contents: []byte("token")},
+			filepath.Join(root, "secrets", "host"):  {Contents: []byte("example.com"), FileMode: 0644},
+			filepath.Join(root, "secrets", "cert"):  {Contents: []byte("cert"), FileMode: 0644, OnlyAllowCurrentProcess: true},
+		},
+	}
+
+	written, err := Write(out)
+	require.NoError(t, err)
+	assert.Empty(t, written.Warnings)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(root, "secrets"),
+		filepath.Join(root, "secrets", "token"),
+		filepath.Join(root, "secrets", "cert"),
+	}, fake.restricted)
+	require.NoError(t, written.Remove())
+}
+
+func TestWriteFallsBackWhenRestrictingAccessFails(t *testing.T) {
+	restrictor = &fakeRestrictor{err: errors.New("access denied")}
+	defer func() { restrictor = acl.Default() }()
+
+	root := t.TempDir()
+	path := filepath.Join(root, "token")
+	written, err := Write(sdk.ProvisionOutput{
+		Files: map[string]sdk.OutputFile{
+			path: {Contents: []byte("token")},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []sdk.Warning{{Message: "could not restrict access to " + path + " to the current user: access denied"}}, written.Warnings)
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "token", string(contents))
+	require.NoError(t, written.Remove())
+}
+
+func TestWriteExecutableScript(t *testing.T) {
+	root := t.TempDir()
+	out := sdk.ProvisionOutput{
+		Environment: make(map[string]string),
+		Files:       make(map[string]sdk.OutputFile),
+	}
+	path := out.AddExecutableScript(sdk.ProvisionInput{
+		TempDir:            root,
+		ParentEnvVarValues: map[string]string{"PATH": "/usr/bin"},
+	}, "askpass", []byte("#!/bin/sh\necho secret\n"))
+	require.Empty(t, out.Diagnostics.Errors)
+
+	written, err := Write(out)
+	require.NoError(t, err)
+	defer written.Remove()
+
+	assertMode(t, filepath.Join(root, "bin"), 0700)
+	assertMode(t, path, 0700)
+}
@@ -2,7 +2,11 @@ package sdk
 
 import (
 	"context"
+	"fmt"
+	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"time"
 )
 
@@ -13,13 +17,99 @@ type Importer func(ctx context.Context, in ImportInput, out *ImportOutput)
 // ImportCandidate represents a single occurrence of a plugin's credential that was
 // detected on the system.
 type ImportCandidate struct {
-	Fields    map[FieldName]string
-	NameHint  string
+	Fields   map[FieldName]string
+	NameHint string
+
+	// (Optional) ExpiresAt is when the credential stops working, for session-style credentials that have their
+	// expiry stored next to them, e.g. cached SSO tokens. Candidates that are already expired get flagged with a
+	// warning on the import attempt.
 	ExpiresAt *time.Time
+
+	// (Optional) Confidence indicates how likely it is that the candidate is current. Importers of the tool's own
+	// config files default to ConfidenceHigh and importers of environment variables to ConfidenceMedium.
+	Confidence Confidence
+
+	// (Optional) CredentialType is set for candidates of another credential type of the same plugin than the one the
+	// importer belongs to, e.g. an auth token found in the same config file as an API key. Use
+	// ImportAttempt.AddCandidateFor to set it.
+	CredentialType CredentialName
+
+	// (Optional) Warnings about the candidate itself, e.g. a field value that doesn't look like values of that field
+	// usually do. The candidate can still be imported, but gets flagged to the user.
+	Warnings []Warning
+
+	// (Optional) FieldSources records where the value of each field came from, for candidates that are assembled from
+	// multiple sources, e.g. a token from a config file and an account ID from an environment variable. The built-in
+	// importers set it automatically. A field can have multiple sources if the same candidate was found in more than
+	// one place.
+	FieldSources map[FieldName][]FieldSource
+}
+
+// FieldSource is where the value of a single field of an import candidate came from.
+type FieldSource struct {
+	// EnvVar is the name of the environment variable that contains the value.
+	EnvVar string
+
+	// File is the path of the file that contains the value, and Key the key, selector or variable name of the value
+	// in that file, e.g. "hosts.github.com.oauth_token". Key is empty if the file as a whole is the source.
+	File string
+	Key  string
+
+	// Other describes sources that are not an environment variable or a file, e.g. "macOS Keychain: example.com".
+	Other string
+}
+
+// AddFieldSource records the source of the value of a field, unless that source was already recorded for the field.
+func (c *ImportCandidate) AddFieldSource(fieldName FieldName, source FieldSource) {
+	for _, existing := range c.FieldSources[fieldName] {
+		if existing == source {
+			return
+		}
+	}
+	if c.FieldSources == nil {
+		c.FieldSources = make(map[FieldName][]FieldSource)
+	}
+	c.FieldSources[fieldName] = append(c.FieldSources[fieldName], source)
+}
+
+// MergeFieldSources adds the field sources of another candidate with the same fields to this candidate, e.g. when the
+// same credential was found in multiple places and only one candidate is kept.
+func (c *ImportCandidate) MergeFieldSources(other ImportCandidate) {
+	for fieldName, sources := range other.FieldSources {
+		for _, source := range sources {
+			c.AddFieldSource(fieldName, source)
+		}
+	}
+}
+
+// Confidence indicates how likely it is that an import candidate is current, e.g. high for the tool's own config
+// file and low for a backup file. Candidates are sorted by confidence before they're presented to the user.
+type Confidence int
+
+const (
+	// ConfidenceUnspecified is used for candidates that don't specify a confidence. They rank the same as candidates
+	// with ConfidenceMedium.
+	ConfidenceUnspecified Confidence = iota
+	ConfidenceLow
+	ConfidenceMedium
+	ConfidenceHigh
+)
+
+// IsValid returns whether the confidence is one of the defined values.
+func (c Confidence) IsValid() bool {
+	return c >= ConfidenceUnspecified && c <= ConfidenceHigh
+}
+
+// rank returns the position of the confidence in the sort order, treating an unspecified confidence as medium.
+func (c Confidence) rank() Confidence {
+	if c == ConfidenceUnspecified {
+		return ConfidenceMedium
+	}
+	return c
 }
 
 func (c *ImportCandidate) Equal(other ImportCandidate) bool {
-	if len(c.Fields) != len(other.Fields) {
+	if c.CredentialType != other.CredentialType || len(c.Fields) != len(other.Fields) {
 		return false
 	}
 
@@ -47,12 +137,41 @@ type ImportInput struct {
 	HomeDir string
 	RootDir string
 
-	// Supported values: "darwin", "linux"
+	// Supported values: "darwin", "linux", "windows"
 	OS string
+
+	// FilePath is the absolute path of the file that's being imported from. It's set by file importers such as
+	// importer.TryFile and importer.TryAllFilesMatching.
+	FilePath string
+
+	// WorkingDir is the directory from which the user runs the import, e.g. a project directory. Importers can use it
+	// to look for project-local config files, such as .env files.
+	WorkingDir string
+
+	// ConfigDir is the user's config directory: $XDG_CONFIG_HOME if set, and otherwise ~/.config on Linux,
+	// ~/Library/Application Support on macOS, and %AppData% on Windows. Use FromConfigDir to build paths in it, which
+	// also resolves the directory if it's not set.
+	ConfigDir string
+
+	// CacheDir is the user's cache directory: $XDG_CACHE_HOME if set, and otherwise ~/.cache on Linux,
+	// ~/Library/Caches on macOS, and %LocalAppData% on Windows. Use FromCacheDir to build paths in it, which also
+	// resolves the directory if it's not set.
+	CacheDir string
+
+	// ProfileName is the name of the profile or section of the file that's being imported from. It's set by profile
+	// importers such as importer.TryAllProfiles.
+	ProfileName string
+
+	// (Optional) ExistingItems is set by the host to the salted hashes of the items the user already saved for the
+	// credential type. Candidates that match one of them are removed after the import.
+	ExistingItems *ExistingItems
 }
 
 type ImportOutput struct {
 	Attempts []*ImportAttempt
+
+	// SkippedExisting is the number of candidates that were removed because they match an item the user already saved.
+	SkippedExisting int
 }
 
 type ImportAttempt struct {
@@ -75,6 +194,27 @@ func (out *ImportOutput) AllCandidates() (candidates []ImportCandidate) {
 	return
 }
 
+// SortByConfidence sorts the candidates of each attempt by confidence, from high to low, and then sorts the attempts by
+// the confidence of their best candidate. Attempts without candidates come last. Candidates and attempts with the
+// same confidence keep the order in which their sources were tried.
+func (out *ImportOutput) SortByConfidence() {
+	for _, attempt := range out.Attempts {
+		sort.SliceStable(attempt.Candidates, func(i, j int) bool {
+			return attempt.Candidates[i].Confidence.rank() > attempt.Candidates[j].Confidence.rank()
+		})
+	}
+
+	bestRank := func(attempt *ImportAttempt) Confidence {
+		if len(attempt.Candidates) == 0 {
+			return ConfidenceUnspecified
+		}
+		return attempt.Candidates[0].Confidence.rank()
+	}
+	sort.SliceStable(out.Attempts, func(i, j int) bool {
+		return bestRank(out.Attempts[i]) > bestRank(out.Attempts[j])
+	})
+}
+
 func (out *ImportOutput) NewAttempt(src ImportSource) *ImportAttempt {
 	attempt := &ImportAttempt{
 		Source: src,
@@ -83,14 +223,46 @@ func (out *ImportOutput) NewAttempt(src ImportSource) *ImportAttempt {
 	return attempt
 }
 
+// AddCandidate adds the candidate to the import attempt. Candidates with an invalid confidence are reported as an
+// error instead. Candidates that are already expired are added with a warning.
 func (out *ImportAttempt) AddCandidate(candidate ImportCandidate) {
+	if !candidate.Confidence.IsValid() {
+		out.AddError(fmt.Errorf("import candidate has invalid confidence %d", candidate.Confidence))
+		return
+	}
+	if candidate.ExpiresAt != nil && !candidate.ExpiresAt.After(time.Now()) {
+		name := ""
+		if candidate.NameHint != "" {
+			name = fmt.Sprintf(" '%s'", candidate.NameHint)
+		}
+		out.AddWarning(fmt.Sprintf("import candidate%s expired at %s", name, candidate.ExpiresAt.Format(time.RFC3339)))
+	}
 	out.Candidates = append(out.Candidates, candidate)
 }
 
+// AddCandidateFor adds a candidate for another credential type of the same plugin to the import attempt, for sources
+// that contain multiple kinds of credentials, e.g. a config file with both an API key and an auth token. The candidate
+// gets checked against the plugin's credential types after the import and is reported as an error instead if that
+// credential type is not part of the plugin or if the fields don't match.
+func (out *ImportAttempt) AddCandidateFor(credentialType CredentialName, candidate ImportCandidate) {
+	candidate.CredentialType = credentialType
+	out.AddCandidate(candidate)
+}
+
 func (out *ImportAttempt) AddError(err error) {
 	out.Diagnostics.Errors = append(out.Diagnostics.Errors, Error{err.Error()})
 }
 
+// AddWarning can be used to report a warning to the import attempt, for problems that don't make the attempt fail.
+func (out *ImportAttempt) AddWarning(message string) {
+	out.Diagnostics.Warnings = append(out.Diagnostics.Warnings, Warning{message})
+}
+
+// AddNote can be used to add an informational note to the import attempt, e.g. to record that a source was skipped.
+func (out *ImportAttempt) AddNote(message string) {
+	out.Diagnostics.Notes = append(out.Diagnostics.Notes, Note{message})
+}
+
 func (in *ImportInput) FromHomeDir(path ...string) string {
 	return filepath.Join(append([]string{in.HomeDir}, path...)...)
 }
@@ -98,3 +270,36 @@ func (in *ImportInput) FromHomeDir(path ...string) string {
 func (in *ImportInput) FromRootDir(path ...string) string {
 	return filepath.Join(append([]string{in.RootDir}, path...)...)
 }
+
+// FromConfigDir returns a path with the user's config directory prepended. If ConfigDir is not set, it gets resolved
+// for the OS of the input in the same way as ProvisionInput.FromConfigDir does.
+func (in *ImportInput) FromConfigDir(path ...string) string {
+	dir := in.ConfigDir
+	if dir == "" {
+		dir = userConfigDir(in.goos(), os.Getenv, in.HomeDir)
+	}
+	return filepath.Join(append([]string{dir}, path...)...)
+}
+
+// FromCacheDir returns a path with the user's cache directory prepended. If CacheDir is not set, it gets resolved for
+// the OS of the input in the same way as ProvisionInput.FromCacheDir does.
+func (in *ImportInput) FromCacheDir(path ...string) string {
+	dir := in.CacheDir
+	if dir == "" {
+		dir = userCacheDir(in.goos(), os.Getenv, in.HomeDir)
+	}
+	return filepath.Join(append([]string{dir}, path...)...)
+}
+
+// FromWorkingDir returns a path with the directory from which the import is run prepended.
+func (in *ImportInput) FromWorkingDir(path ...string) string {
+	return filepath.Join(append([]string{in.WorkingDir}, path...)...)
+}
+
+// goos returns the OS of the input, falling back to the OS the plugin runs on.
+func (in *ImportInput) goos() string {
+	if in.OS != "" {
+		return in.OS
+	}
+	return runtime.GOOS
+}
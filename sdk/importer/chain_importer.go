@@ -0,0 +1,57 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// Chain imports from files whose location is only known at import time, for tools whose main config file or
+// environment only records where the credentials file lives, e.g. a "credentials_file" key in a config file or an
+// environment variable that names a directory.
+//
+// The locate function returns the paths of the files to import from, in order of preference. It can consult the
+// environment or read a first file to find them. Paths are resolved like in TryFile, so they can start with "~/". If
+// locate returns an error that wraps fs.ErrNotExist, such as the error of os.ReadFile for a config file that doesn't
+// exist, nothing is imported. Other errors are reported on the output: errors reading a file as a warning, like in
+// TryFile, and any other error as an error.
+//
+// The then function gets called once for every located file that exists, with its contents and the path as returned
+// by locate. Its path is also set as FilePath on the input. Paths that resolve to the same file are only tried once.
+func Chain(locate func(ctx context.Context, in sdk.ImportInput) (paths []string, err error), then func(ctx context.Context, contents FileContents, path string, in sdk.ImportInput, out *sdk.ImportAttempt)) sdk.Importer {
+	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+		paths, err := locate(ctx, in)
+		if errors.Is(err, fs.ErrNotExist) {
+			return
+		}
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			display := displayPath(resolvePath(pathErr.Path, in), in)
+			out.NewAttempt(SourceFile(display)).AddWarning(fmt.Sprintf("cannot read %s: %s", display, pathErr.Err))
+			return
+		} else if err != nil {
+			out.NewAttempt(sdk.ImportSource{}).AddError(err)
+			return
+		}
+
+		tried := make(map[string]bool, len(paths))
+		for _, path := range paths {
+			if path == "" {
+				continue
+			}
+			abspath := resolvePath(path, in)
+			if tried[abspath] {
+				continue
+			}
+			tried[abspath] = true
+
+			located := path
+			TryFile(path, func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+				then(ctx, contents, located, in, out)
+			})(ctx, in, out)
+		}
+	}
+}
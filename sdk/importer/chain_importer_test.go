@@ -0,0 +1,122 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain(t *testing.T) {
+	// The config file of the tool only records where its credentials file lives.
+	locateFromConfig := func(ctx context.Context, in sdk.ImportInput) ([]string, error) {
+		contents, err := os.ReadFile(in.FromHomeDir(".tool", "config.json"))
+		if err != nil {
+			return nil, err
+		}
+		path, _, err := FileContents(contents).JSONLookup("credentials_file")
+		return []string{path}, err
+	}
+	tryToken := func(locate func(ctx context.Context, in sdk.ImportInput) ([]string, error)) sdk.Importer {
+		return Chain(locate, func(ctx context.Context, contents FileContents, path string, in sdk.ImportInput, out *sdk.ImportAttempt) {
+			out.AddCandidate(sdk.ImportCandidate{
+				Fields:   map[sdk.FieldName]string{fieldname.Token: contents.ToString()},
+				NameHint: path,
+			})
+		})
+	}
+
+	t.Run("located by a config file", func(t *testing.T) {
+		homeDir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(homeDir, ".tool", "credentials"), 0700))
+		require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".tool", "config.json"), []byte(`{"credentials_file": "~/.tool/credentials/work"}`), 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".tool", "credentials", "work"), []byte("token"), 0600))
+
+		out := sdk.ImportOutput{}
+		tryToken(locateFromConfig)(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+		assert.Empty(t, out.Errors())
+		assert.Equal(t, []sdk.ImportCandidate{{
+			Fields:       map[sdk.FieldName]string{fieldname.Token: "token"},
+			NameHint:     "~/.tool/credentials/work",
+			Confidence:   sdk.ConfidenceHigh,
+			FieldSources: map[sdk.FieldName][]sdk.FieldSource{fieldname.Token: {{File: "~/.tool/credentials/work"}}},
+		}}, out.AllCandidates())
+		assert.Equal(t, SourceFile("~/.tool/credentials/work"), out.Attempts[0].Source)
+	})
+
+	t.Run("missing config file", func(t *testing.T) {
+		out := sdk.ImportOutput{}
+		tryToken(locateFromConfig)(context.Background(), sdk.ImportInput{HomeDir: t.TempDir()}, &out)
+
+		assert.Empty(t, out.Attempts)
+	})
+
+	t.Run("unreadable config file", func(t *testing.T) {
+		homeDir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(homeDir, ".tool", "config.json"), 0700))
+
+		out := sdk.ImportOutput{}
+		tryToken(locateFromConfig)(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+		assert.Empty(t, out.AllCandidates())
+		require.Len(t, out.Attempts, 1)
+		assert.Equal(t, SourceFile("~/.tool/config.json"), out.Attempts[0].Source)
+		assert.Equal(t, []sdk.Warning{{Message: "cannot read ~/.tool/config.json: is a directory"}}, out.Attempts[0].Diagnostics.Warnings)
+	})
+
+	t.Run("invalid config file", func(t *testing.T) {
+		homeDir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(homeDir, ".tool"), 0700))
+		require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".tool", "config.json"), []byte(`{"credentials_file": 1}`), 0600))
+
+		out := sdk.ImportOutput{}
+		tryToken(locateFromConfig)(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+		assert.Empty(t, out.AllCandidates())
+		assert.Equal(t, []sdk.Error{{Message: "expected string at 'credentials_file', found number"}}, out.Errors())
+	})
+
+	t.Run("located file doesn't exist", func(t *testing.T) {
+		homeDir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(homeDir, ".tool"), 0700))
+		require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".tool", "config.json"), []byte(`{"credentials_file": "~/.tool/missing"}`), 0600))
+
+		out := sdk.ImportOutput{}
+		tryToken(locateFromConfig)(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+		assert.Empty(t, out.AllCandidates())
+		assert.Empty(t, out.Errors())
+		require.Len(t, out.Attempts, 1)
+		assert.Empty(t, out.Attempts[0].Diagnostics.Warnings)
+	})
+
+	t.Run("same file located twice", func(t *testing.T) {
+		homeDir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(homeDir, "token"), []byte("token"), 0600))
+
+		out := sdk.ImportOutput{}
+		tryToken(func(ctx context.Context, in sdk.ImportInput) ([]string, error) {
+			return []string{"", "~/token", filepath.Join(homeDir, "token")}, nil
+		})(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+		require.Len(t, out.Attempts, 1)
+		assert.Len(t, out.AllCandidates(), 1)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		out := sdk.ImportOutput{}
+		tryToken(func(ctx context.Context, in sdk.ImportInput) ([]string, error) {
+			return nil, errors.New("cannot locate credentials")
+		})(context.Background(), sdk.ImportInput{HomeDir: t.TempDir()}, &out)
+
+		assert.Empty(t, out.AllCandidates())
+		assert.Equal(t, []sdk.Error{{Message: "cannot locate credentials"}}, out.Errors())
+	})
+}
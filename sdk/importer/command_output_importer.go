@@ -0,0 +1,116 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// defaultCommandTimeout is how long a command gets to produce its output, unless overridden with CommandTimeout.
+const defaultCommandTimeout = 5 * time.Second
+
+// commandOutputImporter holds the settings of a command output importer.
+type commandOutputImporter struct {
+	description string
+	timeout     time.Duration
+}
+
+// CommandOutputOption can be used to configure a command output importer.
+type CommandOutputOption func(*commandOutputImporter)
+
+// CommandDescription sets the description that's shown to the user as the source of the candidates, e.g. "GitHub CLI
+// auth token". Defaults to "Command output".
+func CommandDescription(description string) CommandOutputOption {
+	return func(i *commandOutputImporter) {
+		i.description = description
+	}
+}
+
+// CommandTimeout sets how long the command gets to produce its output before it gets killed. Defaults to 5 seconds.
+func CommandTimeout(timeout time.Duration) CommandOutputOption {
+	return func(i *commandOutputImporter) {
+		i.timeout = timeout
+	}
+}
+
+// TryCommandOutput runs the specified command, e.g. []string{"gh", "auth", "token"}, and maps its stdout to the fields
+// of a candidate using parse. This is meant for tools that only expose their stored credentials through a subcommand,
+// so plugins have to opt in to it explicitly. Only use it for commands that print credentials without side effects or
+// user interaction.
+//
+// The executable is always resolved from the PATH and is never run if the command contains a path instead. The command
+// gets killed if it doesn't finish within the timeout. If the executable is not installed, it's silently skipped. If
+// the command exits with a non-zero exit code, there's no candidate and its stderr gets reported as a warning, since
+// that usually just means the user is not logged in. The command line gets recorded as the source of the candidate.
+// Returning no fields from parse results in no candidate.
+func TryCommandOutput(cmd []string, parse func(stdout []byte) map[string]string, opts ...CommandOutputOption) sdk.Importer {
+	settings := commandOutputImporter{
+		description: "Command output",
+		timeout:     defaultCommandTimeout,
+	}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+		attempt := out.NewAttempt(SourceOther(settings.description, strings.Join(cmd, " ")))
+		if len(cmd) == 0 {
+			attempt.AddError(errors.New("no command specified"))
+			return
+		}
+		if strings.ContainsAny(cmd[0], `/\`) {
+			attempt.AddError(fmt.Errorf("executable '%s' must be a name that gets resolved from the PATH, not a path", cmd[0]))
+			return
+		}
+
+		path, err := exec.LookPath(cmd[0])
+		if errors.Is(err, exec.ErrNotFound) {
+			return
+		} else if err != nil {
+			attempt.AddError(err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, settings.timeout)
+		defer cancel()
+
+		var stdout, stderr bytes.Buffer
+		command := exec.CommandContext(ctx, path, cmd[1:]...)
+		command.Stdout = &stdout
+		command.Stderr = &stderr
+		err = command.Run()
+		if ctx.Err() == context.DeadlineExceeded {
+			attempt.AddError(fmt.Errorf("'%s' did not finish within %s", strings.Join(cmd, " "), settings.timeout))
+			return
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			attempt.AddWarning(fmt.Sprintf("'%s' exited with code %d: %s", strings.Join(cmd, " "), exitErr.ExitCode(), strings.TrimSpace(stderr.String())))
+			return
+		} else if err != nil {
+			attempt.AddError(err)
+			return
+		}
+
+		mapped := parse(stdout.Bytes())
+		if len(mapped) == 0 {
+			return
+		}
+
+		fields := make(map[sdk.FieldName]string, len(mapped))
+		for fieldName, value := range mapped {
+			fields[sdk.FieldName(fieldName)] = value
+		}
+		candidate := sdk.ImportCandidate{
+			Fields: fields,
+		}
+		defaultFieldSource(&candidate, sdk.FieldSource{Other: fmt.Sprintf("%s: %s", settings.description, strings.Join(cmd, " "))})
+		attempt.AddCandidate(candidate)
+	}
+}
@@ -0,0 +1,101 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// installStubExecutable puts an executable with the specified shell script on the PATH.
+func installStubExecutable(t *testing.T, name string, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("stub executables are shell scripts")
+	}
+
+	binDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, name), []byte("#!/bin/sh\n"+script+"\n"), 0700))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func parseToken(stdout []byte) map[string]string {
+	token := strings.TrimSpace(string(stdout))
+	if token == "" {
+		return nil
+	}
+	return map[string]string{fieldname.Token.String(): token}
+}
+
+func TestTryCommandOutput(t *testing.T) {
+	installStubExecutable(t, "stub-cli", `[ "$1 $2" = "auth token" ] && echo "token-$1"`)
+
+	out := sdk.ImportOutput{}
+	TryCommandOutput([]string{"stub-cli", "auth", "token"}, parseToken, CommandDescription("Stub CLI auth token"))(context.Background(), sdk.ImportInput{}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Equal(t, []sdk.ImportCandidate{
+		{Fields: map[sdk.FieldName]string{fieldname.Token: "token-auth"}},
+	}, withoutFieldSources(out.AllCandidates()))
+	assert.Equal(t, SourceOther("Stub CLI auth token", "stub-cli auth token"), out.Attempts[0].Source)
+}
+
+func TestTryCommandOutputNoFields(t *testing.T) {
+	installStubExecutable(t, "stub-cli", `echo ""`)
+
+	out := sdk.ImportOutput{}
+	TryCommandOutput([]string{"stub-cli"}, parseToken)(context.Background(), sdk.ImportInput{}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Empty(t, out.AllCandidates())
+	assert.Equal(t, SourceOther("Command output", "stub-cli"), out.Attempts[0].Source)
+}
+
+func TestTryCommandOutputNonZeroExit(t *testing.T) {
+	installStubExecutable(t, "stub-cli", `echo "token"; echo "not logged in" >&2; exit 4`)
+
+	out := sdk.ImportOutput{}
+	TryCommandOutput([]string{"stub-cli", "auth", "token"}, parseToken)(context.Background(), sdk.ImportInput{}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Empty(t, out.AllCandidates())
+	assert.Equal(t, []sdk.Warning{{Message: "'stub-cli auth token' exited with code 4: not logged in"}}, out.Attempts[0].Diagnostics.Warnings)
+}
+
+func TestTryCommandOutputTimeout(t *testing.T) {
+	installStubExecutable(t, "stub-cli", `exec sleep 5`)
+
+	out := sdk.ImportOutput{}
+	TryCommandOutput([]string{"stub-cli"}, parseToken, CommandTimeout(50*time.Millisecond))(context.Background(), sdk.ImportInput{}, &out)
+
+	assert.Empty(t, out.AllCandidates())
+	assert.Equal(t, []sdk.Error{{Message: "'stub-cli' did not finish within 50ms"}}, out.Errors())
+}
+
+func TestTryCommandOutputNotInstalled(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	out := sdk.ImportOutput{}
+	TryCommandOutput([]string{"stub-cli"}, parseToken)(context.Background(), sdk.ImportInput{}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Empty(t, out.AllCandidates())
+}
+
+func TestTryCommandOutputRejectsPaths(t *testing.T) {
+	for _, executable := range []string{"/usr/bin/stub-cli", "./stub-cli", `bin\stub-cli`} {
+		out := sdk.ImportOutput{}
+		TryCommandOutput([]string{executable}, parseToken)(context.Background(), sdk.ImportInput{}, &out)
+
+		assert.Empty(t, out.AllCandidates())
+		assert.Equal(t, []sdk.Error{{Message: "executable '" + executable + "' must be a name that gets resolved from the PATH, not a path"}}, out.Errors())
+	}
+}
@@ -0,0 +1,160 @@
+package importer
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// DockerCredential is the credential of a registry in the Docker config file.
+type DockerCredential struct {
+	Username string
+	Password string
+
+	// IdentityToken is set instead of a password for registries that use OAuth, such as Azure Container Registry.
+	// The username is usually a placeholder in that case.
+	IdentityToken string
+}
+
+type dockerConfig struct {
+	Auths       map[string]dockerAuth `json:"auths"`
+	CredsStore  string                `json:"credsStore"`
+	CredHelpers map[string]string     `json:"credHelpers"`
+}
+
+type dockerAuth struct {
+	Auth          string `json:"auth"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	IdentityToken string `json:"identitytoken"`
+}
+
+// TryDockerConfig looks for the credential of the specified registry, e.g. "ghcr.io" or "docker.io", that `docker login`
+// stored in the Docker config file: $DOCKER_CONFIG/config.json, ~/.docker/config.json, or the legacy ~/.dockercfg.
+// The credential gets mapped to the fields of a candidate by mapFields, with the registry host as name hint. Returning
+// no fields from mapFields results in no candidate.
+//
+// If Docker delegates the credential of the registry to a credential helper, e.g. the macOS Keychain through
+// credsStore or credHelpers, it's not in the config file, which gets recorded as a note on the attempt.
+func TryDockerConfig(registryHost string, mapFields func(credential DockerCredential) map[string]string) sdk.Importer {
+	registryHost = normalizeRegistryHost(registryHost)
+
+	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+		configPath := "~/.docker/config.json"
+		if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+			configPath = filepath.Join(dir, "config.json")
+		}
+
+		TryFile(configPath, func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+			var config dockerConfig
+			if err := contents.ToJSON(&config); err != nil {
+				out.AddError(err)
+				return
+			}
+
+			if helper, ok := findRegistry(config.CredHelpers, registryHost); ok {
+				out.AddNote(fmt.Sprintf("Credentials for %s are stored by docker-credential-%s", registryHost, helper))
+				return
+			}
+			auth, ok := findRegistry(config.Auths, registryHost)
+			if !ok {
+				return
+			}
+			if auth == (dockerAuth{}) && config.CredsStore != "" {
+				out.AddNote(fmt.Sprintf("Credentials for %s are stored by docker-credential-%s", registryHost, config.CredsStore))
+				return
+			}
+			addDockerCandidate(out, registryHost, auth, mapFields)
+		})(ctx, in, out)
+
+		TryFile("~/.dockercfg", func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+			// The legacy format only consists of what's now the auths object.
+			var auths map[string]dockerAuth
+			if err := contents.ToJSON(&auths); err != nil {
+				out.AddError(err)
+				return
+			}
+
+			if auth, ok := findRegistry(auths, registryHost); ok {
+				addDockerCandidate(out, registryHost, auth, mapFields)
+			}
+		})(ctx, in, out)
+	}
+}
+
+func addDockerCandidate(out *sdk.ImportAttempt, registryHost string, auth dockerAuth, mapFields func(credential DockerCredential) map[string]string) {
+	credential := DockerCredential{
+		Username:      auth.Username,
+		Password:      auth.Password,
+		IdentityToken: auth.IdentityToken,
+	}
+	if auth.Auth != "" {
+		decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+		if err != nil {
+			out.AddError(fmt.Errorf("auth of registry %s is not valid base64", registryHost))
+			return
+		}
+		username, password, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			out.AddError(fmt.Errorf("auth of registry %s is not in the format username:password", registryHost))
+			return
+		}
+		credential.Username = username
+		credential.Password = password
+	}
+	if credential.Password == "" && credential.IdentityToken == "" {
+		return
+	}
+
+	mapped := mapFields(credential)
+	if len(mapped) == 0 {
+		return
+	}
+	fields := make(map[sdk.FieldName]string, len(mapped))
+	for fieldName, value := range mapped {
+		fields[sdk.FieldName(fieldName)] = value
+	}
+	out.AddCandidate(sdk.ImportCandidate{
+		Fields:   fields,
+		NameHint: SanitizeNameHint(registryHost),
+	})
+}
+
+// findRegistry returns the value of the registry in a map keyed by registry, in which the keys can be a host, such as
+// "ghcr.io", or a URL, such as "https://index.docker.io/v1/".
+func findRegistry[T any](registries map[string]T, registryHost string) (T, bool) {
+	keys := make([]string, 0, len(registries))
+	for key := range registries {
+		keys = append(keys, key)
+	}
+	// Prefer exact matches, which sort before URLs with the same host.
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if normalizeRegistryHost(key) == registryHost {
+			return registries[key], true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// normalizeRegistryHost returns the host of a registry, in the same way as Docker does: without scheme and path, and
+// with the aliases of Docker Hub mapped to index.docker.io.
+func normalizeRegistryHost(registry string) string {
+	host := strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+	host, _, _ = strings.Cut(host, "/")
+	host = strings.ToLower(host)
+
+	switch host {
+	case "docker.io", "registry-1.docker.io":
+		return "index.docker.io"
+	}
+	return host
+}
@@ -0,0 +1,101 @@
+package importer
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/joho/godotenv"
+)
+
+// dotEnvFilenames are the names of the .env files that are looked for in each directory, in priority order.
+var dotEnvFilenames = []string{".env.local", ".env"}
+
+type dotEnvImporter struct {
+	varNames    map[string]sdk.FieldName
+	searchDepth int
+}
+
+// DotEnvOption can be used to configure a .env file importer.
+type DotEnvOption func(*dotEnvImporter)
+
+// DotEnvSearchDepth sets how many parent directories of the working directory are searched for .env files as well,
+// e.g. to find the .env file in the root of a project when the import is run from a subdirectory. Defaults to 0,
+// which means that only the working directory itself is searched.
+func DotEnvSearchDepth(depth int) DotEnvOption {
+	return func(i *dotEnvImporter) {
+		i.searchDepth = depth
+	}
+}
+
+// TryDotEnv looks for .env.local and .env files in the working directory and adds an import candidate for each file
+// that defines all of the specified variables, mapped to their fields. The files are parsed like shells and dotenv
+// libraries do: lines can start with "export", comments start with "#", values in single quotes are taken literally
+// and escape sequences in values in double quotes are unescaped. The name of the directory that contains the file is
+// used as the name hint, since it's usually the name of the project.
+func TryDotEnv(varNames map[string]sdk.FieldName, opts ...DotEnvOption) sdk.Importer {
+	settings := dotEnvImporter{
+		varNames: varNames,
+	}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+
+	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+		if in.WorkingDir == "" {
+			return
+		}
+
+		var found foundCandidates
+		dir := filepath.Clean(in.WorkingDir)
+		for depth := 0; depth <= settings.searchDepth; depth++ {
+			for _, filename := range dotEnvFilenames {
+				abspath := filepath.Join(dir, filename)
+				attempt := out.NewAttempt(SourceFile(displayPath(abspath, in)))
+				contents, _, ok := readFile(abspath, in, attempt, defaultMaxFileSize)
+				if !ok {
+					continue
+				}
+
+				candidate, err := settings.candidate(contents, displayPath(abspath, in))
+				if err != nil {
+					attempt.AddError(err)
+					continue
+				}
+				if candidate == nil {
+					continue
+				}
+				candidate.NameHint = SanitizeNameHint(filepath.Base(dir))
+				found.add(attempt, *candidate)
+			}
+
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+}
+
+// candidate returns a candidate if the .env file defines all of the variables, or nil otherwise.
+func (i dotEnvImporter) candidate(contents []byte, path string) (*sdk.ImportCandidate, error) {
+	env, err := godotenv.UnmarshalBytes(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	candidate := &sdk.ImportCandidate{
+		Fields:     make(map[sdk.FieldName]string, len(i.varNames)),
+		Confidence: sdk.ConfidenceMedium,
+	}
+	for varName, fieldName := range i.varNames {
+		value := env[varName]
+		if value == "" {
+			return nil, nil
+		}
+		candidate.Fields[fieldName] = value
+		candidate.AddFieldSource(fieldName, sdk.FieldSource{File: path, Key: varName})
+	}
+	return candidate, nil
+}
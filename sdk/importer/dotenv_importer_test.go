@@ -0,0 +1,119 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTryDotEnv(t *testing.T) {
+	projectDir := filepath.Join(t.TempDir(), "my-project")
+	require.NoError(t, os.Mkdir(projectDir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".env"), []byte(plugintest.LoadFixture(t, "dotenv")), 0600))
+
+	for _, scenario := range []struct {
+		description string
+		varNames    map[string]sdk.FieldName
+		expected    []sdk.ImportCandidate
+	}{
+		{
+			description: "export prefix and inline comment",
+			varNames:    map[string]sdk.FieldName{"STRIPE_API_KEY": fieldname.APIKey},
+			expected: []sdk.ImportCandidate{{
+				Fields:     map[sdk.FieldName]string{fieldname.APIKey: "sk_test_abc123"},
+				NameHint:   "my-project",
+				Confidence: sdk.ConfidenceMedium,
+			}},
+		},
+		{
+			description: "single-quoted value is taken literally",
+			varNames:    map[string]sdk.FieldName{"STRIPE_ACCOUNT": fieldname.AccountID},
+			expected: []sdk.ImportCandidate{{
+				Fields:     map[sdk.FieldName]string{fieldname.AccountID: `acct_literal\nvalue`},
+				NameHint:   "my-project",
+				Confidence: sdk.ConfidenceMedium,
+			}},
+		},
+		{
+			description: "double-quoted value is unescaped",
+			varNames:    map[string]sdk.FieldName{"STRIPE_DESCRIPTION": fieldname.Secret},
+			expected: []sdk.ImportCandidate{{
+				Fields:     map[sdk.FieldName]string{fieldname.Secret: "first line\nsecond \"quoted\" line"},
+				NameHint:   "my-project",
+				Confidence: sdk.ConfidenceMedium,
+			}},
+		},
+		{
+			description: "not all variables present",
+			varNames:    map[string]sdk.FieldName{"STRIPE_API_KEY": fieldname.APIKey, "STRIPE_MODE": fieldname.Mode},
+		},
+	} {
+		t.Run(scenario.description, func(t *testing.T) {
+			out := sdk.ImportOutput{}
+			TryDotEnv(scenario.varNames)(context.Background(), sdk.ImportInput{WorkingDir: projectDir}, &out)
+
+			assert.Empty(t, out.Errors())
+			assert.Equal(t, scenario.expected, withoutFieldSources(out.AllCandidates()))
+		})
+	}
+}
+
+func TestTryDotEnvLocal(t *testing.T) {
+	projectDir := filepath.Join(t.TempDir(), "my-project")
+	require.NoError(t, os.Mkdir(projectDir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".env"), []byte("OPENAI_API_KEY=sk-shared\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".env.local"), []byte("OPENAI_API_KEY=sk-local\n"), 0600))
+
+	out := sdk.ImportOutput{}
+	TryDotEnv(map[string]sdk.FieldName{"OPENAI_API_KEY": fieldname.APIKey})(context.Background(), sdk.ImportInput{WorkingDir: projectDir}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Equal(t, []sdk.ImportCandidate{
+		{Fields: map[sdk.FieldName]string{fieldname.APIKey: "sk-local"}, NameHint: "my-project", Confidence: sdk.ConfidenceMedium},
+		{Fields: map[sdk.FieldName]string{fieldname.APIKey: "sk-shared"}, NameHint: "my-project", Confidence: sdk.ConfidenceMedium},
+	}, withoutFieldSources(out.AllCandidates()))
+	assert.Equal(t, filepath.Join(projectDir, ".env.local"), out.Attempts[0].Source.Files[0])
+}
+
+func TestTryDotEnvSearchDepth(t *testing.T) {
+	projectDir := filepath.Join(t.TempDir(), "my-project")
+	subDir := filepath.Join(projectDir, "cmd", "server")
+	require.NoError(t, os.MkdirAll(subDir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".env"), []byte("NGROK_AUTHTOKEN=abc123\n"), 0600))
+
+	varNames := map[string]sdk.FieldName{"NGROK_AUTHTOKEN": fieldname.AuthToken}
+	in := sdk.ImportInput{WorkingDir: subDir}
+
+	t.Run("does not walk upward by default", func(t *testing.T) {
+		out := sdk.ImportOutput{}
+		TryDotEnv(varNames)(context.Background(), in, &out)
+		assert.Empty(t, out.AllCandidates())
+	})
+
+	t.Run("walks upward up to the search depth", func(t *testing.T) {
+		out := sdk.ImportOutput{}
+		TryDotEnv(varNames, DotEnvSearchDepth(1))(context.Background(), in, &out)
+		assert.Empty(t, out.AllCandidates())
+
+		out = sdk.ImportOutput{}
+		TryDotEnv(varNames, DotEnvSearchDepth(2))(context.Background(), in, &out)
+		assert.Equal(t, []sdk.ImportCandidate{{
+			Fields:     map[sdk.FieldName]string{fieldname.AuthToken: "abc123"},
+			NameHint:   "my-project",
+			Confidence: sdk.ConfidenceMedium,
+		}}, withoutFieldSources(out.AllCandidates()))
+	})
+}
+
+func TestTryDotEnvWithoutWorkingDir(t *testing.T) {
+	out := sdk.ImportOutput{}
+	TryDotEnv(map[string]sdk.FieldName{"STRIPE_API_KEY": fieldname.APIKey})(context.Background(), sdk.ImportInput{}, &out)
+	assert.Empty(t, out.Attempts)
+}
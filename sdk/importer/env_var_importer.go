@@ -3,6 +3,7 @@ package importer
 import (
 	"context"
 	"os"
+	"sort"
 
 	"github.com/1Password/shell-plugins/sdk"
 )
@@ -19,32 +20,93 @@ func TryAllEnvVars(fieldName sdk.FieldName, possibleEnvVarNames ...string) sdk.I
 					Fields: map[sdk.FieldName]string{
 						fieldName: value,
 					},
+					Confidence:   sdk.ConfidenceMedium,
+					FieldSources: map[sdk.FieldName][]sdk.FieldSource{fieldName: {{EnvVar: envVarName}}},
 				})
 			}
 		}
 	}
 }
 
+// TryEnvVar tries the specified environment variables in priority order, for credentials of which the value may be
+// in one of multiple environment variables, e.g. OPENAI_API_KEY or OPENAI_KEY. An import candidate gets added for the
+// first environment variable that is set and not empty, with that environment variable as source. If none of them
+// is set, the source lists all of them.
+func TryEnvVar(fieldName sdk.FieldName, possibleEnvVarNames ...string) sdk.Importer {
+	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+		for _, envVarName := range possibleEnvVarNames {
+			if value := os.Getenv(envVarName); value != "" {
+				out.NewAttempt(SourceEnvName(envVarName)).AddCandidate(sdk.ImportCandidate{
+					Fields: map[sdk.FieldName]string{
+						fieldName: value,
+					},
+					Confidence:   sdk.ConfidenceMedium,
+					FieldSources: map[sdk.FieldName][]sdk.FieldSource{fieldName: {{EnvVar: envVarName}}},
+				})
+				return
+			}
+		}
+		out.NewAttempt(SourceEnvVars(possibleEnvVarNames...))
+	}
+}
+
 // TryEnvVarPair tries the specified environment variables and adds an import candidate if at least
 // one environment variable is set.
 func TryEnvVarPair(pairPossibilities map[string]sdk.FieldName) sdk.Importer {
 	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
 		var envVarNames []string
-		candidateFields := make(map[sdk.FieldName]string)
+		candidate := sdk.ImportCandidate{
+			Fields:     make(map[sdk.FieldName]string),
+			Confidence: sdk.ConfidenceMedium,
+		}
 
 		for possibleEnvVarName, fieldName := range pairPossibilities {
 			if value := os.Getenv(possibleEnvVarName); value != "" {
-				candidateFields[fieldName] = value
+				candidate.Fields[fieldName] = value
+				candidate.AddFieldSource(fieldName, sdk.FieldSource{EnvVar: possibleEnvVarName})
 			}
 
 			envVarNames = append(envVarNames, possibleEnvVarName)
 		}
 
 		attempt := out.NewAttempt(SourceEnvVars(envVarNames...))
-		if len(candidateFields) > 0 {
-			attempt.AddCandidate(sdk.ImportCandidate{
-				Fields: candidateFields,
-			})
+		if len(candidate.Fields) > 0 {
+			attempt.AddCandidate(candidate)
+		}
+	}
+}
+
+// TryEnvVarPairs tries the specified alternative sets of environment variables in priority order, for platforms that
+// accept multiple spellings, e.g. GH_TOKEN and GITHUB_TOKEN. An import candidate gets added for every set of which
+// all environment variables are set, unless a set with a higher priority already resulted in the same candidate.
+// The source of each attempt lists the environment variables of its set.
+func TryEnvVarPairs(alternatives ...map[string]sdk.FieldName) sdk.Importer {
+	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+		var found foundCandidates
+		for _, envVarMapping := range alternatives {
+			envVarNames := make([]string, 0, len(envVarMapping))
+			for envVarName := range envVarMapping {
+				envVarNames = append(envVarNames, envVarName)
+			}
+			sort.Strings(envVarNames)
+
+			attempt := out.NewAttempt(SourceEnvVars(envVarNames...))
+			candidate := sdk.ImportCandidate{Fields: make(map[sdk.FieldName]string), Confidence: sdk.ConfidenceMedium}
+			for _, envVarName := range envVarNames {
+				value := os.Getenv(envVarName)
+				if value == "" {
+					// Only consider complete sets.
+					candidate.Fields = nil
+					break
+				}
+				candidate.Fields[envVarMapping[envVarName]] = value
+				candidate.AddFieldSource(envVarMapping[envVarName], sdk.FieldSource{EnvVar: envVarName})
+			}
+
+			if len(candidate.Fields) == 0 {
+				continue
+			}
+			found.add(attempt, candidate)
 		}
 	}
 }
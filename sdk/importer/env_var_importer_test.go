@@ -0,0 +1,109 @@
+package importer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTryEnvVar(t *testing.T) {
+	importer := TryEnvVar(fieldname.APIKey, "EXAMPLE_API_KEY", "EXAMPLE_KEY", "LEGACY_EXAMPLE_KEY")
+	run := func() sdk.ImportOutput {
+		out := sdk.ImportOutput{}
+		importer(context.Background(), sdk.ImportInput{}, &out)
+		return out
+	}
+
+	t.Run("priority", func(t *testing.T) {
+		t.Setenv("EXAMPLE_KEY", "key")
+		t.Setenv("LEGACY_EXAMPLE_KEY", "legacy-key")
+
+		out := run()
+		assert.Equal(t, []sdk.ImportCandidate{
+			{
+				Fields:       map[sdk.FieldName]string{fieldname.APIKey: "key"},
+				Confidence:   sdk.ConfidenceMedium,
+				FieldSources: map[sdk.FieldName][]sdk.FieldSource{fieldname.APIKey: {{EnvVar: "EXAMPLE_KEY"}}},
+			},
+		}, out.AllCandidates())
+		assert.Len(t, out.Attempts, 1)
+		assert.Equal(t, sdk.ImportSource{Env: []string{"EXAMPLE_KEY"}}, out.Attempts[0].Source)
+	})
+
+	t.Run("empty value", func(t *testing.T) {
+		t.Setenv("EXAMPLE_API_KEY", "")
+		t.Setenv("LEGACY_EXAMPLE_KEY", "legacy-key")
+
+		out := run()
+		assert.Equal(t, []sdk.ImportCandidate{
+			{
+				Fields:       map[sdk.FieldName]string{fieldname.APIKey: "legacy-key"},
+				Confidence:   sdk.ConfidenceMedium,
+				FieldSources: map[sdk.FieldName][]sdk.FieldSource{fieldname.APIKey: {{EnvVar: "LEGACY_EXAMPLE_KEY"}}},
+			},
+		}, out.AllCandidates())
+		assert.Equal(t, sdk.ImportSource{Env: []string{"LEGACY_EXAMPLE_KEY"}}, out.Attempts[0].Source)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		t.Setenv("EXAMPLE_API_KEY", "")
+
+		out := run()
+		assert.Empty(t, out.AllCandidates())
+		assert.Len(t, out.Attempts, 1)
+		assert.Equal(t, sdk.ImportSource{Env: []string{"EXAMPLE_API_KEY", "EXAMPLE_KEY", "LEGACY_EXAMPLE_KEY"}}, out.Attempts[0].Source)
+	})
+}
+
+func TestTryEnvVarPairs(t *testing.T) {
+	importer := TryEnvVarPairs(
+		map[string]sdk.FieldName{"EXAMPLE_TOKEN": fieldname.Token, "EXAMPLE_HOST": fieldname.Host},
+		map[string]sdk.FieldName{"EXAMPLE_ACCESS_TOKEN": fieldname.Token, "EXAMPLE_HOST": fieldname.Host},
+		map[string]sdk.FieldName{"LEGACY_EXAMPLE_TOKEN": fieldname.Token, "LEGACY_EXAMPLE_URL": fieldname.Host},
+	)
+	run := func() sdk.ImportOutput {
+		out := sdk.ImportOutput{}
+		importer(context.Background(), sdk.ImportInput{}, &out)
+		return out
+	}
+
+	t.Run("priority", func(t *testing.T) {
+		t.Setenv("EXAMPLE_HOST", "example.com")
+		t.Setenv("EXAMPLE_TOKEN", "token")
+		t.Setenv("EXAMPLE_ACCESS_TOKEN", "access-token")
+
+		out := run()
+		assert.Equal(t, []sdk.ImportCandidate{
+			{Fields: map[sdk.FieldName]string{fieldname.Token: "token", fieldname.Host: "example.com"}, Confidence: sdk.ConfidenceMedium},
+			{Fields: map[sdk.FieldName]string{fieldname.Token: "access-token", fieldname.Host: "example.com"}, Confidence: sdk.ConfidenceMedium},
+		}, withoutFieldSources(out.AllCandidates()))
+		assert.Equal(t, sdk.ImportSource{Env: []string{"EXAMPLE_HOST", "EXAMPLE_TOKEN"}}, out.Attempts[0].Source)
+		assert.Equal(t, sdk.ImportSource{Env: []string{"EXAMPLE_ACCESS_TOKEN", "EXAMPLE_HOST"}}, out.Attempts[1].Source)
+	})
+
+	t.Run("same values in multiple sets", func(t *testing.T) {
+		t.Setenv("EXAMPLE_HOST", "example.com")
+		t.Setenv("EXAMPLE_TOKEN", "token")
+		t.Setenv("EXAMPLE_ACCESS_TOKEN", "token")
+
+		out := run()
+		assert.Len(t, out.AllCandidates(), 1)
+		assert.Len(t, out.Attempts[0].Candidates, 1)
+		assert.Equal(t, map[sdk.FieldName][]sdk.FieldSource{
+			fieldname.Token: {{EnvVar: "EXAMPLE_TOKEN"}, {EnvVar: "EXAMPLE_ACCESS_TOKEN"}},
+			fieldname.Host:  {{EnvVar: "EXAMPLE_HOST"}},
+		}, out.Attempts[0].Candidates[0].FieldSources)
+	})
+
+	t.Run("partial match", func(t *testing.T) {
+		t.Setenv("EXAMPLE_TOKEN", "token")
+		t.Setenv("LEGACY_EXAMPLE_URL", "legacy.example.com")
+
+		out := run()
+		assert.Empty(t, out.AllCandidates())
+		assert.Len(t, out.Attempts, 3)
+	})
+}
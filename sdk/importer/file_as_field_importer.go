@@ -0,0 +1,78 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// defaultMaxFieldFileSize is the size above which a file is not imported as a field value, unless overridden with
+// MaxFileSize. Service account key files are a few KiB.
+const defaultMaxFieldFileSize = 64 * 1024
+
+// NameHintFromJSON sets the name hint of the candidate to the string at the selector in the JSON file, e.g.
+// "client_email" for service account key files. See JSONValue for the selector syntax. Only TryFileAsField uses it.
+func NameHintFromJSON(selector string) FileOption {
+	return func(i *fileImporter) {
+		i.nameHintSelector = selector
+	}
+}
+
+// TryFileAsField imports the complete contents of the file at the specified path as the value of a single field, for
+// credentials that are a whole document rather than a scalar, such as GCP service account key files. Trailing
+// whitespace is trimmed. If validate is set, it gets called with the contents to check the structure of the file,
+// e.g. using RequireJSONValue, and the file is reported as an error instead if it returns an error. Files larger than
+// 64 KiB are skipped with a warning, unless overridden with MaxFileSize.
+func TryFileAsField(path string, fieldName sdk.FieldName, validate func(contents []byte) error, opts ...FileOption) sdk.Importer {
+	opts = append([]FileOption{MaxFileSize(defaultMaxFieldFileSize)}, opts...)
+	settings := newFileImporter(opts)
+
+	return TryFile(path, func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+		display := displayPath(in.FilePath, in)
+		contents = bytes.TrimRight(contents, " \t\r\n")
+		if len(contents) == 0 {
+			return
+		}
+		if validate != nil {
+			if err := validate(contents); err != nil {
+				out.AddError(fmt.Errorf("cannot import %s as %s: %w", display, fieldName, err))
+				return
+			}
+		}
+
+		candidate := sdk.ImportCandidate{
+			Fields: map[sdk.FieldName]string{
+				fieldName: string(contents),
+			},
+		}
+		if settings.nameHintSelector != "" {
+			nameHint, _, err := contents.JSONLookup(settings.nameHintSelector)
+			if err != nil {
+				out.AddError(err)
+				return
+			}
+			candidate.NameHint = SanitizeNameHint(nameHint)
+		}
+		out.AddCandidate(candidate)
+	}, opts...)
+}
+
+// RequireJSONValue returns a validation function for TryFileAsField that requires the file to be a JSON document with
+// the specified string at the selector, e.g. RequireJSONValue("type", "service_account").
+func RequireJSONValue(selector string, expected string) func(contents []byte) error {
+	return func(contents []byte) error {
+		value, ok, err := FileContents(contents).JSONLookup(selector)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("'%s' is missing", selector)
+		}
+		if value != expected {
+			return fmt.Errorf("'%s' is '%s' instead of '%s'", selector, value, expected)
+		}
+		return nil
+	}
+}
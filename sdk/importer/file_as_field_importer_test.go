@@ -0,0 +1,99 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+)
+
+func TestTryFileAsField(t *testing.T) {
+	serviceAccount := plugintest.LoadFixture(t, "service-account.json")
+	importer := TryFileAsField("~/.config/gcloud/key.json", fieldname.Credentials, RequireJSONValue("type", "service_account"), NameHintFromJSON("client_email"))
+
+	plugintest.TestImporter(t, importer, map[string]plugintest.ImportCase{
+		"service account": {
+			Files: map[string]string{
+				"~/.config/gcloud/key.json": serviceAccount,
+			},
+			ExpectedCandidates: []sdk.ImportCandidate{
+				{
+					Fields:   map[sdk.FieldName]string{fieldname.Credentials: strings.TrimRight(serviceAccount, "\n")},
+					NameHint: SanitizeNameHint("deployer@my-project.iam.gserviceaccount.com"),
+				},
+			},
+		},
+		"wrong type": {
+			Files: map[string]string{
+				"~/.config/gcloud/key.json": plugintest.LoadFixture(t, "authorized-user.json"),
+			},
+			ExpectedOutput: &sdk.ImportOutput{Attempts: []*sdk.ImportAttempt{
+				{
+					Source: SourceFile("~/.config/gcloud/key.json"),
+					Diagnostics: sdk.Diagnostics{Errors: []sdk.Error{
+						{Message: "cannot import ~/.config/gcloud/key.json as Credentials: 'type' is 'authorized_user' instead of 'service_account'"},
+					}},
+				},
+			}},
+		},
+		"not JSON": {
+			Files: map[string]string{
+				"~/.config/gcloud/key.json": "type: service_account\n",
+			},
+			ExpectedOutput: &sdk.ImportOutput{Attempts: []*sdk.ImportAttempt{
+				{
+					Source: SourceFile("~/.config/gcloud/key.json"),
+					Diagnostics: sdk.Diagnostics{Errors: []sdk.Error{
+						{Message: "cannot import ~/.config/gcloud/key.json as Credentials: invalid character 'y' in literal true (expecting 'r')"},
+					}},
+				},
+			}},
+		},
+		"too large": {
+			Files: map[string]string{
+				"~/.config/gcloud/key.json": strings.Repeat(" ", 65*1024),
+			},
+			ExpectedOutput: &sdk.ImportOutput{Attempts: []*sdk.ImportAttempt{
+				{
+					Source: SourceFile("~/.config/gcloud/key.json"),
+					Diagnostics: sdk.Diagnostics{Warnings: []sdk.Warning{
+						{Message: "cannot read ~/.config/gcloud/key.json: it's larger than 64 KiB"},
+					}},
+				},
+			}},
+		},
+	})
+}
+
+func TestTryFileAsFieldWithoutValidation(t *testing.T) {
+	plugintest.TestImporter(t, TryFileAsField("~/.tool/token", fieldname.Token, nil, MaxFileSize(16)), map[string]plugintest.ImportCase{
+		"trailing whitespace": {
+			Files: map[string]string{
+				"~/.tool/token": "token \r\n\n",
+			},
+			ExpectedCandidates: []sdk.ImportCandidate{
+				{Fields: map[sdk.FieldName]string{fieldname.Token: "token"}},
+			},
+		},
+		"empty": {
+			Files: map[string]string{
+				"~/.tool/token": "\n",
+			},
+		},
+		"custom size limit": {
+			Files: map[string]string{
+				"~/.tool/token": strings.Repeat("a", 17),
+			},
+			ExpectedOutput: &sdk.ImportOutput{Attempts: []*sdk.ImportAttempt{
+				{
+					Source: SourceFile("~/.tool/token"),
+					Diagnostics: sdk.Diagnostics{Warnings: []sdk.Warning{
+						{Message: "cannot read ~/.tool/token: it's larger than 16 bytes"},
+					}},
+				},
+			}},
+		},
+	})
+}
@@ -1,9 +1,14 @@
 package importer
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,28 +16,344 @@ import (
 	"github.com/1Password/shell-plugins/sdk"
 	"github.com/BurntSushi/toml"
 	"gopkg.in/ini.v1"
-	"gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 )
 
-func TryFile(path string, result func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt)) sdk.Importer {
+// defaultMaxFileSize is the size above which a file is skipped, unless overridden with MaxFileSize. Config and
+// credential files are a few KiB, so anything larger is most likely a different kind of file at the same path.
+const defaultMaxFileSize = 1024 * 1024
+
+type fileImporter struct {
+	maxSize int64
+
+	// nameHintSelector is set by NameHintFromJSON.
+	nameHintSelector string
+}
+
+// FileOption can be used to configure a file importer.
+type FileOption func(*fileImporter)
+
+// MaxFileSize sets the size in bytes above which the file is skipped with a warning, without reading it. Defaults to
+// 1 MiB.
+func MaxFileSize(size int64) FileOption {
+	return func(i *fileImporter) {
+		i.maxSize = size
+	}
+}
+
+func newFileImporter(opts []FileOption) fileImporter {
+	settings := fileImporter{
+		maxSize: defaultMaxFileSize,
+	}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	return settings
+}
+
+// TryFile tries the file at the specified path, e.g. "~/.config/tool/config.json". The result function gets called
+// with the contents of the file, with its path set as FilePath on the input. Files larger than 1 MiB are skipped with
+// a warning, see MaxFileSize.
+func TryFile(path string, result func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt), opts ...FileOption) sdk.Importer {
+	settings := newFileImporter(opts)
 	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
-		abspath := path
-		if strings.HasPrefix(path, "~/") {
-			abspath = filepath.Join(in.HomeDir, strings.TrimPrefix(path, "~/"))
-		} else if strings.HasPrefix(path, "/") {
-			abspath = filepath.Join(in.RootDir, path)
+		abspath := resolvePath(path, in)
+
+		attempt := out.NewAttempt(SourceFile(displayPath(abspath, in)))
+		contents, resolved, ok := readFile(abspath, in, attempt, settings.maxSize)
+		if !ok {
+			return
 		}
 
-		attempt := out.NewAttempt(SourceFile(path))
-		contents, err := os.ReadFile(abspath)
-		if os.IsNotExist(err) {
+		in.FilePath = resolved
+		result(ctx, contents, in, attempt)
+		defaultConfidence(attempt.Candidates, sdk.ConfidenceHigh)
+		defaultFieldSources(attempt.Candidates, sdk.FieldSource{File: displayPath(abspath, in)})
+	}
+}
+
+// TryFileReader is like TryFile, but passes a reader of the file to the result function instead of its contents, for
+// formats that can be scanned line by line, such as netrc or .env files. The size limit still applies, so that a large
+// file at the path doesn't hold up the import.
+func TryFileReader(path string, result func(ctx context.Context, r io.Reader, in sdk.ImportInput, out *sdk.ImportAttempt), opts ...FileOption) sdk.Importer {
+	settings := newFileImporter(opts)
+	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+		abspath := resolvePath(path, in)
+
+		attempt := out.NewAttempt(SourceFile(displayPath(abspath, in)))
+		file, resolved, ok := openFile(abspath, in, attempt, settings.maxSize)
+		if !ok {
 			return
-		} else if err != nil {
-			attempt.AddError(err)
+		}
+		defer file.Close()
+
+		in.FilePath = resolved
+		// The file could have grown since it was checked, so never read past the limit.
+		result(ctx, io.LimitReader(file, settings.maxSize), in, attempt)
+		defaultConfidence(attempt.Candidates, sdk.ConfidenceHigh)
+		defaultFieldSources(attempt.Candidates, sdk.FieldSource{File: displayPath(abspath, in)})
+	}
+}
+
+// TryAllFilesMatching tries all files that match the specified glob pattern, e.g. "~/.config/tool/profiles/*.json",
+// using the syntax of filepath.Match. Patterns starting with "~/" or without leading slash are relative to the home
+// directory. The result function gets called once for every matching file, with its path set as FilePath on the
+// input. Candidates without a name hint get a name hint derived from the path of the file: the name of the directory
+// that contains the file if the pattern has a wildcard in its directory part, e.g. "work" for
+// ~/.config/tool/accounts/work/token matched by "~/.config/tool/accounts/*/token", or the name of the file otherwise.
+// Candidates that were already found in a previous file are skipped. Symlinks are followed and directories are ignored.
+// Files that can't be read or are too large are reported like in TryFile.
+func TryAllFilesMatching(pattern string, result func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt), opts ...FileOption) sdk.Importer {
+	settings := newFileImporter(opts)
+	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+		absPattern := filepath.Join(in.HomeDir, pattern)
+		if strings.HasPrefix(pattern, "~/") || strings.HasPrefix(pattern, "/") {
+			absPattern = resolvePath(pattern, in)
+		}
+
+		matches, err := filepath.Glob(absPattern)
+		if err != nil {
+			out.NewAttempt(SourceFile(pattern)).AddError(err)
 			return
 		}
 
-		result(ctx, contents, in, attempt)
+		var found foundCandidates
+		for _, abspath := range matches {
+			if info, err := os.Stat(abspath); err == nil && info.IsDir() {
+				// Directories that match the pattern are not meant to be imported from.
+				continue
+			}
+
+			attempt := out.NewAttempt(SourceFile(displayPath(abspath, in)))
+			contents, resolved, ok := readFile(abspath, in, attempt, settings.maxSize)
+			if !ok {
+				continue
+			}
+
+			fileAttempt := &sdk.ImportAttempt{}
+			in.FilePath = resolved
+			result(ctx, contents, in, fileAttempt)
+
+			attempt.Diagnostics.Errors = append(attempt.Diagnostics.Errors, fileAttempt.Diagnostics.Errors...)
+			attempt.Diagnostics.Warnings = append(attempt.Diagnostics.Warnings, fileAttempt.Diagnostics.Warnings...)
+			attempt.Diagnostics.Notes = append(attempt.Diagnostics.Notes, fileAttempt.Diagnostics.Notes...)
+			defaultConfidence(fileAttempt.Candidates, sdk.ConfidenceHigh)
+			defaultFieldSources(fileAttempt.Candidates, sdk.FieldSource{File: displayPath(abspath, in)})
+			for _, candidate := range fileAttempt.Candidates {
+				if found.mergeDuplicate(candidate) {
+					continue
+				}
+				if candidate.NameHint == "" {
+					candidate.NameHint = nameHintFromPath(pattern, abspath)
+				}
+				// The candidate was already validated when it was added to the file attempt.
+				attempt.Candidates = append(attempt.Candidates, candidate)
+				found.refs = append(found.refs, candidateRef{attempt: attempt, index: len(attempt.Candidates) - 1})
+			}
+		}
+	}
+}
+
+// readFile reads the file at the absolute path, following symlinks, and returns its contents and resolved path. Files
+// are checked like in openFile, and files that turn out to be larger than maxSize while reading are reported as well.
+func readFile(abspath string, in sdk.ImportInput, attempt *sdk.ImportAttempt, maxSize int64) (contents []byte, resolved string, ok bool) {
+	file, resolved, ok := openFile(abspath, in, attempt, maxSize)
+	if !ok {
+		return nil, "", false
+	}
+	defer file.Close()
+
+	display := displayPath(abspath, in)
+	contents, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		attempt.AddWarning(fmt.Sprintf("cannot read %s: %s", display, pathErrorReason(err)))
+		return nil, "", false
+	}
+	if int64(len(contents)) > maxSize {
+		attempt.AddWarning(fmt.Sprintf("cannot read %s: it's larger than %s", display, formatSize(maxSize)))
+		return nil, "", false
+	}
+	return contents, resolved, true
+}
+
+// openFile opens the file at the absolute path, following symlinks, and returns the file and its resolved path. Files
+// that don't exist are skipped silently. Files that can't be read, broken symlinks, directories and files larger than
+// maxSize are reported as a warning on the attempt instead, so that the user can find out why a file was not imported
+// while the other sources are still tried. If the path is a symlink, the resolved path gets added to the source of the
+// attempt.
+func openFile(abspath string, in sdk.ImportInput, attempt *sdk.ImportAttempt, maxSize int64) (file *os.File, resolved string, ok bool) {
+	display := displayPath(abspath, in)
+
+	info, err := os.Lstat(abspath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", false
+	} else if err != nil {
+		attempt.AddWarning(fmt.Sprintf("cannot read %s: %s", display, pathErrorReason(err)))
+		return nil, "", false
+	}
+
+	resolved = abspath
+	if info.Mode()&fs.ModeSymlink != 0 {
+		resolved, err = filepath.EvalSymlinks(abspath)
+		if errors.Is(err, fs.ErrNotExist) {
+			target, _ := os.Readlink(abspath)
+			attempt.AddWarning(fmt.Sprintf("cannot read %s: it's a symlink to %s, which doesn't exist", display, target))
+			return nil, "", false
+		} else if err != nil {
+			attempt.AddWarning(fmt.Sprintf("cannot read %s: %s", display, pathErrorReason(err)))
+			return nil, "", false
+		}
+		attempt.Source.Files = append(attempt.Source.Files, displayPath(resolved, in))
+
+		info, err = os.Stat(resolved)
+		if err != nil {
+			attempt.AddWarning(fmt.Sprintf("cannot read %s: %s", display, pathErrorReason(err)))
+			return nil, "", false
+		}
+	}
+
+	if info.IsDir() {
+		attempt.AddWarning(fmt.Sprintf("cannot read %s: it's a directory", display))
+		return nil, "", false
+	}
+	if info.Size() > maxSize {
+		attempt.AddWarning(fmt.Sprintf("cannot read %s: it's larger than %s", display, formatSize(maxSize)))
+		return nil, "", false
+	}
+
+	file, err = os.Open(resolved)
+	if err != nil {
+		attempt.AddWarning(fmt.Sprintf("cannot read %s: %s", display, pathErrorReason(err)))
+		return nil, "", false
+	}
+	return file, resolved, true
+}
+
+// formatSize formats a size in bytes for use in diagnostics, e.g. "1 MiB" or "1500 bytes".
+func formatSize(size int64) string {
+	switch {
+	case size >= 1024*1024 && size%(1024*1024) == 0:
+		return fmt.Sprintf("%d MiB", size/(1024*1024))
+	case size >= 1024 && size%1024 == 0:
+		return fmt.Sprintf("%d KiB", size/1024)
+	default:
+		return fmt.Sprintf("%d bytes", size)
+	}
+}
+
+// pathErrorReason returns the reason of a file system error without the path, e.g. "permission denied".
+func pathErrorReason(err error) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err
+	}
+	return err
+}
+
+// nameHintFromPath derives a name hint from the part of the path that's matched by the wildcards in the pattern: the
+// name of the directory that contains the file if the wildcards are in the directory part of the pattern, since all
+// matched files have the same name in that case, or the name of the file otherwise.
+func nameHintFromPath(pattern string, abspath string) string {
+	if strings.ContainsAny(filepath.Dir(pattern), `*?[`) && !strings.ContainsAny(filepath.Base(pattern), `*?[`) {
+		return SanitizeNameHint(strings.TrimPrefix(filepath.Base(filepath.Dir(abspath)), "."))
+	}
+	return SanitizeNameHint(filepath.Base(abspath))
+}
+
+// resolvePath resolves paths starting with "~/" relative to the home directory and absolute paths relative to the
+// root directory. Paths in one of the directories of the input, e.g. built using in.FromConfigDir, are already resolved
+// and are returned as-is.
+func resolvePath(path string, in sdk.ImportInput) string {
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(in.HomeDir, strings.TrimPrefix(path, "~/"))
+	}
+	for _, dir := range []string{in.HomeDir, in.ConfigDir, in.CacheDir, in.WorkingDir} {
+		if dir != "" && (path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))) {
+			return path
+		}
+	}
+	if strings.HasPrefix(path, "/") {
+		return filepath.Join(in.RootDir, path)
+	}
+	return path
+}
+
+// DisplayPath returns the path as it's shown in import diagnostics, see displayPath. Importers outside of this package
+// can use it to report files in the same way.
+func DisplayPath(abspath string, in sdk.ImportInput) string {
+	return displayPath(abspath, in)
+}
+
+// displayPath returns the path as shown to the user, with the home directory abbreviated to "~".
+func displayPath(abspath string, in sdk.ImportInput) string {
+	if in.HomeDir != "" && strings.HasPrefix(abspath, in.HomeDir+string(filepath.Separator)) {
+		return "~/" + filepath.ToSlash(strings.TrimPrefix(abspath, in.HomeDir+string(filepath.Separator)))
+	}
+	if in.RootDir != "" && strings.HasPrefix(abspath, in.RootDir) {
+		return strings.TrimPrefix(abspath, in.RootDir)
+	}
+	return abspath
+}
+
+// defaultConfidence sets the confidence of the candidates that don't specify one.
+func defaultConfidence(candidates []sdk.ImportCandidate, confidence sdk.Confidence) {
+	for i := range candidates {
+		if candidates[i].Confidence == sdk.ConfidenceUnspecified {
+			candidates[i].Confidence = confidence
+		}
+	}
+}
+
+// defaultFieldSources sets the source of the fields of the candidates that don't have a source recorded yet.
+func defaultFieldSources(candidates []sdk.ImportCandidate, source sdk.FieldSource) {
+	for i := range candidates {
+		defaultFieldSource(&candidates[i], source)
+	}
+}
+
+// defaultFieldSource sets the source of the fields of the candidate that don't have a source recorded yet.
+func defaultFieldSource(candidate *sdk.ImportCandidate, source sdk.FieldSource) {
+	for fieldName := range candidate.Fields {
+		if len(candidate.FieldSources[fieldName]) == 0 {
+			candidate.AddFieldSource(fieldName, source)
+		}
+	}
+}
+
+// foundCandidates keeps track of the candidates that an importer added to its attempts, so that the same credential
+// found in another source doesn't get added again. Instead, the field sources of the duplicate get merged into the
+// candidate that was found first.
+type foundCandidates struct {
+	refs []candidateRef
+}
+
+type candidateRef struct {
+	attempt *sdk.ImportAttempt
+	index   int
+}
+
+// mergeDuplicate returns whether an equal candidate was already found, in which case the field sources of the
+// candidate are merged into that one.
+func (f *foundCandidates) mergeDuplicate(candidate sdk.ImportCandidate) bool {
+	for _, ref := range f.refs {
+		existing := &ref.attempt.Candidates[ref.index]
+		if existing.Equal(candidate) {
+			existing.MergeFieldSources(candidate)
+			return true
+		}
+	}
+	return false
+}
+
+// add adds the candidate to the attempt, unless it's a duplicate of a candidate that was already found.
+func (f *foundCandidates) add(attempt *sdk.ImportAttempt, candidate sdk.ImportCandidate) {
+	if f.mergeDuplicate(candidate) {
+		return
+	}
+	before := len(attempt.Candidates)
+	attempt.AddCandidate(candidate)
+	if len(attempt.Candidates) > before {
+		f.refs = append(f.refs, candidateRef{attempt: attempt, index: before})
 	}
 }
 
@@ -51,6 +372,8 @@ func (fc FileContents) ToJSON(result any) error {
 	return nil
 }
 
+// ToYAML decodes the YAML document into the result, which can be a struct or a map[string]any. Keys that are not
+// present in the result struct are ignored. Errors contain the line number at which the document is invalid.
 func (fc FileContents) ToYAML(result any) error {
 	err := yaml.Unmarshal(fc, result)
 	if err != nil {
@@ -60,6 +383,79 @@ func (fc FileContents) ToYAML(result any) error {
 	return nil
 }
 
+// ToYAMLDocuments decodes every document in a YAML stream with multiple documents separated by "---", such as
+// Kubernetes manifests, into the result. Documents without content, e.g. after a trailing separator, are skipped.
+// If a document is invalid, the other documents are still decoded and the error for the first invalid document is
+// returned, mentioning its position among the documents. Line numbers in errors are relative to the whole file.
+func (fc FileContents) ToYAMLDocuments(result *[]map[string]any) error {
+	var firstErr error
+	number := 0
+	for _, document := range splitYAMLDocuments(fc) {
+		if len(bytes.TrimSpace(document)) == 0 {
+			continue
+		}
+		number++
+
+		var decoded map[string]any
+		if err := yaml.Unmarshal(document, &decoded); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("YAML document %d: %w", number, err)
+			}
+			continue
+		}
+		if decoded == nil {
+			// The document only contains comments.
+			continue
+		}
+		*result = append(*result, decoded)
+	}
+	return firstErr
+}
+
+// splitYAMLDocuments splits a YAML stream at the "---" and "..." markers at the start of a line. Every document is
+// prefixed with the newlines that precede it in the stream, so that line numbers in decoding errors match the stream.
+func splitYAMLDocuments(contents []byte) [][]byte {
+	var documents [][]byte
+	var current []byte
+	lines := bytes.SplitAfter(contents, []byte("\n"))
+	for i, line := range lines {
+		marker := bytes.TrimRight(line, "\r\n")
+		isStart := bytes.Equal(marker, []byte("---")) || bytes.HasPrefix(marker, []byte("--- ")) || bytes.HasPrefix(marker, []byte("---\t"))
+		isEnd := bytes.Equal(marker, []byte("...")) || bytes.HasPrefix(marker, []byte("... ")) || bytes.HasPrefix(marker, []byte("...\t"))
+		if !isStart && !isEnd {
+			current = append(current, line...)
+			continue
+		}
+
+		documents = append(documents, current)
+		current = bytes.Repeat([]byte("\n"), i)
+		if isStart {
+			// Content can follow the marker on the same line, e.g. "--- !!map".
+			current = append(current, bytes.TrimLeft(line[3:], " \t")...)
+		} else if bytes.HasSuffix(line, []byte("\n")) {
+			current = append(current, '\n')
+		}
+	}
+	return append(documents, current)
+}
+
+// ToYAMLStrict decodes the YAML document into the result like ToYAML, but reports keys that are not present in the
+// result struct and duplicate keys as errors.
+func (fc FileContents) ToYAMLStrict(result any) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(fc))
+	decoder.KnownFields(true)
+	err := decoder.Decode(result)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
+
+// ToTOML decodes the TOML document into the result, which can be a struct or a map[string]any. Tables and inline
+// tables decode into nested structs or maps, and arrays of tables into slices. Errors contain the line number at
+// which the document is invalid, and should be reported on the import attempt, so that other sources can still be
+// tried.
 func (fc FileContents) ToTOML(result any) error {
 	err := toml.Unmarshal(fc, result)
 	if err != nil {
@@ -69,8 +465,16 @@ func (fc FileContents) ToTOML(result any) error {
 	return nil
 }
 
+// ToXML decodes the XML document into the result, which should be a struct annotated with xml tags. The document
+// must be well-formed. Documents with a DOCTYPE declaration are rejected, since entities declared in it are never
+// resolved. Errors should be reported on the import attempt, so that other sources can still be tried.
 func (fc FileContents) ToXML(result any) error {
-	err := xml.Unmarshal(fc, result)
+	err := checkNoDoctype(fc)
+	if err != nil {
+		return err
+	}
+
+	err = xml.Unmarshal(fc, result)
 	if err != nil {
 		return err
 	}
@@ -78,6 +482,85 @@ func (fc FileContents) ToXML(result any) error {
 	return nil
 }
 
+// ToXMLMap decodes the XML document into nested maps, for importers that only need to look up a few values. Each
+// element maps to its text if it has no child elements, or to a map of its child elements otherwise. Elements that
+// occur multiple times map to a []any. Attributes and namespaces are ignored. Unlike ToXML, this accepts documents
+// that are not well-formed, such as ones with unclosed tags, but documents with a DOCTYPE declaration are rejected.
+func (fc FileContents) ToXMLMap() (map[string]any, error) {
+	err := checkNoDoctype(fc)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(fc))
+	decoder.Strict = false
+
+	type element struct {
+		name     string
+		text     strings.Builder
+		children map[string]any
+	}
+	root := &element{children: make(map[string]any)}
+	stack := []*element{root}
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		current := stack[len(stack)-1]
+		switch token := token.(type) {
+		case xml.StartElement:
+			stack = append(stack, &element{name: token.Name.Local})
+		case xml.CharData:
+			current.text.Write(token)
+		case xml.EndElement:
+			if len(stack) == 1 {
+				continue
+			}
+			stack = stack[:len(stack)-1]
+			parent := stack[len(stack)-1]
+			if parent.children == nil {
+				parent.children = make(map[string]any)
+			}
+
+			var value any = strings.TrimSpace(current.text.String())
+			if current.children != nil {
+				value = current.children
+			}
+			switch existing := parent.children[current.name].(type) {
+			case nil:
+				parent.children[current.name] = value
+			case []any:
+				parent.children[current.name] = append(existing, value)
+			default:
+				parent.children[current.name] = []any{existing, value}
+			}
+		}
+	}
+
+	return root.children, nil
+}
+
+// checkNoDoctype returns an error if the XML document contains a DOCTYPE declaration.
+func checkNoDoctype(contents []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(contents))
+	decoder.Strict = false
+	for {
+		token, err := decoder.RawToken()
+		if err != nil {
+			// Syntax errors get reported by the actual decoding.
+			return nil
+		}
+		if directive, ok := token.(xml.Directive); ok && bytes.HasPrefix(bytes.TrimSpace(directive), []byte("DOCTYPE")) {
+			return errors.New("XML documents with a DOCTYPE declaration are not supported")
+		}
+	}
+}
+
 func (fc FileContents) ToINI() (*ini.File, error) {
 	result, err := ini.Load([]byte(fc))
 	if err != nil {
@@ -0,0 +1,595 @@
+package importer
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTryFileUnreadable(t *testing.T) {
+	homeDir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	toolDir := filepath.Join(homeDir, ".tool")
+	require.NoError(t, os.MkdirAll(filepath.Join(toolDir, "directory"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(toolDir, "token"), []byte("token"), 0600))
+	require.NoError(t, os.Symlink(filepath.Join(toolDir, "token"), filepath.Join(toolDir, "symlink")))
+	require.NoError(t, os.Symlink(filepath.Join(toolDir, "missing"), filepath.Join(toolDir, "broken-symlink")))
+	require.NoError(t, os.WriteFile(filepath.Join(toolDir, "unreadable"), []byte("token"), 0000))
+
+	tryToken := func(path string) sdk.Importer {
+		return TryFile(path, func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+			out.AddCandidate(sdk.ImportCandidate{
+				Fields:   map[sdk.FieldName]string{fieldname.Token: contents.ToString()},
+				NameHint: in.FilePath,
+			})
+		})
+	}
+
+	for _, scenario := range []struct {
+		description        string
+		path               string
+		expectedSource     sdk.ImportSource
+		expectedCandidates []sdk.ImportCandidate
+		expectedWarnings   []sdk.Warning
+	}{
+		{
+			description:    "not found",
+			path:           "~/.tool/missing",
+			expectedSource: SourceFile("~/.tool/missing"),
+		},
+		{
+			description:    "symlink",
+			path:           "~/.tool/symlink",
+			expectedSource: sdk.ImportSource{Files: []string{"~/.tool/symlink", "~/.tool/token"}},
+			expectedCandidates: []sdk.ImportCandidate{{
+				Fields:     map[sdk.FieldName]string{fieldname.Token: "token"},
+				NameHint:   filepath.Join(toolDir, "token"),
+				Confidence: sdk.ConfidenceHigh,
+			}},
+		},
+		{
+			description:    "broken symlink",
+			path:           "~/.tool/broken-symlink",
+			expectedSource: SourceFile("~/.tool/broken-symlink"),
+			expectedWarnings: []sdk.Warning{
+				{Message: "cannot read ~/.tool/broken-symlink: it's a symlink to " + filepath.Join(toolDir, "missing") + ", which doesn't exist"},
+			},
+		},
+		{
+			description:      "directory",
+			path:             "~/.tool/directory",
+			expectedSource:   SourceFile("~/.tool/directory"),
+			expectedWarnings: []sdk.Warning{{Message: "cannot read ~/.tool/directory: it's a directory"}},
+		},
+	} {
+		t.Run(scenario.description, func(t *testing.T) {
+			out := sdk.ImportOutput{}
+			TryAll(tryToken(scenario.path), tryToken("~/.tool/token"))(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+			require.Len(t, out.Attempts, 2)
+			assert.Equal(t, scenario.expectedSource, out.Attempts[0].Source)
+			assert.Equal(t, scenario.expectedCandidates, withoutFieldSources(out.Attempts[0].Candidates))
+			assert.Equal(t, scenario.expectedWarnings, out.Attempts[0].Diagnostics.Warnings)
+			assert.Empty(t, out.Errors())
+
+			// The other sources are still tried.
+			assert.Len(t, out.Attempts[1].Candidates, 1)
+		})
+	}
+
+	t.Run("permission denied", func(t *testing.T) {
+		if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+			t.Skip("file permissions are not enforced")
+		}
+
+		out := sdk.ImportOutput{}
+		tryToken("~/.tool/unreadable")(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+		assert.Empty(t, out.AllCandidates())
+		assert.Equal(t, []sdk.Warning{{Message: "cannot read ~/.tool/unreadable: permission denied"}}, out.Attempts[0].Diagnostics.Warnings)
+	})
+}
+
+func TestTryFileTooLarge(t *testing.T) {
+	homeDir := t.TempDir()
+	toolDir := filepath.Join(homeDir, ".tool")
+	require.NoError(t, os.MkdirAll(toolDir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(toolDir, "token"), []byte("token"), 0600))
+
+	// A sparse file takes no space on disk, but reading it would take a while.
+	large, err := os.Create(filepath.Join(toolDir, "large"))
+	require.NoError(t, err)
+	require.NoError(t, large.Truncate(4*1024*1024*1024))
+	require.NoError(t, large.Close())
+
+	called := false
+	start := time.Now()
+	out := sdk.ImportOutput{}
+	TryFile("~/.tool/large", func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+		called = true
+	})(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+	assert.False(t, called)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, []sdk.Warning{{Message: "cannot read ~/.tool/large: it's larger than 1 MiB"}}, out.Attempts[0].Diagnostics.Warnings)
+
+	t.Run("custom limit", func(t *testing.T) {
+		out := sdk.ImportOutput{}
+		TryFile("~/.tool/token", func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+			out.AddCandidate(sdk.ImportCandidate{Fields: map[sdk.FieldName]string{fieldname.Token: contents.ToString()}})
+		}, MaxFileSize(4))(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+		assert.Empty(t, out.AllCandidates())
+		assert.Equal(t, []sdk.Warning{{Message: "cannot read ~/.tool/token: it's larger than 4 bytes"}}, out.Attempts[0].Diagnostics.Warnings)
+	})
+
+	t.Run("all files matching", func(t *testing.T) {
+		out := sdk.ImportOutput{}
+		TryAllFilesMatching("~/.tool/*", func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+			out.AddCandidate(sdk.ImportCandidate{Fields: map[sdk.FieldName]string{fieldname.Token: contents.ToString()}})
+		})(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+		assert.Equal(t, []sdk.ImportCandidate{
+			{Fields: map[sdk.FieldName]string{fieldname.Token: "token"}, NameHint: "token", Confidence: sdk.ConfidenceHigh},
+		}, withoutFieldSources(out.AllCandidates()))
+		assert.Equal(t, []sdk.Warning{{Message: "cannot read ~/.tool/large: it's larger than 1 MiB"}}, out.Attempts[0].Diagnostics.Warnings)
+	})
+}
+
+func TestTryFileReader(t *testing.T) {
+	homeDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".tool-tokens"), []byte("# comment\ntoken-1\ntoken-2\n"), 0600))
+
+	tryTokens := func(opts ...FileOption) sdk.Importer {
+		return TryFileReader("~/.tool-tokens", func(ctx context.Context, r io.Reader, in sdk.ImportInput, out *sdk.ImportAttempt) {
+			scanner := bufio.NewScanner(r)
+			for scanner.Scan() {
+				if strings.HasPrefix(scanner.Text(), "#") {
+					continue
+				}
+				out.AddCandidate(sdk.ImportCandidate{Fields: map[sdk.FieldName]string{fieldname.Token: scanner.Text()}})
+			}
+			if err := scanner.Err(); err != nil {
+				out.AddError(err)
+			}
+		}, opts...)
+	}
+
+	out := sdk.ImportOutput{}
+	tryTokens()(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Equal(t, []sdk.ImportCandidate{
+		{
+			Fields:       map[sdk.FieldName]string{fieldname.Token: "token-1"},
+			Confidence:   sdk.ConfidenceHigh,
+			FieldSources: map[sdk.FieldName][]sdk.FieldSource{fieldname.Token: {{File: "~/.tool-tokens"}}},
+		},
+		{
+			Fields:       map[sdk.FieldName]string{fieldname.Token: "token-2"},
+			Confidence:   sdk.ConfidenceHigh,
+			FieldSources: map[sdk.FieldName][]sdk.FieldSource{fieldname.Token: {{File: "~/.tool-tokens"}}},
+		},
+	}, out.AllCandidates())
+
+	t.Run("too large", func(t *testing.T) {
+		out := sdk.ImportOutput{}
+		tryTokens(MaxFileSize(16))(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+		assert.Empty(t, out.AllCandidates())
+		assert.Equal(t, []sdk.Warning{{Message: "cannot read ~/.tool-tokens: it's larger than 16 bytes"}}, out.Attempts[0].Diagnostics.Warnings)
+	})
+}
+
+func TestTryAllFilesMatching(t *testing.T) {
+	// Resolve the temp dir itself, which is behind a symlink on macOS, so that resolved paths can be compared.
+	homeDir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	profilesDir := filepath.Join(homeDir, ".config", "tool", "profiles")
+	require.NoError(t, os.MkdirAll(filepath.Join(profilesDir, "archive.json"), 0700))
+
+	writeFile := func(path string, contents string) {
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0600))
+	}
+	writeFile(filepath.Join(profilesDir, "dev.json"), "token-dev")
+	writeFile(filepath.Join(profilesDir, "prod.json"), "token-prod")
+	writeFile(filepath.Join(profilesDir, "prod-copy.json"), "token-prod")
+	writeFile(filepath.Join(profilesDir, "notes.txt"), "token-notes")
+	writeFile(filepath.Join(homeDir, "staging.json"), "token-staging")
+	require.NoError(t, os.Symlink(filepath.Join(homeDir, "staging.json"), filepath.Join(profilesDir, "staging.json")))
+	require.NoError(t, os.Symlink(filepath.Join(homeDir, "missing.json"), filepath.Join(profilesDir, "broken.json")))
+
+	var filePaths []string
+	importer := TryAllFilesMatching("~/.config/tool/profiles/*.json", func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+		filePaths = append(filePaths, in.FilePath)
+		candidate := sdk.ImportCandidate{
+			Fields: map[sdk.FieldName]string{
+				fieldname.Token: contents.ToString(),
+			},
+		}
+		if strings.HasSuffix(in.FilePath, "dev.json") {
+			candidate.NameHint = "development"
+		}
+		out.AddCandidate(candidate)
+	})
+
+	out := sdk.ImportOutput{}
+	importer(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Equal(t, []string{
+		filepath.Join(profilesDir, "dev.json"),
+		filepath.Join(profilesDir, "prod-copy.json"),
+		filepath.Join(profilesDir, "prod.json"),
+		filepath.Join(homeDir, "staging.json"),
+	}, filePaths)
+	assert.Equal(t, []sdk.ImportCandidate{
+		{Fields: map[sdk.FieldName]string{fieldname.Token: "token-dev"}, NameHint: "development", Confidence: sdk.ConfidenceHigh},
+		{Fields: map[sdk.FieldName]string{fieldname.Token: "token-prod"}, NameHint: "prod-copy.json", Confidence: sdk.ConfidenceHigh},
+		{Fields: map[sdk.FieldName]string{fieldname.Token: "token-staging"}, NameHint: "staging.json", Confidence: sdk.ConfidenceHigh},
+	}, withoutFieldSources(out.AllCandidates()))
+	assert.Equal(t, sdk.ImportSource{Files: []string{"~/.config/tool/profiles/broken.json"}}, out.Attempts[0].Source)
+	assert.Equal(t, []sdk.Warning{
+		{Message: "cannot read ~/.config/tool/profiles/broken.json: it's a symlink to " + filepath.Join(homeDir, "missing.json") + ", which doesn't exist"},
+	}, out.Attempts[0].Diagnostics.Warnings)
+	assert.Equal(t, sdk.ImportSource{Files: []string{"~/.config/tool/profiles/dev.json"}}, out.Attempts[1].Source)
+	assert.Equal(t, sdk.ImportSource{Files: []string{"~/.config/tool/profiles/staging.json", "~/staging.json"}}, out.Attempts[4].Source)
+	assert.Equal(t, map[sdk.FieldName][]sdk.FieldSource{
+		fieldname.Token: {{File: "~/.config/tool/profiles/prod-copy.json"}, {File: "~/.config/tool/profiles/prod.json"}},
+	}, out.AllCandidates()[1].FieldSources)
+}
+
+func TestTryAllFilesMatchingNameHintFromDirectory(t *testing.T) {
+	homeDir := t.TempDir()
+	for _, account := range []string{"work", ".personal"} {
+		accountDir := filepath.Join(homeDir, ".tool", "accounts", account)
+		require.NoError(t, os.MkdirAll(accountDir, 0700))
+		require.NoError(t, os.WriteFile(filepath.Join(accountDir, "token"), []byte("token-"+account), 0600))
+	}
+
+	importer := TryAllFilesMatching("~/.tool/accounts/*/token", func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+		out.AddCandidate(sdk.ImportCandidate{
+			Fields: map[sdk.FieldName]string{fieldname.Token: contents.ToString()},
+		})
+	})
+
+	out := sdk.ImportOutput{}
+	importer(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Equal(t, []sdk.ImportCandidate{
+		{Fields: map[sdk.FieldName]string{fieldname.Token: "token-.personal"}, NameHint: "personal", Confidence: sdk.ConfidenceHigh},
+		{Fields: map[sdk.FieldName]string{fieldname.Token: "token-work"}, NameHint: "work", Confidence: sdk.ConfidenceHigh},
+	}, withoutFieldSources(out.AllCandidates()))
+}
+
+func TestTryAllFilesMatchingMissingDirectory(t *testing.T) {
+	called := false
+	importer := TryAllFilesMatching(".config/missing/*.yml", func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+		called = true
+	})
+
+	out := sdk.ImportOutput{}
+	importer(context.Background(), sdk.ImportInput{HomeDir: t.TempDir()}, &out)
+
+	assert.False(t, called)
+	assert.Empty(t, out.Errors())
+	assert.Empty(t, out.AllCandidates())
+}
+
+func TestTryAllFilesMatchingInvalidPattern(t *testing.T) {
+	importer := TryAllFilesMatching("~/.config/tool/[", func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {})
+
+	out := sdk.ImportOutput{}
+	importer(context.Background(), sdk.ImportInput{HomeDir: t.TempDir()}, &out)
+
+	assert.Equal(t, []sdk.Error{{Message: "syntax error in pattern"}}, out.Errors())
+}
+
+type yamlTestConfig struct {
+	Hosts map[string]struct {
+		User  string `yaml:"user"`
+		Token string `yaml:"oauth_token"`
+	} `yaml:"hosts"`
+	Description string `yaml:"description"`
+}
+
+func TestFileContentsToYAML(t *testing.T) {
+	contents := FileContents(`---
+defaults: &defaults
+  user: wendy
+  oauth_token: gho_abc123
+hosts:
+  github.com: *defaults
+  github.example.com:
+    <<: *defaults
+    oauth_token: gho_def456
+description: |
+  first line
+  second line
+unknown: ignored
+`)
+
+	var config yamlTestConfig
+	require.NoError(t, contents.ToYAML(&config))
+	assert.Equal(t, "wendy", config.Hosts["github.com"].User)
+	assert.Equal(t, "gho_abc123", config.Hosts["github.com"].Token)
+	assert.Equal(t, "wendy", config.Hosts["github.example.com"].User)
+	assert.Equal(t, "gho_def456", config.Hosts["github.example.com"].Token)
+	assert.Equal(t, "first line\nsecond line\n", config.Description)
+
+	var generic map[string]any
+	require.NoError(t, contents.ToYAML(&generic))
+	hosts, ok := generic["hosts"].(map[string]any)
+	require.True(t, ok, "nested maps should have string keys")
+	assert.Equal(t, map[string]any{"user": "wendy", "oauth_token": "gho_abc123"}, hosts["github.com"])
+
+	err := contents.ToYAMLStrict(&config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2: field defaults not found")
+}
+
+func TestFileContentsToYAMLInvalid(t *testing.T) {
+	var config yamlTestConfig
+	err := FileContents("description: first\nhosts: github.com: wendy\n").ToYAML(&config)
+	assert.EqualError(t, err, "yaml: line 2: mapping values are not allowed in this context")
+
+	err = FileContents("description: [1, 2]\n").ToYAML(&config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 1: cannot unmarshal !!seq into string")
+}
+
+func TestFileContentsToYAMLStrictEmpty(t *testing.T) {
+	var config yamlTestConfig
+	assert.NoError(t, FileContents("").ToYAMLStrict(&config))
+	assert.NoError(t, FileContents("---\n").ToYAMLStrict(&config))
+}
+
+func TestFileContentsToYAMLDocuments(t *testing.T) {
+	for _, scenario := range []struct {
+		fixture  string
+		expected []map[string]any
+	}{
+		{
+			fixture: "two-documents.yml",
+			expected: []map[string]any{
+				{"host": "api.example.com", "token": "first-token"},
+				{"host": "staging.example.com", "token": "second-token"},
+			},
+		},
+		{
+			fixture: "three-documents.yml",
+			expected: []map[string]any{
+				{"host": "api.example.com", "token": "first-token"},
+				{"host": "staging.example.com", "token": "second-token"},
+				{"description": "---\nnot a separator\n", "token": "third-token"},
+			},
+		},
+	} {
+		t.Run(scenario.fixture, func(t *testing.T) {
+			var documents []map[string]any
+			require.NoError(t, FileContents(plugintest.LoadFixture(t, scenario.fixture)).ToYAMLDocuments(&documents))
+			assert.Equal(t, scenario.expected, documents)
+		})
+	}
+}
+
+func TestFileContentsToYAMLDocumentsInvalid(t *testing.T) {
+	var documents []map[string]any
+	err := FileContents(plugintest.LoadFixture(t, "malformed-document.yml")).ToYAMLDocuments(&documents)
+	assert.EqualError(t, err, "YAML document 2: yaml: line 4: did not find expected ',' or ']'")
+	assert.Equal(t, []map[string]any{
+		{"host": "api.example.com", "token": "first-token"},
+		{"host": "dev.example.com", "token": "third-token"},
+	}, documents)
+
+	documents = nil
+	err = FileContents("host: api.example.com\n---\n- not\n- a mapping\n").ToYAMLDocuments(&documents)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "YAML document 2: yaml: unmarshal errors:\n  line 3: cannot unmarshal !!seq into map[string]interface {}")
+	assert.Len(t, documents, 1)
+
+	documents = nil
+	assert.NoError(t, FileContents("").ToYAMLDocuments(&documents))
+	assert.Empty(t, documents)
+}
+
+func TestFileContentsToTOML(t *testing.T) {
+	contents := FileContents(`
+title = "example"
+server.host = "example.com"
+server.port = 8080
+owner = { name = "Wendy", emails = ["wendy@example.com"] }
+
+[profiles.dev]
+token = "dev-token"
+
+[profiles.prod]
+token = "prod-token"
+
+[[accounts]]
+id = 1
+
+[[accounts]]
+id = 2
+`)
+
+	var config struct {
+		Title  string
+		Server struct {
+			Host string
+			Port int
+		}
+		Owner struct {
+			Name   string
+			Emails []string
+		}
+		Profiles map[string]struct {
+			Token string
+		}
+		Accounts []struct {
+			ID int
+		}
+	}
+	require.NoError(t, contents.ToTOML(&config))
+	assert.Equal(t, "example", config.Title)
+	assert.Equal(t, "example.com", config.Server.Host)
+	assert.Equal(t, 8080, config.Server.Port)
+	assert.Equal(t, "Wendy", config.Owner.Name)
+	assert.Equal(t, []string{"wendy@example.com"}, config.Owner.Emails)
+	assert.Equal(t, "prod-token", config.Profiles["prod"].Token)
+	require.Len(t, config.Accounts, 2)
+	assert.Equal(t, 2, config.Accounts[1].ID)
+
+	var generic map[string]any
+	require.NoError(t, contents.ToTOML(&generic))
+	assert.Equal(t, map[string]any{"host": "example.com", "port": int64(8080)}, generic["server"])
+	assert.Len(t, generic["accounts"], 2)
+}
+
+func TestFileContentsToTOMLInvalid(t *testing.T) {
+	var config map[string]any
+	err := FileContents("[profiles]\ntoken = \"abc\"\ntoken = \"def\"\n").ToTOML(&config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 3")
+}
+
+func TestFileContentsToTOMLFixtures(t *testing.T) {
+	t.Run("cargo", func(t *testing.T) {
+		var config struct {
+			Registry struct {
+				Token string
+			}
+			Registries map[string]struct {
+				Token string
+			}
+		}
+		require.NoError(t, FileContents(plugintest.LoadFixture(t, "cargo-credentials.toml")).ToTOML(&config))
+		assert.Equal(t, "cio_registry_token", config.Registry.Token)
+		assert.Equal(t, "cio_my_registry_token", config.Registries["my-registry"].Token)
+	})
+
+	t.Run("poetry", func(t *testing.T) {
+		var config struct {
+			HTTPBasic map[string]struct {
+				Username string
+				Password string
+			} `toml:"http-basic"`
+			PyPIToken map[string]string `toml:"pypi-token"`
+		}
+		require.NoError(t, FileContents(plugintest.LoadFixture(t, "poetry-auth.toml")).ToTOML(&config))
+		assert.Equal(t, "wendy", config.HTTPBasic["private"].Username)
+		assert.Equal(t, "pypi-AgENdGVzdC5weXBp", config.PyPIToken["testpypi"])
+	})
+
+	t.Run("wrangler", func(t *testing.T) {
+		var config struct {
+			OAuthToken   string   `toml:"oauth_token"`
+			RefreshToken string   `toml:"refresh_token"`
+			Scopes       []string `toml:"scopes"`
+			Accounts     []struct {
+				ID   string `toml:"id"`
+				Name string `toml:"name"`
+			} `toml:"accounts"`
+		}
+		require.NoError(t, FileContents(plugintest.LoadFixture(t, "wrangler-config.toml")).ToTOML(&config))
+		assert.Equal(t, "wrangler-oauth-token", config.OAuthToken)
+		assert.Equal(t, []string{"account:read", "user:read", "workers:write"}, config.Scopes)
+		require.Len(t, config.Accounts, 2)
+		assert.Equal(t, "Appleseed Inc.", config.Accounts[1].Name)
+	})
+}
+
+func TestFileContentsToXML(t *testing.T) {
+	homeDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(homeDir, ".m2"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".m2", "settings.xml"), []byte(plugintest.LoadFixture(t, "maven-settings.xml")), 0600))
+
+	importer := TryFile("~/.m2/settings.xml", func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+		var settings struct {
+			Servers []struct {
+				ID       string `xml:"id"`
+				Username string `xml:"username"`
+				Password string `xml:"password"`
+			} `xml:"servers>server"`
+		}
+		if err := contents.ToXML(&settings); err != nil {
+			out.AddError(err)
+			return
+		}
+
+		for _, server := range settings.Servers {
+			if server.Username == "" || server.Password == "" {
+				continue
+			}
+			out.AddCandidate(sdk.ImportCandidate{
+				Fields: map[sdk.FieldName]string{
+					fieldname.Username: server.Username,
+					fieldname.Password: server.Password,
+				},
+				NameHint: server.ID,
+			})
+		}
+	})
+
+	out := sdk.ImportOutput{}
+	importer(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Equal(t, []sdk.ImportCandidate{
+		{
+			Fields:     map[sdk.FieldName]string{fieldname.Username: "deployer", fieldname.Password: "releases-password"},
+			NameHint:   "internal-releases",
+			Confidence: sdk.ConfidenceHigh,
+		},
+		{
+			Fields:     map[sdk.FieldName]string{fieldname.Username: "wendy", fieldname.Password: "ghp_abc&123<>"},
+			NameHint:   "github",
+			Confidence: sdk.ConfidenceHigh,
+		},
+	}, withoutFieldSources(out.AllCandidates()))
+}
+
+func TestFileContentsToXMLMap(t *testing.T) {
+	result, err := FileContents(plugintest.LoadFixture(t, "maven-settings.xml")).ToXMLMap()
+	require.NoError(t, err)
+
+	settings, ok := result["settings"].(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, "${user.home}/.m2/repository", settings["localRepository"])
+
+	servers := settings["servers"].(map[string]any)["server"].([]any)
+	require.Len(t, servers, 3)
+	assert.Equal(t, map[string]any{"id": "github", "username": "wendy", "password": "ghp_abc&123<>"}, servers[1])
+
+	// Unclosed tags are tolerated.
+	result, err = FileContents("<config><token>abc</token><br></config>").ToXMLMap()
+	require.NoError(t, err)
+	assert.Equal(t, "abc", result["config"].(map[string]any)["token"])
+}
+
+func TestFileContentsToXMLInvalid(t *testing.T) {
+	var result struct{}
+	err := FileContents("<settings><server></settings>").ToXML(&result)
+	assert.EqualError(t, err, "XML syntax error on line 1: element <server> closed by </settings>")
+
+	xxe := FileContents(`<?xml version="1.0"?>
+<!DOCTYPE settings [<!ENTITY secret SYSTEM "file:///etc/passwd">]>
+<settings><password>&secret;</password></settings>`)
+	err = xxe.ToXML(&result)
+	assert.EqualError(t, err, "XML documents with a DOCTYPE declaration are not supported")
+	_, err = xxe.ToXMLMap()
+	assert.EqualError(t, err, "XML documents with a DOCTYPE declaration are not supported")
+}
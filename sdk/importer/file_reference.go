@@ -0,0 +1,54 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// maxFileReferenceSize is the size above which a referenced file is not considered to contain a secret.
+const maxFileReferenceSize = 64 * 1024
+
+// ResolveFileReference follows a config value that points to the file that contains the secret, rather than
+// containing the secret itself, e.g. `token_file: ~/.config/tool/token`. Values starting with "~/" are resolved
+// against the home directory, absolute paths against the root directory, and values starting with "./" or "../"
+// against the directory of the config file that's being imported from. The contents of the referenced file are
+// returned with surrounding whitespace trimmed.
+//
+// Values that don't look like a path, or that don't point to a regular file, are returned as they are, since secrets
+// such as base64-encoded keys can start with "/" as well. If the referenced file can't be read or is larger than
+// 64 KiB, a warning gets added to the attempt and ok is false. Only one level of indirection is followed.
+func ResolveFileReference(in sdk.ImportInput, out *sdk.ImportAttempt, value string) (resolved string, ok bool) {
+	var abspath string
+	switch {
+	case strings.HasPrefix(value, "~/") || strings.HasPrefix(value, "/"):
+		abspath = resolvePath(value, in)
+	case filepath.IsAbs(value):
+		abspath = value
+	case (strings.HasPrefix(value, "./") || strings.HasPrefix(value, "../")) && in.FilePath != "":
+		abspath = filepath.Join(filepath.Dir(in.FilePath), value)
+	default:
+		return value, true
+	}
+
+	info, err := os.Stat(abspath)
+	if err != nil || !info.Mode().IsRegular() {
+		return value, true
+	}
+
+	display := displayPath(abspath, in)
+	if info.Size() > maxFileReferenceSize {
+		out.AddWarning(fmt.Sprintf("cannot read %s: it's larger than %d KiB, which is too large for a secret", display, maxFileReferenceSize/1024))
+		return "", false
+	}
+
+	out.Source.Files = append(out.Source.Files, display)
+	contents, _, ok := readFile(abspath, in, out, defaultMaxFileSize)
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSpace(string(contents)), true
+}
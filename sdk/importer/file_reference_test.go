@@ -0,0 +1,121 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveFileReference(t *testing.T) {
+	rootDir := t.TempDir()
+	homeDir := filepath.Join(rootDir, "home")
+	toolDir := filepath.Join(homeDir, ".config", "tool")
+	require.NoError(t, os.MkdirAll(filepath.Join(toolDir, "secrets"), 0700))
+	require.NoError(t, os.MkdirAll(filepath.Join(rootDir, "etc", "tool"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(toolDir, "token"), []byte("home-token\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(toolDir, "secrets", "password"), []byte("  relative-password  "), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "etc", "tool", "token"), []byte("system-token"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(toolDir, "large"), []byte(strings.Repeat("a", 65*1024)), 0600))
+
+	in := sdk.ImportInput{
+		HomeDir:  homeDir,
+		RootDir:  rootDir,
+		FilePath: filepath.Join(toolDir, "config.yml"),
+	}
+
+	for _, scenario := range []struct {
+		description string
+		value       string
+		expected    string
+		ok          bool
+		files       []string
+		warnings    []sdk.Warning
+	}{
+		{
+			description: "not a reference",
+			value:       "plain-token",
+			expected:    "plain-token",
+			ok:          true,
+		},
+		{
+			description: "tilde expansion",
+			value:       "~/.config/tool/token",
+			expected:    "home-token",
+			ok:          true,
+			files:       []string{"~/.config/tool/token"},
+		},
+		{
+			description: "absolute path",
+			value:       "/etc/tool/token",
+			expected:    "system-token",
+			ok:          true,
+			files:       []string{"/etc/tool/token"},
+		},
+		{
+			description: "relative to the config file",
+			value:       "./secrets/password",
+			expected:    "relative-password",
+			ok:          true,
+			files:       []string{"~/.config/tool/secrets/password"},
+		},
+		{
+			description: "parent of the config file",
+			value:       "../tool/token",
+			expected:    "home-token",
+			ok:          true,
+			files:       []string{"~/.config/tool/token"},
+		},
+		{
+			description: "secret that looks like an absolute path",
+			value:       "/9j4ZmFrZS1rZXk+c2VjcmV0==",
+			expected:    "/9j4ZmFrZS1rZXk+c2VjcmV0==",
+			ok:          true,
+		},
+		{
+			description: "path that doesn't exist",
+			value:       "~/.config/tool/missing",
+			expected:    "~/.config/tool/missing",
+			ok:          true,
+		},
+		{
+			description: "oversized file",
+			value:       "./large",
+			warnings:    []sdk.Warning{{Message: "cannot read ~/.config/tool/large: it's larger than 64 KiB, which is too large for a secret"}},
+		},
+		{
+			description: "directory",
+			value:       "./secrets",
+			expected:    "./secrets",
+			ok:          true,
+		},
+	} {
+		t.Run(scenario.description, func(t *testing.T) {
+			attempt := &sdk.ImportAttempt{}
+			resolved, ok := ResolveFileReference(in, attempt, scenario.value)
+
+			assert.Equal(t, scenario.expected, resolved)
+			assert.Equal(t, scenario.ok, ok)
+			assert.Equal(t, scenario.files, attempt.Source.Files)
+			assert.Equal(t, scenario.warnings, attempt.Diagnostics.Warnings)
+		})
+	}
+}
+
+func TestResolveFileReferenceOutsideFile(t *testing.T) {
+	attempt := &sdk.ImportAttempt{}
+	resolved, ok := ResolveFileReference(sdk.ImportInput{HomeDir: t.TempDir()}, attempt, "./token")
+	assert.Equal(t, "./token", resolved)
+	assert.True(t, ok)
+
+	homeDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, "token"), []byte("home-token"), 0600))
+	resolved, ok = ResolveFileReference(sdk.ImportInput{HomeDir: homeDir}, attempt, "~/token")
+	assert.Equal(t, "home-token", resolved)
+	assert.True(t, ok)
+	assert.Empty(t, attempt.Diagnostics.Warnings)
+}
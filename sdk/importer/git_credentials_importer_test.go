@@ -0,0 +1,85 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTryGitCredentials(t *testing.T) {
+	homeDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".git-credentials"), []byte(plugintest.LoadFixture(t, "git-credentials")), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".gitconfig"), []byte(plugintest.LoadFixture(t, "gitconfig")), 0600))
+
+	mapFields := func(username string, password string) map[string]string {
+		return map[string]string{
+			fieldname.Username.String(): username,
+			fieldname.Token.String():    password,
+		}
+	}
+
+	for _, scenario := range []struct {
+		description string
+		host        string
+		expected    []sdk.ImportCandidate
+	}{
+		{
+			description: "multiple entries with URL-encoded userinfo",
+			host:        "github.com",
+			expected: []sdk.ImportCandidate{
+				{
+					Fields:     map[sdk.FieldName]string{fieldname.Username: "user@example.com", fieldname.Token: "ghp_work/token"},
+					NameHint:   "github.com",
+					Confidence: sdk.ConfidenceHigh,
+				},
+				{
+					Fields:     map[sdk.FieldName]string{fieldname.Username: "octocat", fieldname.Token: "ghp_personal"},
+					NameHint:   "github.com",
+					Confidence: sdk.ConfidenceHigh,
+				},
+				{
+					Fields:     map[sdk.FieldName]string{fieldname.Username: "ci+bot", fieldname.Token: "ghp_ci"},
+					NameHint:   "github.com",
+					Confidence: sdk.ConfidenceHigh,
+				},
+			},
+		},
+		{
+			description: "entries in both files",
+			host:        "gitlab.com",
+			expected: []sdk.ImportCandidate{
+				{
+					Fields:     map[sdk.FieldName]string{fieldname.Username: "deploy", fieldname.Token: "glpat-abc123"},
+					NameHint:   "gitlab.com",
+					Confidence: sdk.ConfidenceHigh,
+				},
+				{
+					Fields:     map[sdk.FieldName]string{fieldname.Username: "deploy", fieldname.Token: "glpat-def456"},
+					NameHint:   "gitlab.com",
+					Confidence: sdk.ConfidenceHigh,
+				},
+			},
+		},
+		{
+			description: "no entries for host",
+			host:        "bitbucket.org",
+		},
+	} {
+		t.Run(scenario.description, func(t *testing.T) {
+			out := sdk.ImportOutput{}
+			TryGitCredentials(scenario.host, mapFields)(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+			assert.Empty(t, out.Errors())
+			assert.Equal(t, scenario.expected, withoutFieldSources(out.AllCandidates()))
+			assert.Equal(t, sdk.ImportSource{Files: []string{"~/.git-credentials"}}, out.Attempts[0].Source)
+			assert.Equal(t, sdk.ImportSource{Files: []string{"~/.gitconfig"}}, out.Attempts[1].Source)
+		})
+	}
+}
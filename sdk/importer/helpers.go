@@ -2,33 +2,133 @@ package importer
 
 import (
 	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
 
 	"github.com/1Password/shell-plugins/sdk"
 )
 
+// maxConcurrentImporters is the number of importers that TryAll runs at the same time.
+const maxConcurrentImporters = 4
+
+// TryAll runs the importers concurrently, so that a slow source, such as a Keychain prompt or a home directory on a
+// network drive, doesn't hold up the others. Each importer writes to its own output, and the attempts of all importers
+// are added to the output in the order of the importers, regardless of which importer finishes first.
+//
+// If the context gets canceled, TryAll returns right away with the attempts of the importers that finished, and a note
+// about the ones that didn't. Importers that are still running are expected to stop when they see the canceled
+// context; their output is discarded. If an importer panics, the panic is re-raised once the other importers are done.
 func TryAll(importers ...sdk.Importer) sdk.Importer {
 	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
-		for _, imp := range importers {
-			imp(ctx, in, out)
+		var mu sync.Mutex
+		results := make([]*sdk.ImportOutput, len(importers))
+		var panicked any
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+
+			var wg sync.WaitGroup
+			workers := make(chan struct{}, maxConcurrentImporters)
+		loop:
+			for i, imp := range importers {
+				select {
+				case workers <- struct{}{}:
+				case <-ctx.Done():
+					break loop
+				}
+
+				wg.Add(1)
+				go func(i int, imp sdk.Importer) {
+					defer wg.Done()
+					defer func() { <-workers }()
+					defer func() {
+						if r := recover(); r != nil {
+							mu.Lock()
+							panicked = fmt.Sprintf("%v\nstack trace of the importer:\n%s", r, debug.Stack())
+							mu.Unlock()
+						}
+					}()
+
+					result := &sdk.ImportOutput{}
+					imp(ctx, in, result)
+
+					mu.Lock()
+					results[i] = result
+					mu.Unlock()
+				}(i, imp)
+			}
+			wg.Wait()
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if panicked != nil {
+			panic(panicked)
+		}
+
+		unfinished := 0
+		for _, result := range results {
+			if result == nil {
+				unfinished++
+				continue
+			}
+			out.Attempts = append(out.Attempts, result.Attempts...)
+		}
+		if unfinished > 0 {
+			out.NewAttempt(sdk.ImportSource{}).AddNote(fmt.Sprintf("Stopped before %d source(s) finished: %s", unfinished, ctx.Err()))
 		}
 	}
 }
 
+// MacOnly only runs the importers on macOS.
 func MacOnly(importers ...sdk.Importer) sdk.Importer {
+	return onlyOn("darwin", "macOS", importers...)
+}
+
+// LinuxOnly only runs the importers on Linux.
+func LinuxOnly(importers ...sdk.Importer) sdk.Importer {
+	return onlyOn("linux", "Linux", importers...)
+}
+
+// WindowsOnly only runs the importers on Windows.
+func WindowsOnly(importers ...sdk.Importer) sdk.Importer {
+	return onlyOn("windows", "Windows", importers...)
+}
+
+// onlyOn runs the importers if the OS of the import input matches the specified one. Otherwise, a note gets recorded
+// that the importers were skipped.
+func onlyOn(goos string, osName string, importers ...sdk.Importer) sdk.Importer {
 	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
-		if in.OS == "darwin" {
-			for _, imp := range importers {
-				imp(ctx, in, out)
-			}
+		if in.OS != goos {
+			out.NewAttempt(sdk.ImportSource{}).AddNote(fmt.Sprintf("Skipped %d source(s) that are only available on %s", len(importers), osName))
+			return
+		}
+
+		for _, imp := range importers {
+			imp(ctx, in, out)
 		}
 	}
 }
 
-func LinuxOnly(importers ...sdk.Importer) sdk.Importer {
+// WithConfidence sets the specified confidence on all candidates found by the importers, e.g. to rank a legacy
+// environment variable or a backup file lower than the tool's current config.
+func WithConfidence(confidence sdk.Confidence, importers ...sdk.Importer) sdk.Importer {
 	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
-		if in.OS == "linux" {
-			for _, imp := range importers {
-				imp(ctx, in, out)
+		start := len(out.Attempts)
+		for _, imp := range importers {
+			imp(ctx, in, out)
+		}
+
+		for _, attempt := range out.Attempts[start:] {
+			for i := range attempt.Candidates {
+				attempt.Candidates[i].Confidence = confidence
 			}
 		}
 	}
@@ -0,0 +1,161 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+)
+
+// slowImporter returns an importer that adds a candidate with the specified token after the delay, unless the context
+// gets canceled first.
+func slowImporter(token string, delay time.Duration) sdk.Importer {
+	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+		attempt := out.NewAttempt(SourceOther("Slow source", token))
+		select {
+		case <-time.After(delay):
+			attempt.AddCandidate(sdk.ImportCandidate{Fields: map[sdk.FieldName]string{fieldname.Token: token}})
+		case <-ctx.Done():
+			attempt.AddError(ctx.Err())
+		}
+	}
+}
+
+func TestTryAllOrdering(t *testing.T) {
+	var importers []sdk.Importer
+	var expected []sdk.ImportCandidate
+	for i := 0; i < 10; i++ {
+		token := fmt.Sprintf("token-%d", i)
+		// Earlier importers take longer, so they finish last.
+		importers = append(importers, slowImporter(token, time.Duration(10-i)*5*time.Millisecond))
+		expected = append(expected, sdk.ImportCandidate{Fields: map[sdk.FieldName]string{fieldname.Token: token}})
+	}
+
+	out := sdk.ImportOutput{}
+	TryAll(importers...)(context.Background(), sdk.ImportInput{}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Equal(t, expected, withoutFieldSources(out.AllCandidates()))
+}
+
+func TestTryAllRunsConcurrently(t *testing.T) {
+	var importers []sdk.Importer
+	for i := 0; i < maxConcurrentImporters; i++ {
+		importers = append(importers, slowImporter(fmt.Sprintf("token-%d", i), 200*time.Millisecond))
+	}
+
+	start := time.Now()
+	out := sdk.ImportOutput{}
+	TryAll(importers...)(context.Background(), sdk.ImportInput{}, &out)
+
+	assert.Len(t, out.AllCandidates(), maxConcurrentImporters)
+	assert.Less(t, time.Since(start), time.Duration(maxConcurrentImporters)*200*time.Millisecond)
+}
+
+func TestTryAllCancellation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	out := sdk.ImportOutput{}
+	start := time.Now()
+	TryAll(
+		slowImporter("fast", 0),
+		func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+			// Ignores the context, like a blocking file read on a network drive.
+			time.Sleep(time.Minute)
+		},
+	)(ctx, sdk.ImportInput{}, &out)
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, []sdk.ImportCandidate{{Fields: map[sdk.FieldName]string{fieldname.Token: "fast"}}}, withoutFieldSources(out.AllCandidates()))
+	assert.Equal(t, sdk.Note{Message: "Stopped before 1 source(s) finished: context deadline exceeded"}, out.Attempts[len(out.Attempts)-1].Diagnostics.Notes[0])
+}
+
+func TestTryAllPanic(t *testing.T) {
+	defer func() {
+		r := recover()
+		assert.IsType(t, "", r)
+		assert.True(t, strings.HasPrefix(r.(string), "oops\nstack trace of the importer:\n"))
+	}()
+
+	out := sdk.ImportOutput{}
+	TryAll(
+		slowImporter("token", 0),
+		func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+			panic("oops")
+		},
+	)(context.Background(), sdk.ImportInput{}, &out)
+	t.Fatal("expected TryAll to re-raise the panic of the importer")
+}
+
+func TestWithConfidence(t *testing.T) {
+	t.Setenv("TOOL_TOKEN", "token")
+	t.Setenv("LEGACY_TOOL_TOKEN", "legacy-token")
+
+	out := sdk.ImportOutput{}
+	TryAll(
+		TryAllEnvVars(fieldname.Token, "TOOL_TOKEN"),
+		WithConfidence(sdk.ConfidenceLow, TryAllEnvVars(fieldname.Token, "LEGACY_TOOL_TOKEN")),
+	)(context.Background(), sdk.ImportInput{}, &out)
+
+	assert.Equal(t, []sdk.ImportCandidate{
+		{Fields: map[sdk.FieldName]string{fieldname.Token: "token"}, Confidence: sdk.ConfidenceMedium},
+		{Fields: map[sdk.FieldName]string{fieldname.Token: "legacy-token"}, Confidence: sdk.ConfidenceLow},
+	}, withoutFieldSources(out.AllCandidates()))
+}
+
+func TestOSOnly(t *testing.T) {
+	t.Setenv("TOOL_TOKEN", "token")
+	tryToken := TryAllEnvVars(fieldname.Token, "TOOL_TOKEN")
+
+	for _, scenario := range []struct {
+		description string
+		importer    sdk.Importer
+		os          string
+		expectedRun bool
+		expectedOS  string
+	}{
+		{description: "MacOnly on macOS", importer: MacOnly(tryToken), os: "darwin", expectedRun: true},
+		{description: "MacOnly on Linux", importer: MacOnly(tryToken), os: "linux", expectedOS: "macOS"},
+		{description: "LinuxOnly on Linux", importer: LinuxOnly(tryToken), os: "linux", expectedRun: true},
+		{description: "LinuxOnly on Windows", importer: LinuxOnly(tryToken), os: "windows", expectedOS: "Linux"},
+		{description: "WindowsOnly on Windows", importer: WindowsOnly(tryToken), os: "windows", expectedRun: true},
+		{description: "WindowsOnly on macOS", importer: WindowsOnly(tryToken), os: "darwin", expectedOS: "Windows"},
+	} {
+		t.Run(scenario.description, func(t *testing.T) {
+			out := sdk.ImportOutput{}
+			scenario.importer(context.Background(), sdk.ImportInput{OS: scenario.os}, &out)
+
+			if scenario.expectedRun {
+				assert.Equal(t, []*sdk.ImportAttempt{{
+					Source: SourceEnvVars("TOOL_TOKEN"),
+					Candidates: []sdk.ImportCandidate{{
+						Fields:       map[sdk.FieldName]string{fieldname.Token: "token"},
+						Confidence:   sdk.ConfidenceMedium,
+						FieldSources: map[sdk.FieldName][]sdk.FieldSource{fieldname.Token: {{EnvVar: "TOOL_TOKEN"}}},
+					}},
+				}}, out.Attempts)
+				return
+			}
+			assert.Equal(t, []*sdk.ImportAttempt{{
+				Diagnostics: sdk.Diagnostics{
+					Notes: []sdk.Note{{Message: "Skipped 1 source(s) that are only available on " + scenario.expectedOS}},
+				},
+			}}, out.Attempts)
+		})
+	}
+}
+
+// withoutFieldSources returns the candidates with their field sources cleared, for tests that don't check where the
+// values came from.
+func withoutFieldSources(candidates []sdk.ImportCandidate) []sdk.ImportCandidate {
+	for i := range candidates {
+		candidates[i].FieldSources = nil
+	}
+	return candidates
+}
@@ -0,0 +1,291 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONValue is a parsed JSON document, or a part of it, that can be queried using selectors. A selector is a path
+// of object keys separated by dots, in which array elements are selected by their index, e.g.
+// "contexts.default.access-token" or "data[0].credential.access_token". In JSONValue.LookupAll, a single "*" selects
+// all keys of an object or all elements of an array, e.g. "auth-contexts.*" or "data[*].credential". Keys that contain
+// dots or brackets, like host names, can be selected with a double-quoted key in brackets, e.g. `auths["ghcr.io"].auth`.
+type JSONValue struct {
+	value any
+}
+
+// JSONMatch is a value that's matched by the wildcard of a selector.
+type JSONMatch struct {
+	// Key is the object key or, for arrays, the index that the wildcard matched.
+	Key string
+
+	// Value is the value at the end of the selector.
+	Value JSONValue
+}
+
+// JSONTypeError is returned if the value at the end of a selector is not of the expected type, e.g. a number where a
+// string was expected.
+type JSONTypeError struct {
+	Path     string
+	Expected string
+	Actual   string
+}
+
+func (e *JSONTypeError) Error() string {
+	return fmt.Sprintf("expected %s at '%s', found %s", e.Expected, e.Path, e.Actual)
+}
+
+// ToJSONValue parses the file contents as JSON, so that values can be looked up using selectors.
+func (fc FileContents) ToJSONValue() (JSONValue, error) {
+	decoder := json.NewDecoder(bytes.NewReader(fc))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return JSONValue{}, err
+	}
+	return JSONValue{value: value}, nil
+}
+
+// JSONLookup returns the string at the specified selector in the JSON file, e.g. "contexts.default.access-token".
+// If the path doesn't exist in the file, ok is false. If the value is not a string, a *JSONTypeError is returned.
+func (fc FileContents) JSONLookup(path string) (value string, ok bool, err error) {
+	document, err := fc.ToJSONValue()
+	if err != nil {
+		return "", false, err
+	}
+	return document.Lookup(path)
+}
+
+// JSONLookupAll returns the values matched by the selector with a wildcard in the JSON file, e.g. "contexts.*", along
+// with the keys matched by the wildcard. This can be used to add a candidate for each profile in the file.
+func (fc FileContents) JSONLookupAll(path string) ([]JSONMatch, error) {
+	document, err := fc.ToJSONValue()
+	if err != nil {
+		return nil, err
+	}
+	return document.LookupAll(path)
+}
+
+// Lookup returns the string at the specified selector, relative to this value. An empty selector returns the value
+// itself. If the path doesn't exist, ok is false. If the value is not a string, a *JSONTypeError is returned.
+func (v JSONValue) Lookup(path string) (value string, ok bool, err error) {
+	steps, err := parseJSONSelector(path)
+	if err != nil {
+		return "", false, err
+	}
+	for _, step := range steps {
+		if step.wildcard {
+			return "", false, fmt.Errorf("invalid selector '%s': wildcards are only supported by LookupAll", path)
+		}
+	}
+
+	result, ok := v.walk(steps)
+	if !ok {
+		return "", false, nil
+	}
+	switch result := result.(type) {
+	case string:
+		return result, true, nil
+	default:
+		return "", false, &JSONTypeError{Path: path, Expected: "string", Actual: jsonTypeName(result)}
+	}
+}
+
+// LookupAll returns the values matched by the selector, relative to this value. The selector must contain exactly one
+// wildcard. Keys of objects are matched in sorted order and elements of arrays in their order in the array. Matches for
+// which the rest of the path doesn't exist are left out.
+func (v JSONValue) LookupAll(path string) ([]JSONMatch, error) {
+	steps, err := parseJSONSelector(path)
+	if err != nil {
+		return nil, err
+	}
+
+	wildcard := -1
+	for i, step := range steps {
+		if !step.wildcard {
+			continue
+		}
+		if wildcard != -1 {
+			return nil, fmt.Errorf("invalid selector '%s': only one wildcard is supported", path)
+		}
+		wildcard = i
+	}
+	if wildcard == -1 {
+		return nil, fmt.Errorf("invalid selector '%s': no wildcard", path)
+	}
+
+	parent, ok := v.walk(steps[:wildcard])
+	if !ok {
+		return nil, nil
+	}
+
+	var children []JSONMatch
+	switch parent := parent.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(parent))
+		for key := range parent {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			children = append(children, JSONMatch{Key: key, Value: JSONValue{value: parent[key]}})
+		}
+	case []any:
+		for i, element := range parent {
+			children = append(children, JSONMatch{Key: strconv.Itoa(i), Value: JSONValue{value: element}})
+		}
+	}
+
+	var matches []JSONMatch
+	for _, child := range children {
+		result, ok := child.Value.walk(steps[wildcard+1:])
+		if !ok {
+			continue
+		}
+		matches = append(matches, JSONMatch{Key: child.Key, Value: JSONValue{value: result}})
+	}
+	return matches, nil
+}
+
+// walk follows the steps from this value and returns the value at the end. If a step can't be followed, for example
+// because a key doesn't exist or an index is out of range, ok is false.
+func (v JSONValue) walk(steps []jsonSelectorStep) (value any, ok bool) {
+	value = v.value
+	for _, step := range steps {
+		switch current := value.(type) {
+		case map[string]any:
+			if step.isIndex {
+				return nil, false
+			}
+			value, ok = current[step.key]
+			if !ok {
+				return nil, false
+			}
+		case []any:
+			if !step.isIndex || step.index >= len(current) {
+				return nil, false
+			}
+			value = current[step.index]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// jsonSelectorStep is a single object key, array index or wildcard in a selector.
+type jsonSelectorStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJSONSelector splits a selector like `data[0].credential` or `auths["ghcr.io"].auth` into its steps.
+func parseJSONSelector(path string) ([]jsonSelectorStep, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	var steps []jsonSelectorStep
+	for i := 0; ; i++ {
+		// A plain object key runs up to the next dot or bracket.
+		start := i
+		for i < len(path) && path[i] != '.' && path[i] != '[' {
+			i++
+		}
+		switch key := path[start:i]; {
+		case key == "*":
+			steps = append(steps, jsonSelectorStep{wildcard: true})
+		case key != "":
+			steps = append(steps, jsonSelectorStep{key: key})
+		case i == len(path) || path[i] == '.':
+			return nil, fmt.Errorf("invalid selector '%s': empty key", path)
+		}
+
+		// A plain key can be followed by any number of brackets, e.g. "[0]", "[*]" or `["ghcr.io"]`.
+		for i < len(path) && path[i] == '[' {
+			segment := path[start:]
+			if end := strings.IndexByte(path[i:], '.'); end != -1 {
+				segment = path[start : i+end]
+			}
+			step, n, err := parseJSONSelectorBracket(path[i:], segment)
+			if err != nil {
+				return nil, fmt.Errorf("invalid selector '%s': %s", path, err)
+			}
+			steps = append(steps, step)
+			i += n
+		}
+
+		if i == len(path) {
+			return steps, nil
+		}
+		if path[i] != '.' {
+			return nil, fmt.Errorf("invalid selector '%s': expected '.' or '[' after ']' in '%s'", path, path[start:])
+		}
+	}
+}
+
+// parseJSONSelectorBracket parses the bracket at the start of the selector part, which contains an array index, a
+// wildcard or a double-quoted object key, and returns the step along with the length of the bracket. The segment is
+// the part of the selector that the bracket belongs to, for use in error messages.
+func parseJSONSelectorBracket(s string, segment string) (step jsonSelectorStep, length int, err error) {
+	if strings.HasPrefix(s, `["`) {
+		// Find the closing quote, skipping escaped characters.
+		for i := 2; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				if i+1 >= len(s) || s[i+1] != ']' {
+					return step, 0, fmt.Errorf("malformed quoted key in '%s'", segment)
+				}
+				key, err := strconv.Unquote(s[1 : i+1])
+				if err != nil {
+					return step, 0, fmt.Errorf("malformed quoted key in '%s'", segment)
+				}
+				return jsonSelectorStep{key: key}, i + 2, nil
+			}
+		}
+		return step, 0, fmt.Errorf("malformed quoted key in '%s'", segment)
+	}
+
+	end := strings.IndexByte(s, ']')
+	if end == -1 {
+		return step, 0, fmt.Errorf("malformed index in '%s'", segment)
+	}
+	index := s[1:end]
+	if index == "*" {
+		return jsonSelectorStep{wildcard: true}, end + 1, nil
+	}
+	i, err := strconv.Atoi(index)
+	if err != nil || i < 0 {
+		return step, 0, fmt.Errorf("'%s' is not a valid index", index)
+	}
+	return jsonSelectorStep{index: i, isIndex: true}, end + 1, nil
+}
+
+// jsonTypeName returns the JSON type of the value, for use in error messages.
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
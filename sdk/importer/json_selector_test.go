@@ -0,0 +1,183 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileContentsJSONLookup(t *testing.T) {
+	doctl := FileContents(plugintest.LoadFixture(t, "doctl-config.json"))
+	gcloud := FileContents(plugintest.LoadFixture(t, "gcloud-credentials.json"))
+
+	for _, scenario := range []struct {
+		description string
+		contents    FileContents
+		path        string
+		expected    string
+		expectedOK  bool
+	}{
+		{description: "top-level key", contents: doctl, path: "access-token", expected: "dop_v1_default0000000000000000000000000000000000000000000000000000", expectedOK: true},
+		{description: "nested key", contents: doctl, path: "auth-contexts.work", expected: "dop_v1_work00000000000000000000000000000000000000000000000000000000", expectedOK: true},
+		{description: "array index", contents: gcloud, path: "data[0].credential.access_token", expected: "ya29.wendy-access-token", expectedOK: true},
+		{description: "missing key", contents: doctl, path: "auth-contexts.staging"},
+		{description: "missing nested key", contents: doctl, path: "apps.prod.config.port"},
+		{description: "index out of range", contents: gcloud, path: "data[2].credential.access_token"},
+		{description: "key on array", contents: gcloud, path: "data.credential"},
+		{description: "index on object", contents: doctl, path: "auth-contexts[0]"},
+		{description: "key on string", contents: doctl, path: "context.name"},
+	} {
+		t.Run(scenario.description, func(t *testing.T) {
+			value, ok, err := scenario.contents.JSONLookup(scenario.path)
+			require.NoError(t, err)
+			assert.Equal(t, scenario.expectedOK, ok)
+			assert.Equal(t, scenario.expected, value)
+		})
+	}
+}
+
+func TestFileContentsJSONLookupTypeMismatch(t *testing.T) {
+	doctl := FileContents(plugintest.LoadFixture(t, "doctl-config.json"))
+	gcloud := FileContents(plugintest.LoadFixture(t, "gcloud-credentials.json"))
+
+	_, ok, err := gcloud.JSONLookup("data[1].credential.private_key_id")
+	assert.False(t, ok)
+	var typeErr *JSONTypeError
+	require.ErrorAs(t, err, &typeErr)
+	assert.Equal(t, &JSONTypeError{Path: "data[1].credential.private_key_id", Expected: "string", Actual: "number"}, typeErr)
+	assert.EqualError(t, err, "expected string at 'data[1].credential.private_key_id', found number")
+
+	_, _, err = doctl.JSONLookup("auth-contexts")
+	assert.EqualError(t, err, "expected string at 'auth-contexts', found object")
+}
+
+func TestFileContentsJSONLookupAll(t *testing.T) {
+	doctl := FileContents(plugintest.LoadFixture(t, "doctl-config.json"))
+	gcloud := FileContents(plugintest.LoadFixture(t, "gcloud-credentials.json"))
+
+	t.Run("object keys", func(t *testing.T) {
+		matches, err := doctl.JSONLookupAll("auth-contexts.*")
+		require.NoError(t, err)
+		require.Len(t, matches, 2)
+
+		var tokens []string
+		for _, match := range matches {
+			token, ok, err := match.Value.Lookup("")
+			require.NoError(t, err)
+			require.True(t, ok)
+			tokens = append(tokens, match.Key+"="+token)
+		}
+		assert.Equal(t, []string{
+			"personal=dop_v1_personal000000000000000000000000000000000000000000000000000",
+			"work=dop_v1_work00000000000000000000000000000000000000000000000000000000",
+		}, tokens)
+	})
+
+	t.Run("array elements", func(t *testing.T) {
+		matches, err := gcloud.JSONLookupAll("data[*]")
+		require.NoError(t, err)
+		require.Len(t, matches, 2)
+		assert.Equal(t, "0", matches[0].Key)
+		assert.Equal(t, "1", matches[1].Key)
+
+		account, ok, err := matches[1].Value.Lookup("key.account")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "ci@project.iam.gserviceaccount.com", account)
+	})
+
+	t.Run("rest of path after wildcard", func(t *testing.T) {
+		matches, err := gcloud.JSONLookupAll("data[*].credential.refresh_token")
+		require.NoError(t, err)
+		require.Len(t, matches, 1)
+		assert.Equal(t, "0", matches[0].Key)
+
+		token, ok, err := matches[0].Value.Lookup("")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "1//wendy-refresh-token", token)
+	})
+
+	t.Run("missing path", func(t *testing.T) {
+		matches, err := doctl.JSONLookupAll("contexts.*")
+		require.NoError(t, err)
+		assert.Empty(t, matches)
+	})
+}
+
+func TestFileContentsJSONLookupQuotedKeys(t *testing.T) {
+	contents := FileContents(`{
+		"auths": {
+			"index.docker.io/v1/": {"auth": "d2VuZHk6aHVudGVyMg=="},
+			"ghcr.io": {"auth": "Z2hjcjp0b2tlbg=="}
+		},
+		"hosts": {
+			"github.com": [{"oauth_token": "gho_wendy"}],
+			"api.heroku.com": {"password": "heroku-key"},
+			"quote\"and\\backslash": "escaped"
+		}
+	}`)
+
+	for path, expected := range map[string]string{
+		`auths["index.docker.io/v1/"].auth`:       "d2VuZHk6aHVudGVyMg==",
+		`auths["ghcr.io"].auth`:                   "Z2hjcjp0b2tlbg==",
+		`hosts["github.com"][0].oauth_token`:      "gho_wendy",
+		`hosts["api.heroku.com"].password`:        "heroku-key",
+		`["hosts"]["api.heroku.com"]["password"]`: "heroku-key",
+		`hosts["quote\"and\\backslash"]`:          "escaped",
+	} {
+		t.Run(path, func(t *testing.T) {
+			value, ok, err := contents.JSONLookup(path)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, expected, value)
+		})
+	}
+
+	// Without quotes, the dots in the key are separators.
+	_, ok, err := contents.JSONLookup("auths.ghcr.io.auth")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	matches, err := contents.JSONLookupAll(`auths[*].auth`)
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.Equal(t, "ghcr.io", matches[0].Key)
+	assert.Equal(t, "index.docker.io/v1/", matches[1].Key)
+}
+
+func TestJSONSelectorErrors(t *testing.T) {
+	contents := FileContents(`{"a": {"b": "c"}}`)
+
+	for _, scenario := range []struct {
+		path     string
+		all      bool
+		expected string
+	}{
+		{path: "a.*", expected: "invalid selector 'a.*': wildcards are only supported by LookupAll"},
+		{path: "a..b", expected: "invalid selector 'a..b': empty key"},
+		{path: "a[x]", expected: "invalid selector 'a[x]': 'x' is not a valid index"},
+		{path: "a[0", expected: "invalid selector 'a[0': malformed index in 'a[0'"},
+		{path: `a["b`, expected: `invalid selector 'a["b': malformed quoted key in 'a["b'`},
+		{path: `a["b"c]`, expected: `invalid selector 'a["b"c]': malformed quoted key in 'a["b"c]'`},
+		{path: `a["b"]c`, expected: `invalid selector 'a["b"]c': expected '.' or '[' after ']' in 'a["b"]c'`},
+		{path: "a.", expected: "invalid selector 'a.': empty key"},
+		{path: "a.b", all: true, expected: "invalid selector 'a.b': no wildcard"},
+		{path: "*.*", all: true, expected: "invalid selector '*.*': only one wildcard is supported"},
+	} {
+		t.Run(scenario.path, func(t *testing.T) {
+			var err error
+			if scenario.all {
+				_, err = contents.JSONLookupAll(scenario.path)
+			} else {
+				_, _, err = contents.JSONLookup(scenario.path)
+			}
+			assert.EqualError(t, err, scenario.expected)
+		})
+	}
+
+	_, _, err := FileContents(`{"a": `).JSONLookup("a")
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// securityItemNotFound is the exit code of the security CLI if no item matches the query.
+const securityItemNotFound = 44
+
+// keychainItem is a generic password item in the macOS Keychain.
+type keychainItem struct {
+	account  string
+	password string
+}
+
+// keychainReader looks up generic password items in the macOS Keychain. It can be replaced in tests, to exercise the
+// importer on any platform.
+type keychainReader interface {
+	// findGenericPasswords returns the items with the specified service and account. An empty account matches any
+	// account. No matching items is not an error.
+	findGenericPasswords(ctx context.Context, service string, account string) ([]keychainItem, error)
+}
+
+// TryKeychain looks up generic password items with the specified service, e.g. "api.heroku.com", in the user's macOS
+// Keychain. If account is empty, items for any account match. Each matching item gets mapped to a candidate by
+// mapFields, with the account of the item as name hint. Returning no fields from mapFields results in no candidate.
+//
+// TryKeychain is silently skipped on platforms other than macOS. Reading a password may show a prompt to the user to
+// allow access to the item. If access is denied, that gets reported as an error on the import attempt.
+func TryKeychain(service string, account string, mapFields func(account string, password string) map[sdk.FieldName]string) sdk.Importer {
+	return tryKeychain(securityCLI{run: execCommand}, service, account, mapFields)
+}
+
+func tryKeychain(keychain keychainReader, service string, account string, mapFields func(account string, password string) map[sdk.FieldName]string) sdk.Importer {
+	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+		if in.OS != "darwin" {
+			return
+		}
+
+		attempt := out.NewAttempt(SourceOther("macOS Keychain", service))
+		items, err := keychain.findGenericPasswords(ctx, service, account)
+		if err != nil {
+			attempt.AddError(err)
+			return
+		}
+
+		for _, item := range items {
+			fields := mapFields(item.account, item.password)
+			if len(fields) == 0 {
+				continue
+			}
+			candidate := sdk.ImportCandidate{
+				Fields:   fields,
+				NameHint: SanitizeNameHint(item.account),
+			}
+			defaultFieldSource(&candidate, sdk.FieldSource{Other: fmt.Sprintf("macOS Keychain: %s (%s)", service, item.account)})
+			attempt.AddCandidate(candidate)
+		}
+	}
+}
+
+// commandRunner runs the specified command and returns its stdout and stderr.
+type commandRunner func(ctx context.Context, name string, args ...string) (stdout []byte, stderr []byte, err error)
+
+func execCommand(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+// securityCLI reads Keychain items using the security CLI that ships with macOS. Since the security CLI only returns
+// the first matching item, it finds at most one item.
+type securityCLI struct {
+	run commandRunner
+}
+
+// accountAttributePattern matches the account attribute in the output of `security find-generic-password`.
+var accountAttributePattern = regexp.MustCompile(`(?m)^\s*"acct"<blob>="(.*)"\s*$`)
+
+func (s securityCLI) findGenericPasswords(ctx context.Context, service string, account string) ([]keychainItem, error) {
+	args := []string{"find-generic-password", "-s", service}
+	if account != "" {
+		args = append(args, "-a", account)
+	}
+
+	// Look up the attributes first, which doesn't require access to the password, to learn the account of the item.
+	attributes, stderr, err := s.run(ctx, "security", args...)
+	if isItemNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("looking up Keychain item for %s: %s: %s", service, err, strings.TrimSpace(string(stderr)))
+	}
+	if match := accountAttributePattern.FindSubmatch(attributes); match != nil && account == "" {
+		account = string(match[1])
+		args = append(args, "-a", account)
+	}
+
+	password, stderr, err := s.run(ctx, "security", append(args, "-w")...)
+	if isItemNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		// This includes the user denying access to the item when prompted.
+		return nil, fmt.Errorf("reading Keychain item for %s: %s: %s", service, err, strings.TrimSpace(string(stderr)))
+	}
+
+	return []keychainItem{{
+		account:  account,
+		password: strings.TrimSuffix(string(password), "\n"),
+	}}, nil
+}
+
+// isItemNotFound returns whether the error is the security CLI reporting that no item matches the query.
+func isItemNotFound(err error) bool {
+	var exitErr interface{ ExitCode() int }
+	return errors.As(err, &exitErr) && exitErr.ExitCode() == securityItemNotFound
+}
@@ -0,0 +1,150 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeKeychain struct {
+	items []keychainItem
+	err   error
+}
+
+func (k fakeKeychain) findGenericPasswords(ctx context.Context, service string, account string) ([]keychainItem, error) {
+	return k.items, k.err
+}
+
+func mapKeychainToken(account string, password string) map[sdk.FieldName]string {
+	if password == "" {
+		return nil
+	}
+	return map[sdk.FieldName]string{fieldname.Token: password}
+}
+
+func TestTryKeychain(t *testing.T) {
+	keychain := fakeKeychain{items: []keychainItem{
+		{account: "user@example.com", password: "token-user"},
+		{account: "default", password: "token-default"},
+		{account: "empty@example.com"},
+	}}
+
+	out := sdk.ImportOutput{}
+	tryKeychain(keychain, "api.example.com", "", mapKeychainToken)(context.Background(), sdk.ImportInput{OS: "darwin"}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Equal(t, []sdk.ImportCandidate{
+		{Fields: map[sdk.FieldName]string{fieldname.Token: "token-user"}, NameHint: "user@example.com"},
+		{Fields: map[sdk.FieldName]string{fieldname.Token: "token-default"}},
+	}, withoutFieldSources(out.AllCandidates()))
+	assert.Equal(t, SourceOther("macOS Keychain", "api.example.com"), out.Attempts[0].Source)
+}
+
+func TestTryKeychainOtherPlatforms(t *testing.T) {
+	keychain := fakeKeychain{items: []keychainItem{{account: "user", password: "token"}}}
+
+	for _, goos := range []string{"linux", "windows"} {
+		out := sdk.ImportOutput{}
+		TryAll(tryKeychain(keychain, "api.example.com", "", mapKeychainToken))(context.Background(), sdk.ImportInput{OS: goos}, &out)
+
+		assert.Empty(t, out.Attempts)
+	}
+}
+
+func TestTryKeychainError(t *testing.T) {
+	keychain := fakeKeychain{err: errors.New("access denied")}
+
+	out := sdk.ImportOutput{}
+	tryKeychain(keychain, "api.example.com", "", mapKeychainToken)(context.Background(), sdk.ImportInput{OS: "darwin"}, &out)
+
+	assert.Empty(t, out.AllCandidates())
+	assert.Equal(t, []sdk.Error{{Message: "access denied"}}, out.Errors())
+}
+
+type exitError int
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+func (e exitError) ExitCode() int {
+	return int(e)
+}
+
+func TestSecurityCLI(t *testing.T) {
+	const attributes = `keychain: "/Users/user/Library/Keychains/login.keychain-db"
+class: "genp"
+attributes:
+    "acct"<blob>="user@example.com"
+    "svce"<blob>="api.example.com"
+`
+
+	for _, scenario := range []struct {
+		description string
+		account     string
+		run         commandRunner
+		expected    []keychainItem
+		expectedErr string
+	}{
+		{
+			description: "any account",
+			run: func(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+				switch strings.Join(args, " ") {
+				case "find-generic-password -s api.example.com":
+					return []byte(attributes), nil, nil
+				case "find-generic-password -s api.example.com -a user@example.com -w":
+					return []byte("token\n"), nil, nil
+				}
+				return nil, nil, fmt.Errorf("unexpected args: %v", args)
+			},
+			expected: []keychainItem{{account: "user@example.com", password: "token"}},
+		},
+		{
+			description: "specific account",
+			account:     "user@example.com",
+			run: func(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+				switch strings.Join(args, " ") {
+				case "find-generic-password -s api.example.com -a user@example.com":
+					return []byte(attributes), nil, nil
+				case "find-generic-password -s api.example.com -a user@example.com -w":
+					return []byte("token\n"), nil, nil
+				}
+				return nil, nil, fmt.Errorf("unexpected args: %v", args)
+			},
+			expected: []keychainItem{{account: "user@example.com", password: "token"}},
+		},
+		{
+			description: "not found",
+			run: func(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+				return nil, []byte("security: SecKeychainSearchCopyNext: The specified item could not be found in the keychain."), exitError(securityItemNotFound)
+			},
+		},
+		{
+			description: "access denied",
+			run: func(ctx context.Context, name string, args ...string) ([]byte, []byte, error) {
+				if args[len(args)-1] == "-w" {
+					return nil, []byte("security: SecKeychainItemCopyContent: User interaction is not allowed."), exitError(51)
+				}
+				return []byte(attributes), nil, nil
+			},
+			expectedErr: "reading Keychain item for api.example.com: exit status 51: security: SecKeychainItemCopyContent: User interaction is not allowed.",
+		},
+	} {
+		t.Run(scenario.description, func(t *testing.T) {
+			items, err := securityCLI{run: scenario.run}.findGenericPasswords(context.Background(), "api.example.com", scenario.account)
+			if scenario.expectedErr != "" {
+				require.EqualError(t, err, scenario.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, scenario.expected, items)
+		})
+	}
+}
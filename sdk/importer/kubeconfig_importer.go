@@ -0,0 +1,194 @@
+package importer
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"gopkg.in/yaml.v3"
+)
+
+// KubeconfigUser is the user of a context in a kubeconfig file, with the credentials it authenticates with.
+type KubeconfigUser struct {
+	Name     string
+	Token    string
+	Username string
+	Password string
+
+	// ClientCertificateData and ClientKeyData are the PEM-encoded client certificate and key, decoded from the base64
+	// in the kubeconfig file.
+	ClientCertificateData string
+	ClientKeyData         string
+}
+
+type kubeconfig struct {
+	Clusters []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server string `yaml:"server"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Users []struct {
+		Name string         `yaml:"name"`
+		User kubeconfigAuth `yaml:"user"`
+	} `yaml:"users"`
+}
+
+type kubeconfigAuth struct {
+	Token                 string `yaml:"token"`
+	Username              string `yaml:"username"`
+	Password              string `yaml:"password"`
+	ClientCertificateData string `yaml:"client-certificate-data"`
+	ClientKeyData         string `yaml:"client-key-data"`
+	Exec                  *struct {
+		Command string `yaml:"command"`
+	} `yaml:"exec"`
+	AuthProvider *struct {
+		Name string `yaml:"name"`
+	} `yaml:"auth-provider"`
+}
+
+// TryKubeconfig looks for contexts in the user's kubeconfig of which the cluster matches, e.g. by its server URL, and
+// maps the credentials of the context's user to the fields of a candidate using mapUser, with the context name as
+// name hint. Returning no fields from mapUser results in no candidate.
+//
+// Like kubectl, it reads the files in the KUBECONFIG environment variable if it's set, and ~/.kube/config otherwise.
+// If a cluster or user is defined in multiple files, the first definition wins. Users that get their credentials from
+// an exec or auth provider plugin are skipped, which gets recorded as a note on the attempt.
+func TryKubeconfig(matchCluster func(name string, server string) bool, mapUser func(user KubeconfigUser) map[string]string) sdk.Importer {
+	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+		paths := []string{resolvePath("~/.kube/config", in)}
+		if env := os.Getenv("KUBECONFIG"); env != "" {
+			separator := ":"
+			if in.OS == "windows" {
+				separator = ";"
+			}
+			paths = nil
+			for _, path := range strings.Split(env, separator) {
+				if path != "" {
+					paths = append(paths, resolvePath(path, in))
+				}
+			}
+		}
+
+		attempt := out.NewAttempt(sdk.ImportSource{})
+		var configs []kubeconfig
+		var configPaths []string
+		for _, path := range paths {
+			attempt.Source.Files = append(attempt.Source.Files, displayPath(path, in))
+			contents, _, ok := readFile(path, in, attempt, defaultMaxFileSize)
+			if !ok {
+				continue
+			}
+
+			var config kubeconfig
+			if err := yaml.Unmarshal(contents, &config); err != nil {
+				attempt.AddError(fmt.Errorf("invalid kubeconfig %s: %w", displayPath(path, in), err))
+				continue
+			}
+			configs = append(configs, config)
+			configPaths = append(configPaths, displayPath(path, in))
+		}
+
+		servers := map[string]string{}
+		users := map[string]kubeconfigAuth{}
+		userPaths := map[string]string{}
+		for i := len(configs) - 1; i >= 0; i-- {
+			// Later files are applied first, so that the definitions in earlier files win.
+			for _, cluster := range configs[i].Clusters {
+				servers[cluster.Name] = cluster.Cluster.Server
+			}
+			for _, user := range configs[i].Users {
+				users[user.Name] = user.User
+				userPaths[user.Name] = configPaths[i]
+			}
+		}
+
+		seen := map[string]bool{}
+		for _, config := range configs {
+			for _, kubeContext := range config.Contexts {
+				if seen[kubeContext.Name] {
+					continue
+				}
+				seen[kubeContext.Name] = true
+
+				server, ok := servers[kubeContext.Context.Cluster]
+				if !ok || !matchCluster(kubeContext.Context.Cluster, server) {
+					continue
+				}
+				auth, ok := users[kubeContext.Context.User]
+				if !ok {
+					continue
+				}
+				if auth.Exec != nil {
+					attempt.AddNote(fmt.Sprintf("Skipped context '%s': user '%s' gets its credentials from exec plugin '%s'", kubeContext.Name, kubeContext.Context.User, auth.Exec.Command))
+					continue
+				}
+				if auth.AuthProvider != nil {
+					attempt.AddNote(fmt.Sprintf("Skipped context '%s': user '%s' gets its credentials from auth provider '%s'", kubeContext.Name, kubeContext.Context.User, auth.AuthProvider.Name))
+					continue
+				}
+
+				user, err := decodeKubeconfigUser(kubeContext.Context.User, auth)
+				if err != nil {
+					attempt.AddError(err)
+					continue
+				}
+				mapped := mapUser(user)
+				if len(mapped) == 0 {
+					continue
+				}
+
+				fields := make(map[sdk.FieldName]string, len(mapped))
+				for fieldName, value := range mapped {
+					fields[sdk.FieldName(fieldName)] = value
+				}
+				candidate := sdk.ImportCandidate{
+					Fields:     fields,
+					NameHint:   SanitizeNameHint(kubeContext.Name),
+					Confidence: sdk.ConfidenceHigh,
+				}
+				defaultFieldSource(&candidate, sdk.FieldSource{File: userPaths[kubeContext.Context.User], Key: "users." + kubeContext.Context.User})
+				attempt.AddCandidate(candidate)
+			}
+		}
+	}
+}
+
+// decodeKubeconfigUser decodes the base64-encoded certificate data of the user.
+func decodeKubeconfigUser(name string, auth kubeconfigAuth) (KubeconfigUser, error) {
+	user := KubeconfigUser{
+		Name:     name,
+		Token:    auth.Token,
+		Username: auth.Username,
+		Password: auth.Password,
+	}
+	for _, data := range []struct {
+		field   string
+		encoded string
+		decoded *string
+	}{
+		{field: "client-certificate-data", encoded: auth.ClientCertificateData, decoded: &user.ClientCertificateData},
+		{field: "client-key-data", encoded: auth.ClientKeyData, decoded: &user.ClientKeyData},
+	} {
+		if data.encoded == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(data.encoded)
+		if err != nil {
+			return KubeconfigUser{}, fmt.Errorf("%s of kubeconfig user '%s' is not valid base64", data.field, name)
+		}
+		*data.decoded = string(decoded)
+	}
+	return user, nil
+}
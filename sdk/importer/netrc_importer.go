@@ -0,0 +1,179 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// TryNetrc looks for the entry of the specified machine, e.g. "api.heroku.com", in the user's netrc file, which is
+// ~/.netrc, or ~/_netrc on Windows. If the file has no entry for the machine, the default entry gets used instead, if
+// there is one. The login and password of the entry get mapped to the fields of the candidate by mapFields, e.g.:
+//
+//	TryNetrc("api.heroku.com", func(login string, password string) map[string]string {
+//		return map[string]string{"API Key": password}
+//	})
+//
+// Returning no fields from mapFields results in no candidate.
+func TryNetrc(machine string, mapFields func(login string, password string) map[string]string) sdk.Importer {
+	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+		path := "~/.netrc"
+		if in.OS == "windows" {
+			path = "~/_netrc"
+		}
+
+		TryFile(path, func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+			entries, err := parseNetrc(contents.ToString())
+			if err != nil {
+				out.AddError(err)
+				return
+			}
+
+			entry, ok := findNetrcEntry(entries, machine)
+			if !ok {
+				return
+			}
+
+			mapped := mapFields(entry.login, entry.password)
+			if len(mapped) == 0 {
+				return
+			}
+
+			fields := make(map[sdk.FieldName]string, len(mapped))
+			for fieldName, value := range mapped {
+				fields[sdk.FieldName(fieldName)] = value
+			}
+			out.AddCandidate(sdk.ImportCandidate{
+				Fields:   fields,
+				NameHint: SanitizeNameHint(entry.machine),
+			})
+		})(ctx, in, out)
+	}
+}
+
+// netrcEntry is a machine entry in a netrc file. The default entry has no machine name.
+type netrcEntry struct {
+	machine  string
+	login    string
+	password string
+	account  string
+}
+
+// findNetrcEntry returns the entry of the specified machine, falling back to the default entry.
+func findNetrcEntry(entries []netrcEntry, machine string) (netrcEntry, bool) {
+	var fallback *netrcEntry
+	for i, entry := range entries {
+		if entry.machine == machine {
+			return entry, true
+		}
+		if entry.machine == "" && fallback == nil {
+			fallback = &entries[i]
+		}
+	}
+	if fallback == nil {
+		return netrcEntry{}, false
+	}
+	return *fallback, true
+}
+
+// parseNetrc parses the entries of a netrc file.
+func parseNetrc(contents string) ([]netrcEntry, error) {
+	tokens, err := netrcTokens(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []netrcEntry
+	for i := 0; i < len(tokens); i++ {
+		keyword := tokens[i]
+		if keyword == "default" {
+			entries = append(entries, netrcEntry{})
+			continue
+		}
+
+		if i+1 >= len(tokens) {
+			return nil, fmt.Errorf("invalid netrc file: missing value for '%s'", keyword)
+		}
+		i++
+		value := tokens[i]
+
+		if keyword == "machine" {
+			entries = append(entries, netrcEntry{machine: value})
+			continue
+		}
+		if len(entries) == 0 {
+			return nil, fmt.Errorf("invalid netrc file: '%s' outside of a machine entry", keyword)
+		}
+
+		entry := &entries[len(entries)-1]
+		switch keyword {
+		case "login":
+			entry.login = value
+		case "password":
+			entry.password = value
+		case "account":
+			entry.account = value
+		default:
+			return nil, fmt.Errorf("invalid netrc file: unexpected token '%s'", keyword)
+		}
+	}
+	return entries, nil
+}
+
+// netrcTokens splits the contents of a netrc file into tokens, which are separated by whitespace and can be quoted
+// with backslash escapes. Comments, which run from "#" until the end of the line, and macro definitions, which run from
+// "macdef" until the next empty line, get left out.
+func netrcTokens(contents string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(contents); {
+		switch c := contents[i]; {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '#':
+			i = skipLine(contents, i)
+		case c == '"':
+			var token strings.Builder
+			for i++; i < len(contents) && contents[i] != '"'; i++ {
+				if contents[i] == '\\' && i+1 < len(contents) {
+					i++
+				}
+				token.WriteByte(contents[i])
+			}
+			if i >= len(contents) {
+				return nil, fmt.Errorf("invalid netrc file: unterminated quoted value")
+			}
+			i++
+			tokens = append(tokens, token.String())
+		default:
+			start := i
+			for i < len(contents) && !unicode.IsSpace(rune(contents[i])) {
+				i++
+			}
+			token := contents[start:i]
+			if token != "macdef" {
+				tokens = append(tokens, token)
+				continue
+			}
+
+			// Skip the macro name on the current line and the macro body up to the next empty line.
+			i = skipLine(contents, i)
+			if end := strings.Index(contents[i:], "\n\n"); end != -1 {
+				i += end
+			} else {
+				i = len(contents)
+			}
+		}
+	}
+	return tokens, nil
+}
+
+// skipLine returns the index of the line break that ends the line at index i.
+func skipLine(contents string, i int) int {
+	if end := strings.IndexByte(contents[i:], '\n'); end != -1 {
+		return i + end
+	}
+	return len(contents)
+}
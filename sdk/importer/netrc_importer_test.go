@@ -0,0 +1,136 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTryNetrc(t *testing.T) {
+	homeDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".netrc"), []byte(plugintest.LoadFixture(t, "netrc")), 0600))
+
+	mapFields := func(login string, password string) map[string]string {
+		return map[string]string{
+			fieldname.Username.String(): login,
+			fieldname.Password.String(): password,
+		}
+	}
+
+	for _, scenario := range []struct {
+		description string
+		machine     string
+		expected    []sdk.ImportCandidate
+	}{
+		{
+			description: "quoted password",
+			machine:     "api.heroku.com",
+			expected: []sdk.ImportCandidate{{
+				Fields:     map[sdk.FieldName]string{fieldname.Username: "user@example.com", fieldname.Password: "heroku token with spaces"},
+				NameHint:   "api.heroku.com",
+				Confidence: sdk.ConfidenceHigh,
+			}},
+		},
+		{
+			description: "single line with inline comment",
+			machine:     "git.heroku.com",
+			expected: []sdk.ImportCandidate{{
+				Fields:     map[sdk.FieldName]string{fieldname.Username: "user@example.com", fieldname.Password: "abc123"},
+				NameHint:   "git.heroku.com",
+				Confidence: sdk.ConfidenceHigh,
+			}},
+		},
+		{
+			description: "after macro definition with escaped quotes",
+			machine:     "registry.example.com",
+			expected: []sdk.ImportCandidate{{
+				Fields:     map[sdk.FieldName]string{fieldname.Username: `quoted "user"`, fieldname.Password: "s3cr3t"},
+				NameHint:   "registry.example.com",
+				Confidence: sdk.ConfidenceHigh,
+			}},
+		},
+		{
+			description: "default entry",
+			machine:     "unknown.example.com",
+			expected: []sdk.ImportCandidate{{
+				Fields:     map[sdk.FieldName]string{fieldname.Username: "anonymous", fieldname.Password: "anonymous@example.com"},
+				Confidence: sdk.ConfidenceHigh,
+			}},
+		},
+	} {
+		t.Run(scenario.description, func(t *testing.T) {
+			out := sdk.ImportOutput{}
+			TryNetrc(scenario.machine, mapFields)(context.Background(), sdk.ImportInput{HomeDir: homeDir, OS: "linux"}, &out)
+
+			assert.Empty(t, out.Errors())
+			assert.Equal(t, scenario.expected, withoutFieldSources(out.AllCandidates()))
+			assert.Equal(t, sdk.ImportSource{Files: []string{"~/.netrc"}}, out.Attempts[0].Source)
+		})
+	}
+}
+
+func TestTryNetrcWindows(t *testing.T) {
+	homeDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, "_netrc"), []byte("machine api.example.com password token"), 0600))
+
+	out := sdk.ImportOutput{}
+	TryNetrc("api.example.com", func(login string, password string) map[string]string {
+		return map[string]string{fieldname.Token.String(): password}
+	})(context.Background(), sdk.ImportInput{HomeDir: homeDir, OS: "windows"}, &out)
+
+	assert.Equal(t, []sdk.ImportCandidate{{
+		Fields:     map[sdk.FieldName]string{fieldname.Token: "token"},
+		NameHint:   "api.example.com",
+		Confidence: sdk.ConfidenceHigh,
+		FieldSources: map[sdk.FieldName][]sdk.FieldSource{
+			fieldname.Token: {{File: "~/_netrc"}},
+		},
+	}}, out.AllCandidates())
+	assert.Equal(t, sdk.ImportSource{Files: []string{"~/_netrc"}}, out.Attempts[0].Source)
+}
+
+func TestTryNetrcNoMatch(t *testing.T) {
+	homeDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".netrc"), []byte("machine api.example.com password token"), 0600))
+
+	out := sdk.ImportOutput{}
+	TryNetrc("other.example.com", func(login string, password string) map[string]string {
+		return map[string]string{fieldname.Token.String(): password}
+	})(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Empty(t, out.AllCandidates())
+}
+
+func TestTryNetrcMalformed(t *testing.T) {
+	for _, scenario := range []struct {
+		contents string
+		expected string
+	}{
+		{contents: "machine api.example.com password", expected: "invalid netrc file: missing value for 'password'"},
+		{contents: "login user machine api.example.com", expected: "invalid netrc file: 'login' outside of a machine entry"},
+		{contents: "machine api.example.com token abc", expected: "invalid netrc file: unexpected token 'token'"},
+		{contents: `machine api.example.com password "abc`, expected: "invalid netrc file: unterminated quoted value"},
+	} {
+		t.Run(scenario.expected, func(t *testing.T) {
+			homeDir := t.TempDir()
+			require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".netrc"), []byte(scenario.contents), 0600))
+
+			out := sdk.ImportOutput{}
+			TryNetrc("api.example.com", func(login string, password string) map[string]string {
+				return map[string]string{fieldname.Token.String(): password}
+			})(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+			assert.Empty(t, out.AllCandidates())
+			require.Len(t, out.Errors(), 1)
+			assert.Equal(t, scenario.expected, out.Errors()[0].Message)
+		})
+	}
+}
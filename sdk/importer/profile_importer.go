@@ -0,0 +1,91 @@
+package importer
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v3"
+)
+
+// TryAllProfiles tries all profiles in the INI file at the specified path, such as ~/.aws/credentials or
+// ~/.databrickscfg, which contain one section per profile. The result function gets called once for every section
+// that contains keys, including the DEFAULT section for keys above the first section header or in an explicit
+// [DEFAULT] section. Key lookups are case-insensitive, and comments at the end of a value are stripped if they're
+// preceded by whitespace, so values can still contain "#" and ";". The profile name is also set as ProfileName on the
+// input. Candidates without a name hint get the profile name as name hint.
+func TryAllProfiles(path string, result func(ctx context.Context, profileName string, section *ini.Section, in sdk.ImportInput, out *sdk.ImportAttempt)) sdk.Importer {
+	return TryFile(path, func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+		file, err := ini.LoadSources(ini.LoadOptions{
+			InsensitiveKeys:          true,
+			SpaceBeforeInlineComment: true,
+		}, []byte(contents))
+		if err != nil {
+			out.AddError(err)
+			return
+		}
+
+		for _, section := range file.Sections() {
+			if len(section.Keys()) == 0 {
+				continue
+			}
+
+			profileName := section.Name()
+			profileAttempt := &sdk.ImportAttempt{}
+			in.ProfileName = profileName
+			result(ctx, profileName, section, in, profileAttempt)
+			addProfileCandidates(out, profileName, profileAttempt)
+		}
+	})
+}
+
+// TryAllYAMLProfiles tries all profiles in the YAML file at the specified path, such as ~/.config/gh/hosts.yml, which
+// maps profile names, or host names, to their config. The result function gets called once for every top-level key in
+// sorted order, with the value of the key as contents, which can be decoded using FileContents.ToYAML. The profile name
+// is also set as ProfileName on the input. Candidates without a name hint get the profile name as name hint.
+func TryAllYAMLProfiles(path string, result func(ctx context.Context, profileName string, profile FileContents, in sdk.ImportInput, out *sdk.ImportAttempt)) sdk.Importer {
+	return TryFile(path, func(ctx context.Context, contents FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+		var profiles map[string]yaml.Node
+		if err := contents.ToYAML(&profiles); err != nil {
+			out.AddError(err)
+			return
+		}
+
+		profileNames := make([]string, 0, len(profiles))
+		for profileName := range profiles {
+			profileNames = append(profileNames, profileName)
+		}
+		sort.Strings(profileNames)
+
+		for _, profileName := range profileNames {
+			node := profiles[profileName]
+			profile, err := yaml.Marshal(&node)
+			if err != nil {
+				out.AddError(err)
+				continue
+			}
+
+			profileAttempt := &sdk.ImportAttempt{}
+			in.ProfileName = profileName
+			result(ctx, profileName, profile, in, profileAttempt)
+			addProfileCandidates(out, profileName, profileAttempt)
+		}
+	})
+}
+
+// addProfileCandidates adds the candidates and diagnostics of a single profile to the attempt of the file. Candidates
+// without a name hint get the profile name as name hint, unless it's the default profile.
+func addProfileCandidates(out *sdk.ImportAttempt, profileName string, profileAttempt *sdk.ImportAttempt) {
+	out.Diagnostics.Errors = append(out.Diagnostics.Errors, profileAttempt.Diagnostics.Errors...)
+	out.Diagnostics.Warnings = append(out.Diagnostics.Warnings, profileAttempt.Diagnostics.Warnings...)
+	out.Diagnostics.Notes = append(out.Diagnostics.Notes, profileAttempt.Diagnostics.Notes...)
+	for _, candidate := range profileAttempt.Candidates {
+		if candidate.NameHint == "" && !strings.EqualFold(profileName, ini.DefaultSection) {
+			candidate.NameHint = SanitizeNameHint(profileName)
+		}
+		// The candidate was already validated when it was added to the profile attempt.
+		out.Candidates = append(out.Candidates, candidate)
+	}
+}
@@ -0,0 +1,180 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ini.v1"
+)
+
+func TestTryAllProfiles(t *testing.T) {
+	homeDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(homeDir, ".tool"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".tool", "credentials"), []byte(plugintest.LoadFixture(t, "multi-profile-credentials.ini")), 0600))
+
+	var profileNames []string
+	importer := TryAllProfiles("~/.tool/credentials", func(ctx context.Context, profileName string, section *ini.Section, in sdk.ImportInput, out *sdk.ImportAttempt) {
+		profileNames = append(profileNames, profileName)
+
+		if profileName == ini.DefaultSection {
+			out.AddCandidate(sdk.ImportCandidate{
+				Fields: map[sdk.FieldName]string{fieldname.Token: section.Key("token").String()},
+			})
+			return
+		}
+
+		accessKeyID := section.Key("aws_access_key_id").String()
+		secretAccessKey := section.Key("aws_secret_access_key").String()
+		if accessKeyID == "" || secretAccessKey == "" {
+			return
+		}
+
+		candidate := sdk.ImportCandidate{
+			Fields: map[sdk.FieldName]string{
+				fieldname.AccessKeyID:     accessKeyID,
+				fieldname.SecretAccessKey: secretAccessKey,
+			},
+		}
+		if profileName == "Staging" {
+			candidate.NameHint = "stage"
+		}
+		out.AddCandidate(candidate)
+	})
+
+	out := sdk.ImportOutput{}
+	importer(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Equal(t, []string{ini.DefaultSection, "default", "Staging", "profile-with-a-very-long-name-for-testing", "incomplete"}, profileNames)
+	assert.Equal(t, []sdk.ImportCandidate{
+		{
+			Fields:     map[sdk.FieldName]string{fieldname.Token: "top-level-token"},
+			Confidence: sdk.ConfidenceHigh,
+		},
+		{
+			Fields: map[sdk.FieldName]string{
+				fieldname.AccessKeyID:     "AKIADEFAULT00EXAMPLE",
+				fieldname.SecretAccessKey: "default/secret#key",
+			},
+			Confidence: sdk.ConfidenceHigh,
+		},
+		{
+			Fields: map[sdk.FieldName]string{
+				fieldname.AccessKeyID:     "AKIASTAGING0EXAMPLE",
+				fieldname.SecretAccessKey: "staging;secret",
+			},
+			NameHint:   "stage",
+			Confidence: sdk.ConfidenceHigh,
+		},
+		{
+			Fields: map[sdk.FieldName]string{
+				fieldname.AccessKeyID:     "AKIALONGNAME0EXAMPLE",
+				fieldname.SecretAccessKey: "long-name-secret",
+			},
+			NameHint:   "profile-with-a-very-lon…",
+			Confidence: sdk.ConfidenceHigh,
+		},
+	}, withoutFieldSources(out.AllCandidates()))
+}
+
+func TestTryAllProfilesInvalidFile(t *testing.T) {
+	homeDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, "credentials"), []byte("[unterminated\n"), 0600))
+
+	importer := TryAllProfiles("~/credentials", func(ctx context.Context, profileName string, section *ini.Section, in sdk.ImportInput, out *sdk.ImportAttempt) {
+		t.Fatal("should not be called for an invalid file")
+	})
+
+	out := sdk.ImportOutput{}
+	importer(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+	assert.Len(t, out.Errors(), 1)
+}
+
+func TestTryAllProfilesSetsProfileName(t *testing.T) {
+	homeDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(homeDir, ".tool"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".tool", "credentials"), []byte(plugintest.LoadFixture(t, "multi-profile-credentials.ini")), 0600))
+
+	var profileNames []string
+	importer := TryAllProfiles("~/.tool/credentials", func(ctx context.Context, profileName string, section *ini.Section, in sdk.ImportInput, out *sdk.ImportAttempt) {
+		profileNames = append(profileNames, in.ProfileName)
+	})
+
+	importer(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &sdk.ImportOutput{})
+
+	assert.Equal(t, []string{ini.DefaultSection, "default", "Staging", "profile-with-a-very-long-name-for-testing", "incomplete"}, profileNames)
+}
+
+func TestTryAllYAMLProfiles(t *testing.T) {
+	homeDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(homeDir, ".config", "gh"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".config", "gh", "hosts.yml"), []byte(plugintest.LoadFixture(t, "gh-hosts.yml")), 0600))
+
+	type hostConfig struct {
+		User       string `yaml:"user"`
+		OAuthToken string `yaml:"oauth_token"`
+	}
+
+	var profileNames []string
+	importer := TryAllYAMLProfiles("~/.config/gh/hosts.yml", func(ctx context.Context, profileName string, profile FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+		profileNames = append(profileNames, in.ProfileName)
+
+		var config hostConfig
+		if err := profile.ToYAML(&config); err != nil {
+			out.AddError(err)
+			return
+		}
+		if config.OAuthToken == "" {
+			return
+		}
+
+		candidate := sdk.ImportCandidate{
+			Fields: map[sdk.FieldName]string{
+				fieldname.Token: config.OAuthToken,
+				fieldname.Host:  profileName,
+			},
+		}
+		// Explicit name hints win over the profile name.
+		if profileName != "github.com" {
+			candidate.NameHint = config.User
+		}
+		out.AddCandidate(candidate)
+	})
+
+	out := sdk.ImportOutput{}
+	importer(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Equal(t, []string{"ghe.internal.example.com", "github.com", "github.example.com"}, profileNames)
+	assert.Equal(t, []sdk.ImportCandidate{
+		{
+			Fields:     map[sdk.FieldName]string{fieldname.Token: "gho_github_token", fieldname.Host: "github.com"},
+			NameHint:   "github.com",
+			Confidence: sdk.ConfidenceHigh,
+		},
+		{
+			Fields:     map[sdk.FieldName]string{fieldname.Token: "gho_enterprise_token", fieldname.Host: "github.example.com"},
+			NameHint:   "wendy-enterprise",
+			Confidence: sdk.ConfidenceHigh,
+		},
+	}, withoutFieldSources(out.AllCandidates()))
+}
+
+func TestTryAllYAMLProfilesInvalid(t *testing.T) {
+	homeDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, "hosts.yml"), []byte("- not\n- a map"), 0600))
+
+	out := sdk.ImportOutput{}
+	TryAllYAMLProfiles("~/hosts.yml", func(ctx context.Context, profileName string, profile FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+		t.Fatal("result should not be called")
+	})(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+	assert.Len(t, out.Errors(), 1)
+}
@@ -0,0 +1,95 @@
+package importer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema"
+)
+
+// ValidateAgainstSchema checks the candidates found by the importers against the specified fields of the credential
+// type, to flag values that were likely picked up by mistake, such as an app ID instead of a secret or a truncated
+// token. Values of fields with a Composition get checked using ValueComposition.Validate, values of fields with
+// AllowedValues get checked against that list, and values that look like a JWT get checked for their structure. Each
+// mismatch is added as a warning to the candidate, which is still imported. Candidates for another credential type,
+// added using sdk.ImportAttempt.AddCandidateFor, are not checked.
+func ValidateAgainstSchema(fields []schema.CredentialField, importers ...sdk.Importer) sdk.Importer {
+	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+		start := len(out.Attempts)
+		for _, imp := range importers {
+			imp(ctx, in, out)
+		}
+
+		for _, attempt := range out.Attempts[start:] {
+			for i := range attempt.Candidates {
+				candidate := &attempt.Candidates[i]
+				if candidate.CredentialType != "" {
+					continue
+				}
+				for _, field := range fields {
+					value, ok := candidate.Fields[field.Name]
+					if !ok {
+						continue
+					}
+					for _, problem := range checkFieldValue(field, value) {
+						candidate.Warnings = append(candidate.Warnings, sdk.Warning{
+							Message: fmt.Sprintf("value of field '%s' %s", field.Name, problem),
+						})
+					}
+				}
+			}
+		}
+	}
+}
+
+// checkFieldValue returns a description of each way in which the value doesn't match the composition or the allowed
+// values of the field and, for values that look like a JWT, how it's malformed.
+func checkFieldValue(field schema.CredentialField, value string) []string {
+	var problems []string
+	if field.Composition != nil {
+		for _, mismatch := range field.Composition.Validate(value) {
+			problems = append(problems, mismatch.Message)
+		}
+	}
+	if err := field.CheckAllowedValue(value); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if looksLikeJWT(value) {
+		if err := checkJWT(value); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	return problems
+}
+
+// looksLikeJWT returns whether the value starts like a JWT does: with a base64url-encoded JSON object.
+func looksLikeJWT(value string) bool {
+	return strings.HasPrefix(value, "eyJ")
+}
+
+// checkJWT returns an error if the value is not made up of a header, a payload and a signature, or if the header or
+// payload is not a base64url-encoded JSON object.
+func checkJWT(value string) error {
+	parts := strings.Split(value, ".")
+	if len(parts) != 3 {
+		return fmt.Errorf("looks like a JWT, but has %d parts instead of 3", len(parts))
+	}
+	for i, name := range []string{"header", "payload"} {
+		decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[i], "="))
+		if err != nil {
+			return fmt.Errorf("looks like a JWT, but its %s is not base64url-encoded", name)
+		}
+		var object map[string]any
+		if err := json.Unmarshal(decoded, &object); err != nil {
+			return fmt.Errorf("looks like a JWT, but its %s is not a JSON object", name)
+		}
+	}
+	if parts[2] == "" {
+		return fmt.Errorf("looks like a JWT, but has no signature")
+	}
+	return nil
+}
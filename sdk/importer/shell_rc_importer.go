@@ -0,0 +1,201 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// shellRCFiles are the startup files of bash, zsh and POSIX shells that users commonly export variables in.
+var shellRCFiles = []string{"~/.bashrc", "~/.bash_profile", "~/.profile", "~/.zshrc", "~/.zprofile", "~/.zshenv"}
+
+// shellAssignmentStart matches the start of a variable assignment, optionally prefixed with "export".
+var shellAssignmentStart = regexp.MustCompile(`^(?:export[ \t]+)?([A-Za-z_][A-Za-z0-9_]*)=`)
+
+// shellAssignment is a variable assignment in a shell startup file.
+type shellAssignment struct {
+	value string
+	line  int
+
+	// dynamic is set if the value references another variable or uses command substitution, which would require
+	// running the shell to know the value.
+	dynamic bool
+}
+
+// TryShellRC looks for assignments of the specified variables in the startup files of the user's shells, such as
+// ~/.bashrc and ~/.zshrc, and adds an import candidate for each file that assigns all of them, mapped to their fields.
+// This finds credentials that are exported in those files, which are not in the environment when the import is not
+// run from an interactive shell. The files are parsed, never executed: both "export NAME=value" and "NAME=value" lines
+// are supported, with single-quoted, double-quoted and unquoted values, while commented-out lines are ignored.
+// Values that reference other variables or use command substitution are skipped with a note, as are assignments that
+// are followed by a command. If a variable is assigned more than once, the last assignment wins, like it does when the
+// shell runs the file. The line of every assignment is recorded as a note on the attempt.
+func TryShellRC(varNames map[string]sdk.FieldName) sdk.Importer {
+	names := make([]string, 0, len(varNames))
+	for varName := range varNames {
+		names = append(names, varName)
+	}
+	sort.Strings(names)
+
+	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+		var found foundCandidates
+		for _, path := range shellRCFiles {
+			abspath := resolvePath(path, in)
+			attempt := out.NewAttempt(SourceFile(displayPath(abspath, in)))
+			contents, _, ok := readFile(abspath, in, attempt, defaultMaxFileSize)
+			if !ok {
+				continue
+			}
+
+			assignments := parseShellAssignments(string(contents))
+			candidate := sdk.ImportCandidate{
+				Fields:     make(map[sdk.FieldName]string, len(varNames)),
+				Confidence: sdk.ConfidenceMedium,
+			}
+			for _, varName := range names {
+				assignment, ok := assignments[varName]
+				if !ok {
+					continue
+				}
+				if assignment.dynamic {
+					attempt.AddNote(fmt.Sprintf("Skipped %s on line %d: its value can only be known by running the shell", varName, assignment.line))
+					continue
+				}
+				if assignment.value == "" {
+					continue
+				}
+				attempt.AddNote(fmt.Sprintf("Found %s on line %d", varName, assignment.line))
+				candidate.Fields[varNames[varName]] = assignment.value
+				candidate.AddFieldSource(varNames[varName], sdk.FieldSource{File: displayPath(abspath, in), Key: varName})
+			}
+			if len(candidate.Fields) != len(varNames) {
+				continue
+			}
+			found.add(attempt, candidate)
+		}
+	}
+}
+
+// parseShellAssignments returns the last assignment of every variable in the shell script. Lines that are not a
+// variable assignment, or that run a command after the assignment, are ignored.
+func parseShellAssignments(contents string) map[string]shellAssignment {
+	assignments := make(map[string]shellAssignment)
+	for i, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		match := shellAssignmentStart.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		value, rest, dynamic, ok := parseShellValue(line[len(match[0]):])
+		if !ok {
+			continue
+		}
+		rest = strings.TrimSpace(rest)
+		if rest != "" && !strings.HasPrefix(rest, "#") && rest != ";" {
+			// The assignment only applies to the command that follows it, e.g. "DEBUG=1 make".
+			continue
+		}
+		assignments[match[1]] = shellAssignment{value: value, line: i + 1, dynamic: dynamic}
+	}
+	return assignments
+}
+
+// parseShellValue parses the value of an assignment up to the first unquoted whitespace or semicolon, and returns the
+// rest of the line. Quoted and unquoted parts can be concatenated, like in "abc"'def'. It's not ok if a quote is not
+// closed on the same line.
+func parseShellValue(s string) (value string, rest string, dynamic bool, ok bool) {
+	var b strings.Builder
+	i := 0
+	for i < len(s) {
+		switch c := s[i]; c {
+		case ' ', '\t', ';', '\r':
+			return b.String(), s[i:], dynamic, true
+		case '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end == -1 {
+				return "", "", false, false
+			}
+			b.WriteString(s[i+1 : i+1+end])
+			i += end + 2
+		case '"':
+			i++
+			for {
+				if i >= len(s) {
+					return "", "", false, false
+				}
+				c := s[i]
+				if c == '"' {
+					i++
+					break
+				}
+				if c == '\\' && i+1 < len(s) && strings.IndexByte("$`\"\\", s[i+1]) != -1 {
+					b.WriteByte(s[i+1])
+					i += 2
+					continue
+				}
+				if c == '$' || c == '`' {
+					dynamic = true
+				}
+				b.WriteByte(c)
+				i++
+			}
+		case '\\':
+			if i+1 < len(s) {
+				b.WriteByte(s[i+1])
+			}
+			i += 2
+		case '`':
+			// Command substitution can contain whitespace, so skip to its end.
+			end := strings.IndexByte(s[i+1:], '`')
+			if end == -1 {
+				return "", "", false, false
+			}
+			b.WriteString(s[i : i+end+2])
+			dynamic = true
+			i += end + 2
+		case '$':
+			dynamic = true
+			end := i + 1
+			if i+1 < len(s) && (s[i+1] == '(' || s[i+1] == '{') {
+				// Command substitution or parameter expansion, which can contain whitespace, so skip to its end.
+				end = closingBracket(s, i+1)
+				if end == -1 {
+					return "", "", false, false
+				}
+				end++
+			}
+			b.WriteString(s[i:end])
+			i = end
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String(), "", dynamic, true
+}
+
+// closingBracket returns the index of the bracket that closes the one at the start index, or -1 if it's not closed.
+func closingBracket(s string, start int) int {
+	openBracket, closeBracket := s[start], byte(')')
+	if openBracket == '{' {
+		closeBracket = '}'
+	}
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case openBracket:
+			depth++
+		case closeBracket:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,88 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTryShellRC(t *testing.T) {
+	homeDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".bashrc"), []byte(plugintest.LoadFixture(t, "bashrc")), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".zshrc"), []byte(plugintest.LoadFixture(t, "zshrc")), 0600))
+
+	for _, scenario := range []struct {
+		description string
+		varNames    map[string]sdk.FieldName
+		expected    []sdk.ImportCandidate
+		notes       []sdk.Note
+	}{
+		{
+			description: "last assignment with escaped quotes and inline comment",
+			varNames:    map[string]sdk.FieldName{"STRIPE_API_KEY": fieldname.APIKey},
+			expected: []sdk.ImportCandidate{
+				{Fields: map[sdk.FieldName]string{fieldname.APIKey: `sk_test_"quoted"_abc123`}, Confidence: sdk.ConfidenceMedium},
+				{Fields: map[sdk.FieldName]string{fieldname.APIKey: "sk_test_zsh_concatenated"}, Confidence: sdk.ConfidenceMedium},
+			},
+			notes: []sdk.Note{{Message: "Found STRIPE_API_KEY on line 9"}},
+		},
+		{
+			description: "multiple variables without export",
+			varNames: map[string]sdk.FieldName{
+				"STRIPE_ACCOUNT":     fieldname.AccountID,
+				"STRIPE_DEVICE_NAME": fieldname.Username,
+			},
+			expected: []sdk.ImportCandidate{
+				{
+					Fields:     map[sdk.FieldName]string{fieldname.AccountID: "acct_$literal", fieldname.Username: "work laptop"},
+					Confidence: sdk.ConfidenceMedium,
+				},
+			},
+			notes: []sdk.Note{
+				{Message: "Found STRIPE_ACCOUNT on line 10"},
+				{Message: "Found STRIPE_DEVICE_NAME on line 11"},
+			},
+		},
+		{
+			description: "variable reference",
+			varNames:    map[string]sdk.FieldName{"STRIPE_WEBHOOK_SECRET": fieldname.Secret},
+			notes:       []sdk.Note{{Message: "Skipped STRIPE_WEBHOOK_SECRET on line 12: its value can only be known by running the shell"}},
+		},
+		{
+			description: "command substitution",
+			varNames: map[string]sdk.FieldName{
+				"STRIPE_CLI_TOKEN":    fieldname.Token,
+				"STRIPE_PROJECT_NAME": fieldname.Project,
+			},
+			notes: []sdk.Note{
+				{Message: "Skipped STRIPE_CLI_TOKEN on line 13: its value can only be known by running the shell"},
+				{Message: "Skipped STRIPE_PROJECT_NAME on line 14: its value can only be known by running the shell"},
+			},
+		},
+		{
+			description: "assignment for a single command and unterminated quote",
+			varNames: map[string]sdk.FieldName{
+				"STRIPE_LOG":          fieldname.Mode,
+				"STRIPE_UNTERMINATED": fieldname.Secret,
+			},
+		},
+	} {
+		t.Run(scenario.description, func(t *testing.T) {
+			out := sdk.ImportOutput{}
+			TryShellRC(scenario.varNames)(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+			assert.Empty(t, out.Errors())
+			assert.Equal(t, scenario.expected, withoutFieldSources(out.AllCandidates()))
+			require.Len(t, out.Attempts, 6)
+			assert.Equal(t, sdk.ImportSource{Files: []string{"~/.bashrc"}}, out.Attempts[0].Source)
+			assert.Equal(t, scenario.notes, out.Attempts[0].Diagnostics.Notes)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+// Package sqliteimporter provides an importer for credentials stored in SQLite databases. It's a separate package
+// from importer, so that only plugins that use it link the SQLite driver.
+package sqliteimporter
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/importer"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// TrySQLite runs the query against the SQLite database at the specified path, e.g.
+// "~/.config/tool/storage.db", and maps each row of the result to the fields of a candidate using mapRow. The columns
+// of a row are passed to mapRow by name, with TEXT values as string, BLOB values as []byte, INTEGER values as int64,
+// REAL values as float64 and NULL values as nil. Returning no fields from mapRow results in no candidate for that row.
+//
+// The database and its write-ahead log, if any, are copied to a temporary directory first, so the import doesn't have
+// to wait for or interfere with the tool that owns the database. The query is run in read-only mode. Databases that
+// are corrupt or locked are reported as a warning, like files that can't be read.
+func TrySQLite(path string, query string, mapRow func(cols map[string]any) map[string]string) sdk.Importer {
+	return importer.TryFile(path, func(ctx context.Context, contents importer.FileContents, in sdk.ImportInput, out *sdk.ImportAttempt) {
+		rows, err := querySQLite(ctx, contents, in.FilePath, query)
+		if err != nil {
+			var sqliteErr *sqlite.Error
+			if errors.As(err, &sqliteErr) && isUnreadableSQLiteError(sqliteErr) {
+				out.AddWarning(fmt.Sprintf("cannot read %s: %s", importer.DisplayPath(in.FilePath, in), sqliteErr))
+				return
+			}
+			out.AddError(err)
+			return
+		}
+
+		for _, row := range rows {
+			mapped := mapRow(row)
+			if len(mapped) == 0 {
+				continue
+			}
+
+			fields := make(map[sdk.FieldName]string, len(mapped))
+			for fieldName, value := range mapped {
+				fields[sdk.FieldName(fieldName)] = value
+			}
+			out.AddCandidate(sdk.ImportCandidate{
+				Fields: fields,
+			})
+		}
+	})
+}
+
+// querySQLite copies the database and its write-ahead log to a temporary directory and runs the query against the
+// copy in read-only mode. It returns the rows of the result as a map from column name to value.
+func querySQLite(ctx context.Context, contents []byte, path string, query string) ([]map[string]any, error) {
+	tempDir, err := os.MkdirTemp("", "sqlite-import-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	dbPath := filepath.Join(tempDir, "import.db")
+	if err := os.WriteFile(dbPath, contents, 0600); err != nil {
+		return nil, err
+	}
+	// Changes that were not checkpointed yet are only present in the write-ahead log.
+	wal, err := os.ReadFile(path + "-wal")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	} else if err == nil {
+		if err := os.WriteFile(dbPath+"-wal", wal, 0600); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?_pragma=query_only(1)")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []map[string]any
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]any, len(columns))
+		for i, column := range columns {
+			row[column] = values[i]
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// isUnreadableSQLiteError returns whether the error means that the database itself can't be read, as opposed to an
+// error in the query.
+func isUnreadableSQLiteError(err *sqlite.Error) bool {
+	switch err.Code() & 0xff {
+	case sqlite3.SQLITE_CORRUPT, sqlite3.SQLITE_NOTADB, sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+		return true
+	}
+	return false
+}
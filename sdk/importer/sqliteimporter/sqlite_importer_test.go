@@ -0,0 +1,156 @@
+package sqliteimporter
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createSQLiteFixture creates a database with a tokens table in the home directory and returns a connection to it.
+func createSQLiteFixture(t *testing.T, homeDir string, journalMode string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(homeDir, "tokens.db")+"?_pragma=journal_mode("+journalMode+")")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`
+		CREATE TABLE tokens (account TEXT, token TEXT, expires INTEGER);
+		INSERT INTO tokens VALUES ('work', 'token-work', 1700000000), ('personal', 'token-personal', NULL), ('revoked', NULL, NULL);
+	`)
+	require.NoError(t, err)
+	return db
+}
+
+func mapTokenRow(cols map[string]any) map[string]string {
+	token, ok := cols["token"].(string)
+	if !ok {
+		return nil
+	}
+	return map[string]string{
+		fieldname.Username.String(): cols["account"].(string),
+		fieldname.Token.String():    token,
+	}
+}
+
+var expectedSQLiteCandidates = []sdk.ImportCandidate{
+	{
+		Fields:     map[sdk.FieldName]string{fieldname.Username: "work", fieldname.Token: "token-work"},
+		Confidence: sdk.ConfidenceHigh,
+	},
+	{
+		Fields:     map[sdk.FieldName]string{fieldname.Username: "personal", fieldname.Token: "token-personal"},
+		Confidence: sdk.ConfidenceHigh,
+	},
+}
+
+func TestTrySQLite(t *testing.T) {
+	homeDir := t.TempDir()
+	db := createSQLiteFixture(t, homeDir, "delete")
+	require.NoError(t, db.Close())
+
+	var columns map[string]any
+	out := sdk.ImportOutput{}
+	TrySQLite("~/tokens.db", "SELECT account, token, expires FROM tokens ORDER BY rowid", func(cols map[string]any) map[string]string {
+		if cols["account"] == "work" {
+			columns = cols
+		}
+		return mapTokenRow(cols)
+	})(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Equal(t, expectedSQLiteCandidates, withoutFieldSources(out.AllCandidates()))
+	assert.Equal(t, map[string]any{"account": "work", "token": "token-work", "expires": int64(1700000000)}, columns)
+	assert.Equal(t, sdk.ImportSource{Files: []string{"~/tokens.db"}}, out.Attempts[0].Source)
+}
+
+func TestTrySQLiteLockedDatabase(t *testing.T) {
+	homeDir := t.TempDir()
+	db := createSQLiteFixture(t, homeDir, "delete")
+
+	// The tool that owns the database holds an exclusive lock, which blocks readers of the original file.
+	conn, err := db.Conn(context.Background())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.ExecContext(context.Background(), "BEGIN EXCLUSIVE")
+	require.NoError(t, err)
+	defer conn.ExecContext(context.Background(), "ROLLBACK")
+	_, err = conn.ExecContext(context.Background(), "INSERT INTO tokens VALUES ('uncommitted', 'token-uncommitted', NULL)")
+	require.NoError(t, err)
+
+	out := sdk.ImportOutput{}
+	TrySQLite("~/tokens.db", "SELECT account, token FROM tokens ORDER BY rowid", mapTokenRow)(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Equal(t, expectedSQLiteCandidates, withoutFieldSources(out.AllCandidates()))
+}
+
+func TestTrySQLiteWriteAheadLog(t *testing.T) {
+	homeDir := t.TempDir()
+	db := createSQLiteFixture(t, homeDir, "wal")
+
+	// Keep the changes in the write-ahead log, as if the tool is still running.
+	_, err := db.Exec("PRAGMA wal_autocheckpoint = 0")
+	require.NoError(t, err)
+	_, err = db.Exec("INSERT INTO tokens VALUES ('ci', 'token-ci', NULL)")
+	require.NoError(t, err)
+
+	out := sdk.ImportOutput{}
+	TrySQLite("~/tokens.db", "SELECT account, token FROM tokens WHERE account = 'ci'", mapTokenRow)(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Equal(t, []sdk.ImportCandidate{{
+		Fields:     map[sdk.FieldName]string{fieldname.Username: "ci", fieldname.Token: "token-ci"},
+		Confidence: sdk.ConfidenceHigh,
+	}}, withoutFieldSources(out.AllCandidates()))
+}
+
+func TestTrySQLiteCorruptDatabase(t *testing.T) {
+	homeDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, "tokens.db"), []byte("this is not a database, but it is long enough to look like one to a quick check"), 0600))
+
+	out := sdk.ImportOutput{}
+	TrySQLite("~/tokens.db", "SELECT account, token FROM tokens", mapTokenRow)(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Empty(t, out.AllCandidates())
+	require.Len(t, out.Attempts[0].Diagnostics.Warnings, 1)
+	assert.Contains(t, out.Attempts[0].Diagnostics.Warnings[0].Message, "cannot read ~/tokens.db: file is not a database")
+}
+
+func TestTrySQLiteInvalidQuery(t *testing.T) {
+	homeDir := t.TempDir()
+	db := createSQLiteFixture(t, homeDir, "delete")
+	require.NoError(t, db.Close())
+
+	for _, scenario := range []struct {
+		description string
+		query       string
+	}{
+		{description: "unknown table", query: "SELECT account, token FROM credentials"},
+		{description: "write statement", query: "DELETE FROM tokens"},
+	} {
+		t.Run(scenario.description, func(t *testing.T) {
+			out := sdk.ImportOutput{}
+			TrySQLite("~/tokens.db", scenario.query, mapTokenRow)(context.Background(), sdk.ImportInput{HomeDir: homeDir}, &out)
+
+			assert.Len(t, out.Errors(), 1)
+			assert.Empty(t, out.AllCandidates())
+		})
+	}
+}
+
+func withoutFieldSources(candidates []sdk.ImportCandidate) []sdk.ImportCandidate {
+	for i := range candidates {
+		candidates[i].FieldSources = nil
+	}
+	return candidates
+}
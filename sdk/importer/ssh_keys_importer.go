@@ -0,0 +1,95 @@
+package importer
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"golang.org/x/crypto/ssh"
+)
+
+// maxSSHKeySize is the size above which a file in ~/.ssh is not considered a private key. Even a 16384-bit RSA key
+// is smaller than this.
+const maxSSHKeySize = 16 * 1024
+
+// TrySSHKeys looks for private keys in ~/.ssh/id_*, skipping the public keys next to them. Every key becomes a
+// candidate with the contents of the key as private key, with the file name as name hint. The candidates have medium
+// confidence, because the user may use different keys for different hosts. Whether the key is protected by a
+// passphrase is detected without decrypting it, and set as "true" or "false" in the encrypted field. Files that
+// are too large or that are not a private key in OpenSSH or PEM format are skipped with a warning.
+func TrySSHKeys() sdk.Importer {
+	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+		matches, err := filepath.Glob(resolvePath("~/.ssh/id_*", in))
+		if err != nil {
+			out.NewAttempt(SourceFile("~/.ssh/id_*")).AddError(err)
+			return
+		}
+
+		for _, abspath := range matches {
+			if strings.HasSuffix(abspath, ".pub") {
+				continue
+			}
+
+			attempt := out.NewAttempt(SourceFile(displayPath(abspath, in)))
+			contents, _, ok := readFile(abspath, in, attempt, defaultMaxFileSize)
+			if !ok {
+				continue
+			}
+
+			display := displayPath(abspath, in)
+			if len(contents) > maxSSHKeySize {
+				attempt.AddWarning(fmt.Sprintf("cannot import %s: it's larger than %d KiB, which is too large for a private key", display, maxSSHKeySize/1024))
+				continue
+			}
+			encrypted, err := isEncryptedSSHKey(contents)
+			if err != nil {
+				attempt.AddWarning(fmt.Sprintf("cannot import %s: %s", display, err))
+				continue
+			}
+
+			candidate := sdk.ImportCandidate{
+				Fields: map[sdk.FieldName]string{
+					fieldname.PrivateKey: string(contents),
+					fieldname.Encrypted:  strconv.FormatBool(encrypted),
+				},
+				NameHint:   SanitizeNameHint(filepath.Base(abspath)),
+				Confidence: sdk.ConfidenceMedium,
+			}
+			defaultFieldSource(&candidate, sdk.FieldSource{File: display})
+			attempt.AddCandidate(candidate)
+		}
+	}
+}
+
+// isEncryptedSSHKey detects the format of the private key and returns whether it's protected by a passphrase. An
+// error is returned if the contents are not a private key in a format that SSH supports.
+func isEncryptedSSHKey(contents []byte) (bool, error) {
+	block, _ := pem.Decode(contents)
+	if block == nil {
+		return false, errors.New("not a private key in OpenSSH or PEM format")
+	}
+
+	switch block.Type {
+	case "OPENSSH PRIVATE KEY", "RSA PRIVATE KEY", "EC PRIVATE KEY", "DSA PRIVATE KEY", "PRIVATE KEY":
+	case "ENCRYPTED PRIVATE KEY":
+		// Encrypted PKCS #8 keys can't be parsed without the passphrase, but the block type tells it all.
+		return true, nil
+	default:
+		return false, fmt.Errorf("unsupported key format '%s'", block.Type)
+	}
+
+	_, err := ssh.ParseRawPrivateKey(contents)
+	var passphraseMissing *ssh.PassphraseMissingError
+	if errors.As(err, &passphraseMissing) {
+		return true, nil
+	} else if err != nil {
+		return false, fmt.Errorf("invalid private key: %w", err)
+	}
+	return false, nil
+}
@@ -0,0 +1,110 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/danieljoos/wincred"
+)
+
+// windowsCredential is a generic credential in the Windows Credential Manager.
+type windowsCredential struct {
+	targetName string
+	userName   string
+	blob       []byte
+}
+
+// windowsCredentialReader looks up generic credentials in the Windows Credential Manager. It can be replaced in tests,
+// to exercise the importer on any platform.
+type windowsCredentialReader interface {
+	// findGenericCredential returns the credential with the specified target name, or nil if there is none.
+	findGenericCredential(targetName string) (*windowsCredential, error)
+}
+
+// TryWindowsCredential looks up the generic credential with the specified target name, e.g. "git:https://github.com",
+// in the Windows Credential Manager. The user name and secret of the credential get mapped to a candidate by mapFields,
+// with the target name as name hint. Returning no fields from mapFields results in no candidate. The secret is decoded
+// from UTF-16 if it's stored that way, like git-credential-manager and other Windows-native tools do, and is used as-is
+// otherwise.
+//
+// TryWindowsCredential is silently skipped on platforms other than Windows.
+func TryWindowsCredential(targetName string, mapFields func(userName string, secret string) map[sdk.FieldName]string) sdk.Importer {
+	return tryWindowsCredential(credentialManager{}, targetName, mapFields)
+}
+
+func tryWindowsCredential(credentials windowsCredentialReader, targetName string, mapFields func(userName string, secret string) map[sdk.FieldName]string) sdk.Importer {
+	return func(ctx context.Context, in sdk.ImportInput, out *sdk.ImportOutput) {
+		if in.OS != "windows" {
+			return
+		}
+
+		attempt := out.NewAttempt(SourceOther("Windows Credential Manager", targetName))
+		credential, err := credentials.findGenericCredential(targetName)
+		if err != nil {
+			attempt.AddError(err)
+			return
+		}
+		if credential == nil {
+			return
+		}
+
+		fields := mapFields(credential.userName, decodeCredentialBlob(credential.blob))
+		if len(fields) == 0 {
+			return
+		}
+		candidate := sdk.ImportCandidate{
+			Fields:   fields,
+			NameHint: SanitizeNameHint(credential.targetName),
+		}
+		defaultFieldSource(&candidate, sdk.FieldSource{Other: "Windows Credential Manager: " + credential.targetName})
+		attempt.AddCandidate(candidate)
+	}
+}
+
+// credentialManager reads credentials using the Windows Credential Management API.
+type credentialManager struct{}
+
+func (credentialManager) findGenericCredential(targetName string) (*windowsCredential, error) {
+	credential, err := wincred.GetGenericCredential(targetName)
+	if errors.Is(err, wincred.ErrElementNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading Windows credential %s: %s", targetName, err)
+	}
+
+	return &windowsCredential{
+		targetName: credential.TargetName,
+		userName:   credential.UserName,
+		blob:       credential.CredentialBlob,
+	}, nil
+}
+
+// decodeCredentialBlob returns the secret in the credential blob as a string. Blobs that are valid UTF-8 without NUL
+// characters are used as-is. Other blobs of even length are decoded as UTF-16LE, which is how the Credential Manager
+// UI, git-credential-manager and the wincred API of other tools store secrets.
+func decodeCredentialBlob(blob []byte) string {
+	isUTF16 := len(blob)%2 == 0 && (!utf8.Valid(blob) || bytes.IndexByte(blob, 0) != -1)
+	if !isUTF16 {
+		return string(blob)
+	}
+
+	encoded := make([]uint16, 0, len(blob)/2)
+	for i := 0; i < len(blob); i += 2 {
+		encoded = append(encoded, binary.LittleEndian.Uint16(blob[i:]))
+	}
+
+	// Leave out the byte order mark and any trailing NUL terminator.
+	if len(encoded) > 0 && encoded[0] == 0xfeff {
+		encoded = encoded[1:]
+	}
+	for len(encoded) > 0 && encoded[len(encoded)-1] == 0 {
+		encoded = encoded[:len(encoded)-1]
+	}
+	return string(utf16.Decode(encoded))
+}
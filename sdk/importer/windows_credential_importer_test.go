@@ -0,0 +1,103 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeCredentialManager struct {
+	credential *windowsCredential
+	err        error
+}
+
+func (m fakeCredentialManager) findGenericCredential(targetName string) (*windowsCredential, error) {
+	return m.credential, m.err
+}
+
+func mapWindowsCredential(userName string, secret string) map[sdk.FieldName]string {
+	return map[sdk.FieldName]string{
+		fieldname.Username: userName,
+		fieldname.Token:    secret,
+	}
+}
+
+func utf16LE(s string) []byte {
+	var b []byte
+	for _, c := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(c), byte(c>>8))
+	}
+	return b
+}
+
+func TestTryWindowsCredential(t *testing.T) {
+	credentials := fakeCredentialManager{credential: &windowsCredential{
+		targetName: "git:https://github.com",
+		userName:   "octocat",
+		blob:       utf16LE("gho_token"),
+	}}
+
+	out := sdk.ImportOutput{}
+	tryWindowsCredential(credentials, "git:https://github.com", mapWindowsCredential)(context.Background(), sdk.ImportInput{OS: "windows"}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Equal(t, []sdk.ImportCandidate{{
+		Fields:   map[sdk.FieldName]string{fieldname.Username: "octocat", fieldname.Token: "gho_token"},
+		NameHint: "git:https://github.com",
+	}}, withoutFieldSources(out.AllCandidates()))
+	assert.Equal(t, SourceOther("Windows Credential Manager", "git:https://github.com"), out.Attempts[0].Source)
+}
+
+func TestTryWindowsCredentialNotFound(t *testing.T) {
+	out := sdk.ImportOutput{}
+	tryWindowsCredential(fakeCredentialManager{}, "example", mapWindowsCredential)(context.Background(), sdk.ImportInput{OS: "windows"}, &out)
+
+	assert.Empty(t, out.Errors())
+	assert.Empty(t, out.AllCandidates())
+}
+
+func TestTryWindowsCredentialError(t *testing.T) {
+	credentials := fakeCredentialManager{err: errors.New("reading Windows credential example: Access is denied.")}
+
+	out := sdk.ImportOutput{}
+	tryWindowsCredential(credentials, "example", mapWindowsCredential)(context.Background(), sdk.ImportInput{OS: "windows"}, &out)
+
+	assert.Empty(t, out.AllCandidates())
+	assert.Equal(t, []sdk.Error{{Message: "reading Windows credential example: Access is denied."}}, out.Errors())
+}
+
+func TestTryWindowsCredentialOtherPlatforms(t *testing.T) {
+	credentials := fakeCredentialManager{credential: &windowsCredential{targetName: "example", blob: []byte("token")}}
+
+	for _, goos := range []string{"darwin", "linux"} {
+		out := sdk.ImportOutput{}
+		TryAll(tryWindowsCredential(credentials, "example", mapWindowsCredential))(context.Background(), sdk.ImportInput{OS: goos}, &out)
+
+		assert.Empty(t, out.Attempts)
+	}
+}
+
+func TestDecodeCredentialBlob(t *testing.T) {
+	for _, scenario := range []struct {
+		description string
+		blob        []byte
+		expected    string
+	}{
+		{description: "UTF-8", blob: []byte("token"), expected: "token"},
+		{description: "UTF-8 of odd length with non-ASCII characters", blob: []byte("tökén"), expected: "tökén"},
+		{description: "UTF-16LE", blob: utf16LE("token"), expected: "token"},
+		{description: "UTF-16LE with non-ASCII characters", blob: utf16LE("tökén🔑"), expected: "tökén🔑"},
+		{description: "UTF-16LE with byte order mark", blob: append([]byte{0xff, 0xfe}, utf16LE("token")...), expected: "token"},
+		{description: "UTF-16LE with NUL terminator", blob: append(utf16LE("token"), 0, 0), expected: "token"},
+		{description: "empty", blob: nil, expected: ""},
+	} {
+		t.Run(scenario.description, func(t *testing.T) {
+			assert.Equal(t, scenario.expected, decodeCredentialBlob(scenario.blob))
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportOutputSortByConfidence(t *testing.T) {
+	candidate := func(token string, confidence Confidence) ImportCandidate {
+		return ImportCandidate{Fields: map[FieldName]string{"Token": token}, Confidence: confidence}
+	}
+
+	out := ImportOutput{}
+	out.NewAttempt(ImportSource{Env: []string{"LEGACY_TOKEN"}}).AddCandidate(candidate("legacy", ConfidenceLow))
+	out.NewAttempt(ImportSource{Files: []string{"~/.tool/backup.json"}})
+	out.NewAttempt(ImportSource{Env: []string{"TOOL_TOKEN"}}).AddCandidate(candidate("env", ConfidenceMedium))
+	out.NewAttempt(ImportSource{Other: CustomSource{Type: "Custom"}}).AddCandidate(candidate("unspecified", ConfidenceUnspecified))
+	config := out.NewAttempt(ImportSource{Files: []string{"~/.tool/config.json"}})
+	config.AddCandidate(candidate("config-medium", ConfidenceMedium))
+	config.AddCandidate(candidate("config-high", ConfidenceHigh))
+
+	out.SortByConfidence()
+
+	var sources []ImportSource
+	for _, attempt := range out.Attempts {
+		sources = append(sources, attempt.Source)
+	}
+	assert.Equal(t, []ImportSource{
+		{Files: []string{"~/.tool/config.json"}},
+		{Env: []string{"TOOL_TOKEN"}},
+		{Other: CustomSource{Type: "Custom"}},
+		{Env: []string{"LEGACY_TOKEN"}},
+		{Files: []string{"~/.tool/backup.json"}},
+	}, sources)
+	assert.Equal(t, []ImportCandidate{
+		candidate("config-high", ConfidenceHigh),
+		candidate("config-medium", ConfidenceMedium),
+		candidate("env", ConfidenceMedium),
+		candidate("unspecified", ConfidenceUnspecified),
+		candidate("legacy", ConfidenceLow),
+	}, out.AllCandidates())
+}
+
+func TestImportAttemptAddCandidateInvalidConfidence(t *testing.T) {
+	for _, confidence := range []Confidence{-1, ConfidenceHigh + 1, 100} {
+		attempt := ImportAttempt{}
+		attempt.AddCandidate(ImportCandidate{Fields: map[FieldName]string{"Token": "token"}, Confidence: confidence})
+
+		assert.Empty(t, attempt.Candidates)
+		assert.Len(t, attempt.Diagnostics.Errors, 1)
+	}
+}
+
+func TestImportAttemptAddCandidateExpiry(t *testing.T) {
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	future := time.Now().Add(time.Hour)
+
+	for _, scenario := range []struct {
+		description      string
+		candidate        ImportCandidate
+		expectedWarnings []Warning
+	}{
+		{
+			description: "expired",
+			candidate:   ImportCandidate{Fields: map[FieldName]string{"Token": "token"}, NameHint: "sso", ExpiresAt: &past},
+			expectedWarnings: []Warning{
+				{Message: "import candidate 'sso' expired at " + past.Format(time.RFC3339)},
+			},
+		},
+		{
+			description: "expired without name hint",
+			candidate:   ImportCandidate{Fields: map[FieldName]string{"Token": "token"}, ExpiresAt: &past},
+			expectedWarnings: []Warning{
+				{Message: "import candidate expired at " + past.Format(time.RFC3339)},
+			},
+		},
+		{
+			description: "future",
+			candidate:   ImportCandidate{Fields: map[FieldName]string{"Token": "token"}, ExpiresAt: &future},
+		},
+		{
+			description: "absent",
+			candidate:   ImportCandidate{Fields: map[FieldName]string{"Token": "token"}},
+		},
+	} {
+		t.Run(scenario.description, func(t *testing.T) {
+			attempt := ImportAttempt{}
+			attempt.AddCandidate(scenario.candidate)
+
+			assert.Equal(t, []ImportCandidate{scenario.candidate}, attempt.Candidates)
+			assert.Equal(t, scenario.expectedWarnings, attempt.Diagnostics.Warnings)
+			assert.Empty(t, attempt.Diagnostics.Errors)
+		})
+	}
+}
+
+func TestImportOutputEncodingKeepsExpiry(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	out := ImportOutput{}
+	out.NewAttempt(ImportSource{Files: []string{"~/.aws/sso/cache/token.json"}}).AddCandidate(ImportCandidate{
+		Fields:    map[FieldName]string{"Token": "token"},
+		ExpiresAt: &expiresAt,
+	})
+
+	t.Run("gob", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, gob.NewEncoder(&buf).Encode(out))
+
+		var decoded ImportOutput
+		require.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+		require.NotNil(t, decoded.AllCandidates()[0].ExpiresAt)
+		assert.True(t, expiresAt.Equal(*decoded.AllCandidates()[0].ExpiresAt))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		encoded, err := json.Marshal(out)
+		require.NoError(t, err)
+
+		var decoded ImportOutput
+		require.NoError(t, json.Unmarshal(encoded, &decoded))
+		require.NotNil(t, decoded.AllCandidates()[0].ExpiresAt)
+		assert.True(t, expiresAt.Equal(*decoded.AllCandidates()[0].ExpiresAt))
+	})
+}
+
+func TestImportInputDirs(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("AppData", "")
+	t.Setenv("LocalAppData", "")
+	home := filepath.Join("/", "home", "wendy")
+
+	for _, scenario := range []struct {
+		os     string
+		config string
+		cache  string
+	}{
+		{os: "linux", config: filepath.Join(home, ".config", "tool"), cache: filepath.Join(home, ".cache", "tool")},
+		{os: "darwin", config: filepath.Join(home, "Library", "Application Support", "tool"), cache: filepath.Join(home, "Library", "Caches", "tool")},
+		{os: "windows", config: filepath.Join(home, "AppData", "Roaming", "tool"), cache: filepath.Join(home, "AppData", "Local", "tool")},
+	} {
+		t.Run(scenario.os, func(t *testing.T) {
+			in := ImportInput{HomeDir: home, OS: scenario.os}
+			assert.Equal(t, scenario.config, in.FromConfigDir("tool"))
+			assert.Equal(t, scenario.cache, in.FromCacheDir("tool"))
+		})
+	}
+
+	t.Run("set by host", func(t *testing.T) {
+		in := ImportInput{
+			HomeDir:    home,
+			OS:         "linux",
+			ConfigDir:  filepath.Join("/", "xdg", "config"),
+			CacheDir:   filepath.Join("/", "xdg", "cache"),
+			WorkingDir: filepath.Join("/", "src", "project"),
+		}
+		assert.Equal(t, filepath.Join("/", "xdg", "config", "tool"), in.FromConfigDir("tool"))
+		assert.Equal(t, filepath.Join("/", "xdg", "cache", "tool"), in.FromCacheDir("tool"))
+		assert.Equal(t, filepath.Join("/", "src", "project", ".env"), in.FromWorkingDir(".env"))
+	})
+}
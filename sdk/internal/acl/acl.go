@@ -0,0 +1,15 @@
+// Package acl restricts access to provisioned files and directories on platforms where file modes alone don't
+// prevent other users from accessing them.
+package acl
+
+// Restrictor restricts access to files and directories.
+type Restrictor interface {
+	// RestrictToCurrentUser makes sure that only the current user can access the file or directory at the specified
+	// path, without inheriting any permissions from its parent directory.
+	RestrictToCurrentUser(path string) error
+}
+
+// Default returns the Restrictor for the current platform.
+func Default() Restrictor {
+	return platformRestrictor{}
+}
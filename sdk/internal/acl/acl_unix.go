@@ -0,0 +1,10 @@
+//go:build !windows
+
+package acl
+
+type platformRestrictor struct{}
+
+func (platformRestrictor) RestrictToCurrentUser(path string) error {
+	// Nothing to do here: on Unix, file modes already restrict access to the current user.
+	return nil
+}
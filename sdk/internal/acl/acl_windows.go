@@ -0,0 +1,50 @@
+//go:build windows
+
+package acl
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+)
+
+type platformRestrictor struct{}
+
+// RestrictToCurrentUser replaces the DACL of the file or directory by one that only grants the current user access,
+// and protects it from inheriting access control entries from its parent directory.
+func (platformRestrictor) RestrictToCurrentUser(path string) error {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return fmt.Errorf("looking up current user: %s", err)
+	}
+
+	dacl, err := windows.ACLFromEntries([]windows.EXPLICIT_ACCESS{
+		{
+			AccessPermissions: windows.GENERIC_ALL,
+			AccessMode:        windows.GRANT_ACCESS,
+			Inheritance:       windows.NO_INHERITANCE,
+			Trustee: windows.TRUSTEE{
+				TrusteeForm:  windows.TRUSTEE_IS_SID,
+				TrusteeType:  windows.TRUSTEE_IS_USER,
+				TrusteeValue: windows.TrusteeValueFromSID(user.User.Sid),
+			},
+		},
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("creating access control list: %s", err)
+	}
+
+	err = windows.SetNamedSecurityInfo(
+		path,
+		windows.SE_FILE_OBJECT,
+		windows.DACL_SECURITY_INFORMATION|windows.PROTECTED_DACL_SECURITY_INFORMATION,
+		nil,
+		nil,
+		dacl,
+		nil,
+	)
+	if err != nil {
+		return fmt.Errorf("applying access control list to %s: %s", path, err)
+	}
+	return nil
+}
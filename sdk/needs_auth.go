@@ -1,9 +1,217 @@
 package sdk
 
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
 // NeedsAuthentication provides a hook to check whether authentication are required for certain command args.
 type NeedsAuthentication func(in NeedsAuthenticationInput) (needsAuthentication bool)
 
 type NeedsAuthenticationInput struct {
 	CredentialType string
 	CommandArgs    []string
+
+	// ParentEnvVarNames contains the names (not the values) of the environment variables that are already set in the
+	// environment that the executable gets started from. Variables that are set to an empty value are left out. Use
+	// EnvVarNames to get the names from a list of KEY=value pairs.
+	ParentEnvVarNames []string
+
+	// HomeDir is the path to current user's home directory. If it's not set, it gets looked up when needed.
+	HomeDir string
+
+	// StdinIsTerminal and StdoutIsTerminal report whether the standard input and output of the executable are
+	// connected to a terminal, as opposed to a pipe or a file. They're detected by the host that runs the executable,
+	// so that rules don't have to inspect the file descriptors of the plugin process. If the host doesn't detect them,
+	// they're false, so rules treat the executable as running non-interactively.
+	StdinIsTerminal  bool
+	StdoutIsTerminal bool
+
+	// validationErrors collects the configuration errors reported by the rules if they're called by Validate, instead
+	// of to check a command.
+	validationErrors *[]error
+
+	// tracer records the rules that get evaluated if they're called by Explain.
+	tracer *ruleTracer
+}
+
+// RuleTrace records the evaluation of a single rule by NeedsAuthentication.Explain.
+type RuleTrace struct {
+	// Rule describes the rule, e.g. `ForCommand("auth", "login")`.
+	Rule string
+
+	// Depth is the number of rules that the rule is nested in, e.g. 1 for the rules passed to a top-level All.
+	Depth int
+
+	// NeedsAuth is the outcome of the rule.
+	NeedsAuth bool
+}
+
+func (t RuleTrace) String() string {
+	outcome := "no authentication"
+	if t.NeedsAuth {
+		outcome = "needs authentication"
+	}
+	return fmt.Sprintf("%s%s: %s", strings.Repeat("  ", t.Depth), t.Rule, outcome)
+}
+
+// FormatRuleTraces formats the traces returned by NeedsAuthentication.Explain as an indented tree, one rule per line.
+func FormatRuleTraces(traces []RuleTrace) string {
+	lines := make([]string, len(traces))
+	for i, trace := range traces {
+		lines[i] = trace.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+type ruleTracer struct {
+	traces []RuleTrace
+	depth  int
+}
+
+// Validate returns the configuration errors of the rule, e.g. a regular expression that doesn't compile, so that they
+// can be reported when validating the plugin instead of when running the executable. To find them, the rule gets
+// called with an input for which IsValidating returns true.
+func (f NeedsAuthentication) Validate() error {
+	var errs []error
+	f(NeedsAuthenticationInput{validationErrors: &errs})
+	return joinErrors(errs)
+}
+
+// Explain evaluates the rule for the command-line args and returns the rules that got evaluated, in evaluation order,
+// with their outcome, to find out which rule decided whether the command needs authentication. The first trace is the
+// outermost rule, and its outcome is the outcome of the rule as a whole.
+//
+// Only rules that record themselves through TraceRule show up, which all rules of the needsauth package do. Rules that
+// don't get evaluated because an earlier rule already decided the outcome of a combination don't show up either.
+func (f NeedsAuthentication) Explain(commandLine []string) []RuleTrace {
+	return f.ExplainInput(NeedsAuthenticationInput{CommandArgs: commandLine})
+}
+
+// ExplainInput is like Explain, for rules that depend on more of the input than the command-line args.
+func (f NeedsAuthentication) ExplainInput(in NeedsAuthenticationInput) []RuleTrace {
+	in.tracer = &ruleTracer{}
+	f(in)
+	return in.tracer.traces
+}
+
+// Description returns the description of the rule itself, e.g. "NotForHelpOrVersion()", or just "All" for rules that
+// combine other rules. It returns an empty string if the rule was not created by the needsauth package or described
+// with needsauth.Describe.
+func (f NeedsAuthentication) Description() string {
+	if f == nil {
+		return ""
+	}
+	for _, trace := range f.Explain(nil) {
+		if trace.Depth == 0 {
+			return trace.Rule
+		}
+	}
+	return ""
+}
+
+// CredentialSelector provides a hook to select which of the credentials that an executable uses need to be
+// provisioned for certain command args, for executables that need different credentials for different commands. If
+// no credentials are selected, all of them get provisioned.
+type CredentialSelector func(in NeedsAuthenticationInput) (credentials []CredentialName)
+
+// Validate returns all credentials that the selector can select, so that they can be checked against the credentials
+// that the executable uses, and the configuration errors of its rules. To find them, the selector gets called with an
+// input for which IsValidating returns true, in which case it must return every credential it can select.
+func (f CredentialSelector) Validate() ([]CredentialName, error) {
+	var errs []error
+	credentials := f(NeedsAuthenticationInput{validationErrors: &errs})
+	return credentials, joinErrors(errs)
+}
+
+// joinErrors returns an error with the messages of all errors, or nil if there are none.
+func joinErrors(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+// IsValidating returns whether the rule is called by NeedsAuthentication.Validate or CredentialSelector.Validate. Rules
+// that combine other rules must call all of them in that case, so that the errors of all rules get reported.
+func (in NeedsAuthenticationInput) IsValidating() bool {
+	return in.validationErrors != nil
+}
+
+// HasParentEnvVar returns whether the environment variable with the specified name is already set in the
+// environment that the executable gets started from.
+func (in NeedsAuthenticationInput) HasParentEnvVar(name string) bool {
+	for _, parentEnvVarName := range in.ParentEnvVarNames {
+		if parentEnvVarName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FromHomeDir returns a path with the user's home directory prepended.
+func (in NeedsAuthenticationInput) FromHomeDir(path ...string) string {
+	return filepath.Join(append([]string{in.homeDir()}, path...)...)
+}
+
+// FromConfigDir returns a path with the user's config directory prepended. This is $XDG_CONFIG_HOME if set, and
+// otherwise ~/.config on Linux, ~/Library/Application Support on macOS, and %AppData% on Windows.
+func (in NeedsAuthenticationInput) FromConfigDir(path ...string) string {
+	return filepath.Join(append([]string{userConfigDir(runtime.GOOS, os.Getenv, in.homeDir())}, path...)...)
+}
+
+// homeDir returns the HomeDir of the input, falling back to the home directory of the user the plugin runs as.
+func (in NeedsAuthenticationInput) homeDir() string {
+	if in.HomeDir != "" {
+		return in.HomeDir
+	}
+	homeDir, _ := os.UserHomeDir()
+	return homeDir
+}
+
+// TraceRule evaluates the rule and, if called by NeedsAuthentication.Explain, records its outcome with the specified
+// description. The rules that it calls in turn are recorded as nested in it.
+func (in NeedsAuthenticationInput) TraceRule(description string, rule NeedsAuthentication) bool {
+	if in.tracer == nil || in.IsValidating() {
+		return rule(in)
+	}
+
+	i := len(in.tracer.traces)
+	in.tracer.traces = append(in.tracer.traces, RuleTrace{Rule: description, Depth: in.tracer.depth})
+	in.tracer.depth++
+	needsAuth := rule(in)
+	in.tracer.depth--
+	in.tracer.traces[i].NeedsAuth = needsAuth
+	return needsAuth
+}
+
+// ReportError reports a configuration error of the rule if it's called by NeedsAuthentication.Validate or
+// CredentialSelector.Validate.
+func (in NeedsAuthenticationInput) ReportError(err error) {
+	if in.validationErrors != nil {
+		*in.validationErrors = append(*in.validationErrors, err)
+	}
+}
+
+// EnvVarNames returns the names of the environment variables in the KEY=value pairs, in the format of os.Environ, that
+// are set to a non-empty value, for use as NeedsAuthenticationInput.ParentEnvVarNames.
+func EnvVarNames(environ []string) []string {
+	var names []string
+	for _, pair := range environ {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || name == "" || value == "" {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
 }
@@ -0,0 +1,19 @@
+package sdk
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvVarNames(t *testing.T) {
+	assert.Equal(t, []string{"HOME", "TOKEN", "EQUALS"}, EnvVarNames([]string{
+		"HOME=/home/user",
+		"TOKEN=abc",
+		"EMPTY=",
+		"EQUALS==value=",
+		"MALFORMED",
+		"=C:=C:\\\\",
+	}))
+	assert.Empty(t, EnvVarNames(nil))
+}
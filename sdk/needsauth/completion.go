@@ -0,0 +1,48 @@
+package needsauth
+
+import (
+	"regexp"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// cobraCompletionCommands are the hidden commands that shell completion scripts of cobra CLIs
+// call on every completion request, e.g. `gh __complete pr view ""`.
+var cobraCompletionCommands = []string{"__complete", "__completeNoDesc"}
+
+// urfaveCompletionFlags are the flags that shell completion scripts of urfave/cli CLIs add as the
+// last arg: --generate-bash-completion up to v2, and --generate-shell-completion since v3.
+var urfaveCompletionFlags = []string{"--generate-bash-completion", "--generate-shell-completion"}
+
+// clickCompletionEnvVar matches the environment variable that shell completion scripts of click
+// CLIs set to request completions, e.g. _FLASK_COMPLETE=bash_complete for flask.
+var clickCompletionEnvVar = regexp.MustCompile(`^_[A-Z0-9_]+_COMPLETE$`)
+
+// NotForShellCompletion returns a NeedsAuthentication rule to opt out of authentication when the
+// CLI is invoked by a shell completion script, which happens on every press of the Tab key, or
+// when it prints such a script. That's the case if:
+//   - the first arg is "__complete" or "__completeNoDesc", used by cobra CLIs like gh and kubectl;
+//   - the last arg is --generate-bash-completion or --generate-shell-completion, used by
+//     urfave/cli CLIs;
+//   - an environment variable like _MY_CLI_COMPLETE is set, used by click CLIs;
+//   - the first arg is "completion", e.g. `kubectl completion bash` or `gh completion -s zsh`.
+func NotForShellCompletion() sdk.NeedsAuthentication {
+	return describe(describeCall("NotForShellCompletion"), func(in sdk.NeedsAuthenticationInput) bool {
+		args := in.CommandArgs
+		if len(args) > 0 && (containsString(cobraCompletionCommands, args[0]) || args[0] == "completion") {
+			return false
+		}
+
+		args = argsBeforeEndOfOptions(args)
+		if len(args) > 0 && containsString(urfaveCompletionFlags, args[len(args)-1]) {
+			return false
+		}
+
+		for _, name := range in.ParentEnvVarNames {
+			if clickCompletionEnvVar.MatchString(name) {
+				return false
+			}
+		}
+		return true
+	})
+}
@@ -0,0 +1,88 @@
+package needsauth
+
+import (
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+)
+
+func TestNotForShellCompletion(t *testing.T) {
+	plugintest.TestNeedsAuth(t, NotForShellCompletion(), map[string]plugintest.NeedsAuthCase{
+		"no for gh completion request": {
+			Args:              []string{"__complete", "pr", "checkout", ""},
+			ExpectedNeedsAuth: false,
+		},
+		"no for gh completion request with flags": {
+			Args:              []string{"__complete", "repo", "clone", "--upstream-remote-name", ""},
+			ExpectedNeedsAuth: false,
+		},
+		"no for kubectl completion request": {
+			Args:              []string{"__complete", "get", "pods", "-n", "kube-system", ""},
+			ExpectedNeedsAuth: false,
+		},
+		"no for kubectl completion request without descriptions": {
+			Args:              []string{"__completeNoDesc", "logs", ""},
+			ExpectedNeedsAuth: false,
+		},
+		"no for gh completion script": {
+			Args:              []string{"completion", "-s", "zsh"},
+			ExpectedNeedsAuth: false,
+		},
+		"no for kubectl completion script": {
+			Args:              []string{"completion", "bash"},
+			ExpectedNeedsAuth: false,
+		},
+		"no for urfave/cli v2 completion request": {
+			Args:              []string{"deploy", "--generate-bash-completion"},
+			ExpectedNeedsAuth: false,
+		},
+		"no for urfave/cli v3 completion request": {
+			Args:              []string{"--generate-shell-completion"},
+			ExpectedNeedsAuth: false,
+		},
+		"no for click completion request": {
+			Args:              []string{},
+			Environment:       map[string]string{"_FLASK_COMPLETE": "bash_complete", "COMP_WORDS": "flask ro", "COMP_CWORD": "1"},
+			ExpectedNeedsAuth: false,
+		},
+		"no for click completion script": {
+			Args:              []string{},
+			Environment:       map[string]string{"_MY_CLI_COMPLETE": "zsh_source"},
+			ExpectedNeedsAuth: false,
+		},
+		"yes for regular command": {
+			Args:              []string{"pr", "checkout", "123"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes without args": {
+			Args:              []string{},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for completion as a later arg": {
+			Args:              []string{"issue", "list", "--label", "completion"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for __complete as a later arg": {
+			Args:              []string{"issue", "create", "--title", "__complete"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for urfave/cli completion flag that's not the last arg": {
+			Args:              []string{"deploy", "--generate-bash-completion", "production"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for urfave/cli completion flag after end of options": {
+			Args:              []string{"exec", "--", "other-cli", "--generate-bash-completion"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for click completion variable with empty value": {
+			Args:              []string{"deploy"},
+			Environment:       map[string]string{"_MY_CLI_COMPLETE": ""},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for other variable that ends with _COMPLETE": {
+			Args:              []string{"deploy"},
+			Environment:       map[string]string{"SETUP_COMPLETE": "1"},
+			ExpectedNeedsAuth: true,
+		},
+	})
+}
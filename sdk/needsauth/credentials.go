@@ -0,0 +1,74 @@
+package needsauth
+
+import (
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// CredentialRule selects one of the credentials that an executable uses to be provisioned if all
+// of its rules opt in. See SelectCredentials.
+type CredentialRule struct {
+	credential sdk.CredentialName
+	rules      []sdk.NeedsAuthentication
+}
+
+// UseCredential returns a CredentialRule to select the credential with the specified name, which
+// has to be one of the credentials that the executable uses. Without ForCommands or When, the
+// credential is selected for every command.
+func UseCredential(credential sdk.CredentialName) CredentialRule {
+	return CredentialRule{credential: credential}
+}
+
+// ForCommands only selects the credential for the specified (sub)commands, e.g. "publish" or
+// "dist-tag add", which are matched like in OnlyForCommands.
+func (r CredentialRule) ForCommands(commands ...string) CredentialRule {
+	return r.When(OnlyForCommands(commands...))
+}
+
+// When only selects the credential if the specified rule opts in, e.g. a rule that checks the
+// value of the --registry flag.
+func (r CredentialRule) When(rule sdk.NeedsAuthentication) CredentialRule {
+	rules := make([]sdk.NeedsAuthentication, 0, len(r.rules)+1)
+	rules = append(rules, r.rules...)
+	r.rules = append(rules, rule)
+	return r
+}
+
+// SelectCredentials returns a CredentialSelector for executables that need different credentials
+// for different commands, e.g. a publish token for `npm publish` and a read token for
+// `npm install`:
+//
+//	SelectCredentials(
+//		UseCredential(credname.PublishToken).ForCommands("publish", "unpublish"),
+//		UseCredential(credname.ReadToken).ForCommands("install", "ci"),
+//	)
+//
+// All credentials of which the rules opt in get selected. If none of them do, no credentials are
+// selected, so all credentials of the executable get provisioned.
+func SelectCredentials(credentialRules ...CredentialRule) sdk.CredentialSelector {
+	return func(in sdk.NeedsAuthenticationInput) []sdk.CredentialName {
+		var credentials []sdk.CredentialName
+		for _, credentialRule := range credentialRules {
+			if containsCredential(credentials, credentialRule.credential) {
+				continue
+			}
+			if in.IsValidating() || All(credentialRule.rules...)(in) {
+				credentials = append(credentials, credentialRule.credential)
+			}
+		}
+		if in.IsValidating() {
+			for _, credentialRule := range credentialRules {
+				validateAll(in, credentialRule.rules)
+			}
+		}
+		return credentials
+	}
+}
+
+func containsCredential(credentials []sdk.CredentialName, credential sdk.CredentialName) bool {
+	for _, c := range credentials {
+		if c == credential {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package needsauth
+
+import (
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectCredentials(t *testing.T) {
+	selector := SelectCredentials(
+		UseCredential("Publish Token").ForCommands("publish", "dist-tag add"),
+		UseCredential("Read Token").ForCommands("install", "ci"),
+		UseCredential("Read Token").When(NotWhenFlagEquals("--registry", "https://registry.npmjs.org/")).ForCommands("view"),
+		UseCredential("Publish Token").When(WhenFlagMatches("--otp", `^\d{6}$`)),
+	)
+
+	for description, tc := range map[string]struct {
+		args     []string
+		expected []sdk.CredentialName
+	}{
+		"selected for command": {
+			args:     []string{"publish", "--access", "public"},
+			expected: []sdk.CredentialName{"Publish Token"},
+		},
+		"selected for subcommand": {
+			args:     []string{"dist-tag", "add", "pkg@1.0.0", "latest"},
+			expected: []sdk.CredentialName{"Publish Token"},
+		},
+		"selected for other command": {
+			args:     []string{"--silent", "ci"},
+			expected: []sdk.CredentialName{"Read Token"},
+		},
+		"selected if all rules opt in": {
+			args:     []string{"view", "pkg", "--registry", "https://npm.example.com/"},
+			expected: []sdk.CredentialName{"Read Token"},
+		},
+		"not selected if one of the rules opts out": {
+			args:     []string{"view", "pkg", "--registry", "https://registry.npmjs.org/"},
+			expected: nil,
+		},
+		"selected by multiple rules": {
+			args:     []string{"publish", "--otp", "123456"},
+			expected: []sdk.CredentialName{"Publish Token"},
+		},
+		"multiple credentials selected": {
+			args:     []string{"install", "--otp=123456"},
+			expected: []sdk.CredentialName{"Read Token", "Publish Token"},
+		},
+		"nothing selected for other commands": {
+			args:     []string{"whoami"},
+			expected: nil,
+		},
+		"nothing selected without args": {
+			args:     []string{},
+			expected: nil,
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			assert.Equal(t, tc.expected, selector(sdk.NeedsAuthenticationInput{CommandArgs: tc.args}))
+		})
+	}
+}
+
+func TestSelectCredentialsWithoutRules(t *testing.T) {
+	assert.Equal(t, []sdk.CredentialName{"API Key"}, SelectCredentials(UseCredential("API Key"))(sdk.NeedsAuthenticationInput{CommandArgs: []string{"deploy"}}))
+	assert.Empty(t, SelectCredentials()(sdk.NeedsAuthenticationInput{CommandArgs: []string{"deploy"}}))
+}
+
+func TestSelectCredentialsValidate(t *testing.T) {
+	credentials, err := SelectCredentials(
+		UseCredential("Publish Token").ForCommands("publish"),
+		UseCredential("Read Token").ForCommands("install"),
+		UseCredential("Publish Token").When(MatchingRegexp(`^publish`)),
+	).Validate()
+	assert.NoError(t, err)
+	assert.Equal(t, []sdk.CredentialName{"Publish Token", "Read Token"}, credentials)
+
+	_, err = SelectCredentials(
+		UseCredential("Publish Token").ForCommands("publish"),
+		UseCredential("Publish Token").When(MatchingRegexp(`^publish (`)),
+	).Validate()
+	assert.ErrorContains(t, err, "invalid regular expression in needsauth.MatchingRegexp")
+}
+
+func TestCredentialRuleIsImmutable(t *testing.T) {
+	publish := UseCredential("Publish Token")
+	forPublish := publish.ForCommands("publish")
+	forUnpublish := publish.ForCommands("unpublish")
+
+	in := sdk.NeedsAuthenticationInput{CommandArgs: []string{"unpublish"}}
+	assert.Empty(t, SelectCredentials(forPublish)(in))
+	assert.Equal(t, []sdk.CredentialName{"Publish Token"}, SelectCredentials(forUnpublish)(in))
+}
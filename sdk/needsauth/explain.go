@@ -0,0 +1,56 @@
+package needsauth
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// Describe returns a NeedsAuthentication rule that evaluates the specified rule and records it
+// with the description in the traces of NeedsAuthentication.Explain, e.g. to explain a custom rule
+// of a plugin. All rules of this package describe themselves already.
+func Describe(description string, rule sdk.NeedsAuthentication) sdk.NeedsAuthentication {
+	return describe(description, rule)
+}
+
+func describe(description string, rule sdk.NeedsAuthentication) sdk.NeedsAuthentication {
+	return func(in sdk.NeedsAuthenticationInput) bool {
+		return in.TraceRule(description, rule)
+	}
+}
+
+// describeCall describes a rule like the Go code that creates it, e.g. `ForCommand("auth")`.
+func describeCall(name string, args ...string) string {
+	return name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// describeCommandPatterns describes CommandPatterns with the specified patterns.
+func describeCommandPatterns(patterns []CommandPattern) string {
+	args := make([]string, len(patterns))
+	for i, pattern := range patterns {
+		name := "NotForCommandPattern"
+		if pattern.needsAuth {
+			name = "ForCommandPattern"
+		}
+		args[i] = describeCall(name, quote(pattern.pattern))
+	}
+	return describeCall("CommandPatterns", args...)
+}
+
+// quote quotes the value like a Go string literal, using backquotes for values with backslashes,
+// like most regular expressions, to keep them readable.
+func quote(value string) string {
+	if strings.Contains(value, `\`) && strconv.CanBackquote(value) {
+		return "`" + value + "`"
+	}
+	return strconv.Quote(value)
+}
+
+func quoteAll(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = quote(value)
+	}
+	return quoted
+}
@@ -0,0 +1,132 @@
+package needsauth
+
+import (
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	for description, tc := range map[string]struct {
+		rule        sdk.NeedsAuthentication
+		commandLine []string
+		expected    []sdk.RuleTrace
+	}{
+		"help": {
+			rule:        NotForHelpOrVersion(),
+			commandLine: []string{"deploy", "--help"},
+			expected: []sdk.RuleTrace{
+				{Rule: "NotForHelpOrVersion()", Depth: 0, NeedsAuth: false},
+				{Rule: "NotForHelp()", Depth: 1, NeedsAuth: false},
+			},
+		},
+		"version with custom flags": {
+			rule:        NotForHelpOrVersion(VersionFlags("--version")),
+			commandLine: []string{"--version"},
+			expected: []sdk.RuleTrace{
+				{Rule: `NotForHelpOrVersion(VersionFlags("--version"))`, Depth: 0, NeedsAuth: false},
+				{Rule: "NotForHelp()", Depth: 1, NeedsAuth: true},
+				{Rule: `NotForVersion(VersionFlags("--version"))`, Depth: 1, NeedsAuth: false},
+			},
+		},
+		"help with flags with values": {
+			rule:        NotForHelpOrVersion(VersionFlags("--version"), FlagsWithValues("--message")),
+			commandLine: []string{"commit", "--message", "-h"},
+			expected: []sdk.RuleTrace{
+				{Rule: `NotForHelpOrVersion(VersionFlags("--version"), FlagsWithValues("--message"))`, Depth: 0, NeedsAuth: true},
+				{Rule: `NotForHelp(FlagsWithValues("--message"))`, Depth: 1, NeedsAuth: true},
+				{Rule: `NotForVersion(VersionFlags("--version"))`, Depth: 1, NeedsAuth: true},
+			},
+		},
+		"nested combinations": {
+			rule: All(
+				NotForHelpOrVersion(),
+				NotWithoutArgs(),
+				Any(ForCommand("deploy"), MatchingRegexp(`^s3 (ls|cp)\b`)),
+			),
+			commandLine: []string{"s3", "ls"},
+			expected: []sdk.RuleTrace{
+				{Rule: "All", Depth: 0, NeedsAuth: true},
+				{Rule: "NotForHelpOrVersion()", Depth: 1, NeedsAuth: true},
+				{Rule: "NotForHelp()", Depth: 2, NeedsAuth: true},
+				{Rule: "NotForVersion()", Depth: 2, NeedsAuth: true},
+				{Rule: "NotWithoutArgs()", Depth: 1, NeedsAuth: true},
+				{Rule: "Any", Depth: 1, NeedsAuth: true},
+				{Rule: `ForCommand("deploy")`, Depth: 2, NeedsAuth: false},
+				{Rule: "MatchingRegexp(`^s3 (ls|cp)\\b`)", Depth: 2, NeedsAuth: true},
+			},
+		},
+		"rules after the deciding rule": {
+			rule:        IfAll(NotForCommand("auth", "login"), NotWhenEnvVarsSet("TOKEN"), Not(Always())),
+			commandLine: []string{"auth", "login"},
+			expected: []sdk.RuleTrace{
+				{Rule: "All", Depth: 0, NeedsAuth: false},
+				{Rule: `NotForCommand("auth", "login")`, Depth: 1, NeedsAuth: false},
+				{Rule: `ForCommand("auth", "login")`, Depth: 2, NeedsAuth: true},
+			},
+		},
+		"command patterns": {
+			rule:        CommandPatterns(NotForCommandPattern("config **"), ForCommandPattern("config get account")),
+			commandLine: []string{"config", "get", "account"},
+			expected: []sdk.RuleTrace{
+				{Rule: `CommandPatterns(NotForCommandPattern("config **"), ForCommandPattern("config get account"))`, Depth: 0, NeedsAuth: true},
+			},
+		},
+		"custom rule": {
+			rule: Any(
+				Describe("NotForDryRun()", func(in sdk.NeedsAuthenticationInput) bool {
+					return !containsString(in.CommandArgs, "--dry-run")
+				}),
+				func(in sdk.NeedsAuthenticationInput) bool {
+					return NotWhenFlagEquals("--output", "json")(in)
+				},
+			),
+			commandLine: []string{"deploy", "--dry-run", "--output", "json"},
+			expected: []sdk.RuleTrace{
+				{Rule: "Any", Depth: 0, NeedsAuth: false},
+				{Rule: "NotForDryRun()", Depth: 1, NeedsAuth: false},
+				{Rule: `NotWhenFlagEquals("--output", "json")`, Depth: 1, NeedsAuth: false},
+			},
+		},
+	} {
+		t.Run(description, func(t *testing.T) {
+			traces := tc.rule.Explain(tc.commandLine)
+			assert.Equal(t, tc.expected, traces)
+			assert.Equal(t, tc.rule(sdk.NeedsAuthenticationInput{CommandArgs: tc.commandLine}), traces[0].NeedsAuth)
+			assert.Equal(t, traces, tc.rule.Explain(tc.commandLine))
+		})
+	}
+}
+
+func TestFormatRuleTraces(t *testing.T) {
+	rule := All(NotForHelpOrVersion(), NotWhenFileExists("~/.tool/credentials"), ForCommand("deploy"))
+
+	assert.Equal(t, `All: no authentication
+  NotForHelpOrVersion(): needs authentication
+    NotForHelp(): needs authentication
+    NotForVersion(): needs authentication
+  NotWhenFileExists("~/.tool/credentials"): needs authentication
+  ForCommand("deploy"): no authentication`, sdk.FormatRuleTraces(rule.ExplainInput(sdk.NeedsAuthenticationInput{
+		CommandArgs: []string{"status"},
+		HomeDir:     t.TempDir(),
+	})))
+}
+
+func TestExplainDoesNotAffectValidation(t *testing.T) {
+	rule := All(NotForHelp(), MatchingRegexp(`(`))
+	assert.Error(t, rule.Validate())
+	assert.Equal(t, []sdk.RuleTrace{
+		{Rule: "All", Depth: 0, NeedsAuth: true},
+		{Rule: "NotForHelp()", Depth: 1, NeedsAuth: true},
+		{Rule: "MatchingRegexp(\"(\")", Depth: 1, NeedsAuth: true},
+	}, rule.Explain([]string{"deploy"}))
+}
+
+func TestDescription(t *testing.T) {
+	assert.Equal(t, "NotForHelpOrVersion()", NotForHelpOrVersion().Description())
+	assert.Equal(t, "All", All(NotForHelp(), ForCommand("deploy")).Description())
+	assert.Equal(t, "NotForDryRun()", Describe("NotForDryRun()", func(in sdk.NeedsAuthenticationInput) bool { return true }).Description())
+	assert.Equal(t, "", sdk.NeedsAuthentication(func(in sdk.NeedsAuthenticationInput) bool { return true }).Description())
+	assert.Equal(t, "", sdk.NeedsAuthentication(nil).Description())
+}
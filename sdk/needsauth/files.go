@@ -0,0 +1,74 @@
+package needsauth
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"gopkg.in/yaml.v3"
+)
+
+// NotWhenFileExists returns a NeedsAuthentication rule to opt out of authentication when the
+// file at the specified path exists, for CLIs that should keep using the authentication that the
+// user configured for them, e.g. after running `doctl auth init`, and only fall back to 1Password
+// if there is none. Paths starting with "~/" are relative to the home directory, other relative
+// paths to the user's config directory, e.g. "doctl/config.yaml" for
+// ~/Library/Application Support/doctl/config.yaml on macOS. See
+// NeedsAuthenticationInput.FromConfigDir.
+//
+// The file is checked every time the rule gets evaluated. If it can't be checked for any other
+// reason than that it doesn't exist, e.g. because of its permissions, it's treated as if it
+// exists, so that the configuration of the user doesn't get overridden.
+func NotWhenFileExists(path string) sdk.NeedsAuthentication {
+	return describe(describeCall("NotWhenFileExists", quote(path)), func(in sdk.NeedsAuthenticationInput) bool {
+		_, err := os.Stat(resolvePath(in, path))
+		return errors.Is(err, fs.ErrNotExist)
+	})
+}
+
+// NotWhenFileHasKey returns a NeedsAuthentication rule to opt out of authentication when the
+// file at the specified path exists and has a non-empty value at the specified key, e.g.
+// "access-token", or "auth-contexts.default" for nested keys. The path is resolved like in
+// NotWhenFileExists. The file is parsed as YAML, which also covers JSON files.
+//
+// Like in NotWhenFileExists, a file that can't be read for any other reason than that it doesn't
+// exist is treated as if it has the key. A file that can't be parsed is treated as if it doesn't
+// have the key, since the CLI can't use it either.
+func NotWhenFileHasKey(path string, key string) sdk.NeedsAuthentication {
+	return describe(describeCall("NotWhenFileHasKey", quote(path), quote(key)), func(in sdk.NeedsAuthenticationInput) bool {
+		contents, err := os.ReadFile(resolvePath(in, path))
+		if errors.Is(err, fs.ErrNotExist) {
+			return true
+		} else if err != nil {
+			return false
+		}
+
+		var document any
+		if err := yaml.Unmarshal(contents, &document); err != nil {
+			return true
+		}
+		for _, segment := range strings.Split(key, ".") {
+			object, ok := document.(map[string]any)
+			if !ok {
+				return true
+			}
+			document = object[segment]
+		}
+		return document == nil || document == ""
+	})
+}
+
+// resolvePath resolves paths starting with "~/" relative to the home directory and other relative
+// paths relative to the config directory.
+func resolvePath(in sdk.NeedsAuthenticationInput, path string) string {
+	if strings.HasPrefix(path, "~/") {
+		return in.FromHomeDir(strings.TrimPrefix(path, "~/"))
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return in.FromConfigDir(path)
+}
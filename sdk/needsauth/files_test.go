@@ -0,0 +1,145 @@
+package needsauth
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotWhenFileExists(t *testing.T) {
+	plugintest.TestNeedsAuth(t, NotWhenFileExists("~/.tugboat.yml"), map[string]plugintest.NeedsAuthCase{
+		"no when the file exists": {
+			Args: []string{"droplets"},
+			Files: map[string]string{
+				"~/.tugboat.yml": "authentication:\n  access_token: abc\n",
+			},
+			ExpectedNeedsAuth: false,
+		},
+		"no when the file is empty": {
+			Args: []string{"droplets"},
+			Files: map[string]string{
+				"~/.tugboat.yml": "",
+			},
+			ExpectedNeedsAuth: false,
+		},
+		"yes when the file doesn't exist": {
+			Args: []string{"droplets"},
+			Files: map[string]string{
+				"~/.tugboat.yml.bak": "authentication:\n  access_token: abc\n",
+			},
+			ExpectedNeedsAuth: true,
+		},
+		"yes without files": {
+			Args:              []string{"droplets"},
+			ExpectedNeedsAuth: true,
+		},
+	})
+}
+
+func TestNotWhenFileExistsInConfigDir(t *testing.T) {
+	configDir := "~/.config"
+	switch runtime.GOOS {
+	case "darwin":
+		configDir = "~/Library/Application Support"
+	case "windows":
+		t.Setenv("AppData", "")
+		configDir = "~/AppData/Roaming"
+	}
+
+	plugintest.TestNeedsAuth(t, NotWhenFileExists("doctl/config.yaml"), map[string]plugintest.NeedsAuthCase{
+		"no when the file exists": {
+			Args: []string{"compute", "droplet", "list"},
+			Files: map[string]string{
+				configDir + "/doctl/config.yaml": "access-token: dop_v1_abc\n",
+			},
+			ExpectedNeedsAuth: false,
+		},
+		"yes when the file is in the home directory": {
+			Args: []string{"compute", "droplet", "list"},
+			Files: map[string]string{
+				"~/doctl/config.yaml": "access-token: dop_v1_abc\n",
+			},
+			ExpectedNeedsAuth: true,
+		},
+	})
+}
+
+func TestNotWhenFileExistsUnreadable(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("file permissions are not enforced")
+	}
+
+	homeDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(homeDir, ".tool"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(homeDir, ".tool", "config.yml"), []byte("token: abc"), 0600))
+	require.NoError(t, os.Chmod(filepath.Join(homeDir, ".tool"), 0000))
+	t.Cleanup(func() { _ = os.Chmod(filepath.Join(homeDir, ".tool"), 0700) })
+
+	in := sdk.NeedsAuthenticationInput{CommandArgs: []string{"deploy"}, HomeDir: homeDir}
+	assert.False(t, NotWhenFileExists("~/.tool/config.yml")(in))
+	assert.False(t, NotWhenFileHasKey("~/.tool/config.yml", "token")(in))
+}
+
+func TestNotWhenFileHasKey(t *testing.T) {
+	plugintest.TestNeedsAuth(t, NotWhenFileHasKey("~/.tool/config.yml", "auth.token"), map[string]plugintest.NeedsAuthCase{
+		"no when the file has the key": {
+			Args: []string{"deploy"},
+			Files: map[string]string{
+				"~/.tool/config.yml": "auth:\n  token: abc\n",
+			},
+			ExpectedNeedsAuth: false,
+		},
+		"no when the JSON file has the key": {
+			Args: []string{"deploy"},
+			Files: map[string]string{
+				"~/.tool/config.yml": `{"auth": {"token": "abc"}}`,
+			},
+			ExpectedNeedsAuth: false,
+		},
+		"yes when the key is empty": {
+			Args: []string{"deploy"},
+			Files: map[string]string{
+				"~/.tool/config.yml": "auth:\n  token: \"\"\n",
+			},
+			ExpectedNeedsAuth: true,
+		},
+		"yes when the key has no value": {
+			Args: []string{"deploy"},
+			Files: map[string]string{
+				"~/.tool/config.yml": "auth:\n  token:\n",
+			},
+			ExpectedNeedsAuth: true,
+		},
+		"yes when only the parent key exists": {
+			Args: []string{"deploy"},
+			Files: map[string]string{
+				"~/.tool/config.yml": "auth: abc\n",
+			},
+			ExpectedNeedsAuth: true,
+		},
+		"yes when the file doesn't have the key": {
+			Args: []string{"deploy"},
+			Files: map[string]string{
+				"~/.tool/config.yml": "output: json\n",
+			},
+			ExpectedNeedsAuth: true,
+		},
+		"yes when the file can't be parsed": {
+			Args: []string{"deploy"},
+			Files: map[string]string{
+				"~/.tool/config.yml": "auth: [token\n",
+			},
+			ExpectedNeedsAuth: true,
+		},
+		"yes when the file doesn't exist": {
+			Args:              []string{"deploy"},
+			ExpectedNeedsAuth: true,
+		},
+	})
+}
@@ -0,0 +1,95 @@
+package needsauth
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// NotWhenFlagEquals returns a NeedsAuthentication rule to opt out of authentication when the
+// value of the specified flag is one of the specified values, e.g. for a profile that doesn't
+// need the credentials of the plugin: NotWhenFlagEquals("--profile", "localstack"). See
+// FlagValue for how the value of the flag is found.
+func NotWhenFlagEquals(flag string, values ...string) sdk.NeedsAuthentication {
+	return describe(describeCall("NotWhenFlagEquals", append([]string{quote(flag)}, quoteAll(values)...)...), func(in sdk.NeedsAuthenticationInput) bool {
+		value, ok := FlagValue(in.CommandArgs, flag)
+		return !ok || !containsString(values, value)
+	})
+}
+
+// WhenFlagMatches returns a NeedsAuthentication rule that only opts in to the authentication
+// requirement when the value of the specified flag matches the regular expression, e.g.
+// Not(WhenFlagMatches("--endpoint-url", `^https?://(localhost|127\.0\.0\.1)([:/]|$)`)) to skip
+// authentication for local endpoints. See FlagValue for how the value of the flag is found.
+//
+// An expression that doesn't compile is reported when the plugin gets validated. Until it's
+// fixed, the rule always opts in to the authentication requirement.
+func WhenFlagMatches(flag string, expr string) sdk.NeedsAuthentication {
+	re, err := regexp.Compile(expr)
+	return describe(describeCall("WhenFlagMatches", quote(flag), quote(expr)), func(in sdk.NeedsAuthenticationInput) bool {
+		if err != nil {
+			in.ReportError(fmt.Errorf("invalid regular expression in needsauth.WhenFlagMatches: %w", err))
+			return true
+		}
+		value, ok := FlagValue(in.CommandArgs, flag)
+		return ok && re.MatchString(value)
+	})
+}
+
+// offlineFlags are the flags that CLIs commonly use to run without contacting their API, so
+// without needing credentials.
+var offlineFlags = []string{"--offline", "--dry-run", "--dryrun", "--local", "--no-network"}
+
+// NotForOfflineFlags returns a NeedsAuthentication rule to opt out of authentication when one of
+// the flags that CLIs commonly use to run offline is passed: --offline, --dry-run, --dryrun,
+// --local or --no-network. Additional flags of the CLI can be passed to extend the list, e.g.
+// NotForOfflineFlags("--air-gapped").
+//
+// Flags only match by their full name, so --local-file or --local-port=8080 don't match --local.
+// A flag with a value only matches if the value is true, like in --offline=true, so that
+// --dry-run=false or --dry-run=server, which do contact the API, require authentication. Args
+// after "--" are not considered.
+func NotForOfflineFlags(flags ...string) sdk.NeedsAuthentication {
+	allFlags := append(append([]string{}, offlineFlags...), flags...)
+	return describe(describeCall("NotForOfflineFlags", quoteAll(flags)...), func(in sdk.NeedsAuthenticationInput) bool {
+		for _, arg := range argsBeforeEndOfOptions(in.CommandArgs) {
+			flag, value, hasValue := strings.Cut(arg, "=")
+			if !containsString(allFlags, flag) {
+				continue
+			}
+			if enabled, err := strconv.ParseBool(value); !hasValue || (err == nil && enabled) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// FlagValue returns the value of the flag in the command-line args. The flag is specified with
+// its dashes, e.g. "--profile", "-p" or "-var". The value can be passed as the next arg, e.g.
+// `--profile work`, or after an equals sign, e.g. `--profile=work`. The value of a short flag
+// like "-p" can also directly follow the flag, e.g. `-pwork`. If the flag is passed multiple
+// times, the last value is returned, like most CLIs do. Args after "--" are not considered.
+func FlagValue(args []string, flag string) (value string, ok bool) {
+	args = argsBeforeEndOfOptions(args)
+	isShortFlag := len(flag) == 2 && flag[0] == '-' && flag[1] != '-'
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == flag:
+			if i+1 < len(args) {
+				i++
+				value, ok = args[i], true
+			}
+		case strings.HasPrefix(arg, flag+"="):
+			value, ok = strings.TrimPrefix(arg, flag+"="), true
+		case isShortFlag && strings.HasPrefix(arg, flag):
+			value, ok = strings.TrimPrefix(arg, flag), true
+		}
+	}
+	return value, ok
+}
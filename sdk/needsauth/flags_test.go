@@ -0,0 +1,189 @@
+package needsauth
+
+import (
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagValue(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		args        []string
+		flag        string
+		value       string
+		ok          bool
+	}{
+		{description: "separate arg", args: []string{"s3", "ls", "--profile", "work"}, flag: "--profile", value: "work", ok: true},
+		{description: "equals sign", args: []string{"s3", "ls", "--profile=work"}, flag: "--profile", value: "work", ok: true},
+		{description: "equals sign with empty value", args: []string{"--profile="}, flag: "--profile", value: "", ok: true},
+		{description: "equals sign in value", args: []string{"--tag", "env=prod"}, flag: "--tag", value: "env=prod", ok: true},
+		{description: "short flag", args: []string{"get", "pods", "-n", "kube-system"}, flag: "-n", value: "kube-system", ok: true},
+		{description: "short flag with equals sign", args: []string{"get", "pods", "-n=kube-system"}, flag: "-n", value: "kube-system", ok: true},
+		{description: "short flag with attached value", args: []string{"get", "pods", "-nkube-system"}, flag: "-n", value: "kube-system", ok: true},
+		{description: "single dash long flag", args: []string{"plan", "-var", "region=eu-west-1"}, flag: "-var", value: "region=eu-west-1", ok: true},
+		{description: "single dash long flag isn't matched by prefix", args: []string{"plan", "-var-file", "prod.tfvars"}, flag: "-var"},
+		{description: "long flag isn't matched by prefix", args: []string{"--profile-name", "work"}, flag: "--profile"},
+		{description: "last occurrence wins", args: []string{"--profile", "work", "s3", "ls", "--profile=localstack"}, flag: "--profile", value: "localstack", ok: true},
+		{description: "last occurrence without value is ignored", args: []string{"--profile", "work", "--profile"}, flag: "--profile", value: "work", ok: true},
+		{description: "missing value", args: []string{"s3", "ls", "--profile"}, flag: "--profile"},
+		{description: "not present", args: []string{"s3", "ls"}, flag: "--profile"},
+		{description: "after end of options", args: []string{"exec", "my-pod", "--", "tool", "--profile", "work"}, flag: "--profile"},
+		{description: "value looks like a flag", args: []string{"--message", "--profile"}, flag: "--message", value: "--profile", ok: true},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			value, ok := FlagValue(tc.args, tc.flag)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.value, value)
+		})
+	}
+}
+
+func TestNotWhenFlagEquals(t *testing.T) {
+	plugintest.TestNeedsAuth(t, NotWhenFlagEquals("--profile", "localstack", "minio"), map[string]plugintest.NeedsAuthCase{
+		"yes without profile": {
+			Args:              []string{"s3", "ls"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for other profile": {
+			Args:              []string{"s3", "ls", "--profile", "work"},
+			ExpectedNeedsAuth: true,
+		},
+		"no for profile": {
+			Args:              []string{"s3", "ls", "--profile", "localstack"},
+			ExpectedNeedsAuth: false,
+		},
+		"no for other listed profile with equals sign": {
+			Args:              []string{"--profile=minio", "s3", "ls"},
+			ExpectedNeedsAuth: false,
+		},
+		"yes when overridden by a later profile": {
+			Args:              []string{"--profile", "localstack", "s3", "ls", "--profile", "work"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for profile after end of options": {
+			Args:              []string{"ecs", "execute-command", "--command", "sh", "--", "--profile", "localstack"},
+			ExpectedNeedsAuth: true,
+		},
+	})
+}
+
+func TestWhenFlagMatches(t *testing.T) {
+	needsAuth := Not(WhenFlagMatches("--endpoint-url", `^https?://(localhost|127\.0\.0\.1)([:/]|$)`))
+
+	plugintest.TestNeedsAuth(t, needsAuth, map[string]plugintest.NeedsAuthCase{
+		"yes without endpoint": {
+			Args:              []string{"s3", "ls"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for remote endpoint": {
+			Args:              []string{"s3", "ls", "--endpoint-url", "https://s3.eu-west-1.amazonaws.com"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for remote endpoint that starts like a local one": {
+			Args:              []string{"s3", "ls", "--endpoint-url", "http://localhost.example.com"},
+			ExpectedNeedsAuth: true,
+		},
+		"no for local endpoint": {
+			Args:              []string{"s3", "ls", "--endpoint-url", "http://localhost:4566"},
+			ExpectedNeedsAuth: false,
+		},
+		"no for local endpoint with equals sign": {
+			Args:              []string{"--endpoint-url=http://127.0.0.1:9000/", "s3", "ls"},
+			ExpectedNeedsAuth: false,
+		},
+	})
+}
+
+func TestWhenFlagMatchesInvalidRegexp(t *testing.T) {
+	rule := WhenFlagMatches("--endpoint-url", `(`)
+	assert.ErrorContains(t, rule.Validate(), "invalid regular expression in needsauth.WhenFlagMatches")
+	assert.ErrorContains(t, Not(rule).Validate(), "invalid regular expression in needsauth.WhenFlagMatches")
+}
+
+func TestNotForOfflineFlags(t *testing.T) {
+	plugintest.TestNeedsAuth(t, NotForOfflineFlags(), map[string]plugintest.NeedsAuthCase{
+		"no for offline flag": {
+			Args:              []string{"install", "--offline"},
+			ExpectedNeedsAuth: false,
+		},
+		"no for dry run flag before subcommand": {
+			Args:              []string{"--dry-run", "deploy", "production"},
+			ExpectedNeedsAuth: false,
+		},
+		"no for dry run flag without dash": {
+			Args:              []string{"publish", "--dryrun"},
+			ExpectedNeedsAuth: false,
+		},
+		"no for local flag": {
+			Args:              []string{"functions", "invoke", "--local"},
+			ExpectedNeedsAuth: false,
+		},
+		"no for no network flag": {
+			Args:              []string{"build", "--no-network"},
+			ExpectedNeedsAuth: false,
+		},
+		"no for flag set to true": {
+			Args:              []string{"deploy", "--dry-run=true"},
+			ExpectedNeedsAuth: false,
+		},
+		"yes for flag set to false": {
+			Args:              []string{"deploy", "--dry-run=false"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for flag with other value": {
+			Args:              []string{"apply", "-f", "pod.yaml", "--dry-run=server"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for flag with the same prefix": {
+			Args:              []string{"serve", "--local-port=8080"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for flag with the same prefix that takes a value": {
+			Args:              []string{"import", "--local-file", "data.json"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for flag with a single dash": {
+			Args:              []string{"deploy", "-local"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for flag as value": {
+			Args:              []string{"commit", "-m", "works --offline"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for flag after end of options": {
+			Args:              []string{"exec", "--", "npm", "install", "--offline"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes without flags": {
+			Args:              []string{"deploy", "production"},
+			ExpectedNeedsAuth: true,
+		},
+	})
+}
+
+func TestNotForOfflineFlagsWithAdditionalFlags(t *testing.T) {
+	rule := NotForOfflineFlags("--air-gapped", "--skip-remote")
+
+	plugintest.TestNeedsAuth(t, rule, map[string]plugintest.NeedsAuthCase{
+		"no for additional flag": {
+			Args:              []string{"scan", "--air-gapped"},
+			ExpectedNeedsAuth: false,
+		},
+		"no for other additional flag": {
+			Args:              []string{"scan", "--skip-remote=1"},
+			ExpectedNeedsAuth: false,
+		},
+		"no for default flag": {
+			Args:              []string{"scan", "--offline"},
+			ExpectedNeedsAuth: false,
+		},
+		"yes without flags": {
+			Args:              []string{"scan"},
+			ExpectedNeedsAuth: true,
+		},
+	})
+	assert.Equal(t, `NotForOfflineFlags("--air-gapped", "--skip-remote")`, rule.Explain([]string{"scan"})[0].Rule)
+	assert.Equal(t, `NotForOfflineFlags()`, NotForOfflineFlags().Explain([]string{"scan"})[0].Rule)
+}
@@ -1,39 +1,93 @@
+// Package needsauth provides rules that decide whether a command needs authentication, see
+// sdk.NeedsAuthentication.
+//
+// Rules get evaluated in a deterministic order, so that a command line always gets the same
+// outcome and the same explanation from sdk.NeedsAuthentication.Explain: All and Any evaluate
+// their rules from first to last and stop as soon as the outcome is decided, and no rule
+// evaluates the rules passed to it more than once.
 package needsauth
 
 import (
+	"strings"
+
 	"github.com/1Password/shell-plugins/sdk"
 )
 
-// IfAll returns a NeedsAuthentication that opts in to the authentication requirement only if
-// all the specified rules opt in to the authentication requirement.
-func IfAll(rules ...sdk.NeedsAuthentication) sdk.NeedsAuthentication {
-	return func(in sdk.NeedsAuthenticationInput) bool {
+// All returns a NeedsAuthentication rule that only opts in to the authentication requirement if
+// all the specified rules opt in to the authentication requirement. The rules are evaluated in
+// order, and evaluation stops at the first rule that opts out. Without rules, All opts in.
+func All(rules ...sdk.NeedsAuthentication) sdk.NeedsAuthentication {
+	return describe("All", func(in sdk.NeedsAuthenticationInput) bool {
+		if in.IsValidating() {
+			return validateAll(in, rules)
+		}
 		for _, rule := range rules {
 			if !rule(in) {
 				return false
 			}
 		}
 		return true
-	}
+	})
 }
 
-// IfAny returns a NeedsAuthentication rule that only opts in to the authentication requirement
-// if at least one specified rule opts in to the authentication requirement.
-func IfAny(rules ...sdk.NeedsAuthentication) sdk.NeedsAuthentication {
-	return func(in sdk.NeedsAuthenticationInput) bool {
+// Any returns a NeedsAuthentication rule that only opts in to the authentication requirement if
+// at least one of the specified rules opts in to the authentication requirement. The rules are
+// evaluated in order, and evaluation stops at the first rule that opts in. Without rules, Any
+// opts out.
+func Any(rules ...sdk.NeedsAuthentication) sdk.NeedsAuthentication {
+	return describe("Any", func(in sdk.NeedsAuthenticationInput) bool {
+		if in.IsValidating() {
+			return validateAll(in, rules)
+		}
 		for _, rule := range rules {
 			if rule(in) {
 				return true
 			}
 		}
 		return false
+	})
+}
+
+// Not returns a NeedsAuthentication rule that opts in to the authentication requirement if the
+// specified rule opts out, and the other way around. Together with All and Any, this can express
+// any combination of rules, e.g. "not for help, or for configure without --token-stdin":
+//
+//	Not(Any(
+//		Not(NotForHelp()),
+//		All(ForCommand("configure"), NotWhenContainsArgs("--token-stdin")),
+//	))
+func Not(rule sdk.NeedsAuthentication) sdk.NeedsAuthentication {
+	return describe("Not", func(in sdk.NeedsAuthenticationInput) bool {
+		if in.IsValidating() {
+			return validateAll(in, []sdk.NeedsAuthentication{rule})
+		}
+		return !rule(in)
+	})
+}
+
+// IfAll is the same as All.
+func IfAll(rules ...sdk.NeedsAuthentication) sdk.NeedsAuthentication {
+	return All(rules...)
+}
+
+// IfAny is the same as Any.
+func IfAny(rules ...sdk.NeedsAuthentication) sdk.NeedsAuthentication {
+	return Any(rules...)
+}
+
+// validateAll calls all rules with the validation input, so that each of them can report its
+// configuration errors.
+func validateAll(in sdk.NeedsAuthenticationInput, rules []sdk.NeedsAuthentication) bool {
+	for _, rule := range rules {
+		rule(in)
 	}
+	return true
 }
 
 // ForCommand returns a NeedsAuthentication rule to require authentication for
 // certain (sub)command, e.g. ["account"] or ["account", "list"].
 func ForCommand(command ...string) sdk.NeedsAuthentication {
-	return func(in sdk.NeedsAuthenticationInput) bool {
+	return describe(describeCall("ForCommand", quoteAll(command)...), func(in sdk.NeedsAuthenticationInput) bool {
 		if len(command) > len(in.CommandArgs) {
 			return false
 		}
@@ -48,20 +102,89 @@ func ForCommand(command ...string) sdk.NeedsAuthentication {
 		}
 
 		return false
+	})
+}
+
+// NotForCommand returns a NeedsAuthentication rule to opt out of authentication for
+// certain (sub)command, e.g. ["configure"] or ["auth", "login"]. Like ForCommand, only the
+// leading command-line args are matched, so an arg that happens to have the same value further
+// down the command line, like the message in `commit -m login`, doesn't count.
+func NotForCommand(command ...string) sdk.NeedsAuthentication {
+	forCommand := ForCommand(command...)
+	return describe(describeCall("NotForCommand", quoteAll(command)...), func(in sdk.NeedsAuthenticationInput) bool {
+		return !forCommand(in)
+	})
+}
+
+// OnlyForCommands returns a NeedsAuthentication rule to require authentication only for the
+// specified (sub)commands, e.g. "publish" or "release publish", for CLIs of which only a few
+// commands need to be authenticated. Each command is matched against the leading args that are
+// not flags, so flags before or in between subcommands, like in `--verbose publish`, are skipped.
+// Flag values can't be told apart from subcommands, so `--profile work publish` doesn't match.
+func OnlyForCommands(commands ...string) sdk.NeedsAuthentication {
+	return describe(describeCall("OnlyForCommands", quoteAll(commands)...), func(in sdk.NeedsAuthenticationInput) bool {
+		positionalArgs := nonFlagArgs(in.CommandArgs)
+		for _, command := range commands {
+			if ForCommand(strings.Fields(command)...)(sdk.NeedsAuthenticationInput{CommandArgs: positionalArgs}) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// NotWhenEnvVarsSet returns a NeedsAuthentication rule to opt out of authentication when all of
+// the specified environment variables are already set to a non-empty value, e.g. when the user
+// exported credentials themselves in CI or in a subshell with an assumed role, so that these
+// don't get overwritten.
+func NotWhenEnvVarsSet(names ...string) sdk.NeedsAuthentication {
+	return describe(describeCall("NotWhenEnvVarsSet", quoteAll(names)...), func(in sdk.NeedsAuthenticationInput) bool {
+		if len(names) == 0 {
+			return true
+		}
+		for _, name := range names {
+			if !in.HasParentEnvVar(name) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// NotWhenAnyEnvVarSet returns a NeedsAuthentication rule to opt out of authentication when at
+// least one of the specified environment variables is already set to a non-empty value, e.g. for
+// CLIs that accept a token in either of multiple environment variables.
+func NotWhenAnyEnvVarSet(names ...string) sdk.NeedsAuthentication {
+	return describe(describeCall("NotWhenAnyEnvVarSet", quoteAll(names)...), func(in sdk.NeedsAuthenticationInput) bool {
+		for _, name := range names {
+			if in.HasParentEnvVar(name) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
 	}
+	return false
 }
 
 // Always returns a NeedsAuthentication rule to always require authentication.
 func Always() sdk.NeedsAuthentication {
-	return func(in sdk.NeedsAuthenticationInput) bool {
+	return describe(describeCall("Always"), func(in sdk.NeedsAuthenticationInput) bool {
 		return true
-	}
+	})
 }
 
 // NotForExactArgs returns a NeedsAuthentication rule to opt out of authentication when
 // the command-line args are an exact match with the passed in args.
 func NotForExactArgs(argsToSkip ...string) sdk.NeedsAuthentication {
-	return func(in sdk.NeedsAuthenticationInput) bool {
+	return describe(describeCall("NotForExactArgs", quoteAll(argsToSkip)...), func(in sdk.NeedsAuthenticationInput) bool {
 		if len(in.CommandArgs) != len(argsToSkip) {
 			return true
 		}
@@ -73,28 +196,32 @@ func NotForExactArgs(argsToSkip ...string) sdk.NeedsAuthentication {
 		}
 
 		return false
-	}
+	})
 }
 
 // NotWhenContainsArgs returns a NeedsAuthentication rule to not require authentication when
-// the exact sequence of argsToSkip is present somewhere in the command-line args.
+// the exact sequence of argsToSkip is present somewhere in the command-line args. Args after
+// "--" are not matched, since they're passed on to another command, e.g. in
+// `kubectl exec my-pod -- sh --help`. Other args are matched regardless of their position, so
+// flag values match as well. Use NotForCommand to match subcommands instead.
 func NotWhenContainsArgs(argsSequence ...string) sdk.NeedsAuthentication {
-	return func(in sdk.NeedsAuthenticationInput) bool {
+	return describe(describeCall("NotWhenContainsArgs", quoteAll(argsSequence)...), func(in sdk.NeedsAuthenticationInput) bool {
 		if len(argsSequence) == 0 {
 			return true
 		}
 
-		if len(argsSequence) > len(in.CommandArgs) {
+		args := argsBeforeEndOfOptions(in.CommandArgs)
+		if len(argsSequence) > len(args) {
 			return true
 		}
 
-		for i := range in.CommandArgs {
-			if i+len(argsSequence) > len(in.CommandArgs) {
+		for i := range args {
+			if i+len(argsSequence) > len(args) {
 				return true
 			}
 
 			matches := true
-			for i, argsToCompare := range in.CommandArgs[i : i+len(argsSequence)] {
+			for i, argsToCompare := range args[i : i+len(argsSequence)] {
 				if argsToCompare != argsSequence[i] {
 					matches = false
 				}
@@ -107,32 +234,164 @@ func NotWhenContainsArgs(argsSequence ...string) sdk.NeedsAuthentication {
 			}
 		}
 		return true
+	})
+}
+
+// helpFlags are the flags that CLIs commonly use to print help.
+var helpFlags = []string{"-h", "--help", "-help"}
+
+// defaultVersionFlags are the flags that CLIs commonly use to print their version.
+var defaultVersionFlags = []string{"-v", "--version", "-version", "-V"}
+
+// NotForHelp returns a NeedsAuthentication rule to opt out of authentication when help is
+// requested, which is the case if the command-line args contain:
+//   - one of the flags -h, --help or -help, also after subcommands, e.g. `kubectl get --help` or
+//     `aws s3 ls --recursive --help`, unless it directly follows a flag that's declared to take a
+//     value with FlagsWithValues, e.g. `git commit --message -h`;
+//   - "help" as the first arg, e.g. `kubectl help get`, or as the last arg, e.g. `aws s3 help`,
+//     unless it directly follows another flag, since it's most likely the value of that flag then.
+//
+// Args after "--" are not considered, since they're passed on to another command.
+func NotForHelp(opts ...HelpOrVersionOption) sdk.NeedsAuthentication {
+	rule := newHelpOrVersionRule(opts)
+	return describe(describeCall("NotForHelp", rule.describeHelpOptions()...), rule.notForHelp)
+}
+
+func (r helpOrVersionRule) notForHelp(in sdk.NeedsAuthenticationInput) bool {
+	args := argsBeforeEndOfOptions(in.CommandArgs)
+	for i, arg := range args {
+		if containsString(helpFlags, arg) {
+			if i > 0 && containsString(r.flagsWithValues, args[i-1]) {
+				continue
+			}
+			return false
+		}
+		if arg == "help" && (i == 0 || i == len(args)-1) {
+			if i > 0 && isFlagWithoutValue(args[i-1]) {
+				continue
+			}
+			return false
+		}
+	}
+	return true
+}
+
+type helpOrVersionRule struct {
+	versionFlags    []string
+	flagsWithValues []string
+}
+
+func newHelpOrVersionRule(opts []HelpOrVersionOption) helpOrVersionRule {
+	rule := helpOrVersionRule{
+		versionFlags: defaultVersionFlags,
+	}
+	for _, opt := range opts {
+		opt(&rule)
+	}
+	return rule
+}
+
+// describeVersionOptions describes the options that affect NotForVersion, leaving out the
+// default version flags.
+func (r helpOrVersionRule) describeVersionOptions() []string {
+	if strings.Join(r.versionFlags, " ") == strings.Join(defaultVersionFlags, " ") {
+		return nil
 	}
+	return []string{describeCall("VersionFlags", quoteAll(r.versionFlags)...)}
 }
 
-func NotForHelp() sdk.NeedsAuthentication {
-	return IfAll(
-		NotWhenContainsArgs("-h"),
-		NotWhenContainsArgs("--help"),
-		NotWhenContainsArgs("-help"),
-		NotWhenContainsArgs("help"),
-	)
+// describeHelpOptions describes the options that affect NotForHelp.
+func (r helpOrVersionRule) describeHelpOptions() []string {
+	if len(r.flagsWithValues) == 0 {
+		return nil
+	}
+	return []string{describeCall("FlagsWithValues", quoteAll(r.flagsWithValues)...)}
+}
+
+// HelpOrVersionOption can be used to configure NotForHelp, NotForVersion and NotForHelpOrVersion.
+type HelpOrVersionOption func(*helpOrVersionRule)
+
+// VersionFlags sets the flags that print the version of the CLI. Defaults to -v, --version,
+// -version and -V. This can be used to leave out -v for CLIs that use it for verbose output.
+func VersionFlags(flags ...string) HelpOrVersionOption {
+	return func(r *helpOrVersionRule) {
+		r.versionFlags = flags
+	}
 }
 
-func NotForVersion() sdk.NeedsAuthentication {
-	return IfAll(
-		NotForExactArgs("-v"),
-		NotForExactArgs("--version"),
-		NotForExactArgs("-version"),
-		NotForExactArgs("version"),
-		NotForExactArgs("-V"),
-	)
+// FlagsWithValues sets the flags of the CLI that take the next arg as their value, e.g. --message
+// or -m. A help flag that directly follows one of them is treated as its value instead of a
+// request for help, like in `git commit --message -h`.
+func FlagsWithValues(flags ...string) HelpOrVersionOption {
+	return func(r *helpOrVersionRule) {
+		r.flagsWithValues = flags
+	}
+}
+
+// NotForVersion returns a NeedsAuthentication rule to opt out of authentication when the version
+// of the CLI is requested: if one of the version flags is the only arg, e.g. `gh --version`, or
+// if the first arg is "version", e.g. `kubectl version --client`. Version flags after subcommands
+// are not matched, since those are often used to pass a version, e.g. `deploy --version 1.0.0`.
+func NotForVersion(opts ...HelpOrVersionOption) sdk.NeedsAuthentication {
+	rule := newHelpOrVersionRule(opts)
+	return describe(describeCall("NotForVersion", rule.describeVersionOptions()...), func(in sdk.NeedsAuthenticationInput) bool {
+		args := in.CommandArgs
+		if len(args) == 1 && containsString(rule.versionFlags, args[0]) {
+			return false
+		}
+		return len(args) == 0 || args[0] != "version"
+	})
 }
 
 func NotWithoutArgs() sdk.NeedsAuthentication {
-	return NotForExactArgs()
+	return describe(describeCall("NotWithoutArgs"), func(in sdk.NeedsAuthenticationInput) bool {
+		return len(in.CommandArgs) > 0
+	})
+}
+
+// NotForHelpOrVersion combines NotForHelp and NotForVersion, which both get configured with the
+// specified options.
+func NotForHelpOrVersion(opts ...HelpOrVersionOption) sdk.NeedsAuthentication {
+	notForHelp := NotForHelp(opts...)
+	notForVersion := NotForVersion(opts...)
+	rule := newHelpOrVersionRule(opts)
+	description := describeCall("NotForHelpOrVersion", append(rule.describeVersionOptions(), rule.describeHelpOptions()...)...)
+	return describe(description, func(in sdk.NeedsAuthenticationInput) bool {
+		return notForHelp(in) && notForVersion(in)
+	})
+}
+
+// argsBeforeEndOfOptions returns the args before "--", which marks the end of the options of the
+// command. The args after it are passed on to another command, e.g. in
+// `kubectl exec my-pod -- sh --help`.
+func argsBeforeEndOfOptions(args []string) []string {
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i]
+		}
+	}
+	return args
 }
 
-func NotForHelpOrVersion() sdk.NeedsAuthentication {
-	return IfAll(NotForHelp(), NotForVersion())
+// nonFlagArgs returns the args before "--" that are not flags. Flag values can't be told apart
+// from subcommands, so these are included.
+func nonFlagArgs(args []string) []string {
+	var nonFlags []string
+	for _, arg := range argsBeforeEndOfOptions(args) {
+		if strings.HasPrefix(arg, "-") && arg != "-" {
+			continue
+		}
+		nonFlags = append(nonFlags, arg)
+	}
+	return nonFlags
+}
+
+// isFlagWithoutValue returns whether the arg is a flag that doesn't include its value, like
+// --message or -m, as opposed to --message=hello. The arg that follows it could be its value.
+// Help and version flags never take a value.
+func isFlagWithoutValue(arg string) bool {
+	if !strings.HasPrefix(arg, "-") || arg == "-" || strings.Contains(arg, "=") {
+		return false
+	}
+	return !containsString(helpFlags, arg) && !containsString(defaultVersionFlags, arg)
 }
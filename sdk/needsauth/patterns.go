@@ -0,0 +1,159 @@
+package needsauth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// CommandPattern describes a branch of the subcommand tree of a CLI, and whether commands in it
+// need authentication. See CommandPatterns.
+type CommandPattern struct {
+	pattern   string
+	tokens    []string
+	needsAuth bool
+}
+
+// ForCommandPattern returns a CommandPattern for commands that need authentication, e.g.
+// "compute **". See CommandPatterns for the syntax.
+func ForCommandPattern(pattern string) CommandPattern {
+	return CommandPattern{pattern: pattern, tokens: strings.Fields(pattern), needsAuth: true}
+}
+
+// NotForCommandPattern returns a CommandPattern for commands that don't need authentication,
+// e.g. "auth **". See CommandPatterns for the syntax.
+func NotForCommandPattern(pattern string) CommandPattern {
+	return CommandPattern{pattern: pattern, tokens: strings.Fields(pattern), needsAuth: false}
+}
+
+// CommandPatterns returns a NeedsAuthentication rule for CLIs with deep subcommand trees, in
+// which branches differ in whether they need authentication, e.g.:
+//
+//	CommandPatterns(
+//		NotForCommandPattern("auth **"),
+//		NotForCommandPattern("config **"),
+//		ForCommandPattern("config get account"),
+//	)
+//
+// A pattern consists of space-separated tokens, which are matched against the leading args that
+// are not flags, up to "--". A "*" matches exactly one arg and other tokens match an arg with the
+// same value. Since positional args and the values of flags follow the subcommand, the args after
+// the ones that the tokens match are ignored: "configure *" matches "configure set region
+// us-east-1", but not "configure" alone. A "**", which can only be the last token, also matches if
+// there are no args left, so "auth **" matches both "auth" and "auth login".
+//
+// If multiple patterns match, the most specific one decides: the one with the most tokens that
+// aren't wildcards, then the one with the most "*" tokens, and then one without "**". If a
+// ForCommandPattern and a NotForCommandPattern are equally specific, authentication is required.
+// If no pattern matches, authentication is required as well.
+//
+// Invalid patterns are reported when the plugin gets validated.
+func CommandPatterns(patterns ...CommandPattern) sdk.NeedsAuthentication {
+	return describe(describeCommandPatterns(patterns), func(in sdk.NeedsAuthenticationInput) bool {
+		if in.IsValidating() {
+			for _, pattern := range patterns {
+				if err := pattern.validate(); err != nil {
+					in.ReportError(err)
+				}
+			}
+			return true
+		}
+
+		args := nonFlagArgs(in.CommandArgs)
+		var best *CommandPattern
+		for i, pattern := range patterns {
+			if !pattern.matches(args) {
+				continue
+			}
+			if best == nil {
+				best = &patterns[i]
+				continue
+			}
+			switch compareSpecificity(pattern, *best) {
+			case 1:
+				best = &patterns[i]
+			case 0:
+				if pattern.needsAuth {
+					best = &patterns[i]
+				}
+			}
+		}
+		return best == nil || best.needsAuth
+	})
+}
+
+// validate returns an error if the pattern is empty or has "**" in any other place than at the end.
+func (p CommandPattern) validate() error {
+	if len(p.tokens) == 0 {
+		return fmt.Errorf("invalid command pattern '%s': no tokens", p.pattern)
+	}
+	for i, token := range p.tokens {
+		if token == "**" && i != len(p.tokens)-1 {
+			return fmt.Errorf("invalid command pattern '%s': '**' can only be the last token", p.pattern)
+		}
+	}
+	return nil
+}
+
+// matches returns whether the pattern matches the start of the args. Invalid patterns never match.
+func (p CommandPattern) matches(args []string) bool {
+	if p.validate() != nil {
+		return false
+	}
+	for i, token := range p.tokens {
+		if token == "**" {
+			return true
+		}
+		if i >= len(args) {
+			return false
+		}
+		if token != "*" && token != args[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// specificity returns the number of literal tokens, the number of "*" tokens and whether the
+// pattern ends with "**".
+func (p CommandPattern) specificity() (literals int, wildcards int, open bool) {
+	for _, token := range p.tokens {
+		switch token {
+		case "**":
+			open = true
+		case "*":
+			wildcards++
+		default:
+			literals++
+		}
+	}
+	return literals, wildcards, open
+}
+
+// compareSpecificity returns 1 if a is more specific than b, -1 if it's less specific, and 0 if
+// they're equally specific.
+func compareSpecificity(a CommandPattern, b CommandPattern) int {
+	literalsA, wildcardsA, openA := a.specificity()
+	literalsB, wildcardsB, openB := b.specificity()
+	switch {
+	case literalsA != literalsB:
+		return compareInts(literalsA, literalsB)
+	case wildcardsA != wildcardsB:
+		return compareInts(wildcardsA, wildcardsB)
+	case openA != openB:
+		if openB {
+			return 1
+		}
+		return -1
+	default:
+		return 0
+	}
+}
+
+func compareInts(a int, b int) int {
+	if a > b {
+		return 1
+	}
+	return -1
+}
@@ -0,0 +1,175 @@
+package needsauth
+
+import (
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandPatterns(t *testing.T) {
+	// Example of gcloud, which doesn't need authentication for its auth and config commands,
+	// except for reading the account, and does for all others.
+	needsAuth := CommandPatterns(
+		NotForCommandPattern("auth **"),
+		NotForCommandPattern("config **"),
+		ForCommandPattern("config get account"),
+		ForCommandPattern("compute **"),
+		NotForCommandPattern("compute * list"),
+		ForCommandPattern("compute instances list"),
+	)
+
+	plugintest.TestNeedsAuth(t, needsAuth, map[string]plugintest.NeedsAuthCase{
+		"no for branch": {
+			Args:              []string{"auth", "login"},
+			ExpectedNeedsAuth: false,
+		},
+		"no for root of branch": {
+			Args:              []string{"auth"},
+			ExpectedNeedsAuth: false,
+		},
+		"no for branch with flags": {
+			Args:              []string{"--verbosity=debug", "config", "set", "project", "my-project"},
+			ExpectedNeedsAuth: false,
+		},
+		"yes for more specific command in branch": {
+			Args:              []string{"config", "get", "account"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for more specific command in branch with flags": {
+			Args:              []string{"config", "get", "--quiet", "account"},
+			ExpectedNeedsAuth: true,
+		},
+		"no for other command in branch that starts like the more specific one": {
+			Args:              []string{"config", "get", "project"},
+			ExpectedNeedsAuth: false,
+		},
+		"yes for more specific command with extra args": {
+			Args:              []string{"config", "get", "account", "extra"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for branch that needs authentication": {
+			Args:              []string{"compute", "instances", "create", "my-vm"},
+			ExpectedNeedsAuth: true,
+		},
+		"no for wildcard inside branch that needs authentication": {
+			Args:              []string{"compute", "disks", "list"},
+			ExpectedNeedsAuth: false,
+		},
+		"yes for literal that is more specific than wildcard": {
+			Args:              []string{"compute", "instances", "list"},
+			ExpectedNeedsAuth: true,
+		},
+		"no for wildcard with extra args": {
+			Args:              []string{"compute", "disks", "list", "--zone", "us-east1-b"},
+			ExpectedNeedsAuth: false,
+		},
+		"yes when wildcard doesn't match because of missing args": {
+			Args:              []string{"compute", "disks"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes when no pattern matches": {
+			Args:              []string{"storage", "ls"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes without args": {
+			Args:              []string{},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for branch after end of options": {
+			Args:              []string{"compute", "ssh", "my-vm", "--", "auth", "login"},
+			ExpectedNeedsAuth: true,
+		},
+	})
+}
+
+func TestCommandPatternsPrefix(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		args    []string
+	}{
+		// aws configure set region us-east-1
+		{pattern: "configure *", args: []string{"configure", "set", "region", "us-east-1"}},
+		// gcloud auth login user@x
+		{pattern: "auth *", args: []string{"auth", "login", "user@x"}},
+		// gcloud --quiet auth login --brief user@x
+		{pattern: "auth login", args: []string{"--quiet", "auth", "login", "--brief", "user@x"}},
+	} {
+		t.Run(tc.pattern, func(t *testing.T) {
+			assert.False(t, CommandPatterns(NotForCommandPattern(tc.pattern))(sdk.NeedsAuthenticationInput{CommandArgs: tc.args}))
+		})
+	}
+
+	// A "*" still requires an arg to match.
+	assert.True(t, CommandPatterns(NotForCommandPattern("configure *"))(sdk.NeedsAuthenticationInput{CommandArgs: []string{"configure"}}))
+	assert.False(t, CommandPatterns(NotForCommandPattern("configure **"))(sdk.NeedsAuthenticationInput{CommandArgs: []string{"configure"}}))
+}
+
+func TestCommandPatternsPrecedence(t *testing.T) {
+	for _, tc := range []struct {
+		description string
+		patterns    []CommandPattern
+		args        []string
+		expected    bool
+	}{
+		{
+			description: "more literals win over fewer literals",
+			patterns:    []CommandPattern{NotForCommandPattern("configure **"), ForCommandPattern("configure sso **")},
+			args:        []string{"configure", "sso"},
+			expected:    true,
+		},
+		{
+			description: "more literals win regardless of order",
+			patterns:    []CommandPattern{ForCommandPattern("configure sso **"), NotForCommandPattern("configure **")},
+			args:        []string{"configure", "sso"},
+			expected:    true,
+		},
+		{
+			description: "literals win over wildcards",
+			patterns:    []CommandPattern{ForCommandPattern("s3 *"), NotForCommandPattern("* ls")},
+			args:        []string{"s3", "ls"},
+			expected:    true,
+		},
+		{
+			description: "more single wildcards win over fewer",
+			patterns:    []CommandPattern{ForCommandPattern("s3 **"), NotForCommandPattern("s3 * *")},
+			args:        []string{"s3", "cp", "file.txt"},
+			expected:    false,
+		},
+		{
+			description: "closed patterns win over open patterns",
+			patterns:    []CommandPattern{ForCommandPattern("s3 * **"), NotForCommandPattern("s3 *")},
+			args:        []string{"s3", "ls"},
+			expected:    false,
+		},
+		{
+			description: "authentication is required for equally specific patterns",
+			patterns:    []CommandPattern{NotForCommandPattern("s3 *"), ForCommandPattern("* ls")},
+			args:        []string{"s3", "ls"},
+			expected:    true,
+		},
+		{
+			description: "authentication is required for equally specific patterns regardless of order",
+			patterns:    []CommandPattern{ForCommandPattern("* ls"), NotForCommandPattern("s3 *")},
+			args:        []string{"s3", "ls"},
+			expected:    true,
+		},
+		{
+			description: "double wildcard matches everything",
+			patterns:    []CommandPattern{NotForCommandPattern("**")},
+			args:        []string{"s3", "ls"},
+			expected:    false,
+		},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			assert.Equal(t, tc.expected, CommandPatterns(tc.patterns...)(sdk.NeedsAuthenticationInput{CommandArgs: tc.args}))
+		})
+	}
+}
+
+func TestCommandPatternsInvalid(t *testing.T) {
+	assert.NoError(t, CommandPatterns(NotForCommandPattern("auth **"), ForCommandPattern("* list")).Validate())
+	assert.EqualError(t, CommandPatterns(NotForCommandPattern("auth ** login")).Validate(), "invalid command pattern 'auth ** login': '**' can only be the last token")
+	assert.EqualError(t, All(NotForHelp(), CommandPatterns(ForCommandPattern(" "))).Validate(), "invalid command pattern ' ': no tokens")
+}
@@ -0,0 +1,74 @@
+package needsauth
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// MatchingRegexp returns a NeedsAuthentication rule to only require authentication when the
+// command line matches the regular expression, for decisions that depend on the shape of the
+// args rather than fixed words, e.g. `^s3 ls s3://` or `(^| )--remote( |=|$)`. See QuoteArgs for
+// how the command line is built from the args.
+//
+// An expression that doesn't compile is reported when the plugin gets validated. Until it's
+// fixed, the rule always requires authentication.
+func MatchingRegexp(expr string) sdk.NeedsAuthentication {
+	re, err := regexp.Compile(expr)
+	return describe(describeCall("MatchingRegexp", quote(expr)), func(in sdk.NeedsAuthenticationInput) bool {
+		if err != nil {
+			in.ReportError(fmt.Errorf("invalid regular expression in needsauth.MatchingRegexp: %w", err))
+			return true
+		}
+		return re.MatchString(QuoteArgs(in.CommandArgs))
+	})
+}
+
+// NotMatchingRegexp returns a NeedsAuthentication rule to opt out of authentication when the
+// command line matches the regular expression, e.g. `(^| )--local( |=|$)` to opt out when the
+// --local flag is present anywhere. See QuoteArgs for how the command line is built from the
+// args.
+//
+// An expression that doesn't compile is reported when the plugin gets validated. Until it's
+// fixed, the rule always requires authentication.
+func NotMatchingRegexp(expr string) sdk.NeedsAuthentication {
+	re, err := regexp.Compile(expr)
+	return describe(describeCall("NotMatchingRegexp", quote(expr)), func(in sdk.NeedsAuthenticationInput) bool {
+		if err != nil {
+			in.ReportError(fmt.Errorf("invalid regular expression in needsauth.NotMatchingRegexp: %w", err))
+			return true
+		}
+		return !re.MatchString(QuoteArgs(in.CommandArgs))
+	})
+}
+
+// QuoteArgs joins the command-line args into the command line that the regular expressions of
+// MatchingRegexp and NotMatchingRegexp are matched against. The args are separated by a single
+// space. Args that only consist of letters, digits and any of `@%+=:,./_-` are used as-is. Other
+// args, such as args with spaces or empty args, are put between single quotes as they would be in
+// a POSIX shell. For example, the args ["commit", "-m", "it's done", ""] result in:
+//
+//	commit -m 'it'\''s done' ''
+//
+// Since the whole command line is matched, a pattern can also match text inside a quoted arg,
+// like "--local" in the message of `commit -m "add --local flag"`. The name of the executable
+// itself is not part of the command line.
+func QuoteArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = quoteArg(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// unquotedArg matches args that don't need to be quoted.
+var unquotedArg = regexp.MustCompile(`^[A-Za-z0-9@%+=:,./_-]+$`)
+
+func quoteArg(arg string) string {
+	if unquotedArg.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
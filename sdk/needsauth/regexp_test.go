@@ -0,0 +1,97 @@
+package needsauth
+
+import (
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuoteArgs(t *testing.T) {
+	for _, tc := range []struct {
+		args     []string
+		expected string
+	}{
+		{args: nil, expected: ""},
+		{args: []string{"s3", "ls", "s3://my-bucket/path/"}, expected: "s3 ls s3://my-bucket/path/"},
+		{args: []string{"--profile=work", "user@example.com", "50%"}, expected: "--profile=work user@example.com 50%"},
+		{args: []string{"commit", "-m", "fix the build"}, expected: "commit -m 'fix the build'"},
+		{args: []string{"commit", "-m", "it's done"}, expected: `commit -m 'it'\''s done'`},
+		{args: []string{"run", "$HOME", "a*b"}, expected: "run '$HOME' 'a*b'"},
+		{args: []string{"set", "key", ""}, expected: "set key ''"},
+	} {
+		t.Run(tc.expected, func(t *testing.T) {
+			assert.Equal(t, tc.expected, QuoteArgs(tc.args))
+		})
+	}
+}
+
+func TestMatchingRegexp(t *testing.T) {
+	plugintest.TestNeedsAuth(t, MatchingRegexp(`^s3 (ls|cp) s3://`), map[string]plugintest.NeedsAuthCase{
+		"yes for listing a bucket": {
+			Args:              []string{"s3", "ls", "s3://my-bucket"},
+			ExpectedNeedsAuth: true,
+		},
+		"yes for copying from a bucket": {
+			Args:              []string{"s3", "cp", "s3://my-bucket/file.txt", "."},
+			ExpectedNeedsAuth: true,
+		},
+		"no for copying a local file": {
+			Args:              []string{"s3", "cp", "./file.txt", "./other.txt"},
+			ExpectedNeedsAuth: false,
+		},
+		"no for other command": {
+			Args:              []string{"s3", "presign", "s3://my-bucket/file.txt"},
+			ExpectedNeedsAuth: false,
+		},
+	})
+}
+
+func TestNotMatchingRegexp(t *testing.T) {
+	plugintest.TestNeedsAuth(t, NotMatchingRegexp(`(^| )--local( |$)`), map[string]plugintest.NeedsAuthCase{
+		"no for local flag at the end": {
+			Args:              []string{"deploy", "--local"},
+			ExpectedNeedsAuth: false,
+		},
+		"no for local flag at the start": {
+			Args:              []string{"--local", "deploy", "my-app"},
+			ExpectedNeedsAuth: false,
+		},
+		"yes for other flag with the same prefix": {
+			Args:              []string{"deploy", "--local-only"},
+			ExpectedNeedsAuth: true,
+		},
+		"no for local flag inside an arg with spaces, since the whole command line is matched": {
+			Args:              []string{"commit", "-m", "add --local flag"},
+			ExpectedNeedsAuth: false,
+		},
+		"yes without local flag": {
+			Args:              []string{"deploy"},
+			ExpectedNeedsAuth: true,
+		},
+	})
+}
+
+func TestRegexpWithSpaces(t *testing.T) {
+	plugintest.TestNeedsAuth(t, NotMatchingRegexp(`^config set name '[^']*'$`), map[string]plugintest.NeedsAuthCase{
+		"no for arg with spaces": {
+			Args:              []string{"config", "set", "name", "Wendy Appleseed"},
+			ExpectedNeedsAuth: false,
+		},
+		"yes for separate args": {
+			Args:              []string{"config", "set", "name", "Wendy", "Appleseed"},
+			ExpectedNeedsAuth: true,
+		},
+	})
+}
+
+func TestInvalidRegexp(t *testing.T) {
+	for _, rule := range []sdk.NeedsAuthentication{MatchingRegexp(`(`), NotMatchingRegexp(`(`)} {
+		assert.True(t, rule(sdk.NeedsAuthenticationInput{CommandArgs: []string{"deploy"}}))
+		assert.EqualError(t, IfAll(NotForHelpOrVersion(), IfAny(ForCommand("deploy"), rule)).Validate(), rule.Validate().Error())
+		assert.Contains(t, rule.Validate().Error(), "error parsing regexp: missing closing ): `(`")
+	}
+
+	assert.NoError(t, IfAll(NotForHelpOrVersion(), MatchingRegexp(`^deploy`)).Validate())
+}
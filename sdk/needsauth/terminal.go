@@ -0,0 +1,24 @@
+package needsauth
+
+import (
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// OnlyWhenInteractive returns a NeedsAuthentication rule to only require authentication when
+// both the standard input and output of the executable are connected to a terminal, so that
+// non-interactive runs, like in CI or in a pipeline, fail without credentials instead of
+// blocking on an authentication prompt that nobody answers.
+func OnlyWhenInteractive() sdk.NeedsAuthentication {
+	return describe(describeCall("OnlyWhenInteractive"), func(in sdk.NeedsAuthenticationInput) bool {
+		return in.StdinIsTerminal && in.StdoutIsTerminal
+	})
+}
+
+// NotWhenPiped returns a NeedsAuthentication rule to opt out of authentication when the standard
+// output of the executable is piped or redirected to a file, for CLIs that behave differently in
+// that case, e.g. that print documentation instead of making an API call.
+func NotWhenPiped() sdk.NeedsAuthentication {
+	return describe(describeCall("NotWhenPiped"), func(in sdk.NeedsAuthenticationInput) bool {
+		return in.StdoutIsTerminal
+	})
+}
@@ -0,0 +1,61 @@
+package needsauth
+
+import (
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+)
+
+func TestOnlyWhenInteractive(t *testing.T) {
+	plugintest.TestNeedsAuth(t, OnlyWhenInteractive(), map[string]plugintest.NeedsAuthCase{
+		"yes in terminal": {
+			Args:              []string{"deploy"},
+			StdinIsTerminal:   true,
+			StdoutIsTerminal:  true,
+			ExpectedNeedsAuth: true,
+		},
+		"no with piped input": {
+			Args:              []string{"deploy"},
+			StdinIsTerminal:   false,
+			StdoutIsTerminal:  true,
+			ExpectedNeedsAuth: false,
+		},
+		"no with piped output": {
+			Args:              []string{"deploy"},
+			StdinIsTerminal:   true,
+			StdoutIsTerminal:  false,
+			ExpectedNeedsAuth: false,
+		},
+		"no without terminal": {
+			Args:              []string{"deploy"},
+			ExpectedNeedsAuth: false,
+		},
+	})
+}
+
+func TestNotWhenPiped(t *testing.T) {
+	plugintest.TestNeedsAuth(t, NotWhenPiped(), map[string]plugintest.NeedsAuthCase{
+		"yes in terminal": {
+			Args:              []string{"docs"},
+			StdinIsTerminal:   true,
+			StdoutIsTerminal:  true,
+			ExpectedNeedsAuth: true,
+		},
+		"yes with piped input": {
+			Args:              []string{"docs"},
+			StdinIsTerminal:   false,
+			StdoutIsTerminal:  true,
+			ExpectedNeedsAuth: true,
+		},
+		"no with piped output": {
+			Args:              []string{"docs"},
+			StdinIsTerminal:   true,
+			StdoutIsTerminal:  false,
+			ExpectedNeedsAuth: false,
+		},
+		"no without terminal": {
+			Args:              []string{"docs"},
+			ExpectedNeedsAuth: false,
+		},
+	})
+}
@@ -24,6 +24,10 @@ func TestImporter(t *testing.T, importer sdk.Importer, cases map[string]ImportCa
 				t.Fatal("ExpectedOutput and ExpectedCandidates can't both be set in the same test case")
 			}
 
+			// Resolve the user's directories the same way on every machine that runs the test.
+			for _, envVarName := range []string{"XDG_CONFIG_HOME", "XDG_CACHE_HOME", "AppData", "LocalAppData"} {
+				t.Setenv(envVarName, "")
+			}
 			for envVarName, value := range c.Environment {
 				t.Setenv(envVarName, value)
 			}
@@ -34,6 +38,17 @@ func TestImporter(t *testing.T, importer sdk.Importer, cases map[string]ImportCa
 				RootDir: fsRoot,
 				OS:      c.OS,
 			}
+			if c.WorkingDir != "" {
+				in.WorkingDir = filepath.Join(fsRoot, c.WorkingDir)
+			}
+			in.ConfigDir = in.FromConfigDir()
+			if c.ConfigDir != "" {
+				in.ConfigDir = filepath.Join(fsRoot, c.ConfigDir)
+			}
+			in.CacheDir = in.FromCacheDir()
+			if c.CacheDir != "" {
+				in.CacheDir = filepath.Join(fsRoot, c.CacheDir)
+			}
 
 			for path, contents := range c.Files {
 				path = filepath.Join(fsRoot, path)
@@ -55,8 +70,20 @@ func TestImporter(t *testing.T, importer sdk.Importer, cases map[string]ImportCa
 			description := fmt.Sprintf("Import: %s", name)
 
 			if c.ExpectedOutput != nil {
+				if !specifiesConfidence(c.ExpectedOutput.AllCandidates()) {
+					clearConfidence(&out)
+				}
+				if !specifiesFieldSources(c.ExpectedOutput.AllCandidates()) {
+					clearFieldSources(&out)
+				}
 				assert.Equal(t, *c.ExpectedOutput, out, description)
 			} else {
+				if !specifiesConfidence(c.ExpectedCandidates) {
+					clearConfidence(&out)
+				}
+				if !specifiesFieldSources(c.ExpectedCandidates) {
+					clearFieldSources(&out)
+				}
 				assert.ElementsMatch(t, c.ExpectedCandidates, out.AllCandidates(), description)
 			}
 
@@ -67,6 +94,46 @@ func TestImporter(t *testing.T, importer sdk.Importer, cases map[string]ImportCa
 	}
 }
 
+// specifiesConfidence returns whether any of the candidates has a confidence set. If not, the test case doesn't check
+// the confidence of the candidates.
+func specifiesConfidence(candidates []sdk.ImportCandidate) bool {
+	for _, candidate := range candidates {
+		if candidate.Confidence != sdk.ConfidenceUnspecified {
+			return true
+		}
+	}
+	return false
+}
+
+// clearConfidence resets the confidence of all candidates in the import output.
+func clearConfidence(out *sdk.ImportOutput) {
+	for _, attempt := range out.Attempts {
+		for i := range attempt.Candidates {
+			attempt.Candidates[i].Confidence = sdk.ConfidenceUnspecified
+		}
+	}
+}
+
+// specifiesFieldSources returns whether any of the candidates has field sources set. If not, the test case doesn't
+// check the field sources of the candidates.
+func specifiesFieldSources(candidates []sdk.ImportCandidate) bool {
+	for _, candidate := range candidates {
+		if len(candidate.FieldSources) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// clearFieldSources removes the field sources of all candidates in the import output.
+func clearFieldSources(out *sdk.ImportOutput) {
+	for _, attempt := range out.Attempts {
+		for i := range attempt.Candidates {
+			attempt.Candidates[i].FieldSources = nil
+		}
+	}
+}
+
 type ImportCase struct {
 	// Environment can be used to set environment variables for the importer test.
 	Environment map[string]string
@@ -76,9 +143,20 @@ type ImportCase struct {
 	// LoadFixture helper.
 	Files map[string]string
 
-	// OS can be used to test OS-specific importers. Supported values: "darwin", "linux"
+	// OS can be used to test OS-specific importers. Supported values: "darwin", "linux", "windows"
 	OS string
 
+	// WorkingDir can be used to set the directory from which the import is run, relative to the root of the temp dir,
+	// e.g. "/projects/my-project". This is useful for importers that look for project-local files, like .env files.
+	WorkingDir string
+
+	// ConfigDir and CacheDir can be used to set the user's config and cache directory, relative to the root of the
+	// temp dir, e.g. "~/.xdg/config". By default, they're resolved for the OS of the test case, e.g.
+	// "~/Library/Application Support" on macOS, ignoring the XDG and AppData environment variables of the machine
+	// that runs the test.
+	ConfigDir string
+	CacheDir  string
+
 	// ExpectedCandidates is a shorthand to set the expected import candidates. Mutually exclusive with ExpectedOutput.
 	ExpectedCandidates []sdk.ImportCandidate
 
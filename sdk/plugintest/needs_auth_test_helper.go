@@ -1,14 +1,34 @@
 package plugintest
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema"
 	"github.com/stretchr/testify/assert"
 )
 
 type NeedsAuthCase struct {
-	Args              []string
+	Args []string
+
+	// Environment can be used to set the environment variables that are already set when the executable gets started,
+	// using the format: name -> value. Only the names of the variables with a non-empty value are passed to the rule.
+	Environment map[string]string
+
+	// Files can be used to set files for the test, using the format: path -> contents, e.g.
+	// ~/.config/my-plugin/config -> 'token: abc'. The home directory is a temp dir, also if no files are set.
+	Files map[string]string
+
+	// StdinIsTerminal and StdoutIsTerminal can be used to simulate whether the standard input and output of the
+	// executable are connected to a terminal. Both default to false, like in a non-interactive run.
+	StdinIsTerminal  bool
+	StdoutIsTerminal bool
+
 	ExpectedNeedsAuth bool
 }
 
@@ -17,10 +37,180 @@ func TestNeedsAuth(t *testing.T, rule sdk.NeedsAuthentication, cases map[string]
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
 			t.Helper()
+			var environ []string
+			for name, value := range c.Environment {
+				environ = append(environ, name+"="+value)
+			}
+			sort.Strings(environ)
+
+			// Resolve the user's directories the same way on every machine that runs the test.
+			t.Setenv("XDG_CONFIG_HOME", "")
+			fsRoot := t.TempDir()
+			for path, contents := range c.Files {
+				path = filepath.Join(fsRoot, path)
+				err := os.MkdirAll(filepath.Dir(path), 0700)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				err = os.WriteFile(path, []byte(contents), 0600)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
 			in := sdk.NeedsAuthenticationInput{
-				CommandArgs: c.Args,
+				CommandArgs:       c.Args,
+				ParentEnvVarNames: sdk.EnvVarNames(environ),
+				HomeDir:           filepath.Join(fsRoot, "~"),
+				StdinIsTerminal:   c.StdinIsTerminal,
+				StdoutIsTerminal:  c.StdoutIsTerminal,
+			}
+			if !assert.Equal(t, c.ExpectedNeedsAuth, rule(in), name) {
+				t.Logf("Evaluated rules:\n%s", sdk.FormatRuleTraces(rule.ExplainInput(in)))
+			}
+		})
+	}
+}
+
+// TestExecutableNeedsAuth checks the NeedsAuth rule of the executable for each of the command lines, which map to
+// whether the command needs authentication, e.g.:
+//
+//	plugintest.TestExecutableNeedsAuth(t, MyCLI(), map[string]bool{
+//		"deploy production":                  true,
+//		"deploy --help":                      false,
+//		`deploy --message "fix the build"`:  true,
+//		"MY_CLI_TOKEN=abc deploy production": false,
+//		"deploy production | ...":            false,
+//	})
+//
+// A command line consists of the args of the executable, without the executable itself, split on spaces. Single and
+// double quotes can be used for args that contain spaces, like in a shell. Like in a shell as well, a command line can
+// start with NAME=value pairs to set environment variables in the environment that the executable gets started from.
+// The standard input and output are terminals, unless the command line starts with "... |", for piped input, or ends
+// with "| ...", for piped output, where "..." stands for the other command in the pipeline.
+//
+// If the executable has no NeedsAuth rule, every command needs authentication. If a case fails, the rules that got
+// evaluated are logged, see sdk.NeedsAuthentication.Explain.
+//
+// Use TestNeedsAuth to test with files, or with input and output that aren't terminals by default.
+func TestExecutableNeedsAuth(t *testing.T, executable schema.Executable, cases map[string]bool) {
+	t.Helper()
+	for commandLine, expected := range cases {
+		t.Run(commandLine, func(t *testing.T) {
+			t.Helper()
+			in, err := parseCommandLine(commandLine)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Resolve the user's directories the same way on every machine that runs the test.
+			t.Setenv("XDG_CONFIG_HOME", "")
+			in.HomeDir = filepath.Join(t.TempDir(), "~")
+
+			if executable.NeedsAuth == nil {
+				assert.True(t, expected, "%s: executable has no NeedsAuth rule, so every command needs authentication", commandLine)
+				return
+			}
+			if !assert.Equal(t, expected, executable.NeedsAuth(in), commandLine) {
+				t.Logf("Evaluated rules:\n%s", sdk.FormatRuleTraces(executable.NeedsAuth.ExplainInput(in)))
 			}
-			assert.Equal(t, c.ExpectedNeedsAuth, rule(in), name)
 		})
 	}
 }
+
+// parseCommandLine parses a command line of TestExecutableNeedsAuth into the input of a NeedsAuth rule.
+func parseCommandLine(commandLine string) (sdk.NeedsAuthenticationInput, error) {
+	in := sdk.NeedsAuthenticationInput{
+		StdinIsTerminal:  true,
+		StdoutIsTerminal: true,
+	}
+	commandLine = strings.TrimSpace(commandLine)
+	if rest, ok := cutPrefix(commandLine, "... |"); ok {
+		in.StdinIsTerminal = false
+		commandLine = rest
+	}
+	if rest, ok := cutSuffix(commandLine, "| ..."); ok {
+		in.StdoutIsTerminal = false
+		commandLine = rest
+	}
+
+	words, err := splitCommandLine(commandLine)
+	if err != nil {
+		return sdk.NeedsAuthenticationInput{}, err
+	}
+
+	var environ []string
+	for len(words) > 0 {
+		name, _, ok := strings.Cut(words[0], "=")
+		if !ok || name == "" || strings.HasPrefix(name, "-") {
+			break
+		}
+		environ = append(environ, words[0])
+		words = words[1:]
+	}
+	in.ParentEnvVarNames = sdk.EnvVarNames(environ)
+	in.CommandArgs = words
+	if in.CommandArgs == nil {
+		in.CommandArgs = []string{}
+	}
+	return in, nil
+}
+
+func cutPrefix(s string, prefix string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
+
+func cutSuffix(s string, suffix string) (string, bool) {
+	if !strings.HasSuffix(s, suffix) {
+		return s, false
+	}
+	return s[:len(s)-len(suffix)], true
+}
+
+// splitCommandLine splits the command line on spaces, except for spaces in single or double quotes. Within double
+// quotes, a backslash escapes a double quote or another backslash.
+func splitCommandLine(commandLine string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range commandLine {
+		switch {
+		case escaped:
+			if r != '"' && r != '\\' {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("invalid command line %q: unterminated quote", commandLine)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,64 @@
+package plugintest
+
+import (
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCommandLine(t *testing.T) {
+	for commandLine, expected := range map[string]sdk.NeedsAuthenticationInput{
+		"": {
+			CommandArgs:      []string{},
+			StdinIsTerminal:  true,
+			StdoutIsTerminal: true,
+		},
+		"deploy  production --force": {
+			CommandArgs:      []string{"deploy", "production", "--force"},
+			StdinIsTerminal:  true,
+			StdoutIsTerminal: true,
+		},
+		`commit -m "fix the \"build\"" --author='A. User <user@example.com>' a\b`: {
+			CommandArgs:      []string{"commit", "-m", `fix the "build"`, "--author=A. User <user@example.com>", `a\b`},
+			StdinIsTerminal:  true,
+			StdoutIsTerminal: true,
+		},
+		`deploy ""`: {
+			CommandArgs:      []string{"deploy", ""},
+			StdinIsTerminal:  true,
+			StdoutIsTerminal: true,
+		},
+		"TOKEN=abc EMPTY= deploy --env=production": {
+			CommandArgs:       []string{"deploy", "--env=production"},
+			ParentEnvVarNames: []string{"TOKEN"},
+			StdinIsTerminal:   true,
+			StdoutIsTerminal:  true,
+		},
+		"... | deploy | ...": {
+			CommandArgs:      []string{"deploy"},
+			StdinIsTerminal:  false,
+			StdoutIsTerminal: false,
+		},
+		"... | TOKEN=abc deploy": {
+			CommandArgs:       []string{"deploy"},
+			ParentEnvVarNames: []string{"TOKEN"},
+			StdinIsTerminal:   false,
+			StdoutIsTerminal:  true,
+		},
+		"deploy '|' ...": {
+			CommandArgs:      []string{"deploy", "|", "..."},
+			StdinIsTerminal:  true,
+			StdoutIsTerminal: true,
+		},
+	} {
+		t.Run(commandLine, func(t *testing.T) {
+			in, err := parseCommandLine(commandLine)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, in)
+		})
+	}
+
+	_, err := parseCommandLine(`commit -m "fix`)
+	assert.EqualError(t, err, "invalid command line \"commit -m \\\"fix\": unterminated quote")
+}
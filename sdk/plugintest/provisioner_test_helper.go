@@ -29,9 +29,14 @@ func TestProvisioner(t *testing.T, provisioner sdk.Provisioner, cases map[string
 			ctx := context.Background()
 
 			in := sdk.ProvisionInput{
-				ItemFields: c.ItemFields,
-				HomeDir:    "~",
-				TempDir:    "/tmp",
+				ItemFields:         c.ItemFields,
+				RequiredFields:     c.RequiredFields,
+				FieldDefaults:      c.FieldDefaults,
+				FieldSets:          c.FieldSets,
+				HomeDir:            "~",
+				TempDir:            "/tmp",
+				ParentEnvVarNames:  c.ParentEnvVarNames,
+				ParentEnvVarValues: c.ParentEnvVarValues,
 			}
 
 			out := sdk.ProvisionOutput{
@@ -52,10 +57,78 @@ type ProvisionCase struct {
 	// ItemFields can be used to populate the item fields to pass to the provisioner.
 	ItemFields map[sdk.FieldName]string
 
+	// RequiredFields can be used to populate the fields that the credential type requires, see
+	// schema.CredentialType.RequiredFields.
+	RequiredFields []sdk.FieldName
+
+	// FieldDefaults can be used to populate the default values of the fields that the credential type has, see
+	// schema.CredentialType.FieldDefaults.
+	FieldDefaults map[sdk.FieldName]string
+
+	// FieldSets can be used to populate the alternative sets of fields that the credential type has, see
+	// schema.CredentialType.FieldSets.
+	FieldSets [][]sdk.FieldName
+
 	// CommandLine can be used to populate the command line to pass to the provisioner.
 	CommandLine []string
 
+	// ParentEnvVarNames can be used to populate the names of the environment variables that are already set.
+	ParentEnvVarNames []string
+
+	// ParentEnvVarValues can be used to populate the values of the environment variables that are already set, for the
+	// environment variables in sdk.ParentEnvVarsWithValues.
+	ParentEnvVarValues map[string]string
+
 	// ExpectedOutput can be used to set the exact expected provision output, which contains the
 	// environment, files, and command line.
 	ExpectedOutput sdk.ProvisionOutput
 }
+
+// TestCredentialProvisioners can be used to test provisioning multiple credentials for a single executable, see
+// sdk.ProvisionCredentials.
+func TestCredentialProvisioners(t *testing.T, provisioners []sdk.CredentialProvisioner, cases map[string]CredentialsProvisionCase) {
+	t.Helper()
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Helper()
+
+			if c.ExpectedOutput.Environment == nil {
+				c.ExpectedOutput.Environment = make(map[string]string)
+			}
+
+			if c.ExpectedOutput.Files == nil {
+				c.ExpectedOutput.Files = make(map[string]sdk.OutputFile)
+			}
+
+			ctx := context.Background()
+
+			in := sdk.ProvisionInput{
+				HomeDir: "~",
+				TempDir: "/tmp",
+			}
+
+			out := sdk.ProvisionOutput{
+				Environment: make(map[string]string),
+				Files:       make(map[string]sdk.OutputFile),
+				CommandLine: c.CommandLine,
+			}
+
+			sdk.ProvisionCredentials(ctx, in, provisioners, c.ItemFields, &out)
+
+			description := fmt.Sprintf("Provision: %s", name)
+			assert.Equal(t, c.ExpectedOutput, out, description)
+		})
+	}
+}
+
+type CredentialsProvisionCase struct {
+	// ItemFields can be used to populate the item fields to pass to the provisioner of each credential.
+	ItemFields map[sdk.CredentialName]map[sdk.FieldName]string
+
+	// CommandLine can be used to populate the command line to pass to the provisioners.
+	CommandLine []string
+
+	// ExpectedOutput can be used to set the exact expected combined provision output.
+	ExpectedOutput sdk.ProvisionOutput
+}
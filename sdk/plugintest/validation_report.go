@@ -7,8 +7,8 @@ import (
 	"github.com/fatih/color"
 )
 
-func PrintValidationReport(plugin schema.Plugin) {
-	reports := plugin.DeepValidate()
+func PrintValidationReport(plugin schema.Plugin, opts ...schema.ValidationOption) {
+	reports := plugin.DeepValidate(opts...)
 	printer := &ValidationReportPrinter{
 		Reports: reports,
 		Format:  PrintFormat{}.ValidationReportFormat(),
@@ -16,8 +16,8 @@ func PrintValidationReport(plugin schema.Plugin) {
 	printer.Print()
 }
 
-func PrintReportIfErrors(plugin schema.Plugin) (hasErrors bool) {
-	pluginReports := plugin.DeepValidate()
+func PrintReportIfErrors(plugin schema.Plugin, opts ...schema.ValidationOption) (hasErrors bool) {
+	pluginReports := plugin.DeepValidate(opts...)
 	for _, report := range pluginReports {
 		if report.HasErrors() {
 			printer := &ValidationReportPrinter{
@@ -74,9 +74,10 @@ func (p *ValidationReportPrinter) PrintReport(report schema.ValidationReport) {
 	p.printChecks(report.Checks)
 }
 
-// sortChecks in the order ["success", "warning", "error"]
+// sortChecks in the order ["success", "suppressed", "warning", "error"]
 func (p *ValidationReportPrinter) sortChecks(checks []schema.ValidationCheck) []schema.ValidationCheck {
 	var successChecks []schema.ValidationCheck
+	var suppressedChecks []schema.ValidationCheck
 	var warningChecks []schema.ValidationCheck
 	var errorChecks []schema.ValidationCheck
 
@@ -86,6 +87,11 @@ func (p *ValidationReportPrinter) sortChecks(checks []schema.ValidationCheck) []
 			continue
 		}
 
+		if c.Suppressed {
+			suppressedChecks = append(suppressedChecks, c)
+			continue
+		}
+
 		if c.Severity == schema.ValidationSeverityWarning {
 			warningChecks = append(warningChecks, c)
 			continue
@@ -94,7 +100,8 @@ func (p *ValidationReportPrinter) sortChecks(checks []schema.ValidationCheck) []
 		errorChecks = append(errorChecks, c)
 	}
 
-	result := append(successChecks, warningChecks...)
+	result := append(successChecks, suppressedChecks...)
+	result = append(result, warningChecks...)
 	result = append(result, errorChecks...)
 
 	return result
@@ -113,14 +120,19 @@ func (p *ValidationReportPrinter) printHeading(heading string) {
 
 func (p *ValidationReportPrinter) printCheck(check schema.ValidationCheck) {
 	if check.Assertion {
-		p.Format.Success.Printf("✔ %s\n", check.Description)
+		p.Format.Success.Printf("✔ %s\n", check)
+		return
+	}
+
+	if check.Suppressed {
+		p.Format.Success.Printf("- %s\n", check)
 		return
 	}
 
 	if check.Severity == schema.ValidationSeverityWarning {
-		p.Format.Warning.Printf("⚠ %s\n", check.Description)
+		p.Format.Warning.Printf("⚠ %s\n", check)
 		return
 	}
 
-	p.Format.Error.Printf("✘ %s\n", check.Description)
+	p.Format.Error.Printf("✘ %s\n", check)
 }
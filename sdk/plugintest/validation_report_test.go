@@ -13,9 +13,11 @@ func TestValidationReportPrinter_printFields(t *testing.T) {
 		{Description: "error", Assertion: false, Severity: schema.ValidationSeverityError},
 		{Description: "success", Assertion: true},
 		{Description: "warning", Assertion: false, Severity: schema.ValidationSeverityWarning},
+		{Description: "suppressed", Assertion: false, Severity: schema.ValidationSeverityError, Suppressed: true},
 	}
 	checks = printer.sortChecks(checks)
 	assert.Equal(t, "success", checks[0].Description, "first check should be success")
-	assert.Equal(t, "warning", checks[1].Description, "second check should be warning")
-	assert.Equal(t, "error", checks[2].Description, "third check should be error")
+	assert.Equal(t, "suppressed", checks[1].Description, "second check should be suppressed")
+	assert.Equal(t, "warning", checks[2].Description, "third check should be warning")
+	assert.Equal(t, "error", checks[3].Description, "fourth check should be error")
 }
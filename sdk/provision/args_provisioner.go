@@ -0,0 +1,144 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// ArgsPosition describes where in the command line args get inserted.
+type ArgsPosition int
+
+const (
+	// ArgsAtEnd appends the args to the end of the command line, but before "--" if present.
+	ArgsAtEnd ArgsPosition = iota
+
+	// ArgsAtStart inserts the args directly after the executable, before any other args.
+	ArgsAtStart
+
+	// ArgsBeforeFirstNonFlag inserts the args before the first arg that's not a flag, which is usually the
+	// subcommand. Note that values of flags that are passed as a separate arg are considered non-flag args too.
+	ArgsBeforeFirstNonFlag
+
+	// ArgsAfterSubcommand inserts the args directly after the subcommand specified in the ArgsSpec.
+	ArgsAfterSubcommand
+)
+
+// ArgsSpec describes which args to insert into the command line and where.
+type ArgsSpec struct {
+	// Args contains the args to insert. Each arg is a text/template that has access to all item fields by their
+	// field name, e.g. "-p{{ .Password }}".
+	Args []string
+
+	// Position describes where the args get inserted. Defaults to ArgsAtEnd.
+	Position ArgsPosition
+
+	// Subcommand is the (sub)command after which the args get inserted when using ArgsAfterSubcommand,
+	// e.g. ["auth"] or ["auth", "login"]. If the command line does not contain the subcommand, no args get inserted.
+	Subcommand []string
+}
+
+// ArgsProvisioner provisions secrets as command-line args at a specific position in the command line.
+type ArgsProvisioner struct {
+	sdk.Provisioner
+
+	Spec ArgsSpec
+}
+
+// Args creates an ArgsProvisioner that inserts the args described in the spec into the command line. The inserted
+// args are marked as sensitive, so they get masked wherever the command line gets displayed.
+func Args(spec ArgsSpec) sdk.Provisioner {
+	return ArgsProvisioner{
+		Spec: spec,
+	}
+}
+
+func (p ArgsProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	index, ok := p.insertIndex(out.CommandLine)
+	if !ok {
+		return
+	}
+
+	if in.DryRun {
+		in = in.Concealed()
+	}
+
+	tmplData := fieldTemplateData(in)
+	args := make([]string, len(p.Spec.Args))
+	for i, tmplStr := range p.Spec.Args {
+		arg, err := executeTemplate("arg", tmplStr, tmplData)
+		if err != nil {
+			out.AddError(fmt.Errorf("resolving template for arg %d: %s", i, err))
+			return
+		}
+		args[i] = arg
+	}
+
+	out.InsertSensitiveArgs(index, args...)
+	if in.DryRun {
+		out.AddPlan(fmt.Sprintf("Insert %d sensitive command-line args at position %d", len(args), index))
+	}
+}
+
+// insertIndex returns the index in the command line at which the args should be inserted. The first item of the
+// command line is the executable itself. Args after "--" are never considered.
+func (p ArgsProvisioner) insertIndex(commandLine []string) (int, bool) {
+	if len(commandLine) == 0 {
+		return 0, true
+	}
+
+	end := len(commandLine)
+	for i, arg := range commandLine[1:] {
+		if arg == "--" {
+			end = i + 1
+			break
+		}
+	}
+
+	switch p.Spec.Position {
+	case ArgsAtStart:
+		return 1, true
+	case ArgsBeforeFirstNonFlag:
+		for i := 1; i < end; i++ {
+			if !strings.HasPrefix(commandLine[i], "-") {
+				return i, true
+			}
+		}
+		return end, true
+	case ArgsAfterSubcommand:
+		subcommand := p.Spec.Subcommand
+		if len(subcommand) == 0 {
+			return 0, false
+		}
+		for i := 1; i+len(subcommand) <= end; i++ {
+			if equalArgs(commandLine[i:i+len(subcommand)], subcommand) {
+				return i + len(subcommand), true
+			}
+		}
+		return 0, false
+	default:
+		return end, true
+	}
+}
+
+func (p ArgsProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	// Nothing to do here: command-line args are only passed to the process.
+}
+
+func (p ArgsProvisioner) Description() string {
+	return "Provision command-line args"
+}
+
+func equalArgs(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
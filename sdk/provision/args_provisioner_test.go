@@ -0,0 +1,99 @@
+package provision
+
+import (
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+)
+
+func TestArgsProvisioner(t *testing.T) {
+	itemFields := map[sdk.FieldName]string{
+		fieldname.Username: "root",
+		fieldname.Password: "hunter2",
+	}
+	args := []string{"-u", "{{ .Username }}", "-p{{ .Password }}"}
+
+	plugintest.TestProvisioner(t, Args(ArgsSpec{Args: args}), map[string]plugintest.ProvisionCase{
+		"at end": {
+			ItemFields:  itemFields,
+			CommandLine: []string{"mysql", "mydb"},
+			ExpectedOutput: sdk.ProvisionOutput{
+				CommandLine:         []string{"mysql", "mydb", "-u", "root", "-phunter2"},
+				SensitiveArgIndexes: []int{2, 3, 4},
+			},
+		},
+		"at end before double dash": {
+			ItemFields:  itemFields,
+			CommandLine: []string{"mysql", "mydb", "--", "-e"},
+			ExpectedOutput: sdk.ProvisionOutput{
+				CommandLine:         []string{"mysql", "mydb", "-u", "root", "-phunter2", "--", "-e"},
+				SensitiveArgIndexes: []int{2, 3, 4},
+			},
+		},
+	})
+
+	plugintest.TestProvisioner(t, Args(ArgsSpec{Args: args, Position: ArgsAtStart}), map[string]plugintest.ProvisionCase{
+		"at start": {
+			ItemFields:  itemFields,
+			CommandLine: []string{"mysql", "--verbose", "mydb"},
+			ExpectedOutput: sdk.ProvisionOutput{
+				CommandLine:         []string{"mysql", "-u", "root", "-phunter2", "--verbose", "mydb"},
+				SensitiveArgIndexes: []int{1, 2, 3},
+			},
+		},
+		"at start with double dash": {
+			ItemFields:  itemFields,
+			CommandLine: []string{"mysql", "--", "mydb"},
+			ExpectedOutput: sdk.ProvisionOutput{
+				CommandLine:         []string{"mysql", "-u", "root", "-phunter2", "--", "mydb"},
+				SensitiveArgIndexes: []int{1, 2, 3},
+			},
+		},
+	})
+
+	plugintest.TestProvisioner(t, Args(ArgsSpec{Args: args, Position: ArgsBeforeFirstNonFlag}), map[string]plugintest.ProvisionCase{
+		"before first non-flag": {
+			ItemFields:  itemFields,
+			CommandLine: []string{"redis-cli", "--no-raw", "get", "key"},
+			ExpectedOutput: sdk.ProvisionOutput{
+				CommandLine:         []string{"redis-cli", "--no-raw", "-u", "root", "-phunter2", "get", "key"},
+				SensitiveArgIndexes: []int{2, 3, 4},
+			},
+		},
+		"before double dash when there are only flags": {
+			ItemFields:  itemFields,
+			CommandLine: []string{"redis-cli", "--no-raw", "--", "get"},
+			ExpectedOutput: sdk.ProvisionOutput{
+				CommandLine:         []string{"redis-cli", "--no-raw", "-u", "root", "-phunter2", "--", "get"},
+				SensitiveArgIndexes: []int{2, 3, 4},
+			},
+		},
+	})
+
+	plugintest.TestProvisioner(t, Args(ArgsSpec{Args: []string{"--token={{ .Password }}"}, Position: ArgsAfterSubcommand, Subcommand: []string{"auth", "login"}}), map[string]plugintest.ProvisionCase{
+		"after subcommand": {
+			ItemFields:  itemFields,
+			CommandLine: []string{"gh", "auth", "login", "--web"},
+			ExpectedOutput: sdk.ProvisionOutput{
+				CommandLine:         []string{"gh", "auth", "login", "--token=hunter2", "--web"},
+				SensitiveArgIndexes: []int{3},
+			},
+		},
+		"not when subcommand only appears after double dash": {
+			ItemFields:  itemFields,
+			CommandLine: []string{"gh", "--", "auth", "login"},
+			ExpectedOutput: sdk.ProvisionOutput{
+				CommandLine: []string{"gh", "--", "auth", "login"},
+			},
+		},
+		"not when subcommand is absent": {
+			ItemFields:  itemFields,
+			CommandLine: []string{"gh", "repo", "list"},
+			ExpectedOutput: sdk.ProvisionOutput{
+				CommandLine: []string{"gh", "repo", "list"},
+			},
+		},
+	})
+}
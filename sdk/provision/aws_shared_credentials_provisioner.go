@@ -0,0 +1,92 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+)
+
+// AWSSharedCredentialsProvisioner provisions AWS credentials as a shared credentials file, which is read by the aws
+// CLI, the AWS SDKs, and tools built on top of them, such as terraform, sam, and copilot.
+type AWSSharedCredentialsProvisioner struct {
+	sdk.Provisioner
+
+	profile string
+}
+
+// AWSSharedCredentials creates an AWSSharedCredentialsProvisioner that writes the Access Key ID, Secret Access Key,
+// and (optional) Session Token fields of the item to a temp credentials file under the specified profile, and points
+// the AWS_SHARED_CREDENTIALS_FILE and AWS_PROFILE environment variables at it. If the item has a Default Region or
+// Region field, a config file with the region for the profile is written as well and AWS_CONFIG_FILE is set.
+// If the profile is empty, the "default" profile is used.
+func AWSSharedCredentials(profile string) sdk.Provisioner {
+	if profile == "" {
+		profile = "default"
+	}
+	return AWSSharedCredentialsProvisioner{
+		profile: profile,
+	}
+}
+
+func (p AWSSharedCredentialsProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	if in.DryRun {
+		in = in.Concealed()
+	}
+
+	accessKeyID, ok := in.ItemFields[fieldname.AccessKeyID]
+	if !ok {
+		out.AddError(fmt.Errorf("no value present in the item for field '%s'", fieldname.AccessKeyID))
+		return
+	}
+
+	secretAccessKey, ok := in.ItemFields[fieldname.SecretAccessKey]
+	if !ok {
+		out.AddError(fmt.Errorf("no value present in the item for field '%s'", fieldname.SecretAccessKey))
+		return
+	}
+
+	credentials := new(strings.Builder)
+	fmt.Fprintf(credentials, "[%s]\n", p.profile)
+	fmt.Fprintf(credentials, "aws_access_key_id = %s\n", accessKeyID)
+	fmt.Fprintf(credentials, "aws_secret_access_key = %s\n", secretAccessKey)
+	if sessionToken, ok := in.ItemFields[fieldname.SessionToken]; ok && sessionToken != "" {
+		fmt.Fprintf(credentials, "aws_session_token = %s\n", sessionToken)
+	}
+
+	awsDir := in.FromTempDir("aws")
+	credentialsPath := filepath.Join(awsDir, "credentials")
+	out.AddSecretFile(credentialsPath, []byte(credentials.String()))
+	out.AddEnvVar("AWS_SHARED_CREDENTIALS_FILE", credentialsPath)
+	out.AddEnvVar("AWS_PROFILE", p.profile)
+	if in.DryRun {
+		out.AddPlan(fmt.Sprintf("Write AWS credentials for profile %s to %s", p.profile, credentialsPath))
+	}
+
+	region := in.ItemFields[fieldname.DefaultRegion]
+	if region == "" {
+		region = in.ItemFields[fieldname.Region]
+	}
+	if region != "" {
+		// Apart from the default profile, profiles in the config file are prefixed with "profile".
+		section := p.profile
+		if section != "default" {
+			section = "profile " + section
+		}
+
+		configPath := filepath.Join(awsDir, "config")
+		out.AddNonSecretFile(configPath, []byte(fmt.Sprintf("[%s]\nregion = %s\n", section, region)))
+		out.AddEnvVar("AWS_CONFIG_FILE", configPath)
+	}
+}
+
+func (p AWSSharedCredentialsProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	// Nothing to do here: deleting the files gets taken care of.
+}
+
+func (p AWSSharedCredentialsProvisioner) Description() string {
+	return fmt.Sprintf("Provision AWS shared credentials file for profile %s", p.profile)
+}
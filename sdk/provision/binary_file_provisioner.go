@@ -0,0 +1,42 @@
+package provision
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// BinaryFileFromField returns a file provisioner for binary material that's stored encoded in a field, such as
+// PKCS#12 bundles or DER certificates. The value of the field gets decoded using the specified encoding and written as
+// is. An absolute path is used as the location of the file, a relative one as its name in the temp dir. If the path is
+// empty, a random name gets generated. Use VerifySHA256 to check the decoded contents against a checksum field.
+func BinaryFileFromField(fieldName sdk.FieldName, path string, encoding Encoding, opts ...FileOption) sdk.Provisioner {
+	var pathOpt FileOption
+	switch {
+	case filepath.IsAbs(path):
+		pathOpt = AtFixedPath(path)
+	case path != "":
+		pathOpt = Filename(path)
+	default:
+		pathOpt = func(*FileProvisioner) {}
+	}
+
+	return TempFile(DecodedFieldAsFileContents(fieldName, encoding), append([]FileOption{pathOpt}, opts...)...)
+}
+
+// DecodedFieldAsFileContents can be used to store the decoded value of a single field as a file.
+func DecodedFieldAsFileContents(fieldName sdk.FieldName, encoding Encoding) ItemToFileContents {
+	return ItemToFileContents(func(in sdk.ProvisionInput) ([]byte, error) {
+		value, ok := in.ItemFields[fieldName]
+		if !ok || value == "" {
+			return nil, fmt.Errorf("no value present in the item for field '%s'", fieldName)
+		}
+
+		contents, err := encoding.decode(value)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode the value of field '%s' as %s: %s", fieldName, encoding, err)
+		}
+		return contents, nil
+	})
+}
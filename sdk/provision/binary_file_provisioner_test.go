@@ -0,0 +1,120 @@
+package provision
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/filewriter"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checksumField is the field that holds the SHA-256 checksum of the certificate in these tests.
+const checksumField = sdk.FieldName("Checksum")
+
+func TestBinaryFileFromField(t *testing.T) {
+	blob := []byte{0x30, 0x82, 0x00, 0x00, 0xff, 0xfe, 0x00, '\n', 0x7f}
+	checksum := sha256.Sum256(blob)
+
+	for name, tc := range map[string]struct {
+		encoding Encoding
+		value    string
+	}{
+		"base64":           {encoding: EncodingBase64, value: base64.StdEncoding.EncodeToString(blob)},
+		"unpadded base64":  {encoding: EncodingBase64, value: base64.RawStdEncoding.EncodeToString(blob)},
+		"wrapped base64":   {encoding: EncodingBase64, value: "MIIAAP/+\nAAp/\n"},
+		"base64url":        {encoding: EncodingBase64URL, value: base64.URLEncoding.EncodeToString(blob)},
+		"hex":              {encoding: EncodingHex, value: hex.EncodeToString(blob)},
+		"uppercase hex":    {encoding: EncodingHex, value: "30820000FFFE000A7F"},
+		"hex with spacing": {encoding: EncodingHex, value: "3082 0000 fffe 000a 7f"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			plugintest.TestProvisioner(t, BinaryFileFromField(fieldname.Certificate, "cert.der", tc.encoding, VerifySHA256(checksumField)), map[string]plugintest.ProvisionCase{
+				"default": {
+					ItemFields: map[sdk.FieldName]string{
+						fieldname.Certificate: tc.value,
+						checksumField:         "sha256:" + hex.EncodeToString(checksum[:]),
+					},
+					ExpectedOutput: sdk.ProvisionOutput{
+						Files: map[string]sdk.OutputFile{
+							"/tmp/cert.der": {
+								Contents: blob,
+							},
+						},
+					},
+				},
+			})
+		})
+	}
+}
+
+func TestBinaryFileFromFieldErrors(t *testing.T) {
+	plugintest.TestProvisioner(t, BinaryFileFromField(fieldname.Certificate, "/etc/tool/cert.p12", EncodingBase64, VerifySHA256(checksumField)), map[string]plugintest.ProvisionCase{
+		"malformed encoding": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Certificate: "not*base64",
+				checksumField:         "abc",
+			},
+			ExpectedOutput: sdk.ProvisionOutput{
+				Diagnostics: sdk.Diagnostics{Errors: []sdk.Error{{Message: "could not decode the value of field 'Certificate' as base64: illegal base64 data at input byte 3"}}},
+			},
+		},
+		"checksum mismatch": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Certificate: base64.StdEncoding.EncodeToString([]byte("truncated")),
+				checksumField:         "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+			},
+			ExpectedOutput: sdk.ProvisionOutput{
+				Diagnostics: sdk.Diagnostics{Errors: []sdk.Error{{Message: "file contents do not match the SHA-256 checksum in field 'Checksum'"}}},
+			},
+		},
+		"missing checksum": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Certificate: base64.StdEncoding.EncodeToString([]byte("cert")),
+			},
+			ExpectedOutput: sdk.ProvisionOutput{
+				Diagnostics: sdk.Diagnostics{Errors: []sdk.Error{{Message: "no value present in the item for field 'Checksum'"}}},
+			},
+		},
+	})
+}
+
+func TestBinaryFileFromFieldWritesFile(t *testing.T) {
+	blob := make([]byte, 256)
+	for i := range blob {
+		blob[i] = byte(i)
+	}
+
+	tempDir := t.TempDir()
+	out := sdk.ProvisionOutput{
+		Environment: make(map[string]string),
+		Files:       make(map[string]sdk.OutputFile),
+	}
+	BinaryFileFromField(fieldname.Certificate, "bundle.p12", EncodingBase64, SetPathAsEnvVar("BUNDLE_PATH")).Provision(context.Background(), sdk.ProvisionInput{
+		TempDir:    tempDir,
+		ItemFields: map[sdk.FieldName]string{fieldname.Certificate: base64.StdEncoding.EncodeToString(blob)},
+	}, &out)
+	require.Empty(t, out.Diagnostics.Errors)
+
+	written, err := filewriter.Write(out)
+	require.NoError(t, err)
+	defer written.Remove()
+
+	path := filepath.Join(tempDir, "bundle.p12")
+	assert.Equal(t, path, out.Environment["BUNDLE_PATH"])
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, blob, contents)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
@@ -0,0 +1,165 @@
+package provision
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// CachedProvisioner caches the environment variables and files provisioned by another provisioner in the encrypted
+// cache, and replays them on consecutive runs until they expire.
+type CachedProvisioner struct {
+	sdk.Provisioner
+
+	provisioner sdk.Provisioner
+	ttl         time.Duration
+	now         func() time.Time
+}
+
+// Cached wraps the specified provisioner so that the environment variables and files it provisions get cached for
+// the specified TTL. This is useful for provisioners that derive short-lived credentials, such as a session token
+// exchanged for a long-lived key, to avoid performing the exchange on every run. Expired or corrupt cache entries
+// result in a fresh provision. Only use this with provisioners that don't change the command line.
+//
+// If the TTL is 0, the default TTL of the credential type gets used, see sdk.ProvisionInput.DefaultTTL. Without
+// either, nothing gets cached.
+//
+// If the wrapped provisioner implements sdk.Refresher, so does the returned provisioner. Refreshes get forwarded
+// without being cached.
+func Cached(provisioner sdk.Provisioner, ttl time.Duration) sdk.Provisioner {
+	cached := CachedProvisioner{
+		provisioner: provisioner,
+		ttl:         ttl,
+		now:         time.Now,
+	}
+	if refresher, ok := provisioner.(sdk.Refresher); ok {
+		return refreshingCachedProvisioner{CachedProvisioner: cached, refresher: refresher}
+	}
+	return cached
+}
+
+// refreshingCachedProvisioner is a CachedProvisioner that forwards refreshes to the wrapped provisioner. It's a
+// separate type so that only wrappers of provisioners that implement sdk.Refresher implement it as well.
+type refreshingCachedProvisioner struct {
+	CachedProvisioner
+
+	refresher sdk.Refresher
+}
+
+func (p refreshingCachedProvisioner) Refresh(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	p.refresher.Refresh(ctx, in, out)
+}
+
+// cachedProvision is the data that gets stored in the cache. Paths inside the temp dir are stored relative to it,
+// since every run has its own temp dir.
+type cachedProvision struct {
+	TempDir     string
+	Environment map[string]string
+	Files       map[string]sdk.OutputFile
+	NextRefresh time.Time
+}
+
+func (p CachedProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	if in.DryRun {
+		p.provisioner.Provision(ctx, in, out)
+		return
+	}
+
+	key := p.cacheKey()
+	if entry, ok := in.Cache[key]; ok && p.now().Before(entry.ExpiresAt) {
+		var cached cachedProvision
+		if in.Cache.Get(key, &cached) {
+			for name, value := range cached.Environment {
+				out.AddEnvVar(name, rebasePath(value, cached.TempDir, in.TempDir))
+			}
+			for path, file := range cached.Files {
+				out.AddFile(rebasePath(path, cached.TempDir, in.TempDir), file)
+			}
+			out.NextRefresh = cached.NextRefresh
+			return
+		}
+	}
+
+	envBefore := make(map[string]string)
+	for name, value := range out.Environment {
+		envBefore[name] = value
+	}
+	filesBefore := make(map[string]sdk.OutputFile)
+	for path, file := range out.Files {
+		filesBefore[path] = file
+	}
+
+	p.provisioner.Provision(ctx, in, out)
+	if len(out.Diagnostics.Errors) > 0 {
+		return
+	}
+
+	// Only cache what the wrapped provisioner added or changed.
+	cached := cachedProvision{
+		TempDir:     in.TempDir,
+		Environment: make(map[string]string),
+		Files:       make(map[string]sdk.OutputFile),
+		NextRefresh: out.NextRefresh,
+	}
+	for name, value := range out.Environment {
+		if before, ok := envBefore[name]; !ok || before != value {
+			cached.Environment[name] = value
+		}
+	}
+	for path, file := range out.Files {
+		if before, ok := filesBefore[path]; !ok || !bytes.Equal(before.Contents, file.Contents) || before.FileMode != file.FileMode {
+			cached.Files[path] = file
+		}
+	}
+
+	ttl := p.ttl
+	if ttl == 0 {
+		ttl = in.DefaultTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	if out.Cache.Puts == nil {
+		out.Cache.Puts = make(map[string]sdk.CacheEntry)
+	}
+	err := out.Cache.Put(key, cached, p.now().Add(ttl))
+	if err != nil {
+		out.AddWarning(fmt.Sprintf("caching provisioned credentials: %s", err))
+	}
+}
+
+func (p CachedProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	// Leave the cache untouched, so that it can be used by consecutive runs.
+	p.provisioner.Deprovision(ctx, in, out)
+}
+
+func (p CachedProvisioner) Description() string {
+	if p.ttl == 0 {
+		return fmt.Sprintf("%s (cached for the default TTL of the credential)", p.provisioner.Description())
+	}
+	return fmt.Sprintf("%s (cached for %s)", p.provisioner.Description(), p.ttl)
+}
+
+func (p CachedProvisioner) cacheKey() string {
+	return fmt.Sprintf("provision-cached|%s", p.provisioner.Description())
+}
+
+// rebasePath replaces the specified old temp dir by the new temp dir if the value is a path inside the old temp dir.
+func rebasePath(value string, oldTempDir string, newTempDir string) string {
+	if oldTempDir == "" || oldTempDir == newTempDir {
+		return value
+	}
+	if value == oldTempDir {
+		return newTempDir
+	}
+	if strings.HasPrefix(value, oldTempDir+string(filepath.Separator)) {
+		return filepath.Join(newTempDir, strings.TrimPrefix(value, oldTempDir))
+	}
+	return value
+}
@@ -0,0 +1,180 @@
+package provision
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingProvisioner struct {
+	sdk.Provisioner
+
+	calls *int
+}
+
+func (p countingProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	*p.calls++
+	out.AddEnvVar("SESSION_TOKEN", "token")
+	out.AddEnvVar("SESSION_FILE", in.FromTempDir("session"))
+	out.AddSecretFile(in.FromTempDir("session"), []byte("session"))
+}
+
+func (p countingProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+}
+
+func (p countingProvisioner) Description() string {
+	return "Provision session token"
+}
+
+func TestCachedProvisioner(t *testing.T) {
+	calls := 0
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := CachedProvisioner{
+		provisioner: countingProvisioner{calls: &calls},
+		ttl:         5 * time.Minute,
+		now:         func() time.Time { return now },
+	}
+
+	cache := sdk.CacheState{}
+	run := func(tempDir string) sdk.ProvisionOutput {
+		out := sdk.ProvisionOutput{
+			Environment: make(map[string]string),
+			Files:       make(map[string]sdk.OutputFile),
+			Cache:       sdk.CacheOperations{Puts: make(map[string]sdk.CacheEntry)},
+		}
+		p.Provision(context.Background(), sdk.ProvisionInput{TempDir: tempDir, Cache: cache}, &out)
+		for key, entry := range out.Cache.Puts {
+			cache[key] = entry
+		}
+		return out
+	}
+
+	expected := func(tempDir string) (map[string]string, map[string]sdk.OutputFile) {
+		return map[string]string{
+			"SESSION_TOKEN": "token",
+			"SESSION_FILE":  tempDir + "/session",
+		}, map[string]sdk.OutputFile{
+			tempDir + "/session": {Contents: []byte("session")},
+		}
+	}
+
+	out := run("/tmp/run1")
+	env, files := expected("/tmp/run1")
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, env, out.Environment)
+	assert.Equal(t, files, out.Files)
+
+	// Replays from the cache with paths in the new temp dir.
+	now = now.Add(4 * time.Minute)
+	out = run("/tmp/run2")
+	env, files = expected("/tmp/run2")
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, env, out.Environment)
+	assert.Equal(t, files, out.Files)
+	assert.Empty(t, out.Cache.Puts)
+
+	// Provisions again after expiry.
+	now = now.Add(2 * time.Minute)
+	run("/tmp/run3")
+	assert.Equal(t, 2, calls)
+
+	// Provisions again when the cache entry is corrupt.
+	for key, entry := range cache {
+		entry.Data = []byte("corrupt")
+		cache[key] = entry
+	}
+	out = run("/tmp/run4")
+	env, _ = expected("/tmp/run4")
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, env, out.Environment)
+}
+
+func TestCachedProvisionerDefaultTTL(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	provision := func(ttl time.Duration, defaultTTL time.Duration) sdk.ProvisionOutput {
+		calls := 0
+		p := CachedProvisioner{
+			provisioner: countingProvisioner{calls: &calls},
+			ttl:         ttl,
+			now:         func() time.Time { return now },
+		}
+		out := sdk.ProvisionOutput{
+			Environment: make(map[string]string),
+			Files:       make(map[string]sdk.OutputFile),
+		}
+		p.Provision(context.Background(), sdk.ProvisionInput{TempDir: "/tmp", DefaultTTL: defaultTTL}, &out)
+		return out
+	}
+	expiresAt := func(out sdk.ProvisionOutput) []time.Time {
+		var times []time.Time
+		for _, entry := range out.Cache.Puts {
+			times = append(times, entry.ExpiresAt)
+		}
+		return times
+	}
+
+	assert.Equal(t, []time.Time{now.Add(5 * time.Minute)}, expiresAt(provision(5*time.Minute, time.Hour)))
+	assert.Equal(t, []time.Time{now.Add(time.Hour)}, expiresAt(provision(0, time.Hour)))
+
+	out := provision(0, 0)
+	assert.Empty(t, out.Cache.Puts)
+	assert.Equal(t, "token", out.Environment["SESSION_TOKEN"])
+}
+
+// refreshingProvisioner provisions a token file and schedules a refresh at a fixed time.
+type refreshingProvisioner struct {
+	countingProvisioner
+
+	nextRefresh time.Time
+	refreshes   *int
+}
+
+func (p refreshingProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	p.countingProvisioner.Provision(ctx, in, out)
+	out.NextRefresh = p.nextRefresh
+}
+
+func (p refreshingProvisioner) Refresh(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	*p.refreshes++
+	out.AddSecretFile(in.FromTempDir("session"), []byte("refreshed"))
+	out.NextRefresh = p.nextRefresh.Add(time.Duration(*p.refreshes) * time.Minute)
+}
+
+func TestCachedProvisionerRefresh(t *testing.T) {
+	_, ok := Cached(countingProvisioner{}, time.Minute).(sdk.Refresher)
+	assert.False(t, ok, "wrapping a provisioner without refresher should not add one")
+
+	calls, refreshes := 0, 0
+	nextRefresh := time.Date(2023, 1, 1, 12, 10, 0, 0, time.UTC)
+	p := Cached(refreshingProvisioner{
+		countingProvisioner: countingProvisioner{calls: &calls},
+		nextRefresh:         nextRefresh,
+		refreshes:           &refreshes,
+	}, 5*time.Minute)
+	refresher, ok := p.(sdk.Refresher)
+	require.True(t, ok, "wrapping a provisioner with refresher should keep it")
+
+	cache := sdk.CacheState{}
+	for i := 0; i < 2; i++ {
+		out := sdk.ProvisionOutput{
+			Environment: make(map[string]string),
+			Files:       make(map[string]sdk.OutputFile),
+		}
+		p.Provision(context.Background(), sdk.ProvisionInput{TempDir: "/tmp", Cache: cache}, &out)
+		for key, entry := range out.Cache.Puts {
+			cache[key] = entry
+		}
+		assert.Equal(t, nextRefresh, out.NextRefresh, "the first refresh should be scheduled, also from the cache")
+	}
+	assert.Equal(t, 1, calls)
+
+	out := sdk.ProvisionOutput{Files: make(map[string]sdk.OutputFile)}
+	refresher.Refresh(context.Background(), sdk.ProvisionInput{TempDir: "/tmp"}, &out)
+	assert.Equal(t, 1, refreshes)
+	assert.Equal(t, []byte("refreshed"), out.Files["/tmp/session"].Contents)
+	assert.Equal(t, nextRefresh.Add(time.Minute), out.NextRefresh)
+}
@@ -0,0 +1,162 @@
+package provision
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// DockerAuthProvisioner provisions registry credentials as a Docker config.json file, which is read by docker,
+// podman, crane, and other tools that talk to container registries.
+type DockerAuthProvisioner struct {
+	sdk.Provisioner
+
+	registryHost           string
+	usernameField          sdk.FieldName
+	passwordField          sdk.FieldName
+	setRegistryAuthFile    bool
+	passThroughCredHelpers bool
+}
+
+// DockerAuthOption can be used to influence the behavior of the Docker auth provisioner.
+type DockerAuthOption func(*DockerAuthProvisioner)
+
+// DockerAuthConfig creates a DockerAuthProvisioner that writes a config.json with an "auths" entry for the specified
+// registry to a temp config directory, using the values of the username and password (or token) fields, and points
+// the DOCKER_CONFIG environment variable at that directory. When multiple Docker auth provisioners are chained for
+// the same executable, their registries get combined into a single config file.
+func DockerAuthConfig(registryHost string, usernameField sdk.FieldName, passwordOrTokenField sdk.FieldName, opts ...DockerAuthOption) sdk.Provisioner {
+	p := DockerAuthProvisioner{
+		registryHost:  registryHost,
+		usernameField: usernameField,
+		passwordField: passwordOrTokenField,
+	}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
+
+// DockerAuthWithRegistryAuthFile can be used to also point the REGISTRY_AUTH_FILE environment variable at the
+// config file, which is used by podman, buildah, and skopeo instead of DOCKER_CONFIG.
+func DockerAuthWithRegistryAuthFile() DockerAuthOption {
+	return func(p *DockerAuthProvisioner) {
+		p.setRegistryAuthFile = true
+	}
+}
+
+// DockerAuthWithCredHelpers can be used to copy the "credHelpers" from the user's own ~/.docker/config.json into
+// the provisioned config file, so registries that are not provisioned by 1Password keep working.
+func DockerAuthWithCredHelpers() DockerAuthOption {
+	return func(p *DockerAuthProvisioner) {
+		p.passThroughCredHelpers = true
+	}
+}
+
+type dockerConfig struct {
+	Auths       map[string]dockerAuth `json:"auths"`
+	CredHelpers map[string]string     `json:"credHelpers,omitempty"`
+}
+
+type dockerAuth struct {
+	Auth string `json:"auth"`
+}
+
+func (p DockerAuthProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	if in.DryRun {
+		in = in.Concealed()
+	}
+
+	username, ok := in.ItemFields[p.usernameField]
+	if !ok {
+		out.AddError(fmt.Errorf("no value present in the item for field '%s'", p.usernameField))
+		return
+	}
+
+	password, ok := in.ItemFields[p.passwordField]
+	if !ok {
+		out.AddError(fmt.Errorf("no value present in the item for field '%s'", p.passwordField))
+		return
+	}
+
+	configDir := in.FromTempDir("docker")
+	configPath := filepath.Join(configDir, "config.json")
+
+	// Add the registry to the config file of a previous Docker auth provisioner, if there is one.
+	config := dockerConfig{Auths: make(map[string]dockerAuth)}
+	if existing, ok := out.Files[configPath]; ok {
+		err := json.Unmarshal(existing.Contents, &config)
+		if err != nil {
+			out.AddError(fmt.Errorf("could not parse previously provisioned Docker config: %s", err))
+			return
+		}
+	}
+
+	if p.passThroughCredHelpers && !in.DryRun {
+		credHelpers, err := readDockerCredHelpers(in.FromHomeDir(".docker", "config.json"))
+		if err != nil {
+			out.AddError(err)
+			return
+		}
+		for registry, helper := range credHelpers {
+			if config.CredHelpers == nil {
+				config.CredHelpers = make(map[string]string)
+			}
+			config.CredHelpers[registry] = helper
+		}
+	}
+
+	// Credentials provisioned by 1Password take precedence over the user's own credential helpers.
+	delete(config.CredHelpers, p.registryHost)
+	config.Auths[p.registryHost] = dockerAuth{
+		Auth: base64.StdEncoding.EncodeToString([]byte(username + ":" + password)),
+	}
+
+	contents, err := json.MarshalIndent(config, "", "\t")
+	if err != nil {
+		out.AddError(err)
+		return
+	}
+
+	out.AddDirectory(configDir, sdk.OutputDirectory{})
+	out.AddSecretFile(configPath, contents)
+	out.AddEnvVar("DOCKER_CONFIG", configDir)
+	if p.setRegistryAuthFile {
+		out.AddEnvVar("REGISTRY_AUTH_FILE", configPath)
+	}
+	if in.DryRun {
+		out.AddPlan(fmt.Sprintf("Add registry credentials for %s to %s", p.registryHost, configPath))
+	}
+}
+
+// readDockerCredHelpers returns the "credHelpers" of the Docker config file at the specified path, if it exists.
+func readDockerCredHelpers(path string) (map[string]string, error) {
+	contents, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("could not read Docker config %s: %s", path, err)
+	}
+
+	var config struct {
+		CredHelpers map[string]string `json:"credHelpers"`
+	}
+	err = json.Unmarshal(contents, &config)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse Docker config %s: %s", path, err)
+	}
+	return config.CredHelpers, nil
+}
+
+func (p DockerAuthProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	// Nothing to do here: deleting the files gets taken care of.
+}
+
+func (p DockerAuthProvisioner) Description() string {
+	return fmt.Sprintf("Provision Docker config with credentials for %s", p.registryHost)
+}
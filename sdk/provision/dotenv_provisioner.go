@@ -0,0 +1,54 @@
+package provision
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// DotEnv returns a file provisioner that writes the values of the specified fields to a .env file in the temp dir,
+// as `KEY='value'` lines, see quoteDotEnvValue. The mapping is from environment variable name to field name, like for
+// EnvVars. The file options can be used to make the file known to the executable, e.g.
+// SetPathAsEnvVar("DOTENV_CONFIG_PATH") or SetOutputDirAsWorkingDir() for tools that load .env from their working
+// directory.
+func DotEnv(mapping map[string]sdk.FieldName, opts ...FileOption) sdk.Provisioner {
+	return TempFile(dotEnvContents(mapping), append([]FileOption{Filename(".env")}, opts...)...)
+}
+
+func dotEnvContents(mapping map[string]sdk.FieldName) ItemToFileContents {
+	return ItemToFileContents(func(in sdk.ProvisionInput) ([]byte, error) {
+		var contents strings.Builder
+		for _, key := range sortedKeys(mapping) {
+			value, ok := in.ItemFields[mapping[key]]
+			if !ok {
+				return nil, fmt.Errorf("no value present in the item for field '%s'", mapping[key])
+			}
+			contents.WriteString(fmt.Sprintf("%s=%s\n", key, quoteDotEnvValue(value)))
+		}
+		return []byte(contents.String()), nil
+	})
+}
+
+// dotEnvEscaper escapes the characters that dotenv parsers interpret inside double-quoted values.
+var dotEnvEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	`$`, `\$`,
+	"\n", `\n`,
+	"\r", `\r`,
+)
+
+// quoteDotEnvValue quotes the value, so that whitespace and "#" are preserved and variables are not expanded.
+//
+// The supported parsers are godotenv (Go), python-dotenv and dotenv (Node). All of them read single-quoted values
+// literally, so single quotes are used whenever the value allows it: when it contains no single quotes or line
+// breaks, and no backslash that python-dotenv would read as an escape. Other values are double-quoted and escaped,
+// which godotenv reads back exactly. python-dotenv and Node's dotenv don't unescape all of these sequences, so such
+// values may not be read back exactly by them.
+func quoteDotEnvValue(value string) string {
+	if !strings.ContainsAny(value, "'\n\r") && !strings.Contains(value, `\\`) && !strings.HasSuffix(value, `\`) {
+		return "'" + value + "'"
+	}
+	return `"` + dotEnvEscaper.Replace(value) + `"`
+}
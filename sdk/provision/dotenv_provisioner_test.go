@@ -0,0 +1,123 @@
+package provision
+
+import (
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/joho/godotenv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDotEnvProvisioner(t *testing.T) {
+	plugintest.TestProvisioner(t, DotEnv(map[string]sdk.FieldName{
+		"API_KEY":  fieldname.APIKey,
+		"DB_PASS":  fieldname.Password,
+		"DB_USER":  fieldname.Username,
+		"API_HOST": fieldname.Host,
+	}, SetPathAsEnvVar("DOTENV_CONFIG_PATH")), map[string]plugintest.ProvisionCase{
+		"default": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.APIKey:   "sk_live_123",
+				fieldname.Password: `p"a$s\word`,
+				fieldname.Username: "wendy",
+				fieldname.Host:     "api.example.com",
+			},
+			ExpectedOutput: sdk.ProvisionOutput{
+				Environment: map[string]string{
+					"DOTENV_CONFIG_PATH": "/tmp/.env",
+				},
+				Files: map[string]sdk.OutputFile{
+					"/tmp/.env": {
+						Contents: []byte("API_HOST='api.example.com'\nAPI_KEY='sk_live_123'\nDB_PASS='p\"a$s\\word'\nDB_USER='wendy'\n"),
+					},
+				},
+			},
+		},
+		"missing field": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.APIKey: "sk_live_123",
+			},
+			ExpectedOutput: sdk.ProvisionOutput{
+				Diagnostics: sdk.Diagnostics{Errors: []sdk.Error{{Message: "no value present in the item for field 'Host'"}}},
+			},
+		},
+	})
+}
+
+func TestDotEnvProvisionerWorkingDir(t *testing.T) {
+	plugintest.TestProvisioner(t, DotEnv(map[string]sdk.FieldName{"TOKEN": fieldname.Token}, SetOutputDirAsWorkingDir()), map[string]plugintest.ProvisionCase{
+		"default": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Token: "abc123",
+			},
+			ExpectedOutput: sdk.ProvisionOutput{
+				WorkingDirectory: "/tmp",
+				Files: map[string]sdk.OutputFile{
+					"/tmp/.env": {
+						Contents: []byte("TOKEN='abc123'\n"),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestDotEnvProvisionerQuoting(t *testing.T) {
+	for value, expected := range map[string]string{
+		`$HOME`:             `'$HOME'`,
+		`say "hi"`:          `'say "hi"'`,
+		`C:\path\to\file`:   `'C:\path\to\file'`,
+		`p"a$s\word`:        `'p"a$s\word'`,
+		`it's $HOME`:        `"it's \$HOME"`,
+		"line\nbreak":       `"line\nbreak"`,
+		`double\\backslash`: `"double\\\\backslash"`,
+		`trailing\`:         `"trailing\\"`,
+	} {
+		assert.Equal(t, expected, quoteDotEnvValue(value), value)
+	}
+}
+
+func TestDotEnvProvisionerRoundTrip(t *testing.T) {
+	values := map[string]string{
+		"hash":               "abc#def # not a comment",
+		"surrounding spaces": "  padded  ",
+		"double quotes":      `say "hi"!`,
+		"single quotes":      `it's`,
+		"dollar":             "$HOME and ${USER} and $(whoami)",
+		"backslash":          `C:\path\to\file.txt`,
+		"backslashes":        `\\server\share`,
+		"quotes and dollar":  `it's "$HOME" \o/`,
+		"newlines":           "-----BEGIN KEY-----\nabc\r\ndef\n-----END KEY-----",
+		"literal escape":     `line\nbreak`,
+		"equals and unicode": "a=b=c 🔑",
+		"empty":              "",
+	}
+
+	for name, value := range values {
+		t.Run(name, func(t *testing.T) {
+			contents, err := dotEnvContents(map[string]sdk.FieldName{"VALUE": fieldname.Token})(sdk.ProvisionInput{
+				ItemFields: map[sdk.FieldName]string{fieldname.Token: value},
+			})
+			require.NoError(t, err)
+
+			parsed, err := godotenv.UnmarshalBytes(contents)
+			require.NoError(t, err)
+			assert.Equal(t, value, parsed["VALUE"])
+		})
+	}
+}
+
+func TestDotEnvProvisionerDoesNotExpandParentEnv(t *testing.T) {
+	t.Setenv("DOTENV_TEST_SECRET", "leaked")
+	contents, err := dotEnvContents(map[string]sdk.FieldName{"VALUE": fieldname.Token})(sdk.ProvisionInput{
+		ItemFields: map[sdk.FieldName]string{fieldname.Token: "$DOTENV_TEST_SECRET"},
+	})
+	require.NoError(t, err)
+
+	parsed, err := godotenv.UnmarshalBytes(contents)
+	require.NoError(t, err)
+	assert.Equal(t, "$DOTENV_TEST_SECRET", parsed["VALUE"])
+}
@@ -0,0 +1,86 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDryRunDoesNotExposeSecrets(t *testing.T) {
+	itemFields := map[sdk.FieldName]string{
+		fieldname.Username:        "dry-run-username",
+		fieldname.Password:        "dry-run-password",
+		fieldname.Token:           "dry-run-token",
+		fieldname.MFASecret:       "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		fieldname.PrivateKey:      "dry-run-private-key",
+		fieldname.Certificate:     "dry-run-certificate",
+		fieldname.AccessKeyID:     "dry-run-access-key-id",
+		fieldname.SecretAccessKey: "dry-run-secret-access-key",
+		fieldname.Host:            "dry-run-host",
+	}
+
+	provisioners := map[string]sdk.Provisioner{
+		"env vars":           EnvVars(map[string]sdk.FieldName{"TOKEN": fieldname.Token}),
+		"templated env vars": EnvVarsTemplated(map[string]string{"AUTH": "{{ .Username }}:{{ .Password }}"}),
+		"temp file":          FieldAsFile(fieldname.Token, Filename("token"), SetPathAsEnvVar("TOKEN_FILE")),
+		"fifo":               FIFO(fieldname.Token, "TOKEN_FILE"),
+		"netrc":              NetRC("example.com", fieldname.Username, fieldname.Password),
+		"merge file":         MergeFile("~/.config/tool/config.json", MergeJSON, map[string]string{"token": "{{ .Token }}"}),
+		"stdin":              Stdin(fieldname.Password),
+		"args":               Args(ArgsSpec{Args: []string{"-p{{ .Password }}"}, Position: ArgsAtStart}),
+		"totp":               TOTP(fieldname.MFASecret, TOTPAsEnvVar("MFA_CODE")),
+		"expiring token file": ExpiringTokenFile(func(ctx context.Context, in sdk.ProvisionInput) ([]byte, time.Time, error) {
+			return []byte(in.ItemFields[fieldname.Token]), time.Now().Add(time.Hour), nil
+		}, time.Minute),
+		"pgpass":                  PGPassFile(PGPassWithConnectionEnvVars()),
+		"docker auth":             DockerAuthConfig("ghcr.io", fieldname.Username, fieldname.Token),
+		"kubeconfig":              KubeConfig("staging", KubeConfigFields{Server: fieldname.Host, CertificateAuthority: fieldname.Certificate, Token: fieldname.Token}),
+		"aws shared credentials":  AWSSharedCredentials("deploy"),
+		"encoded env vars":        EnvVarsWithOptions(map[string]EnvVarSpec{"TOKEN": {Field: fieldname.Token, Encoding: EncodingBase64}}),
+		"ssh agent":               SSHAgent(fieldname.PrivateKey, fieldname.Password),
+		"sandboxed config home":   FieldAsFile(fieldname.Token, InSandboxedConfigHome("tool", "token")),
+		"local credential server": LocalCredentialServer("CREDENTIALS_URL", "CREDENTIALS_AUTHORIZATION", map[string]sdk.FieldName{"token": fieldname.Token}),
+		"dotenv":                  DotEnv(map[string]sdk.FieldName{"TOKEN": fieldname.Token}),
+		"binary file":             BinaryFileFromField(fieldname.Certificate, "cert.der", EncodingBase64),
+		"token exchange": TokenExchange("Example", func(ctx context.Context, fields map[string]string) (map[string]string, time.Time, error) {
+			panic("exchanging credentials in a dry run")
+		}, EnvVars(map[string]sdk.FieldName{"ACCESS_TOKEN": "Access Token"})),
+		"header file": HeaderFile("Authorization", "Bearer {{ .Token }}"),
+		"cached":      Cached(EnvVars(map[string]sdk.FieldName{"TOKEN": fieldname.Token}), time.Hour),
+	}
+
+	for name, p := range provisioners {
+		t.Run(name, func(t *testing.T) {
+			homeDir := t.TempDir()
+			out := sdk.ProvisionOutput{
+				Environment: make(map[string]string),
+				Files:       make(map[string]sdk.OutputFile),
+				CommandLine: []string{"tool", "deploy"},
+			}
+			p.Provision(context.Background(), sdk.ProvisionInput{
+				HomeDir:    homeDir,
+				TempDir:    t.TempDir(),
+				DryRun:     true,
+				ItemFields: itemFields,
+			}, &out)
+
+			assert.Empty(t, out.Diagnostics.Errors)
+			assert.NotEmpty(t, out.Plan)
+
+			dump := fmt.Sprintf("%+v", out)
+			for _, file := range out.Files {
+				dump += string(file.Contents)
+			}
+			dump += string(out.Stdin)
+			for fieldName, value := range itemFields {
+				assert.False(t, strings.Contains(dump, value), "dry-run output contains the value of %s", fieldName)
+			}
+		})
+	}
+}
@@ -2,8 +2,11 @@ package provision
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"strings"
+	"unicode"
 
 	"github.com/1Password/shell-plugins/sdk"
 )
@@ -13,33 +16,348 @@ type EnvVarProvisioner struct {
 	sdk.Provisioner
 
 	Schema map[string]sdk.FieldName
+
+	// CollisionPolicy determines what happens when an environment variable is already set in the parent environment.
+	// Defaults to EnvCollisionWarn.
+	CollisionPolicy EnvCollisionPolicy
+
+	// CollisionPolicies can be used to override CollisionPolicy for specific environment variables.
+	CollisionPolicies map[string]EnvCollisionPolicy
 }
 
+// EnvCollisionPolicy determines what happens when a provisioned environment variable would shadow an environment
+// variable that is already set in the parent environment, e.g. because the user exported it in their shell.
+type EnvCollisionPolicy string
+
+const (
+	// EnvCollisionWarn overwrites the existing environment variable and reports a warning.
+	EnvCollisionWarn EnvCollisionPolicy = "warn"
+
+	// EnvCollisionOverwrite silently overwrites the existing environment variable.
+	EnvCollisionOverwrite EnvCollisionPolicy = "overwrite"
+
+	// EnvCollisionFail fails provisioning, so the user can decide which of the two values should be used.
+	EnvCollisionFail EnvCollisionPolicy = "fail"
+)
+
+// EnvVarOption can be used to influence the behavior of the env var provisioner.
+type EnvVarOption func(*EnvVarProvisioner)
+
 // EnvVars creates an EnvVarProvisioner that provisions secrets as environment variables, based
 // on the specified schema of field name and environment variable name.
-func EnvVars(schema map[string]sdk.FieldName) sdk.Provisioner {
-	return EnvVarProvisioner{
-		Schema: schema,
+// Fields that are missing from the item get their default value, see sdk.ProvisionInput.FieldDefaults.
+// Fields without a default are skipped, unless they're required, see sdk.ProvisionInput.RequiredFields,
+// in which case an error is reported. For credentials with alternative sets of fields, only the fields of the set
+// that the item uses get provisioned, see sdk.ProvisionInput.FieldSets.
+func EnvVars(schema map[string]sdk.FieldName, opts ...EnvVarOption) sdk.Provisioner {
+	p := EnvVarProvisioner{
+		Schema:          schema,
+		CollisionPolicy: EnvCollisionWarn,
+	}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
+
+// FailOnEnvCollision can be used to fail provisioning when one of the specified environment variables is already set
+// in the parent environment. If no environment variables are specified, this applies to all of them.
+func FailOnEnvCollision(envVarNames ...string) EnvVarOption {
+	return withEnvCollisionPolicy(EnvCollisionFail, envVarNames)
+}
+
+// OverwriteOnEnvCollision can be used to silently overwrite the specified environment variables when they are
+// already set in the parent environment. If no environment variables are specified, this applies to all of them.
+func OverwriteOnEnvCollision(envVarNames ...string) EnvVarOption {
+	return withEnvCollisionPolicy(EnvCollisionOverwrite, envVarNames)
+}
+
+// WarnOnEnvCollision can be used to report a warning when one of the specified environment variables is already set
+// in the parent environment. If no environment variables are specified, this applies to all of them. This is the
+// default behavior.
+func WarnOnEnvCollision(envVarNames ...string) EnvVarOption {
+	return withEnvCollisionPolicy(EnvCollisionWarn, envVarNames)
+}
+
+func withEnvCollisionPolicy(policy EnvCollisionPolicy, envVarNames []string) EnvVarOption {
+	return func(p *EnvVarProvisioner) {
+		if len(envVarNames) == 0 {
+			p.CollisionPolicy = policy
+			return
+		}
+
+		if p.CollisionPolicies == nil {
+			p.CollisionPolicies = make(map[string]EnvCollisionPolicy)
+		}
+		for _, envVarName := range envVarNames {
+			p.CollisionPolicies[envVarName] = policy
+		}
 	}
 }
 
 func (p EnvVarProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
-	for envVarName, fieldName := range p.Schema {
-		if value, ok := in.ItemFields[fieldName]; ok {
-			out.AddEnvVar(envVarName, value)
+	if in.DryRun {
+		in = in.Concealed()
+	}
+
+	for _, envVarName := range sortedKeys(p.Schema) {
+		fieldName := p.Schema[envVarName]
+		if !in.UsesField(fieldName) {
+			continue
+		}
+		value, ok := in.FieldValueOrDefault(fieldName)
+		if !ok {
+			if in.IsRequiredField(fieldName) {
+				out.AddError(fmt.Errorf("cannot set environment variable %s: the item is missing the required field %s", envVarName, fieldName))
+			}
+			continue
+		}
+
+		if in.HasParentEnvVar(envVarName) {
+			switch p.collisionPolicy(envVarName) {
+			case EnvCollisionFail:
+				out.AddError(fmt.Errorf("environment variable %s is already set, unset it to use the value of %s instead", envVarName, fieldName))
+				continue
+			case EnvCollisionWarn:
+				out.AddWarning(fmt.Sprintf("environment variable %s is already set and gets overridden by the value of %s", envVarName, fieldName))
+			}
+		}
+
+		out.AddEnvVar(envVarName, value)
+		if in.DryRun {
+			out.AddPlan(fmt.Sprintf("Set environment variable %s to the value of %s", envVarName, fieldName))
 		}
 	}
 }
 
+func (p EnvVarProvisioner) collisionPolicy(envVarName string) EnvCollisionPolicy {
+	if policy, ok := p.CollisionPolicies[envVarName]; ok {
+		return policy
+	}
+	if p.CollisionPolicy == "" {
+		return EnvCollisionWarn
+	}
+	return p.CollisionPolicy
+}
+
 func (p EnvVarProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
 	// Nothing to do here: environment variables get wiped automatically when the process exits.
 }
 
 func (p EnvVarProvisioner) Description() string {
-	var envVarNames []string
-	for envVarName := range p.Schema {
-		envVarNames = append(envVarNames, envVarName)
+	return fmt.Sprintf("Provision environment variables: %s", strings.Join(sortedKeys(p.Schema), ", "))
+}
+
+// TemplatedEnvVarProvisioner provisions secrets as environment variables, composing their values from templates.
+type TemplatedEnvVarProvisioner struct {
+	sdk.Provisioner
+
+	Templates map[string]string
+}
+
+// EnvVarsTemplated creates a TemplatedEnvVarProvisioner that provisions secrets as environment variables, based
+// on the specified schema of environment variable name and value template. The templates use the text/template
+// syntax and have access to all item fields by their field name. For example:
+// * `"GIT_ASKPASS_TOKEN": "{{ .Username }}:{{ .Token }}"`
+// * `"AUTH_HEADER": "Bearer {{ index . \"API Key\" }}"`
+// Referencing a field that's not present in the item results in a provisioning error.
+func EnvVarsTemplated(templates map[string]string) sdk.Provisioner {
+	return TemplatedEnvVarProvisioner{
+		Templates: templates,
+	}
+}
+
+func (p TemplatedEnvVarProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	if in.DryRun {
+		in = in.Concealed()
+	}
+
+	tmplData := fieldTemplateData(in)
+
+	// Resolve all templates before provisioning any of them, so that nothing gets provisioned on failure.
+	resolved := make(map[string]string)
+	for envVarName, tmplStr := range p.Templates {
+		value, err := executeTemplate(envVarName, tmplStr, tmplData)
+		if err != nil {
+			out.AddError(fmt.Errorf("resolving template for environment variable '%s': %s", envVarName, err))
+			return
+		}
+
+		resolved[envVarName] = value
+	}
+
+	for envVarName, value := range resolved {
+		out.AddEnvVar(envVarName, value)
+	}
+
+	if in.DryRun {
+		for _, envVarName := range sortedKeys(resolved) {
+			out.AddPlan(fmt.Sprintf("Set environment variable %s to %s", envVarName, resolved[envVarName]))
+		}
+	}
+}
+
+func (p TemplatedEnvVarProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	// Nothing to do here: environment variables get wiped automatically when the process exits.
+}
+
+func (p TemplatedEnvVarProvisioner) Description() string {
+	return fmt.Sprintf("Provision environment variables: %s", strings.Join(sortedKeys(p.Templates), ", "))
+}
+
+// EnvVarSpec specifies how a single environment variable gets provisioned by EnvVarsWithOptions.
+type EnvVarSpec struct {
+	// Field is the name of the field to provision the value of.
+	Field sdk.FieldName
+
+	// Encoding is the encoding that gets applied to the value. Defaults to EncodingNone.
+	Encoding Encoding
+
+	// (Optional) Transform gets applied to the value before it's encoded, e.g. TrimSpace or StripWhitespace.
+	Transform func(string) string
+}
+
+// Encoding is an encoding that can be applied to provisioned values, for executables that expect their secrets
+// pre-encoded.
+type Encoding string
+
+const (
+	// EncodingNone provisions the value as is.
+	EncodingNone Encoding = ""
+
+	// EncodingBase64 provisions the value using standard base64 encoding, as defined in RFC 4648.
+	EncodingBase64 Encoding = "base64"
+
+	// EncodingBase64URL provisions the value using URL-safe base64 encoding with padding, as defined in RFC 4648.
+	EncodingBase64URL Encoding = "base64url"
+
+	// EncodingHex provisions the value hex-encoded, using lowercase letters.
+	EncodingHex Encoding = "hex"
+)
+
+func (e Encoding) encode(value string) (string, error) {
+	switch e {
+	case EncodingNone:
+		return value, nil
+	case EncodingBase64:
+		return base64.StdEncoding.EncodeToString([]byte(value)), nil
+	case EncodingBase64URL:
+		return base64.URLEncoding.EncodeToString([]byte(value)), nil
+	case EncodingHex:
+		return hex.EncodeToString([]byte(value)), nil
+	default:
+		return "", fmt.Errorf("unknown encoding '%s'", e)
 	}
+}
+
+// decode decodes the value. Whitespace and line breaks are ignored, and base64 values are accepted both with and
+// without padding.
+func (e Encoding) decode(value string) ([]byte, error) {
+	switch e {
+	case EncodingNone:
+		return []byte(value), nil
+	case EncodingBase64:
+		return base64.RawStdEncoding.DecodeString(strings.TrimRight(StripWhitespace(value), "="))
+	case EncodingBase64URL:
+		return base64.RawURLEncoding.DecodeString(strings.TrimRight(StripWhitespace(value), "="))
+	case EncodingHex:
+		return hex.DecodeString(StripWhitespace(value))
+	default:
+		return nil, fmt.Errorf("unknown encoding '%s'", e)
+	}
+}
+
+// TrimSpace can be used as EnvVarSpec.Transform to remove leading and trailing whitespace and newlines.
+func TrimSpace(value string) string {
+	return strings.TrimSpace(value)
+}
+
+// StripWhitespace can be used as EnvVarSpec.Transform to remove all whitespace and newlines, e.g. from keys that
+// got pasted with line breaks.
+func StripWhitespace(value string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, value)
+}
+
+// EnvVarSpecProvisioner provisions secrets as environment variables, optionally transforming and encoding them.
+type EnvVarSpecProvisioner struct {
+	sdk.Provisioner
+
+	Specs map[string]EnvVarSpec
+}
+
+// EnvVarsWithOptions creates an EnvVarSpecProvisioner that provisions secrets as environment variables, based on the
+// specified schema of environment variable name and spec. For example, to provision a service account JSON as base64:
+// `"GOOGLE_CREDENTIALS_BASE64": {Field: fieldname.Credentials, Encoding: provision.EncodingBase64}`
+// Unknown encodings are reported when the plugin gets validated.
+func EnvVarsWithOptions(specs map[string]EnvVarSpec) sdk.Provisioner {
+	return EnvVarSpecProvisioner{
+		Specs: specs,
+	}
+}
+
+// Validate checks whether all specs use a known encoding.
+func (p EnvVarSpecProvisioner) Validate() error {
+	for _, envVarName := range sortedKeys(p.Specs) {
+		if _, err := p.Specs[envVarName].Encoding.encode(""); err != nil {
+			return fmt.Errorf("environment variable %s: %s", envVarName, err)
+		}
+	}
+	return nil
+}
+
+func (p EnvVarSpecProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	if in.DryRun {
+		in = in.Concealed()
+	}
+
+	// Encode all values before provisioning any of them, so that nothing gets provisioned on failure.
+	encoded := make(map[string]string)
+	for _, envVarName := range sortedKeys(p.Specs) {
+		spec := p.Specs[envVarName]
+		value, ok := in.ItemFields[spec.Field]
+		if !ok {
+			continue
+		}
+
+		// In a dry run, the value is a placeholder, so it's provisioned as is.
+		if !in.DryRun {
+			if spec.Transform != nil {
+				value = spec.Transform(value)
+			}
+
+			var err error
+			value, err = spec.Encoding.encode(value)
+			if err != nil {
+				out.AddError(fmt.Errorf("environment variable %s: %s", envVarName, err))
+				return
+			}
+		}
+
+		encoded[envVarName] = value
+	}
+
+	for _, envVarName := range sortedKeys(encoded) {
+		out.AddEnvVar(envVarName, encoded[envVarName])
+		if in.DryRun {
+			spec := p.Specs[envVarName]
+			if spec.Encoding == EncodingNone {
+				out.AddPlan(fmt.Sprintf("Set environment variable %s to the value of %s", envVarName, spec.Field))
+			} else {
+				out.AddPlan(fmt.Sprintf("Set environment variable %s to the %s-encoded value of %s", envVarName, spec.Encoding, spec.Field))
+			}
+		}
+	}
+}
+
+func (p EnvVarSpecProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	// Nothing to do here: environment variables get wiped automatically when the process exits.
+}
 
-	return fmt.Sprintf("Provision environment variables: %s", strings.Join(envVarNames, ", "))
+func (p EnvVarSpecProvisioner) Description() string {
+	return fmt.Sprintf("Provision environment variables: %s", strings.Join(sortedKeys(p.Specs), ", "))
 }
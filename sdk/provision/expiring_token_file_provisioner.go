@@ -0,0 +1,111 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// TokenSource retrieves a short-lived token, for example by exchanging a long-lived credential from the item, and
+// returns when the token expires.
+type TokenSource func(ctx context.Context, in sdk.ProvisionInput) (token []byte, expiresAt time.Time, err error)
+
+// ExpiringTokenFileProvisioner provisions a short-lived token as a file and refreshes it while the executable is
+// running, before it expires.
+type ExpiringTokenFileProvisioner struct {
+	sdk.Provisioner
+
+	source        TokenSource
+	refreshBefore time.Duration
+	file          FileProvisioner
+	now           func() time.Time
+}
+
+// ExpiringTokenFile creates an ExpiringTokenFileProvisioner that writes the token retrieved from the token source to a
+// temp file, and refreshes it the specified duration before it expires. The file options can be used to influence
+// where the file gets stored and how its path is passed to the executable. Defaults to a file called "token" in the
+// temp dir.
+func ExpiringTokenFile(source TokenSource, refreshBefore time.Duration, opts ...FileOption) sdk.Provisioner {
+	file := FileProvisioner{
+		outfileName: "token",
+	}
+	for _, opt := range opts {
+		opt(&file)
+	}
+
+	return ExpiringTokenFileProvisioner{
+		source:        source,
+		refreshBefore: refreshBefore,
+		file:          file,
+		now:           time.Now,
+	}
+}
+
+func (p ExpiringTokenFileProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	if in.DryRun {
+		// Don't retrieve a token in a dry run, since that might require network calls using real secrets.
+		outpath, err := p.file.outputPath(in)
+		if err != nil {
+			out.AddError(err)
+			return
+		}
+		out.AddSecretFile(outpath, []byte(sdk.ConcealedFileContents))
+		out.AddPlan(fmt.Sprintf("Write short-lived token to %s and refresh it before it expires", outpath))
+		p.file.addPathReferences(outpath, out)
+		return
+	}
+
+	outpath, nextRefresh, err := p.provisionToken(ctx, in, out)
+	if err != nil {
+		out.AddError(err)
+		return
+	}
+
+	out.NextRefresh = nextRefresh
+	p.file.addPathReferences(outpath, out)
+}
+
+func (p ExpiringTokenFileProvisioner) Refresh(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	_, nextRefresh, err := p.provisionToken(ctx, in, out)
+	if err != nil {
+		out.AddError(fmt.Errorf("refreshing token: %s", err))
+		return
+	}
+	out.NextRefresh = nextRefresh
+}
+
+// provisionToken retrieves a token, adds it to the output as file, and returns when the token should be refreshed.
+func (p ExpiringTokenFileProvisioner) provisionToken(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) (string, time.Time, error) {
+	token, expiresAt, err := p.source(ctx, in)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	outpath, err := p.file.outputPath(in)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	out.AddSecretFile(outpath, token)
+
+	if expiresAt.IsZero() {
+		// The token doesn't expire, so there's no need to refresh it.
+		return outpath, time.Time{}, nil
+	}
+
+	nextRefresh := expiresAt.Add(-p.refreshBefore)
+	if now := p.now(); nextRefresh.Before(now) {
+		nextRefresh = now
+	}
+	return outpath, nextRefresh, nil
+}
+
+func (p ExpiringTokenFileProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	// Nothing to do here: deleting the files gets taken care of.
+}
+
+func (p ExpiringTokenFileProvisioner) Description() string {
+	return "Provision expiring token file"
+}
@@ -0,0 +1,61 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpiringTokenFileRefreshCadence(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	issued := 0
+	source := func(ctx context.Context, in sdk.ProvisionInput) ([]byte, time.Time, error) {
+		issued++
+		return []byte(fmt.Sprintf("token-%d", issued)), now.Add(15 * time.Minute), nil
+	}
+
+	p := ExpiringTokenFile(source, time.Minute, SetPathAsEnvVar("TOKEN_FILE")).(ExpiringTokenFileProvisioner)
+	p.now = func() time.Time { return now }
+	in := sdk.ProvisionInput{TempDir: "/tmp"}
+
+	out := sdk.ProvisionOutput{
+		Environment: make(map[string]string),
+		Files:       make(map[string]sdk.OutputFile),
+	}
+	p.Provision(context.Background(), in, &out)
+	assert.Equal(t, map[string]string{"TOKEN_FILE": "/tmp/token"}, out.Environment)
+	assert.Equal(t, []byte("token-1"), out.Files["/tmp/token"].Contents)
+	assert.Equal(t, now.Add(14*time.Minute), out.NextRefresh)
+
+	// Every refresh schedules the next one a minute before the new token expires.
+	for i := 2; i <= 4; i++ {
+		now = now.Add(14 * time.Minute)
+		refreshOut := sdk.ProvisionOutput{Files: make(map[string]sdk.OutputFile)}
+		p.Refresh(context.Background(), in, &refreshOut)
+		assert.Empty(t, refreshOut.Diagnostics.Errors)
+		assert.Equal(t, now.Add(14*time.Minute), refreshOut.NextRefresh)
+		assert.Equal(t, []byte(fmt.Sprintf("token-%d", i)), refreshOut.Files["/tmp/token"].Contents)
+	}
+}
+
+func TestExpiringTokenFileRefreshError(t *testing.T) {
+	source := func(ctx context.Context, in sdk.ProvisionInput) ([]byte, time.Time, error) {
+		return nil, time.Time{}, errors.New("token endpoint unavailable")
+	}
+
+	p := ExpiringTokenFile(source, time.Minute)
+	out := sdk.ProvisionOutput{Files: make(map[string]sdk.OutputFile)}
+	p.(sdk.Refresher).Refresh(context.Background(), sdk.ProvisionInput{TempDir: "/tmp"}, &out)
+	assert.Equal(t, []sdk.Error{{Message: "refreshing token: token endpoint unavailable"}}, out.Diagnostics.Errors)
+	assert.True(t, out.NextRefresh.IsZero())
+	assert.Empty(t, out.Files)
+
+	out = sdk.ProvisionOutput{Files: make(map[string]sdk.OutputFile)}
+	p.Provision(context.Background(), sdk.ProvisionInput{TempDir: "/tmp"}, &out)
+	assert.Equal(t, []sdk.Error{{Message: "token endpoint unavailable"}}, out.Diagnostics.Errors)
+}
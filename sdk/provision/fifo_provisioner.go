@@ -0,0 +1,111 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+var errFIFOUnsupported = errors.New("named pipes are not supported on this platform")
+
+// FIFOProvisioner provisions a secret through a named pipe, so that the secret never gets written to disk.
+type FIFOProvisioner struct {
+	sdk.Provisioner
+
+	fieldName sdk.FieldName
+	file      FileProvisioner
+}
+
+// FIFO creates a FIFOProvisioner that exposes the value of the specified field through a named pipe in the
+// temp dir. The value gets written to the pipe once the executable opens it for reading. The path of the pipe
+// gets provisioned as the specified environment variable, if set. The file options can be used to further
+// influence the name of the pipe and how its path is passed to the executable, e.g. with provision.AddArgs.
+//
+// On platforms that don't support named pipes, such as Windows, the value gets provisioned as a temp file instead.
+func FIFO(fieldName sdk.FieldName, envVarForPath string, opts ...FileOption) sdk.Provisioner {
+	file := FileProvisioner{}
+	if envVarForPath != "" {
+		SetPathAsEnvVar(envVarForPath)(&file)
+	}
+	for _, opt := range opts {
+		opt(&file)
+	}
+
+	return FIFOProvisioner{
+		fieldName: fieldName,
+		file:      file,
+	}
+}
+
+func (p FIFOProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	value, ok := in.ItemFields[p.fieldName]
+	if !ok {
+		out.AddError(fmt.Errorf("no value present in the item for field '%s'", p.fieldName))
+		return
+	}
+
+	outpath, err := p.file.outputPath(in)
+	if err != nil {
+		out.AddError(err)
+		return
+	}
+
+	if in.DryRun {
+		out.AddPlan(fmt.Sprintf("Create named pipe %s that provides the value of %s", outpath, p.fieldName))
+	} else {
+		err = mkfifo(outpath)
+		if errors.Is(err, errFIFOUnsupported) {
+			out.AddWarning(fmt.Sprintf("%s, provisioning %s as a temporary file instead", err, p.fieldName))
+			out.AddSecretFile(outpath, []byte(value))
+		} else if err != nil {
+			out.AddError(fmt.Errorf("creating named pipe: %s", err))
+			return
+		} else {
+			go writeFIFO(outpath, []byte(value))
+			out.AddState(p.stateKey(), outpath)
+		}
+	}
+
+	p.file.addPathReferences(outpath, out)
+}
+
+func (p FIFOProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	path, ok := in.State[p.stateKey()]
+	if !ok {
+		// Nothing to do here: no named pipe got created.
+		return
+	}
+
+	// Unblock the writer of this execution, also if the executable never opened the pipe, and remove the pipe.
+	// The writer may run in another process, so it gets unblocked through the pipe itself.
+	unblockFIFOWriter(path)
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		out.AddError(fmt.Errorf("removing named pipe: %s", err))
+	}
+}
+
+// stateKey returns the key of the state that holds the path to the named pipe.
+func (p FIFOProvisioner) stateKey() string {
+	return fmt.Sprintf("fifo|%s", p.fieldName)
+}
+
+func (p FIFOProvisioner) Description() string {
+	return fmt.Sprintf("Provision %s through a named pipe", p.fieldName)
+}
+
+// writeFIFO writes contents to a named pipe once, as soon as a reader opens the pipe. If the pipe only got opened
+// to unblock the writer, the reader is gone by the time the contents get written, so they don't get handed out.
+func writeFIFO(path string, contents []byte) {
+	// Opening a named pipe for writing blocks until the other end gets opened for reading.
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	_, _ = f.Write(contents)
+}
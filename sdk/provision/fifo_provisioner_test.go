@@ -0,0 +1,91 @@
+//go:build !windows
+
+package provision
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func provisionFIFO(t *testing.T, provisioner sdk.Provisioner, tempDir string) sdk.ProvisionOutput {
+	t.Helper()
+
+	out := sdk.ProvisionOutput{
+		Environment: make(map[string]string),
+		Files:       make(map[string]sdk.OutputFile),
+	}
+	provisioner.Provision(context.Background(), sdk.ProvisionInput{
+		ItemFields: map[sdk.FieldName]string{fieldname.Token: "abc123"},
+		TempDir:    tempDir,
+	}, &out)
+	require.Empty(t, out.Diagnostics.Errors)
+	return out
+}
+
+// deprovisionFIFO deprovisions in the background, so that a writer that never gets unblocked fails the test
+// instead of hanging it.
+func deprovisionFIFO(t *testing.T, provisioner sdk.Provisioner, in sdk.DeprovisionInput) sdk.DeprovisionOutput {
+	t.Helper()
+
+	done := make(chan sdk.DeprovisionOutput)
+	go func() {
+		var out sdk.DeprovisionOutput
+		provisioner.Deprovision(context.Background(), in, &out)
+		done <- out
+	}()
+
+	select {
+	case out := <-done:
+		return out
+	case <-time.After(5 * time.Second):
+		t.Fatal("deprovisioning did not finish in time")
+		return sdk.DeprovisionOutput{}
+	}
+}
+
+func TestFIFOProvisionerRead(t *testing.T) {
+	tempDir := t.TempDir()
+	provisioner := FIFO(fieldname.Token, "TOKEN_FILE", Filename("token"))
+	out := provisionFIFO(t, provisioner, tempDir)
+
+	path := filepath.Join(tempDir, "token")
+	assert.Equal(t, map[string]string{"TOKEN_FILE": path}, out.Environment)
+	assert.Equal(t, map[string]string{"fifo|Token": path}, out.State)
+	assert.Empty(t, out.Files, "the value should not be written to disk")
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeNamedPipe, info.Mode()&os.ModeNamedPipe)
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", string(contents))
+
+	deprovisionOut := deprovisionFIFO(t, provisioner, sdk.DeprovisionInput{TempDir: tempDir, State: out.State})
+	assert.Empty(t, deprovisionOut.Diagnostics.Errors)
+	assert.NoFileExists(t, path)
+}
+
+func TestFIFOProvisionerNeverRead(t *testing.T) {
+	tempDir := t.TempDir()
+	out := provisionFIFO(t, FIFO(fieldname.Token, "TOKEN_FILE"), tempDir)
+	path := out.Environment["TOKEN_FILE"]
+
+	// Deprovision with a fresh provisioner and only the state, like a different plugin process would.
+	deprovisionOut := deprovisionFIFO(t, FIFO(fieldname.Token, "TOKEN_FILE"), sdk.DeprovisionInput{TempDir: tempDir, State: out.State})
+	assert.Empty(t, deprovisionOut.Diagnostics.Errors)
+	assert.NoFileExists(t, path)
+}
+
+func TestFIFOProvisionerDeprovisionWithoutState(t *testing.T) {
+	deprovisionOut := deprovisionFIFO(t, FIFO(fieldname.Token, "TOKEN_FILE"), sdk.DeprovisionInput{TempDir: t.TempDir()})
+	assert.Empty(t, deprovisionOut.Diagnostics.Errors)
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package provision
+
+import (
+	"os"
+	"syscall"
+)
+
+func mkfifo(path string) error {
+	return syscall.Mkfifo(path, 0600)
+}
+
+// unblockFIFOWriter briefly opens the named pipe for reading, so that a writer that's waiting for a reader
+// gets unblocked.
+func unblockFIFOWriter(path string) {
+	f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return
+	}
+	_ = f.Close()
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package provision
+
+func mkfifo(path string) error {
+	return errFIFOUnsupported
+}
+
+func unblockFIFOWriter(path string) {
+	// Nothing to do here: named pipes never get created on Windows.
+}
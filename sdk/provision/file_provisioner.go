@@ -4,8 +4,12 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io/fs"
 	"path/filepath"
+	"strings"
 	"text/template"
 
 	"github.com/1Password/shell-plugins/sdk"
@@ -17,17 +21,26 @@ type FileProvisioner struct {
 
 	fileContents        ItemToFileContents
 	outfileName         string
+	outfileExtension    string
+	fileMode            fs.FileMode
+	trailingNewline     bool
+	checksumField       sdk.FieldName
 	outpathFixed        string
+	configHomePath      []string
 	outpathEnvVar       string
 	outdirEnvVar        string
+	outdirAsWorkingDir  bool
 	setOutpathAsArg     bool
 	outpathArgTemplates []string
 }
 
 type ItemToFileContents func(in sdk.ProvisionInput) ([]byte, error)
 
-// FieldAsFile can be used to store the value of a single field as a file.
-func FieldAsFile(fieldName sdk.FieldName) ItemToFileContents {
+// FieldAsFileContents can be used to store the value of a single field as a file.
+//
+// This used to be called FieldAsFile, which is now a provisioner on its own: replace `TempFile(FieldAsFile(f), opts...)`
+// by `FieldAsFile(f, opts...)`, or by `TempFile(FieldAsFileContents(f), opts...)` to keep provisioning empty values.
+func FieldAsFileContents(fieldName sdk.FieldName) ItemToFileContents {
 	return ItemToFileContents(func(in sdk.ProvisionInput) ([]byte, error) {
 		if value, ok := in.ItemFields[fieldName]; ok {
 			return []byte(value), nil
@@ -37,6 +50,26 @@ func FieldAsFile(fieldName sdk.FieldName) ItemToFileContents {
 	})
 }
 
+// FieldAsFile returns a file provisioner that stores the value of a single field as is in a temp file. An empty value
+// is reported as an error. The file options can be used to make the path known to the executable, e.g.
+// `FieldAsFile(fieldname.Credentials, SetPathAsEnvVar("GOOGLE_APPLICATION_CREDENTIALS"), FileExtension(".json"))`
+// or `FieldAsFile(fieldname.PrivateKey, AddArgs("--key-file", "{{ .Path }}"))`.
+func FieldAsFile(fieldName sdk.FieldName, opts ...FileOption) sdk.Provisioner {
+	return TempFile(nonEmptyFieldAsFileContents(fieldName), opts...)
+}
+
+// nonEmptyFieldAsFileContents is like FieldAsFileContents, but also reports an empty value as missing, since an empty
+// credential file is never what the executable expects.
+func nonEmptyFieldAsFileContents(fieldName sdk.FieldName) ItemToFileContents {
+	return ItemToFileContents(func(in sdk.ProvisionInput) ([]byte, error) {
+		if value, ok := in.ItemFields[fieldName]; ok && value != "" {
+			return []byte(value), nil
+		} else {
+			return nil, fmt.Errorf("no value present in the item for field '%s'", fieldName)
+		}
+	})
+}
+
 // TempFile returns a file provisioner and takes a function that maps a 1Password item to the contents of
 // a single file.
 func TempFile(fileContents ItemToFileContents, opts ...FileOption) sdk.Provisioner {
@@ -60,15 +93,59 @@ func AtFixedPath(path string) FileOption {
 	}
 }
 
+// InSandboxedConfigHome can be used to store the credential at the specified path relative to a config directory
+// in the temp dir, e.g. InSandboxedConfigHome("gh", "hosts.yml"), and to point XDG_CONFIG_HOME at that directory.
+// This is useful for executables that only load credentials from $XDG_CONFIG_HOME/<tool>, since everything
+// they write there gets cleaned up automatically. Note that the executable won't see any other config in the user's
+// own config directory. Gets ignored if the provision.AtFixedPath option is also set.
+func InSandboxedConfigHome(path ...string) FileOption {
+	return func(p *FileProvisioner) {
+		p.configHomePath = path
+	}
+}
+
 // Filename can be used to tell the file provisioner to store the credential with a specific name, instead of
 // an autogenerated name. The specified filename will be appended to the path of the autogenerated temp dir.
-// Gets ignored if the provision.AtFixedPath option is also set.
+// Gets ignored if the provision.AtFixedPath or provision.InSandboxedConfigHome option is also set.
 func Filename(name string) FileOption {
 	return func(p *FileProvisioner) {
 		p.outfileName = name
 	}
 }
 
+// FileExtension can be used to add an extension to the autogenerated file name, e.g. ".pem" or ".json", for
+// executables that infer the file format from it. Gets ignored if the provision.Filename, provision.AtFixedPath,
+// or provision.InSandboxedConfigHome option is also set.
+func FileExtension(extension string) FileOption {
+	return func(p *FileProvisioner) {
+		p.outfileExtension = extension
+	}
+}
+
+// FileMode can be used to write the file with specific permissions, instead of the default 0600.
+func FileMode(mode fs.FileMode) FileOption {
+	return func(p *FileProvisioner) {
+		p.fileMode = mode
+	}
+}
+
+// AddTrailingNewline can be used to end the file contents with a newline, if they don't already, for executables
+// that can't read files without one. By default, the contents are written exactly as is.
+func AddTrailingNewline() FileOption {
+	return func(p *FileProvisioner) {
+		p.trailingNewline = true
+	}
+}
+
+// VerifySHA256 can be used to verify the file contents against the hex-encoded SHA-256 checksum stored in the
+// specified field, e.g. to detect binary material that got truncated when it was stored. The checksum may be prefixed
+// with "sha256:". A mismatch gets reported as an error and the file doesn't get provisioned.
+func VerifySHA256(checksumField sdk.FieldName) FileOption {
+	return func(p *FileProvisioner) {
+		p.checksumField = checksumField
+	}
+}
+
 // SetPathAsEnvVar can be used to provision the temporary file path as an environment variable.
 func SetPathAsEnvVar(envVarName string) FileOption {
 	return func(p *FileProvisioner) {
@@ -83,6 +160,14 @@ func SetOutputDirAsEnvVar(envVarName string) FileOption {
 	}
 }
 
+// SetOutputDirAsWorkingDir can be used to run the executable from the directory of the output file. This is useful
+// for executables that only look for files in their working directory, such as .env files.
+func SetOutputDirAsWorkingDir() FileOption {
+	return func(p *FileProvisioner) {
+		p.outdirAsWorkingDir = true
+	}
+}
+
 // AddArgs can be used to add args to the command line. This is useful when the output file path
 // should be passed as an arg. The output path is available as "{{ .Path }}" in each arg.
 // For example:
@@ -96,32 +181,77 @@ func AddArgs(argTemplates ...string) FileOption {
 }
 
 func (p FileProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
-	contents, err := p.fileContents(in)
+	var contents []byte
+	if in.DryRun {
+		contents = []byte(sdk.ConcealedFileContents)
+	} else {
+		var err error
+		contents, err = p.fileContents(in)
+		if err != nil {
+			out.AddError(err)
+			return
+		}
+		if p.checksumField != "" {
+			if err := verifySHA256(contents, in, p.checksumField); err != nil {
+				out.AddError(err)
+				return
+			}
+		}
+		if p.trailingNewline && !bytes.HasSuffix(contents, []byte("\n")) {
+			contents = append(contents, '\n')
+		}
+	}
+
+	outpath, err := p.outputPath(in)
 	if err != nil {
 		out.AddError(err)
 		return
 	}
 
-	outpath := ""
+	out.AddFile(outpath, sdk.OutputFile{
+		Contents: contents,
+		FileMode: p.fileMode,
+	})
+	if in.DryRun {
+		out.AddPlan(fmt.Sprintf("Write secret file %s", outpath))
+	}
+
+	if p.outpathFixed == "" && len(p.configHomePath) > 0 {
+		out.AddEnvVar("XDG_CONFIG_HOME", in.FromTempDir(sandboxedConfigHomeDir))
+	}
+
+	p.addPathReferences(outpath, out)
+}
+
+// sandboxedConfigHomeDir is the directory in the temp dir that XDG_CONFIG_HOME points at when using the
+// provision.InSandboxedConfigHome option.
+const sandboxedConfigHomeDir = "config"
+
+// outputPath resolves the path the file should be provisioned at, based on the configured options.
+func (p FileProvisioner) outputPath(in sdk.ProvisionInput) (string, error) {
 	if p.outpathFixed != "" {
 		// Default to the provision.AtFixedPath option
-		outpath = p.outpathFixed
+		return p.outpathFixed, nil
+	} else if len(p.configHomePath) > 0 {
+		// Fall back to the provision.InSandboxedConfigHome option
+		return in.FromTempDir(append([]string{sandboxedConfigHomeDir}, p.configHomePath...)...), nil
 	} else if p.outfileName != "" {
 		// Fall back to the provision.Filename option
-		outpath = in.FromTempDir(p.outfileName)
-	} else {
-		// If both are undefined, resort to generating a random filename
-		fileName, err := randomFilename()
-		if err != nil {
-			// This should only fail in rare circumstances
-			out.AddError(fmt.Errorf("generating random file name: %s", err))
-			return
-		}
-		outpath = in.FromTempDir(fileName)
+		return in.FromTempDir(p.outfileName), nil
 	}
 
-	out.AddSecretFile(outpath, contents)
+	// If both are undefined, resort to generating a random filename
+	fileName, err := randomFilename()
+	if err != nil {
+		// This should only fail in rare circumstances
+		return "", fmt.Errorf("generating random file name: %s", err)
+	}
+	return in.FromTempDir(fileName + p.outfileExtension), nil
+}
 
+// addPathReferences makes the output path known to the executable, through environment variables and/or
+// command-line args, based on the configured options.
+func (p FileProvisioner) addPathReferences(outpath string, out *sdk.ProvisionOutput) {
 	if p.outpathEnvVar != "" {
 		// Populate the specified environment variable with the output path.
 		out.AddEnvVar(p.outpathEnvVar, outpath)
@@ -133,6 +263,10 @@ func (p FileProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, o
 		out.AddEnvVar(p.outpathEnvVar, dir)
 	}
 
+	if p.outdirAsWorkingDir {
+		out.SetWorkingDir(filepath.Dir(outpath))
+	}
+
 	// Add args to specify the output path.
 	if p.setOutpathAsArg {
 		tmplData := struct{ Path string }{
@@ -171,6 +305,21 @@ func (p FileProvisioner) Description() string {
 	return "Provision secret file"
 }
 
+// verifySHA256 checks whether the SHA-256 checksum of the contents matches the one stored in the checksum field.
+func verifySHA256(contents []byte, in sdk.ProvisionInput, checksumField sdk.FieldName) error {
+	expected, ok := in.ItemFields[checksumField]
+	if !ok {
+		return fmt.Errorf("no value present in the item for field '%s'", checksumField)
+	}
+	expected = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(expected), "sha256:"))
+
+	actual := sha256.Sum256(contents)
+	if hex.EncodeToString(actual[:]) != expected {
+		return fmt.Errorf("file contents do not match the SHA-256 checksum in field '%s'", checksumField)
+	}
+	return nil
+}
+
 func randomFilename() (string, error) {
 	b := make([]byte, 16)
 	_, err := rand.Read(b)
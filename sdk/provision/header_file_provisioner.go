@@ -0,0 +1,101 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// HeaderFileProvisioner provisions an HTTP header as a temp file, so that it doesn't show up in the command line.
+type HeaderFileProvisioner struct {
+	sdk.Provisioner
+
+	headerName    string
+	valueTemplate string
+	file          FileProvisioner
+}
+
+// HeaderFile creates a HeaderFileProvisioner that writes a single HTTP header line to a temp file. The value template
+// has access to all item fields by their field name, e.g. "Bearer {{ .Token }}", or `{{ index . "API Key" }}` for field
+// names that contain spaces. For basic auth, the base64 function encodes the credentials, e.g.
+// `Basic {{ base64 (printf "%s:%s" .Username .Password) }}`.
+// By default, the file gets passed to the executable as `-H @/path/to/headers`, which is understood by curl 7.55 and
+// up. The file options can be used to pass the path differently instead, e.g. AddArgs("--headers-file", "{{ .Path }}")
+// or SetPathAsEnvVar("HEADERS_FILE"). When multiple header file provisioners are chained for the same executable,
+// their headers get combined into a single file.
+func HeaderFile(headerName string, valueTemplate string, opts ...FileOption) sdk.Provisioner {
+	file := FileProvisioner{
+		outfileName: "headers",
+	}
+	for _, opt := range opts {
+		opt(&file)
+	}
+
+	// Default to curl's syntax if the path is not passed to the executable in any other way.
+	if !file.setOutpathAsArg && file.outpathEnvVar == "" {
+		file.setOutpathAsArg = true
+		file.outpathArgTemplates = []string{"-H", "@{{ .Path }}"}
+	}
+
+	return HeaderFileProvisioner{
+		headerName:    headerName,
+		valueTemplate: valueTemplate,
+		file:          file,
+	}
+}
+
+func (p HeaderFileProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	if in.DryRun {
+		in = in.Concealed()
+	}
+
+	value, err := executeTemplate("header", p.valueTemplate, fieldTemplateData(in))
+	if err != nil {
+		out.AddError(fmt.Errorf("resolving template for header %s: %s", p.headerName, err))
+		return
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		out.AddError(fmt.Errorf("value for header %s must not contain line breaks", p.headerName))
+		return
+	}
+
+	outpath, err := p.file.outputPath(in)
+	if err != nil {
+		out.AddError(err)
+		return
+	}
+
+	line := []byte(fmt.Sprintf("%s: %s\n", p.headerName, value))
+	if in.DryRun {
+		out.AddPlan(fmt.Sprintf("Add %s header to %s", p.headerName, outpath))
+	}
+
+	// Add the header to the file of a previous header file provisioner, if there is one. In that case, the path is
+	// already known to the executable.
+	if existing, ok := out.Files[outpath]; ok {
+		contents := make([]byte, 0, len(existing.Contents)+len(line))
+		contents = append(contents, existing.Contents...)
+		contents = append(contents, line...)
+		out.AddFile(outpath, sdk.OutputFile{
+			Contents: contents,
+			FileMode: existing.FileMode,
+		})
+		return
+	}
+
+	out.AddFile(outpath, sdk.OutputFile{
+		Contents: line,
+		FileMode: p.file.fileMode,
+	})
+	p.file.addPathReferences(outpath, out)
+}
+
+func (p HeaderFileProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	// Nothing to do here: deleting the files gets taken care of.
+}
+
+func (p HeaderFileProvisioner) Description() string {
+	return fmt.Sprintf("Provision %s header as a file", p.headerName)
+}
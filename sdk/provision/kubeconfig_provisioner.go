@@ -0,0 +1,213 @@
+package provision
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"gopkg.in/yaml.v3"
+)
+
+// KubeConfigProvisioner provisions a Kubernetes cluster and the credentials to access it as a kubeconfig file, which
+// is read by kubectl, helm, k9s, flux, and other Kubernetes tools.
+type KubeConfigProvisioner struct {
+	sdk.Provisioner
+
+	name              string
+	fields            KubeConfigFields
+	mergeWithExisting bool
+}
+
+// KubeConfigFields specifies which item fields hold the cluster details and credentials. Server is required. For
+// the user, either Token or both ClientCertificate and ClientKey should be present in the item. Certificates and
+// keys can be stored either PEM-encoded or as base64-encoded PEM.
+type KubeConfigFields struct {
+	Server               sdk.FieldName
+	CertificateAuthority sdk.FieldName
+	Token                sdk.FieldName
+	ClientCertificate    sdk.FieldName
+	ClientKey            sdk.FieldName
+}
+
+// KubeConfigOption can be used to influence the behavior of the kubeconfig provisioner.
+type KubeConfigOption func(*KubeConfigProvisioner)
+
+// KubeConfig creates a KubeConfigProvisioner that writes a kubeconfig with a single cluster, user, and context, all
+// with the specified name, to a temp file and points the KUBECONFIG environment variable at it.
+func KubeConfig(name string, fields KubeConfigFields, opts ...KubeConfigOption) sdk.Provisioner {
+	p := KubeConfigProvisioner{
+		name:   name,
+		fields: fields,
+	}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
+
+// KubeConfigMergeWithExisting can be used to keep the user's existing kubeconfig files available to the executable.
+// The provisioned kubeconfig gets prepended to the KUBECONFIG path list of the user's environment, as passed by the
+// host in sdk.ProvisionInput.ParentEnvVarValues, or to ~/.kube/config if that's not known, so its context is the
+// current context, while other contexts can still be selected.
+func KubeConfigMergeWithExisting() KubeConfigOption {
+	return func(p *KubeConfigProvisioner) {
+		p.mergeWithExisting = true
+	}
+}
+
+type kubeConfig struct {
+	APIVersion     string             `yaml:"apiVersion"`
+	Kind           string             `yaml:"kind"`
+	Clusters       []kubeNamedCluster `yaml:"clusters"`
+	Users          []kubeNamedUser    `yaml:"users"`
+	Contexts       []kubeNamedContext `yaml:"contexts"`
+	CurrentContext string             `yaml:"current-context"`
+}
+
+type kubeNamedCluster struct {
+	Name    string      `yaml:"name"`
+	Cluster kubeCluster `yaml:"cluster"`
+}
+
+type kubeCluster struct {
+	Server                   string `yaml:"server"`
+	CertificateAuthorityData string `yaml:"certificate-authority-data,omitempty"`
+}
+
+type kubeNamedUser struct {
+	Name string   `yaml:"name"`
+	User kubeUser `yaml:"user"`
+}
+
+type kubeUser struct {
+	Token                 string `yaml:"token,omitempty"`
+	ClientCertificateData string `yaml:"client-certificate-data,omitempty"`
+	ClientKeyData         string `yaml:"client-key-data,omitempty"`
+}
+
+type kubeNamedContext struct {
+	Name    string      `yaml:"name"`
+	Context kubeContext `yaml:"context"`
+}
+
+type kubeContext struct {
+	Cluster string `yaml:"cluster"`
+	User    string `yaml:"user"`
+}
+
+func (p KubeConfigProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	if in.DryRun {
+		in = in.Concealed()
+	}
+
+	contents, err := p.render(in)
+	if err != nil {
+		out.AddError(err)
+		return
+	}
+
+	outpath := in.FromTempDir("kubeconfig")
+	out.AddSecretFile(outpath, contents)
+	if in.DryRun {
+		out.AddPlan(fmt.Sprintf("Write kubeconfig for %s to %s", p.name, outpath))
+	}
+
+	kubeconfigPaths := []string{outpath}
+	if p.mergeWithExisting {
+		if existing, ok := in.ParentEnvVarValue("KUBECONFIG"); ok && existing != "" {
+			kubeconfigPaths = append(kubeconfigPaths, existing)
+		} else {
+			kubeconfigPaths = append(kubeconfigPaths, in.FromHomeDir(".kube", "config"))
+		}
+	}
+	out.AddEnvVar("KUBECONFIG", strings.Join(kubeconfigPaths, string(filepath.ListSeparator)))
+}
+
+func (p KubeConfigProvisioner) render(in sdk.ProvisionInput) ([]byte, error) {
+	server, ok := in.ItemFields[p.fields.Server]
+	if !ok {
+		return nil, fmt.Errorf("no value present in the item for field '%s'", p.fields.Server)
+	}
+
+	cluster := kubeCluster{Server: server}
+	if caCert, ok := in.ItemFields[p.fields.CertificateAuthority]; ok && p.fields.CertificateAuthority != "" {
+		data, err := kubeConfigData(caCert, in.DryRun)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for field '%s': %s", p.fields.CertificateAuthority, err)
+		}
+		cluster.CertificateAuthorityData = data
+	}
+
+	var user kubeUser
+	if token, ok := in.ItemFields[p.fields.Token]; ok && p.fields.Token != "" {
+		user.Token = token
+	} else if clientCert, ok := in.ItemFields[p.fields.ClientCertificate]; ok && p.fields.ClientCertificate != "" {
+		clientKey, ok := in.ItemFields[p.fields.ClientKey]
+		if !ok {
+			return nil, fmt.Errorf("no value present in the item for field '%s'", p.fields.ClientKey)
+		}
+
+		data, err := kubeConfigData(clientCert, in.DryRun)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for field '%s': %s", p.fields.ClientCertificate, err)
+		}
+		user.ClientCertificateData = data
+
+		data, err = kubeConfigData(clientKey, in.DryRun)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for field '%s': %s", p.fields.ClientKey, err)
+		}
+		user.ClientKeyData = data
+	} else {
+		return nil, fmt.Errorf("no token or client certificate present in the item")
+	}
+
+	config := kubeConfig{
+		APIVersion:     "v1",
+		Kind:           "Config",
+		Clusters:       []kubeNamedCluster{{Name: p.name, Cluster: cluster}},
+		Users:          []kubeNamedUser{{Name: p.name, User: user}},
+		Contexts:       []kubeNamedContext{{Name: p.name, Context: kubeContext{Cluster: p.name, User: p.name}}},
+		CurrentContext: p.name,
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	err := encoder.Encode(config)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// kubeConfigData converts a PEM-encoded or base64-encoded PEM value to the base64 format used by the "-data" fields
+// in a kubeconfig. In a dry run, the value is a placeholder and gets returned as is.
+func kubeConfigData(value string, dryRun bool) (string, error) {
+	if dryRun {
+		return value, nil
+	}
+
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "-----BEGIN") {
+		return base64.StdEncoding.EncodeToString([]byte(value + "\n")), nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+	if err != nil || !bytes.HasPrefix(bytes.TrimSpace(decoded), []byte("-----BEGIN")) {
+		return "", fmt.Errorf("expected PEM or base64-encoded PEM")
+	}
+	return base64.StdEncoding.EncodeToString(decoded), nil
+}
+
+func (p KubeConfigProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	// Nothing to do here: deleting the files gets taken care of.
+}
+
+func (p KubeConfigProvisioner) Description() string {
+	return fmt.Sprintf("Provision kubeconfig for %s", p.name)
+}
@@ -0,0 +1,210 @@
+package provision
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// localCredentialServerPath is the path at which the local credential server serves the credentials.
+const localCredentialServerPath = "/credentials"
+
+// LocalCredentialServerProvisioner provisions credentials through an HTTP server on the loopback interface that only
+// lives as long as the executable.
+type LocalCredentialServerProvisioner struct {
+	sdk.Provisioner
+
+	urlEnvVar           string
+	authorizationEnvVar string
+	document            map[string]sdk.FieldName
+	servers             *credentialServers
+}
+
+// LocalCredentialServer creates a LocalCredentialServerProvisioner that serves a JSON document built from the item
+// fields over HTTP on 127.0.0.1, at a random port. The document maps each JSON key to the value of the specified field.
+// The URL of the document gets set as the urlEnvVar environment variable and requests to it are only answered if they
+// contain the header `Authorization: Bearer <token>`, with a token that's generated for each execution. The full
+// header value gets set as the authorizationEnvVar environment variable. For example, to provide credentials to the
+// AWS SDKs through the container credential provider:
+//
+//	LocalCredentialServer("AWS_CONTAINER_CREDENTIALS_FULL_URI", "AWS_CONTAINER_AUTHORIZATION_TOKEN", map[string]sdk.FieldName{
+//		"AccessKeyId":     fieldname.AccessKeyID,
+//		"SecretAccessKey": fieldname.SecretAccessKey,
+//	})
+//
+// The server gets shut down when the executable exits.
+func LocalCredentialServer(urlEnvVar string, authorizationEnvVar string, document map[string]sdk.FieldName) sdk.Provisioner {
+	return LocalCredentialServerProvisioner{
+		urlEnvVar:           urlEnvVar,
+		authorizationEnvVar: authorizationEnvVar,
+		document:            document,
+		servers: &credentialServers{
+			byAddr: make(map[string]*http.Server),
+		},
+	}
+}
+
+func (p LocalCredentialServerProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	document := make(map[string]string, len(p.document))
+	for _, key := range sortedKeys(p.document) {
+		fieldName := p.document[key]
+		value, ok := in.ItemFields[fieldName]
+		if !ok {
+			out.AddError(fmt.Errorf("no value present in the item for field '%s'", fieldName))
+			return
+		}
+		document[key] = value
+	}
+
+	if in.DryRun {
+		out.AddEnvVar(p.urlEnvVar, "http://127.0.0.1:<port>"+localCredentialServerPath)
+		out.AddEnvVar(p.authorizationEnvVar, "Bearer <concealed:token>")
+		out.AddPlan(fmt.Sprintf("Serve %s on 127.0.0.1 and set %s to its URL", joinFieldNames(p.document), p.urlEnvVar))
+		return
+	}
+
+	body, err := json.Marshal(document)
+	if err != nil {
+		out.AddError(err)
+		return
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		out.AddError(fmt.Errorf("generating token: %s", err))
+		return
+	}
+	authorization := "Bearer " + token
+
+	addr, err := p.servers.start(authorization, body)
+	if err != nil {
+		out.AddError(fmt.Errorf("starting local credential server: %s", err))
+		return
+	}
+
+	out.AddEnvVar(p.urlEnvVar, "http://"+addr+localCredentialServerPath)
+	out.AddEnvVar(p.authorizationEnvVar, authorization)
+	out.AddState(p.stateKey(), addr)
+}
+
+func (p LocalCredentialServerProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	addr, ok := in.State[p.stateKey()]
+	if !ok {
+		// Nothing to do here: no server got started.
+		return
+	}
+
+	if err := p.servers.stop(ctx, addr); err != nil {
+		out.AddError(err)
+	}
+}
+
+// stateKey returns the key of the state that holds the address of the server.
+func (p LocalCredentialServerProvisioner) stateKey() string {
+	return fmt.Sprintf("local-credential-server|%s", p.urlEnvVar)
+}
+
+func (p LocalCredentialServerProvisioner) Description() string {
+	return fmt.Sprintf("Provision %s through a local credential server", joinFieldNames(p.document))
+}
+
+// joinFieldNames returns the field names of the document in a deterministic order, for use in descriptions.
+func joinFieldNames(document map[string]sdk.FieldName) string {
+	names := make([]string, 0, len(document))
+	for _, key := range sortedKeys(document) {
+		names = append(names, document[key].String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// credentialServers keeps track of the servers that are running for each execution, identified by their address.
+type credentialServers struct {
+	mu     sync.Mutex
+	byAddr map[string]*http.Server
+}
+
+func (s *credentialServers) start(authorization string, body []byte) (string, error) {
+	// Only bind to the loopback interface, so that the credentials can't be fetched from other machines.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+
+	server := &http.Server{
+		Handler:           credentialHandler(authorization, body),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	addr := listener.Addr().String()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.byAddr[addr] = server
+	return addr, nil
+}
+
+func (s *credentialServers) stop(ctx context.Context, addr string) error {
+	s.mu.Lock()
+	server, ok := s.byAddr[addr]
+	delete(s.byAddr, addr)
+	s.mu.Unlock()
+
+	if !ok {
+		// The server is not running in this process anymore, so there's nothing left to clean up.
+		return nil
+	}
+
+	err := server.Shutdown(ctx)
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		// Don't wait for clients that outlived the executable.
+		err = server.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("stopping local credential server: %s", err)
+	}
+	return nil
+}
+
+// credentialHandler serves the document at the credentials path to requests with the expected Authorization header.
+func credentialHandler(authorization string, body []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(authorization)) != 1 {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != localCredentialServerPath {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodGet {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write(body)
+	})
+}
+
+// randomToken returns a random hex-encoded token that can't be guessed by other processes.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", b), nil
+}
@@ -0,0 +1,322 @@
+package provision
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v3"
+)
+
+// MergeFormat describes the format of a config file that secrets get merged into.
+type MergeFormat string
+
+const (
+	MergeJSON MergeFormat = "json"
+	MergeYAML MergeFormat = "yaml"
+	MergeINI  MergeFormat = "ini"
+)
+
+// MergeFileProvisioner provisions secrets by merging them into an existing config file at a fixed path, and
+// restores the original file on deprovision.
+type MergeFileProvisioner struct {
+	sdk.Provisioner
+
+	path   string
+	format MergeFormat
+	values map[string]string
+}
+
+// MergeFile creates a MergeFileProvisioner that merges the specified values into the config file at the specified
+// path, which may start with "~/" to point to the user's home directory. This is useful for executables that can only
+// load credentials from a fixed path that may already contain the user's own settings.
+//
+// The keys of the values are dot-separated paths, e.g. "auth.token" for JSON and YAML, or "section.key" for INI, in
+// which case keys without a dot end up in the default section. The values are text/templates that have access to all
+// item fields by their field name, e.g. "{{ .Token }}".
+//
+// The original file is backed up and gets restored exactly on deprovision, or deleted if it didn't exist before. The
+// backup records the process that created it: if that process is gone because a previous run got interrupted before
+// deprovisioning, the original file gets restored on the next run. If that process is still running, provisioning
+// fails instead of taking over the file of a concurrent run.
+func MergeFile(path string, format MergeFormat, values map[string]string) sdk.Provisioner {
+	return MergeFileProvisioner{
+		path:   path,
+		format: format,
+		values: values,
+	}
+}
+
+// mergeFileBackup contains the state of the config file before provisioning.
+type mergeFileBackup struct {
+	Existed  bool
+	Contents []byte
+	FileMode os.FileMode
+	// OwnerPID is the PID of the process that created the backup.
+	OwnerPID int
+}
+
+func (p MergeFileProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	path := p.resolvePath(in.HomeDir)
+
+	if in.DryRun {
+		in = in.Concealed()
+	}
+
+	tmplData := fieldTemplateData(in)
+	values := make(map[string]string)
+	for key, tmplStr := range p.values {
+		value, err := executeTemplate(key, tmplStr, tmplData)
+		if err != nil {
+			out.AddError(fmt.Errorf("resolving template for key '%s': %s", key, err))
+			return
+		}
+		values[key] = value
+	}
+
+	if in.DryRun {
+		out.AddPlan(fmt.Sprintf("Merge %s into %s and restore the original file afterwards", strings.Join(sortedKeys(p.values), ", "), path))
+		return
+	}
+
+	// Restore the original file if a previous run didn't get to deprovision. A backup that's owned by a process that's
+	// still running belongs to a concurrent run, which will restore the file itself.
+	if existing, err := readBackup(backupPath(path)); err == nil && processRunning(existing.OwnerPID) {
+		out.AddError(fmt.Errorf("%s is in use by another process (PID %d) that merged secrets into it", path, existing.OwnerPID))
+		return
+	}
+	if _, err := os.Stat(backupPath(path)); err == nil {
+		if err := restoreFromBackup(path, backupPath(path)); err != nil {
+			out.AddError(err)
+			return
+		}
+		out.AddWarning(fmt.Sprintf("restored %s, which was left behind by a previous run", path))
+	}
+
+	backup := mergeFileBackup{
+		FileMode: 0600,
+		OwnerPID: os.Getpid(),
+	}
+	contents, err := os.ReadFile(path)
+	if err == nil {
+		backup.Existed = true
+		backup.Contents = contents
+		if info, err := os.Stat(path); err == nil {
+			backup.FileMode = info.Mode().Perm()
+		}
+	} else if !os.IsNotExist(err) {
+		out.AddError(err)
+		return
+	}
+
+	merged, err := mergeValues(p.format, backup.Contents, values)
+	if err != nil {
+		out.AddError(fmt.Errorf("merging into %s: %s", path, err))
+		return
+	}
+
+	// Keep a copy of the backup in the temp dir as well, in case the backup next to the file gets lost.
+	if err := writeBackup(backupPath(path), backup); err != nil {
+		out.AddError(err)
+		return
+	}
+	tempBackupPath := in.FromTempDir(filepath.Base(backupPath(path)))
+	if err := writeBackup(tempBackupPath, backup); err != nil {
+		out.AddError(err)
+		return
+	}
+	out.AddState(mergeFileStateKey(path), tempBackupPath)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		out.AddError(err)
+		return
+	}
+	if err := os.WriteFile(path, merged, backup.FileMode); err != nil {
+		out.AddError(err)
+		return
+	}
+}
+
+func (p MergeFileProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	path := p.resolvePath(in.HomeDir)
+	tempBackupPath, ok := in.State[mergeFileStateKey(path)]
+	if !ok {
+		// Nothing to do here: the file didn't get merged into.
+		return
+	}
+
+	backup := backupPath(path)
+	if _, err := os.Stat(backup); os.IsNotExist(err) {
+		backup = tempBackupPath
+	}
+
+	if err := restoreFromBackup(path, backup); err != nil {
+		out.AddError(err)
+	}
+}
+
+func (p MergeFileProvisioner) Description() string {
+	return fmt.Sprintf("Provision secrets by merging them into %s", p.path)
+}
+
+func (p MergeFileProvisioner) resolvePath(homeDir string) string {
+	if strings.HasPrefix(p.path, "~/") {
+		return filepath.Join(homeDir, strings.TrimPrefix(p.path, "~/"))
+	}
+	return p.path
+}
+
+// mergeFileStateKey returns the key of the state that holds the location of the backup in the temp dir.
+func mergeFileStateKey(path string) string {
+	return "merge-file|" + path
+}
+
+// backupPath returns the path of the backup of the original file, which is stored next to the file so that it
+// can be found by consecutive runs.
+func backupPath(path string) string {
+	return filepath.Join(filepath.Dir(path), fmt.Sprintf(".%s.op-backup", filepath.Base(path)))
+}
+
+func writeBackup(path string, backup mergeFileBackup) error {
+	contents, err := json.Marshal(backup)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, contents, 0600)
+}
+
+func readBackup(path string) (mergeFileBackup, error) {
+	var backup mergeFileBackup
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return backup, err
+	}
+	err = json.Unmarshal(contents, &backup)
+	return backup, err
+}
+
+// restoreFromBackup restores the original file from the backup and removes the backup. If restoring fails, the file
+// gets removed altogether, so that no secrets are left behind.
+func restoreFromBackup(path string, backupPath string) error {
+	backup, err := readBackup(backupPath)
+	if err != nil {
+		return removeMergedFile(path, fmt.Errorf("reading backup of %s: %s", path, err))
+	}
+
+	if backup.Existed {
+		err = os.WriteFile(path, backup.Contents, backup.FileMode)
+		if err == nil {
+			err = os.Chmod(path, backup.FileMode)
+		}
+	} else {
+		err = os.Remove(path)
+		if os.IsNotExist(err) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return removeMergedFile(path, fmt.Errorf("restoring %s: %s", path, err))
+	}
+
+	err = os.Remove(backupPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func removeMergedFile(path string, cause error) error {
+	err := os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("%s; removing %s to not leave secrets behind also failed: %s", cause, path, err)
+	}
+	return fmt.Errorf("%s; removed %s to not leave secrets behind", cause, path)
+}
+
+// mergeValues sets the specified values in the contents, which are in the specified format.
+func mergeValues(format MergeFormat, contents []byte, values map[string]string) ([]byte, error) {
+	// Apply the values in a deterministic order.
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	switch format {
+	case MergeJSON, MergeYAML:
+		config := make(map[string]any)
+		if len(contents) > 0 {
+			var err error
+			if format == MergeJSON {
+				err = json.Unmarshal(contents, &config)
+			} else {
+				err = yaml.Unmarshal(contents, &config)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, key := range keys {
+			if err := setNestedValue(config, strings.Split(key, "."), values[key]); err != nil {
+				return nil, fmt.Errorf("setting '%s': %s", key, err)
+			}
+		}
+
+		if format == MergeJSON {
+			return json.MarshalIndent(config, "", "  ")
+		}
+		return yaml.Marshal(config)
+	case MergeINI:
+		config, err := ini.Load(contents)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			section, name := ini.DefaultSection, key
+			if i := strings.LastIndex(key, "."); i >= 0 {
+				section, name = key[:i], key[i+1:]
+			}
+			config.Section(section).Key(name).SetValue(values[key])
+		}
+
+		var result strings.Builder
+		if _, err := config.WriteTo(&result); err != nil {
+			return nil, err
+		}
+		return []byte(result.String()), nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+func setNestedValue(config map[string]any, path []string, value string) error {
+	if len(path) == 1 {
+		config[path[0]] = value
+		return nil
+	}
+
+	child, ok := config[path[0]]
+	if !ok || child == nil {
+		child = make(map[string]any)
+		config[path[0]] = child
+	}
+
+	childMap, ok := child.(map[string]any)
+	if !ok {
+		return errors.New("existing value is not an object")
+	}
+
+	return setNestedValue(childMap, path[1:], value)
+}
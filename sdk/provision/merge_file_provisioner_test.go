@@ -0,0 +1,189 @@
+package provision
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeFileProvisioner(t *testing.T) {
+	itemFields := map[sdk.FieldName]string{
+		fieldname.Token: "s3cret",
+	}
+
+	cases := map[string]struct {
+		format   MergeFormat
+		values   map[string]string
+		original *string
+		expected string
+	}{
+		"json into existing file": {
+			format:   MergeJSON,
+			values:   map[string]string{"auth.token": "{{ .Token }}"},
+			original: strPtr("{\"theme\":\"dark\",\"auth\":{\"user\":\"wendy\"}}\n"),
+			expected: "{\n  \"auth\": {\n    \"token\": \"s3cret\",\n    \"user\": \"wendy\"\n  },\n  \"theme\": \"dark\"\n}",
+		},
+		"yaml into new file": {
+			format:   MergeYAML,
+			values:   map[string]string{"auth.token": "{{ .Token }}"},
+			expected: "auth:\n    token: s3cret\n",
+		},
+		"ini into existing file": {
+			format:   MergeINI,
+			values:   map[string]string{"credentials.token": "{{ .Token }}"},
+			original: strPtr("; settings\n[settings]\ntheme = dark\n"),
+			expected: "; settings\n[settings]\ntheme = dark\n\n[credentials]\ntoken = s3cret\n",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			homeDir := t.TempDir()
+			tempDir := t.TempDir()
+			path := filepath.Join(homeDir, ".config", "tool", "config")
+			if c.original != nil {
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+				require.NoError(t, os.WriteFile(path, []byte(*c.original), 0640))
+			}
+
+			p := MergeFile("~/.config/tool/config", c.format, c.values)
+			out := sdk.ProvisionOutput{}
+			p.Provision(context.Background(), sdk.ProvisionInput{HomeDir: homeDir, TempDir: tempDir, ItemFields: itemFields}, &out)
+			require.Empty(t, out.Diagnostics.Errors)
+
+			merged, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, string(merged))
+
+			deprovisionOut := sdk.DeprovisionOutput{}
+			p.Deprovision(context.Background(), sdk.DeprovisionInput{HomeDir: homeDir, TempDir: tempDir, State: out.State}, &deprovisionOut)
+			require.Empty(t, deprovisionOut.Diagnostics.Errors)
+
+			restored, err := os.ReadFile(path)
+			if c.original == nil {
+				assert.True(t, os.IsNotExist(err))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, *c.original, string(restored))
+				info, err := os.Stat(path)
+				require.NoError(t, err)
+				assert.Equal(t, os.FileMode(0640), info.Mode().Perm())
+			}
+
+			_, err = os.Stat(backupPath(path))
+			assert.True(t, os.IsNotExist(err))
+		})
+	}
+}
+
+func TestMergeFileProvisionerRestoresAfterInterruptedRun(t *testing.T) {
+	homeDir := t.TempDir()
+	path := filepath.Join(homeDir, "config.json")
+	original := "{\"theme\":\"dark\"}"
+	require.NoError(t, os.WriteFile(path, []byte(original), 0600))
+
+	p := MergeFile("~/config.json", MergeJSON, map[string]string{"token": "{{ .Token }}"})
+	in := sdk.ProvisionInput{HomeDir: homeDir, TempDir: t.TempDir(), ItemFields: map[sdk.FieldName]string{fieldname.Token: "s3cret"}}
+
+	// The first run never gets to deprovision, because its process got killed.
+	p.Provision(context.Background(), in, &sdk.ProvisionOutput{})
+	backup, err := readBackup(backupPath(path))
+	require.NoError(t, err)
+	backup.OwnerPID = exitedProcessPID(t)
+	require.NoError(t, writeBackup(backupPath(path), backup))
+
+	in.TempDir = t.TempDir()
+	out := sdk.ProvisionOutput{}
+	p.Provision(context.Background(), in, &out)
+	require.Empty(t, out.Diagnostics.Errors)
+	assert.Len(t, out.Diagnostics.Warnings, 1)
+
+	p.Deprovision(context.Background(), sdk.DeprovisionInput{HomeDir: homeDir, TempDir: in.TempDir, State: out.State}, &sdk.DeprovisionOutput{})
+
+	restored, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(restored))
+}
+
+func TestMergeFileProvisionerConcurrentRun(t *testing.T) {
+	homeDir := t.TempDir()
+	path := filepath.Join(homeDir, "config.json")
+	original := "{\"theme\":\"dark\"}"
+	require.NoError(t, os.WriteFile(path, []byte(original), 0600))
+
+	p := MergeFile("~/config.json", MergeJSON, map[string]string{"token": "{{ .Token }}"})
+	in := sdk.ProvisionInput{HomeDir: homeDir, TempDir: t.TempDir(), ItemFields: map[sdk.FieldName]string{fieldname.Token: "s3cret"}}
+
+	// The first run is still going, so its backup must be left alone.
+	first := sdk.ProvisionOutput{}
+	p.Provision(context.Background(), in, &first)
+	require.Empty(t, first.Diagnostics.Errors)
+
+	second := sdk.ProvisionOutput{}
+	p.Provision(context.Background(), sdk.ProvisionInput{HomeDir: homeDir, TempDir: t.TempDir(), ItemFields: in.ItemFields}, &second)
+	require.Len(t, second.Diagnostics.Errors, 1)
+	assert.Contains(t, second.Diagnostics.Errors[0].Message, "is in use by another process")
+
+	p.Deprovision(context.Background(), sdk.DeprovisionInput{HomeDir: homeDir, TempDir: in.TempDir, State: first.State}, &sdk.DeprovisionOutput{})
+
+	restored, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(restored))
+}
+
+// exitedProcessPID returns the PID of a process that has already exited.
+func exitedProcessPID(t *testing.T) int {
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	require.NoError(t, cmd.Run())
+	return cmd.Process.Pid
+}
+
+func TestMergeFileProvisionerState(t *testing.T) {
+	homeDir := t.TempDir()
+	tempDir := t.TempDir()
+	path := filepath.Join(homeDir, "config.json")
+	original := "{\"theme\":\"dark\"}"
+	require.NoError(t, os.WriteFile(path, []byte(original), 0600))
+
+	p := MergeFile("~/config.json", MergeJSON, map[string]string{"token": "{{ .Token }}"})
+	out := sdk.ProvisionOutput{}
+	p.Provision(context.Background(), sdk.ProvisionInput{HomeDir: homeDir, TempDir: tempDir, ItemFields: map[sdk.FieldName]string{fieldname.Token: "s3cret"}}, &out)
+	require.Empty(t, out.Diagnostics.Errors)
+	assert.Equal(t, map[string]string{"merge-file|" + path: filepath.Join(tempDir, ".config.json.op-backup")}, out.State)
+
+	// The backup next to the file got lost, so the one in the temp dir gets restored, as recorded in the state.
+	require.NoError(t, os.Remove(backupPath(path)))
+	deprovisionOut := sdk.DeprovisionOutput{}
+	p.Deprovision(context.Background(), sdk.DeprovisionInput{HomeDir: homeDir, TempDir: tempDir, State: out.State}, &deprovisionOut)
+	require.Empty(t, deprovisionOut.Diagnostics.Errors)
+
+	restored, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(restored))
+}
+
+func TestMergeFileProvisionerWithoutState(t *testing.T) {
+	homeDir := t.TempDir()
+	path := filepath.Join(homeDir, "config.json")
+	require.NoError(t, os.WriteFile(path, []byte("{}"), 0600))
+
+	// Deprovisioning without a corresponding provision step leaves the file alone.
+	deprovisionOut := sdk.DeprovisionOutput{}
+	MergeFile("~/config.json", MergeJSON, map[string]string{"token": "{{ .Token }}"}).Deprovision(context.Background(), sdk.DeprovisionInput{HomeDir: homeDir, TempDir: t.TempDir()}, &deprovisionOut)
+	assert.Empty(t, deprovisionOut.Diagnostics.Errors)
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(contents))
+}
+
+func strPtr(s string) *string {
+	return &s
+}
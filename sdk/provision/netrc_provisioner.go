@@ -0,0 +1,117 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// NetRCProvisioner provisions a login and password for a single machine as a netrc file.
+type NetRCProvisioner struct {
+	sdk.Provisioner
+
+	machine       string
+	loginField    sdk.FieldName
+	passwordField sdk.FieldName
+	file          FileProvisioner
+}
+
+// NetRC creates a NetRCProvisioner that writes a netrc entry for the specified machine to a temp file, using the
+// values of the login and password fields, and points the NETRC environment variable at it. When multiple netrc
+// provisioners are chained for the same executable, their entries get combined into a single netrc file.
+// The file options can be used to influence how the path is passed to the executable, e.g. with
+// provision.AddArgs("--netrc-file", "{{ .Path }}") for curl.
+func NetRC(machine string, loginField sdk.FieldName, passwordField sdk.FieldName, opts ...FileOption) sdk.Provisioner {
+	file := FileProvisioner{
+		outfileName:   ".netrc",
+		outpathEnvVar: "NETRC",
+	}
+	for _, opt := range opts {
+		opt(&file)
+	}
+
+	return NetRCProvisioner{
+		machine:       machine,
+		loginField:    loginField,
+		passwordField: passwordField,
+		file:          file,
+	}
+}
+
+func (p NetRCProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	if in.DryRun {
+		in = in.Concealed()
+	}
+
+	login, ok := in.ItemFields[p.loginField]
+	if !ok {
+		out.AddError(fmt.Errorf("no value present in the item for field '%s'", p.loginField))
+		return
+	}
+
+	password, ok := in.ItemFields[p.passwordField]
+	if !ok {
+		out.AddError(fmt.Errorf("no value present in the item for field '%s'", p.passwordField))
+		return
+	}
+
+	if err := validateNetRCToken(p.loginField, login); err != nil {
+		out.AddError(err)
+		return
+	}
+	if err := validateNetRCToken(p.passwordField, password); err != nil {
+		out.AddError(err)
+		return
+	}
+
+	outpath, err := p.file.outputPath(in)
+	if err != nil {
+		out.AddError(err)
+		return
+	}
+
+	entry := []byte(fmt.Sprintf("machine %s\n  login %s\n  password %s\n", p.machine, login, password))
+	if in.DryRun {
+		out.AddPlan(fmt.Sprintf("Add netrc entry for %s to %s", p.machine, outpath))
+	}
+
+	// Add the entry to the netrc file of a previous netrc provisioner, if there is one. In that case, the path is
+	// already known to the executable.
+	if existing, ok := out.Files[outpath]; ok {
+		contents := make([]byte, 0, len(existing.Contents)+1+len(entry))
+		contents = append(contents, existing.Contents...)
+		contents = append(contents, '\n')
+		contents = append(contents, entry...)
+		out.AddFile(outpath, sdk.OutputFile{
+			Contents: contents,
+			FileMode: existing.FileMode,
+		})
+		return
+	}
+
+	out.AddSecretFile(outpath, entry)
+	p.file.addPathReferences(outpath, out)
+}
+
+// validateNetRCToken reports values that can't be written to a netrc file as is. Tokens in netrc files are separated
+// by whitespace and not all parsers support quoting, so a value with whitespace or '#' would corrupt the file or
+// inject additional tokens, such as another machine entry.
+func validateNetRCToken(fieldName sdk.FieldName, value string) error {
+	if value == "" {
+		return fmt.Errorf("the value of field '%s' can't be written to a netrc file, because it's empty", fieldName)
+	}
+	if strings.ContainsAny(value, " \t\n\r\v\f#") {
+		return fmt.Errorf("the value of field '%s' can't be written to a netrc file, because it contains whitespace or '#'", fieldName)
+	}
+	return nil
+}
+
+func (p NetRCProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	// Nothing to do here: deleting the files gets taken care of.
+}
+
+func (p NetRCProvisioner) Description() string {
+	return fmt.Sprintf("Provision netrc file for %s", p.machine)
+}
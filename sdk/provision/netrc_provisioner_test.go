@@ -0,0 +1,110 @@
+package provision
+
+import (
+	"context"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetRCProvisioner(t *testing.T) {
+	plugintest.TestProvisioner(t, NetRC("api.heroku.com", fieldname.Username, fieldname.APIKey), map[string]plugintest.ProvisionCase{
+		"default": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Username: "wendy@example.com",
+				fieldname.APIKey:   "5e2b1a4c-0000-4b1e-9a5e-123456789abc",
+			},
+			ExpectedOutput: sdk.ProvisionOutput{
+				Environment: map[string]string{
+					"NETRC": "/tmp/.netrc",
+				},
+				Files: map[string]sdk.OutputFile{
+					"/tmp/.netrc": {
+						Contents: []byte("machine api.heroku.com\n  login wendy@example.com\n  password 5e2b1a4c-0000-4b1e-9a5e-123456789abc\n"),
+					},
+				},
+			},
+		},
+		"missing password": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Username: "wendy@example.com",
+			},
+			ExpectedOutput: sdk.ProvisionOutput{
+				Diagnostics: sdk.Diagnostics{Errors: []sdk.Error{{Message: "no value present in the item for field 'API Key'"}}},
+			},
+		},
+	})
+}
+
+func TestNetRCProvisionerRejectsUnsafeValues(t *testing.T) {
+	cases := map[string]plugintest.ProvisionCase{}
+	for name, password := range map[string]string{
+		"space":            "correct horse",
+		"tab":              "correct\thorse",
+		"injected machine": "x\nmachine evil.example.com login attacker password stolen",
+		"comment":          "pass#word",
+	} {
+		cases[name] = plugintest.ProvisionCase{
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Username: "wendy@example.com",
+				fieldname.APIKey:   password,
+			},
+			ExpectedOutput: sdk.ProvisionOutput{
+				Diagnostics: sdk.Diagnostics{Errors: []sdk.Error{{Message: "the value of field 'API Key' can't be written to a netrc file, because it contains whitespace or '#'"}}},
+			},
+		}
+	}
+	cases["empty login"] = plugintest.ProvisionCase{
+		ItemFields: map[sdk.FieldName]string{
+			fieldname.Username: "",
+			fieldname.APIKey:   "heroku-key",
+		},
+		ExpectedOutput: sdk.ProvisionOutput{
+			Diagnostics: sdk.Diagnostics{Errors: []sdk.Error{{Message: "the value of field 'Username' can't be written to a netrc file, because it's empty"}}},
+		},
+	}
+
+	plugintest.TestProvisioner(t, NetRC("api.heroku.com", fieldname.Username, fieldname.APIKey), cases)
+}
+
+func TestNetRCProvisionerChained(t *testing.T) {
+	ctx := context.Background()
+	out := sdk.ProvisionOutput{
+		Environment: make(map[string]string),
+		Files:       make(map[string]sdk.OutputFile),
+		CommandLine: []string{"curl", "https://api.heroku.com"},
+	}
+
+	heroku := NetRC("api.heroku.com", fieldname.Username, fieldname.APIKey, AddArgs("--netrc-file", "{{ .Path }}"))
+	heroku.Provision(ctx, sdk.ProvisionInput{
+		TempDir: "/tmp",
+		ItemFields: map[sdk.FieldName]string{
+			fieldname.Username: "wendy@example.com",
+			fieldname.APIKey:   "heroku-key",
+		},
+	}, &out)
+
+	git := NetRC("git.heroku.com", fieldname.Username, fieldname.Password, AddArgs("--netrc-file", "{{ .Path }}"))
+	git.Provision(ctx, sdk.ProvisionInput{
+		TempDir: "/tmp",
+		ItemFields: map[sdk.FieldName]string{
+			fieldname.Username: "wendy",
+			fieldname.Password: "git-password",
+		},
+	}, &out)
+
+	assert.Equal(t, sdk.ProvisionOutput{
+		Environment: map[string]string{
+			"NETRC": "/tmp/.netrc",
+		},
+		CommandLine: []string{"curl", "https://api.heroku.com", "--netrc-file", "/tmp/.netrc"},
+		Files: map[string]sdk.OutputFile{
+			"/tmp/.netrc": {
+				Contents: []byte("machine api.heroku.com\n  login wendy@example.com\n  password heroku-key\n\nmachine git.heroku.com\n  login wendy\n  password git-password\n"),
+			},
+		},
+	}, out)
+}
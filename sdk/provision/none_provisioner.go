@@ -0,0 +1,41 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// NoneProvisioner is a provisioner that intentionally provisions nothing.
+type NoneProvisioner struct {
+	sdk.Provisioner
+
+	Reason string
+}
+
+// None creates a NoneProvisioner, which can be used for credential types of executables that have a native
+// 1Password integration, e.g. because they resolve op:// references themselves. In that case, the plugin only
+// provides the credential schema and importer. The reason explains why nothing gets provisioned and is required.
+func None(reason string) sdk.Provisioner {
+	return NoneProvisioner{
+		Reason: reason,
+	}
+}
+
+func (p NoneProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	// Nothing to do here: the executable takes care of loading the credential itself.
+}
+
+func (p NoneProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	// Nothing to do here: nothing got provisioned.
+}
+
+func (p NoneProvisioner) Description() string {
+	return fmt.Sprintf("Provision nothing: %s", p.Reason)
+}
+
+// NoProvisioningReason returns why this provisioner intentionally provisions nothing.
+func (p NoneProvisioner) NoProvisioningReason() string {
+	return p.Reason
+}
@@ -0,0 +1,105 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+)
+
+// PGPassProvisioner provisions Postgres credentials as a password file, which is read by libpq-based tools such as
+// psql, pg_dump, and pgcli.
+type PGPassProvisioner struct {
+	sdk.Provisioner
+
+	setConnectionEnvVars bool
+}
+
+// PGPassOption can be used to influence the behavior of the pgpass provisioner.
+type PGPassOption func(*PGPassProvisioner)
+
+// PGPassFile creates a PGPassProvisioner that writes a single pgpass entry to a temp file, using the Host, Port,
+// Database, User, and Password fields of the item, and points the PGPASSFILE environment variable at it. Only the
+// Password field is required: other fields that are not present in the item match any value.
+func PGPassFile(opts ...PGPassOption) sdk.Provisioner {
+	p := PGPassProvisioner{}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
+
+// PGPassWithConnectionEnvVars can be used to also set the non-secret PGHOST, PGPORT, PGUSER, and PGDATABASE
+// environment variables for the fields that are present in the item, so the executable connects to the database
+// that the password file entry is for.
+func PGPassWithConnectionEnvVars() PGPassOption {
+	return func(p *PGPassProvisioner) {
+		p.setConnectionEnvVars = true
+	}
+}
+
+var pgConnectionEnvVars = map[string]sdk.FieldName{
+	"PGHOST":     fieldname.Host,
+	"PGPORT":     fieldname.Port,
+	"PGUSER":     fieldname.User,
+	"PGDATABASE": fieldname.Database,
+}
+
+func (p PGPassProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	if in.DryRun {
+		in = in.Concealed()
+	}
+
+	password, ok := in.ItemFields[fieldname.Password]
+	if !ok {
+		out.AddError(fmt.Errorf("no value present in the item for field '%s'", fieldname.Password))
+		return
+	}
+
+	entry := strings.Join([]string{
+		pgPassValueOrWildcard(in.ItemFields[fieldname.Host]),
+		pgPassValueOrWildcard(in.ItemFields[fieldname.Port]),
+		pgPassValueOrWildcard(in.ItemFields[fieldname.Database]),
+		pgPassValueOrWildcard(in.ItemFields[fieldname.User]),
+		escapePGPassValue(password),
+	}, ":") + "\n"
+
+	outpath := in.FromTempDir(".pgpass")
+	out.AddSecretFile(outpath, []byte(entry))
+	out.AddEnvVar("PGPASSFILE", outpath)
+	if in.DryRun {
+		out.AddPlan(fmt.Sprintf("Write pgpass file %s", outpath))
+	}
+
+	if p.setConnectionEnvVars {
+		for _, envVarName := range sortedKeys(pgConnectionEnvVars) {
+			if value, ok := in.ItemFields[pgConnectionEnvVars[envVarName]]; ok && value != "" {
+				out.AddEnvVar(envVarName, value)
+			}
+		}
+	}
+}
+
+// pgPassValueOrWildcard returns the escaped value, or the "*" wildcard if the value is empty.
+func pgPassValueOrWildcard(value string) string {
+	if value == "" {
+		return "*"
+	}
+	return escapePGPassValue(value)
+}
+
+// escapePGPassValue escapes the characters that have a special meaning in the pgpass format: ':' and '\'.
+func escapePGPassValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return strings.ReplaceAll(value, ":", `\:`)
+}
+
+func (p PGPassProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	// Nothing to do here: deleting the files gets taken care of.
+}
+
+func (p PGPassProvisioner) Description() string {
+	return "Provision Postgres credentials as pgpass file"
+}
@@ -0,0 +1,98 @@
+package provision
+
+import (
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+)
+
+func TestPGPassProvisioner(t *testing.T) {
+	plugintest.TestProvisioner(t, PGPassFile(), map[string]plugintest.ProvisionCase{
+		"default": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Host:     "db.example.com",
+				fieldname.Port:     "5432",
+				fieldname.Database: "orders",
+				fieldname.User:     "app",
+				fieldname.Password: "hunter2",
+			},
+			ExpectedOutput: sdk.ProvisionOutput{
+				Environment: map[string]string{
+					"PGPASSFILE": "/tmp/.pgpass",
+				},
+				Files: map[string]sdk.OutputFile{
+					"/tmp/.pgpass": {
+						Contents: []byte("db.example.com:5432:orders:app:hunter2\n"),
+					},
+				},
+			},
+		},
+		"wildcards for missing fields": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Host:     "db.example.com",
+				fieldname.Password: "hunter2",
+			},
+			ExpectedOutput: sdk.ProvisionOutput{
+				Environment: map[string]string{
+					"PGPASSFILE": "/tmp/.pgpass",
+				},
+				Files: map[string]sdk.OutputFile{
+					"/tmp/.pgpass": {
+						Contents: []byte("db.example.com:*:*:*:hunter2\n"),
+					},
+				},
+			},
+		},
+		"escaping": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Host:     "::1",
+				fieldname.User:     `DOMAIN\app`,
+				fieldname.Password: `p:ss\word`,
+			},
+			ExpectedOutput: sdk.ProvisionOutput{
+				Environment: map[string]string{
+					"PGPASSFILE": "/tmp/.pgpass",
+				},
+				Files: map[string]sdk.OutputFile{
+					"/tmp/.pgpass": {
+						Contents: []byte(`\:\:1:*:*:DOMAIN\\app:p\:ss\\word` + "\n"),
+					},
+				},
+			},
+		},
+		"missing password": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Host: "db.example.com",
+			},
+			ExpectedOutput: sdk.ProvisionOutput{
+				Diagnostics: sdk.Diagnostics{Errors: []sdk.Error{{Message: "no value present in the item for field 'Password'"}}},
+			},
+		},
+	})
+}
+
+func TestPGPassProvisionerWithConnectionEnvVars(t *testing.T) {
+	plugintest.TestProvisioner(t, PGPassFile(PGPassWithConnectionEnvVars()), map[string]plugintest.ProvisionCase{
+		"default": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Host:     "db.example.com",
+				fieldname.User:     "app",
+				fieldname.Password: "hunter2",
+			},
+			ExpectedOutput: sdk.ProvisionOutput{
+				Environment: map[string]string{
+					"PGPASSFILE": "/tmp/.pgpass",
+					"PGHOST":     "db.example.com",
+					"PGUSER":     "app",
+				},
+				Files: map[string]sdk.OutputFile{
+					"/tmp/.pgpass": {
+						Contents: []byte("db.example.com:*:*:app:hunter2\n"),
+					},
+				},
+			},
+		},
+	})
+}
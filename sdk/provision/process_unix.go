@@ -0,0 +1,18 @@
+//go:build !windows
+
+package provision
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processRunning reports whether a process with the specified PID is still running.
+func processRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	// Signal 0 only checks whether the process exists. EPERM means it exists, but belongs to another user.
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
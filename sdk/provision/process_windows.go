@@ -0,0 +1,19 @@
+//go:build windows
+
+package provision
+
+import "os"
+
+// processRunning reports whether a process with the specified PID is still running.
+func processRunning(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	// On Windows, FindProcess opens a handle to the process, which fails if it doesn't exist.
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = p.Release()
+	return true
+}
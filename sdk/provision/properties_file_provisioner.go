@@ -0,0 +1,78 @@
+package provision
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// PropertiesFile returns a file provisioner that writes the values of the specified fields to a Java .properties
+// file in the temp dir, as `key=value` lines. The mapping is from property key to field name. Keys and values are
+// escaped as specified for java.util.Properties, with non-ASCII characters written as \uXXXX escapes, so the file
+// loads the same regardless of whether the tool reads it as ISO-8859-1 or UTF-8. The file options can be used to make
+// the file known to the executable, e.g. SetPathAsEnvVar("GRADLE_PROPERTIES") or
+// AddArgs("-Dcredentials.file={{ .Path }}") to pass it as a system property.
+func PropertiesFile(mapping map[string]sdk.FieldName, opts ...FileOption) sdk.Provisioner {
+	return TempFile(propertiesContents(mapping), append([]FileOption{Filename("credentials.properties")}, opts...)...)
+}
+
+func propertiesContents(mapping map[string]sdk.FieldName) ItemToFileContents {
+	return ItemToFileContents(func(in sdk.ProvisionInput) ([]byte, error) {
+		var contents strings.Builder
+		for _, key := range sortedKeys(mapping) {
+			value, ok := in.ItemFields[mapping[key]]
+			if !ok {
+				return nil, fmt.Errorf("no value present in the item for field '%s'", mapping[key])
+			}
+			contents.WriteString(fmt.Sprintf("%s=%s\n", escapeProperty(key, true), escapeProperty(value, false)))
+		}
+		return []byte(contents.String()), nil
+	})
+}
+
+// escapeProperty escapes a key or value the way java.util.Properties#store does. In keys, every space gets escaped,
+// since whitespace would otherwise end the key. In values, only a leading space needs escaping to be preserved.
+func escapeProperty(s string, isKey bool) string {
+	var escaped strings.Builder
+	for i, r := range s {
+		switch r {
+		case ' ':
+			if i == 0 || isKey {
+				escaped.WriteString(`\ `)
+			} else {
+				escaped.WriteRune(r)
+			}
+		case '\\', '=', ':', '#', '!':
+			escaped.WriteRune('\\')
+			escaped.WriteRune(r)
+		case '\t':
+			escaped.WriteString(`\t`)
+		case '\n':
+			escaped.WriteString(`\n`)
+		case '\r':
+			escaped.WriteString(`\r`)
+		case '\f':
+			escaped.WriteString(`\f`)
+		default:
+			if r < 0x20 || r > 0x7e {
+				writeUnicodeEscape(&escaped, r)
+			} else {
+				escaped.WriteRune(r)
+			}
+		}
+	}
+	return escaped.String()
+}
+
+// writeUnicodeEscape writes the rune as \uXXXX, using a UTF-16 surrogate pair for runes outside of the Basic
+// Multilingual Plane, since that's how Java represents them.
+func writeUnicodeEscape(b *strings.Builder, r rune) {
+	if r1, r2 := utf16.EncodeRune(r); r1 != unicode.ReplacementChar {
+		b.WriteString(fmt.Sprintf(`\u%04X\u%04X`, r1, r2))
+		return
+	}
+	b.WriteString(fmt.Sprintf(`\u%04X`, r))
+}
@@ -0,0 +1,190 @@
+package provision
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPropertiesFileProvisioner(t *testing.T) {
+	plugintest.TestProvisioner(t, PropertiesFile(map[string]sdk.FieldName{
+		"db.user":     fieldname.Username,
+		"db.password": fieldname.Password,
+		"db.url":      fieldname.URL,
+	}, SetPathAsEnvVar("CREDENTIALS_PROPERTIES")), map[string]plugintest.ProvisionCase{
+		"default": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Username: "wendy",
+				fieldname.Password: "p=ss:word",
+				fieldname.URL:      "jdbc:postgresql://localhost/app",
+			},
+			ExpectedOutput: sdk.ProvisionOutput{
+				Environment: map[string]string{
+					"CREDENTIALS_PROPERTIES": "/tmp/credentials.properties",
+				},
+				Files: map[string]sdk.OutputFile{
+					"/tmp/credentials.properties": {
+						Contents: []byte("db.password=p\\=ss\\:word\ndb.url=jdbc\\:postgresql\\://localhost/app\ndb.user=wendy\n"),
+					},
+				},
+			},
+		},
+		"missing field": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Username: "wendy",
+			},
+			ExpectedOutput: sdk.ProvisionOutput{
+				Diagnostics: sdk.Diagnostics{Errors: []sdk.Error{{Message: "no value present in the item for field 'Password'"}}},
+			},
+		},
+	})
+}
+
+func TestPropertiesFileProvisionerSystemProperty(t *testing.T) {
+	plugintest.TestProvisioner(t, PropertiesFile(map[string]sdk.FieldName{"token": fieldname.Token}, AddArgs("-Dcredentials.file={{ .Path }}")), map[string]plugintest.ProvisionCase{
+		"default": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Token: "abc123",
+			},
+			CommandLine: []string{"gradle", "publish"},
+			ExpectedOutput: sdk.ProvisionOutput{
+				CommandLine: []string{"gradle", "publish", "-Dcredentials.file=/tmp/credentials.properties"},
+				Files: map[string]sdk.OutputFile{
+					"/tmp/credentials.properties": {
+						Contents: []byte("token=abc123\n"),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestPropertiesFileRoundTrip(t *testing.T) {
+	values := map[string]string{
+		"plain":            "abc123",
+		"equals and colon": "a=b:c",
+		"newlines":         "line1\nline2\r\nline3",
+		"comment chars":    "#not a comment !either",
+		"backslashes":      `C:\Users\wendy\`,
+		"leading spaces":   "  padded  ",
+		"tabs":             "\tindented",
+		"unicode":          "pässwörd €",
+		"astral plane":     "key 🔑",
+		"control chars":    "bell\a",
+		"empty":            "",
+	}
+
+	for name, value := range values {
+		t.Run(name, func(t *testing.T) {
+			key := "my.key " + name
+			contents, err := propertiesContents(map[string]sdk.FieldName{key: fieldname.Token})(sdk.ProvisionInput{
+				ItemFields: map[sdk.FieldName]string{fieldname.Token: value},
+			})
+			require.NoError(t, err)
+
+			for _, b := range contents {
+				assert.Less(t, b, byte(0x80), "properties file should be ASCII-only")
+			}
+
+			parsed := loadProperties(t, contents)
+			assert.Equal(t, map[string]string{key: value}, parsed)
+		})
+	}
+}
+
+// loadProperties parses .properties file contents as specified for java.util.Properties#load, to verify the output
+// of the provisioner without depending on a Java runtime.
+func loadProperties(t *testing.T, contents []byte) map[string]string {
+	t.Helper()
+
+	properties := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	var logical string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if logical == "" {
+			line = strings.TrimLeft(line, " \t\f")
+			if line == "" || line[0] == '#' || line[0] == '!' {
+				continue
+			}
+		} else {
+			line = strings.TrimLeft(line, " \t\f")
+		}
+
+		if strings.HasSuffix(line, `\`) && (len(line)-len(strings.TrimRight(line, `\`)))%2 == 1 {
+			logical += line[:len(line)-1]
+			continue
+		}
+		logical += line
+
+		key, value := splitProperty(logical)
+		properties[unescapeProperty(t, key)] = unescapeProperty(t, value)
+		logical = ""
+	}
+	require.NoError(t, scanner.Err())
+	return properties
+}
+
+// splitProperty splits a logical line at the first unescaped '=', ':' or whitespace, skipping the whitespace and at
+// most one '=' or ':' that separate the key from the value.
+func splitProperty(line string) (string, string) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	return line[:end], rest
+}
+
+func unescapeProperty(t *testing.T, s string) string {
+	t.Helper()
+
+	var units []uint16
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			units = append(units, uint16(s[i]))
+			continue
+		}
+		i++
+		require.Less(t, i, len(s), "dangling escape in %q", s)
+		switch s[i] {
+		case 't':
+			units = append(units, '\t')
+		case 'n':
+			units = append(units, '\n')
+		case 'r':
+			units = append(units, '\r')
+		case 'f':
+			units = append(units, '\f')
+		case 'u':
+			require.LessOrEqual(t, i+5, len(s), "malformed \\uXXXX escape in %q", s)
+			code, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			require.NoError(t, err)
+			units = append(units, uint16(code))
+			i += 4
+		default:
+			units = append(units, uint16(s[i]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,220 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// SSHAgentProvisioner provisions an SSH private key through an SSH agent that only lives as long as the executable.
+type SSHAgentProvisioner struct {
+	sdk.Provisioner
+
+	privateKeyField sdk.FieldName
+	passphraseField sdk.FieldName
+	agents          *sshAgents
+}
+
+// SSHAgent creates an SSHAgentProvisioner that loads the private key from the specified field into an in-process SSH
+// agent, which listens on a unix socket in the temp dir, and sets SSH_AUTH_SOCK to point to it. This makes the key
+// available to executables that shell out to ssh or git, without writing the key to disk. Both OpenSSH and PEM formats
+// are supported. If the key is encrypted, the passphrase gets read from the passphrase field. The passphrase field can
+// be left empty for credential types without a passphrase.
+func SSHAgent(privateKeyField sdk.FieldName, passphraseField sdk.FieldName) sdk.Provisioner {
+	return SSHAgentProvisioner{
+		privateKeyField: privateKeyField,
+		passphraseField: passphraseField,
+		agents: &sshAgents{
+			bySocketPath: make(map[string]*sshAgent),
+		},
+	}
+}
+
+func (p SSHAgentProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	privateKey, ok := in.ItemFields[p.privateKeyField]
+	if !ok {
+		out.AddError(fmt.Errorf("no value present in the item for field '%s'", p.privateKeyField))
+		return
+	}
+
+	socketPath := in.FromTempDir("ssh-agent.sock")
+	if in.DryRun {
+		out.AddEnvVar("SSH_AUTH_SOCK", socketPath)
+		out.AddPlan(fmt.Sprintf("Load %s into an SSH agent listening on %s", p.privateKeyField, socketPath))
+		return
+	}
+
+	key, err := p.parsePrivateKey([]byte(privateKey), in.ItemFields)
+	if err != nil {
+		out.AddError(err)
+		return
+	}
+
+	keyring := agent.NewKeyring()
+	err = keyring.Add(agent.AddedKey{
+		PrivateKey: key,
+		Comment:    p.privateKeyField.String(),
+	})
+	if err != nil {
+		out.AddError(fmt.Errorf("loading private key into SSH agent: %s", err))
+		return
+	}
+
+	err = p.agents.start(socketPath, keyring)
+	if err != nil {
+		out.AddError(fmt.Errorf("starting SSH agent: %s", err))
+		return
+	}
+
+	out.AddEnvVar("SSH_AUTH_SOCK", socketPath)
+	out.AddState(p.stateKey(), socketPath)
+}
+
+func (p SSHAgentProvisioner) parsePrivateKey(privateKey []byte, itemFields map[sdk.FieldName]string) (any, error) {
+	if passphrase, ok := itemFields[p.passphraseField]; ok && p.passphraseField != "" && passphrase != "" {
+		key, err := ssh.ParseRawPrivateKeyWithPassphrase(privateKey, []byte(passphrase))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse private key from field '%s': %s", p.privateKeyField, err)
+		}
+		return key, nil
+	}
+
+	key, err := ssh.ParseRawPrivateKey(privateKey)
+	var passphraseMissingErr *ssh.PassphraseMissingError
+	if errors.As(err, &passphraseMissingErr) {
+		return nil, fmt.Errorf("private key from field '%s' is encrypted, but no passphrase is present in the item", p.privateKeyField)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not parse private key from field '%s': %s", p.privateKeyField, err)
+	}
+	return key, nil
+}
+
+func (p SSHAgentProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	socketPath, ok := in.State[p.stateKey()]
+	if !ok {
+		// Nothing to do here: no agent got started.
+		return
+	}
+
+	// Stop the agent for this execution and remove its socket.
+	if err := p.agents.stop(socketPath); err != nil {
+		out.AddError(err)
+	}
+}
+
+// stateKey returns the key of the state that holds the path to the socket of the agent.
+func (p SSHAgentProvisioner) stateKey() string {
+	return fmt.Sprintf("ssh-agent|%s", p.privateKeyField)
+}
+
+func (p SSHAgentProvisioner) Description() string {
+	return fmt.Sprintf("Provision %s through an SSH agent", p.privateKeyField)
+}
+
+// sshAgents keeps track of the agents that are running for each execution, identified by their socket path.
+type sshAgents struct {
+	mu           sync.Mutex
+	bySocketPath map[string]*sshAgent
+}
+
+func (a *sshAgents) start(socketPath string, keyring agent.Agent) error {
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(socketPath, 0600); err != nil {
+		_ = listener.Close()
+		return err
+	}
+
+	sshAgent := &sshAgent{
+		socketPath: socketPath,
+		listener:   listener,
+		conns:      make(map[net.Conn]struct{}),
+		done:       make(chan struct{}),
+	}
+	go sshAgent.serve(keyring)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.bySocketPath[socketPath] = sshAgent
+	return nil
+}
+
+func (a *sshAgents) stop(socketPath string) error {
+	a.mu.Lock()
+	sshAgent, ok := a.bySocketPath[socketPath]
+	delete(a.bySocketPath, socketPath)
+	a.mu.Unlock()
+
+	if !ok {
+		// The agent is not running in this process anymore, so only the socket is left to clean up.
+		if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("removing SSH agent socket: %s", err)
+		}
+		return nil
+	}
+	return sshAgent.close()
+}
+
+// sshAgent serves the SSH agent protocol on a unix socket.
+type sshAgent struct {
+	socketPath string
+	listener   net.Listener
+	done       chan struct{}
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+func (a *sshAgent) serve(keyring agent.Agent) {
+	defer close(a.done)
+
+	for {
+		conn, err := a.listener.Accept()
+		if err != nil {
+			// The listener got closed.
+			return
+		}
+
+		a.mu.Lock()
+		a.conns[conn] = struct{}{}
+		a.mu.Unlock()
+
+		go func() {
+			_ = agent.ServeAgent(keyring, conn)
+
+			a.mu.Lock()
+			delete(a.conns, conn)
+			a.mu.Unlock()
+			_ = conn.Close()
+		}()
+	}
+}
+
+func (a *sshAgent) close() error {
+	err := a.listener.Close()
+	<-a.done
+
+	// Also close connections of processes that outlived the executable, e.g. a lingering ssh control master.
+	a.mu.Lock()
+	for conn := range a.conns {
+		_ = conn.Close()
+	}
+	a.mu.Unlock()
+
+	if removeErr := os.Remove(a.socketPath); removeErr != nil && !os.IsNotExist(removeErr) {
+		return fmt.Errorf("removing SSH agent socket: %s", removeErr)
+	}
+	if err != nil {
+		return fmt.Errorf("stopping SSH agent: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package provision
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+func TestSSHAgentProvisioner(t *testing.T) {
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	openSSHBlock, err := ssh.MarshalPrivateKey(ed25519Key, "")
+	require.NoError(t, err)
+	encryptedBlock, err := ssh.MarshalPrivateKeyWithPassphrase(ed25519Key, "", []byte("correct horse"))
+	require.NoError(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pemBlock := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}
+
+	for name, tc := range map[string]struct {
+		itemFields      map[sdk.FieldName]string
+		expectedKeyType string
+	}{
+		"OpenSSH format": {
+			itemFields: map[sdk.FieldName]string{
+				fieldname.PrivateKey: string(pem.EncodeToMemory(openSSHBlock)),
+			},
+			expectedKeyType: ssh.KeyAlgoED25519,
+		},
+		"encrypted OpenSSH format": {
+			itemFields: map[sdk.FieldName]string{
+				fieldname.PrivateKey: string(pem.EncodeToMemory(encryptedBlock)),
+				fieldname.Password:   "correct horse",
+			},
+			expectedKeyType: ssh.KeyAlgoED25519,
+		},
+		"PEM format": {
+			itemFields: map[sdk.FieldName]string{
+				fieldname.PrivateKey: string(pem.EncodeToMemory(pemBlock)),
+			},
+			expectedKeyType: ssh.KeyAlgoRSA,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tempDir := shortTempDir(t)
+			p := SSHAgent(fieldname.PrivateKey, fieldname.Password)
+
+			out := sdk.ProvisionOutput{Environment: make(map[string]string)}
+			p.Provision(context.Background(), sdk.ProvisionInput{TempDir: tempDir, ItemFields: tc.itemFields}, &out)
+			require.Empty(t, out.Diagnostics.Errors)
+
+			socketPath := out.Environment["SSH_AUTH_SOCK"]
+			require.NotEmpty(t, socketPath)
+			assert.Equal(t, map[string]string{"ssh-agent|Private Key": socketPath}, out.State)
+
+			conn, err := net.Dial("unix", socketPath)
+			require.NoError(t, err)
+			keys, err := agent.NewClient(conn).List()
+			require.NoError(t, err)
+			require.Len(t, keys, 1)
+			assert.Equal(t, tc.expectedKeyType, keys[0].Type())
+
+			var deprovisionOut sdk.DeprovisionOutput
+			p.Deprovision(context.Background(), sdk.DeprovisionInput{TempDir: tempDir, State: out.State}, &deprovisionOut)
+			assert.Empty(t, deprovisionOut.Diagnostics.Errors)
+
+			_, err = os.Stat(socketPath)
+			assert.True(t, os.IsNotExist(err))
+			_, err = agent.NewClient(conn).List()
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSSHAgentProvisionerInvalidKeys(t *testing.T) {
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	encryptedBlock, err := ssh.MarshalPrivateKeyWithPassphrase(ed25519Key, "", []byte("correct horse"))
+	require.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		itemFields    map[sdk.FieldName]string
+		expectedError string
+	}{
+		"missing private key": {
+			itemFields:    map[sdk.FieldName]string{},
+			expectedError: "no value present in the item for field 'Private Key'",
+		},
+		"missing passphrase": {
+			itemFields: map[sdk.FieldName]string{
+				fieldname.PrivateKey: string(pem.EncodeToMemory(encryptedBlock)),
+			},
+			expectedError: "private key from field 'Private Key' is encrypted, but no passphrase is present in the item",
+		},
+		"wrong passphrase": {
+			itemFields: map[sdk.FieldName]string{
+				fieldname.PrivateKey: string(pem.EncodeToMemory(encryptedBlock)),
+				fieldname.Password:   "battery staple",
+			},
+			expectedError: "could not parse private key from field 'Private Key': x509: decryption password incorrect",
+		},
+		"garbage": {
+			itemFields: map[sdk.FieldName]string{
+				fieldname.PrivateKey: "not a private key",
+			},
+			expectedError: "could not parse private key from field 'Private Key': ssh: no key found",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			out := sdk.ProvisionOutput{Environment: make(map[string]string)}
+			SSHAgent(fieldname.PrivateKey, fieldname.Password).Provision(context.Background(), sdk.ProvisionInput{
+				TempDir:    shortTempDir(t),
+				ItemFields: tc.itemFields,
+			}, &out)
+
+			require.Len(t, out.Diagnostics.Errors, 1)
+			assert.Equal(t, tc.expectedError, out.Diagnostics.Errors[0].Message)
+			assert.Empty(t, out.Environment)
+		})
+	}
+}
+
+func TestSSHAgentProvisionerRemovesSocketOfAgentInOtherProcess(t *testing.T) {
+	socketPath := filepath.Join(shortTempDir(t), "ssh-agent.sock")
+	require.NoError(t, os.WriteFile(socketPath, nil, 0600))
+
+	var out sdk.DeprovisionOutput
+	SSHAgent(fieldname.PrivateKey, fieldname.Password).Deprovision(context.Background(), sdk.DeprovisionInput{
+		State: map[string]string{"ssh-agent|Private Key": socketPath},
+	}, &out)
+	assert.Empty(t, out.Diagnostics.Errors)
+
+	_, err := os.Stat(socketPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+// shortTempDir creates a temp dir with a path that's short enough to hold a unix socket.
+func shortTempDir(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "ssh")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
@@ -0,0 +1,48 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// StdinProvisioner provisions a secret through the executable's standard input.
+type StdinProvisioner struct {
+	sdk.Provisioner
+
+	fieldName sdk.FieldName
+}
+
+// Stdin creates a StdinProvisioner that pipes the value of the specified field to the executable's stdin.
+// This is useful for executables that are designed to read secrets from stdin, such as
+// `docker login --password-stdin` or `gh auth login --with-token`.
+func Stdin(fieldName sdk.FieldName) sdk.Provisioner {
+	return StdinProvisioner{
+		fieldName: fieldName,
+	}
+}
+
+func (p StdinProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	value, ok := in.ItemFields[p.fieldName]
+	if !ok {
+		out.AddError(fmt.Errorf("no value present in the item for field '%s'", p.fieldName))
+		return
+	}
+
+	if in.DryRun {
+		out.AddStdin([]byte(sdk.ConcealedValue(p.fieldName)))
+		out.AddPlan(fmt.Sprintf("Pipe the value of %s to stdin", p.fieldName))
+		return
+	}
+
+	out.AddStdin([]byte(value))
+}
+
+func (p StdinProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	// Nothing to do here: stdin gets closed automatically when the process exits.
+}
+
+func (p StdinProvisioner) Description() string {
+	return fmt.Sprintf("Provision %s through stdin", p.fieldName)
+}
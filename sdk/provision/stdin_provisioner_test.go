@@ -0,0 +1,32 @@
+package provision
+
+import (
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/plugintest"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+)
+
+func TestStdinProvisioner(t *testing.T) {
+	plugintest.TestProvisioner(t, Stdin(fieldname.Token), map[string]plugintest.ProvisionCase{
+		"default": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Token: "ghp_abc123",
+			},
+			CommandLine: []string{"gh", "auth", "login", "--with-token"},
+			ExpectedOutput: sdk.ProvisionOutput{
+				CommandLine: []string{"gh", "auth", "login", "--with-token"},
+				Stdin:       []byte("ghp_abc123"),
+			},
+		},
+		"missing field": {
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.Username: "wendy",
+			},
+			ExpectedOutput: sdk.ProvisionOutput{
+				Diagnostics: sdk.Diagnostics{Errors: []sdk.Error{{Message: "no value present in the item for field 'Token'"}}},
+			},
+		},
+	})
+}
@@ -0,0 +1,304 @@
+package provision
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// KeychainItem describes a generic password item that gets added to a temporary keychain.
+type KeychainItem struct {
+	// Service is the service name of the item, e.g. "api.example.com".
+	Service string
+
+	// Account is the account name of the item.
+	Account string
+
+	// Password is the field whose value gets stored as the password of the item.
+	Password sdk.FieldName
+}
+
+// TempKeychainProvisioner provisions secrets as generic password items in a throwaway macOS keychain.
+type TempKeychainProvisioner struct {
+	sdk.Provisioner
+
+	envVarForPath string
+	items         []KeychainItem
+
+	// goos and run can be replaced in tests, to run the provisioner against a fake security CLI on any platform.
+	goos string
+	run  commandRunner
+}
+
+// commandRunner runs the specified command with the specified stdin and returns its combined output.
+type commandRunner func(ctx context.Context, stdin []byte, name string, args ...string) ([]byte, error)
+
+func execCommand(ctx context.Context, stdin []byte, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	return cmd.CombinedOutput()
+}
+
+// TempKeychain creates a TempKeychainProvisioner that creates a keychain in the temp dir, adds the specified items to
+// it, and puts it in front of the user's keychain search list, for macOS tools that only read credentials from the
+// Keychain. Since macOS has no way to scope the search list to a single process, the user's search list gets changed
+// while the executable runs and the keychain gets removed from it again afterwards. The path of the keychain gets
+// provisioned as the specified environment variable, if set, for tools that accept an explicit keychain.
+//
+// Secrets are passed to the security CLI through stdin, so that they never show up in the process list. TempKeychain
+// is only supported on macOS and reports an error on other platforms.
+//
+// Access to the items is restricted to the executable, which gets looked up on the PATH. Keychains of previous runs
+// that didn't get to deprovision are removed from the search list on the next run.
+func TempKeychain(envVarForPath string, items ...KeychainItem) sdk.Provisioner {
+	return TempKeychainProvisioner{
+		envVarForPath: envVarForPath,
+		items:         items,
+		goos:          runtime.GOOS,
+		run:           execCommand,
+	}
+}
+
+func (p TempKeychainProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	if p.goos != "darwin" {
+		out.AddError(fmt.Errorf("temporary keychains are only supported on macOS, not on %s", p.goos))
+		return
+	}
+
+	for _, item := range p.items {
+		if _, ok := in.ItemFields[item.Password]; !ok {
+			out.AddError(fmt.Errorf("no value present in the item for field '%s'", item.Password))
+			return
+		}
+	}
+
+	path := in.FromTempDir(tempKeychainName(os.Getpid()))
+	if in.DryRun {
+		out.AddPlan(fmt.Sprintf("Create temporary keychain %s with %d item(s) and add it to the keychain search list", path, len(p.items)))
+		if p.envVarForPath != "" {
+			out.AddEnvVar(p.envVarForPath, path)
+		}
+		return
+	}
+
+	executablePath, err := lookPathIn(out.CommandLine, executableSearchPath(in, out))
+	if err != nil {
+		out.AddError(fmt.Errorf("restricting access to the temporary keychain: %s", err))
+		return
+	}
+
+	// Make sure the keychain gets cleaned up again, also if provisioning fails halfway, since creating a keychain
+	// already adds it to the search list.
+	out.AddState(p.stateKey(), path)
+	if err := p.createKeychain(ctx, path, executablePath, in.ItemFields); err != nil {
+		out.AddError(err)
+		return
+	}
+
+	searchList, err := p.searchList(ctx)
+	if err != nil {
+		out.AddError(err)
+		return
+	}
+	searchList = withoutStaleKeychains(withoutPath(searchList, path))
+	if err := p.setSearchList(ctx, append([]string{path}, searchList...)); err != nil {
+		out.AddError(err)
+		return
+	}
+
+	if p.envVarForPath != "" {
+		out.AddEnvVar(p.envVarForPath, path)
+	}
+}
+
+// createKeychain creates an unlocked keychain at the specified path that contains the items, which only the
+// specified executable can access.
+func (p TempKeychainProvisioner) createKeychain(ctx context.Context, path string, executablePath string, itemFields map[sdk.FieldName]string) error {
+	if err := validateSecurityArg(path); err != nil {
+		return fmt.Errorf("keychain path %s", err)
+	}
+	if err := validateSecurityArg(executablePath); err != nil {
+		return fmt.Errorf("executable path %s", err)
+	}
+
+	// The keychain only lives as long as the executable, so its password doesn't have to be known by anyone else.
+	password, err := randomToken()
+	if err != nil {
+		return fmt.Errorf("generating keychain password: %s", err)
+	}
+
+	// Pass all commands through stdin in interactive mode, so that no secrets show up in the process list.
+	var commands strings.Builder
+	fmt.Fprintf(&commands, "create-keychain -p %s \"%s\"\n", password, path)
+	fmt.Fprintf(&commands, "set-keychain-settings \"%s\"\n", path)
+	fmt.Fprintf(&commands, "unlock-keychain -p %s \"%s\"\n", password, path)
+	for _, item := range p.items {
+		if err := validateSecurityArg(item.Service); err != nil {
+			return fmt.Errorf("keychain service %s", err)
+		}
+		if err := validateSecurityArg(item.Account); err != nil {
+			return fmt.Errorf("keychain account %s", err)
+		}
+		fmt.Fprintf(&commands, "add-generic-password -T \"%s\" -s \"%s\" -a \"%s\" -X %s \"%s\"\n",
+			executablePath, item.Service, item.Account, hex.EncodeToString([]byte(itemFields[item.Password])), path)
+	}
+
+	// The output of interactive mode may echo the commands, so it must never end up in diagnostics.
+	if _, err := p.run(ctx, []byte(commands.String()), "security", "-i"); err != nil {
+		return fmt.Errorf("creating temporary keychain: %s", err)
+	}
+
+	// Interactive mode doesn't reliably report failing commands, so check the results instead.
+	for _, item := range p.items {
+		_, err := p.run(ctx, nil, "security", "find-generic-password", "-s", item.Service, "-a", item.Account, path)
+		if err != nil {
+			return fmt.Errorf("adding %s to temporary keychain: %s", item.Password, err)
+		}
+	}
+	return nil
+}
+
+func (p TempKeychainProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	path, ok := in.State[p.stateKey()]
+	if !ok {
+		// Nothing to do here: no keychain got created.
+		return
+	}
+
+	// Remove the keychain from the search list, also if the keychain file is already gone.
+	searchList, err := p.searchList(ctx)
+	if err != nil {
+		out.AddError(err)
+	} else if remaining := withoutPath(searchList, path); len(remaining) != len(searchList) {
+		if err := p.setSearchList(ctx, remaining); err != nil {
+			out.AddError(err)
+		}
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if output, err := p.run(ctx, nil, "security", "delete-keychain", path); err != nil {
+		out.AddError(fmt.Errorf("deleting temporary keychain: %s: %s", err, strings.TrimSpace(string(output))))
+	}
+}
+
+// searchList returns the paths of the keychains in the user's keychain search list.
+func (p TempKeychainProvisioner) searchList(ctx context.Context) ([]string, error) {
+	output, err := p.run(ctx, nil, "security", "list-keychains", "-d", "user")
+	if err != nil {
+		return nil, fmt.Errorf("reading keychain search list: %s: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	var paths []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if path := strings.Trim(strings.TrimSpace(line), `"`); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// setSearchList replaces the user's keychain search list.
+func (p TempKeychainProvisioner) setSearchList(ctx context.Context, paths []string) error {
+	args := append([]string{"list-keychains", "-d", "user", "-s"}, paths...)
+	if output, err := p.run(ctx, nil, "security", args...); err != nil {
+		return fmt.Errorf("updating keychain search list: %s: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// stateKey returns the key of the state that holds the path to the keychain.
+func (p TempKeychainProvisioner) stateKey() string {
+	return fmt.Sprintf("temp-keychain|%s", p.envVarForPath)
+}
+
+func (p TempKeychainProvisioner) Description() string {
+	return fmt.Sprintf("Provision %d item(s) through a temporary keychain", len(p.items))
+}
+
+// validateSecurityArg checks whether the value can be passed as a quoted arg to the security CLI in interactive mode.
+func validateSecurityArg(value string) error {
+	if strings.ContainsAny(value, "\"\\\r\n") {
+		return fmt.Errorf("'%s' must not contain quotes, backslashes or line breaks", value)
+	}
+	return nil
+}
+
+// tempKeychainName returns the file name of the temporary keychain of the process with the specified PID, so that
+// keychains left behind by processes that are gone can be recognized by later runs.
+func tempKeychainName(pid int) string {
+	return fmt.Sprintf("op-temp-%d.keychain-db", pid)
+}
+
+// withoutStaleKeychains returns the paths except for the temporary keychains of processes that are no longer running,
+// which didn't get to deprovision. Those keychains get deleted as well, if they still exist.
+func withoutStaleKeychains(paths []string) []string {
+	var result []string
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if strings.HasPrefix(name, "op-temp-") && strings.HasSuffix(name, ".keychain-db") {
+			pid, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "op-temp-"), ".keychain-db"))
+			if err == nil && !processRunning(pid) {
+				_ = os.Remove(path)
+				continue
+			}
+		}
+		result = append(result, path)
+	}
+	return result
+}
+
+// executableSearchPath returns the PATH that the executable gets started with: the one provisioned so far, or else the
+// one of the environment that the executable gets started from.
+func executableSearchPath(in sdk.ProvisionInput, out *sdk.ProvisionOutput) string {
+	if path, ok := out.Environment["PATH"]; ok {
+		return path
+	}
+	path, _ := in.ParentEnvVarValue("PATH")
+	return path
+}
+
+// lookPathIn returns the absolute path of the executable of the command line, which gets looked up in the directories
+// of the specified PATH if it's not a path itself.
+func lookPathIn(commandLine []string, searchPath string) (string, error) {
+	if len(commandLine) == 0 {
+		return "", errors.New("no executable to restrict access to")
+	}
+	name := commandLine[0]
+	if strings.Contains(name, string(filepath.Separator)) {
+		return filepath.Abs(name)
+	}
+	for _, dir := range filepath.SplitList(searchPath) {
+		if dir == "" || !filepath.IsAbs(dir) {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0 {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("executable %s not found in PATH", name)
+}
+
+// withoutPath returns the paths except for the specified one. Since the security CLI resolves /var to /private/var
+// on macOS, the resolved variant of the path gets removed as well.
+func withoutPath(paths []string, path string) []string {
+	var result []string
+	for _, p := range paths {
+		if p != path && p != filepath.Join("/private", path) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
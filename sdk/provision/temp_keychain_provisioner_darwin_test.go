@@ -0,0 +1,46 @@
+//go:build darwin
+
+package provision
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTempKeychainProvisionerWithSecurityCLI(t *testing.T) {
+	if testing.Short() {
+		t.Skip("changes the keychain search list of the current user")
+	}
+
+	p := TempKeychain("KEYCHAIN_PATH", KeychainItem{
+		Service:  "shell-plugins-test.example.com",
+		Account:  "wendy",
+		Password: fieldname.Password,
+	})
+
+	out := sdk.ProvisionOutput{Environment: make(map[string]string)}
+	p.Provision(context.Background(), sdk.ProvisionInput{
+		TempDir:    t.TempDir(),
+		ItemFields: map[sdk.FieldName]string{fieldname.Password: "correct horse battery staple"},
+	}, &out)
+	require.Empty(t, out.Diagnostics.Errors)
+
+	var deprovisionOut sdk.DeprovisionOutput
+	defer p.Deprovision(context.Background(), sdk.DeprovisionInput{State: out.State}, &deprovisionOut)
+
+	path := out.Environment["KEYCHAIN_PATH"]
+	password, err := exec.Command("security", "find-generic-password", "-s", "shell-plugins-test.example.com", "-a", "wendy", "-w", path).Output()
+	require.NoError(t, err)
+	assert.Equal(t, "correct horse battery staple", strings.TrimSpace(string(password)))
+
+	searchList, err := exec.Command("security", "list-keychains", "-d", "user").Output()
+	require.NoError(t, err)
+	assert.Contains(t, string(searchList), "shell-plugin.keychain-db")
+}
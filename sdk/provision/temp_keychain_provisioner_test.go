@@ -0,0 +1,206 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSecurity imitates the parts of the macOS security CLI that TempKeychain uses.
+type fakeSecurity struct {
+	searchList []string
+	stdin      []string
+	args       [][]string
+	failOn     string
+}
+
+func (s *fakeSecurity) run(ctx context.Context, stdin []byte, name string, args ...string) ([]byte, error) {
+	if name != "security" {
+		return nil, fmt.Errorf("unexpected command %s", name)
+	}
+	s.args = append(s.args, args)
+	if len(stdin) > 0 {
+		s.stdin = append(s.stdin, string(stdin))
+	}
+	if s.failOn != "" && args[0] == s.failOn {
+		return []byte("security: something went wrong"), errors.New("exit status 1")
+	}
+
+	switch {
+	case len(args) == 3 && args[0] == "list-keychains":
+		var output string
+		for _, path := range s.searchList {
+			output += fmt.Sprintf("    \"%s\"\n", path)
+		}
+		return []byte(output), nil
+	case args[0] == "list-keychains":
+		s.searchList = args[4:]
+	}
+	return nil, nil
+}
+
+func fakeTempKeychain(security *fakeSecurity, envVarForPath string, items ...KeychainItem) TempKeychainProvisioner {
+	p := TempKeychain(envVarForPath, items...).(TempKeychainProvisioner)
+	p.goos = "darwin"
+	p.run = security.run
+	return p
+}
+
+// fakeExecutable creates an executable file with the specified name in a temp dir, and returns the dir.
+func fakeExecutable(t *testing.T, name string) string {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0700))
+	return dir
+}
+
+func TestTempKeychainProvisioner(t *testing.T) {
+	binDir := fakeExecutable(t, "vpnctl")
+	staleKeychain := filepath.Join(t.TempDir(), tempKeychainName(exitedProcessPID(t)))
+	security := &fakeSecurity{
+		searchList: []string{staleKeychain, "/Users/wendy/Library/Keychains/login.keychain-db"},
+	}
+	p := fakeTempKeychain(security, "KEYCHAIN_PATH", KeychainItem{
+		Service:  "vpn.example.com",
+		Account:  "wendy",
+		Password: fieldname.Password,
+	})
+
+	out := sdk.ProvisionOutput{Environment: make(map[string]string), CommandLine: []string{"vpnctl", "connect"}}
+	p.Provision(context.Background(), sdk.ProvisionInput{
+		TempDir: "/tmp",
+		ItemFields: map[sdk.FieldName]string{
+			fieldname.Password: "hunter2",
+		},
+		ParentEnvVarValues: map[string]string{"PATH": "relative/bin" + string(filepath.ListSeparator) + binDir},
+	}, &out)
+	require.Empty(t, out.Diagnostics.Errors)
+
+	// The keychain of a previous run that's gone got pruned from the search list.
+	keychainPath := filepath.Join("/tmp", tempKeychainName(os.Getpid()))
+	assert.Equal(t, map[string]string{"KEYCHAIN_PATH": keychainPath}, out.Environment)
+	assert.Equal(t, map[string]string{"temp-keychain|KEYCHAIN_PATH": keychainPath}, out.State)
+	assert.Equal(t, []string{keychainPath, "/Users/wendy/Library/Keychains/login.keychain-db"}, security.searchList)
+
+	// The password must only be passed through stdin, hex-encoded, and only the executable may access it.
+	require.Len(t, security.stdin, 1)
+	assert.Contains(t, security.stdin[0], fmt.Sprintf(`add-generic-password -T "%s" -s "vpn.example.com" -a "wendy" -X 68756e74657232 "%s"`, filepath.Join(binDir, "vpnctl"), keychainPath))
+	assert.NotContains(t, security.stdin[0], " -A ")
+	for _, args := range security.args {
+		assert.NotContains(t, strings.Join(args, " "), "hunter2")
+		assert.NotContains(t, strings.Join(args, " "), "68756e74657232")
+	}
+
+	// Another run adds its own keychain to the search list while the executable runs, which must be kept.
+	otherKeychain := filepath.Join("/tmp/other", tempKeychainName(os.Getpid()))
+	security.searchList = append([]string{otherKeychain}, security.searchList...)
+
+	var deprovisionOut sdk.DeprovisionOutput
+	p.Deprovision(context.Background(), sdk.DeprovisionInput{State: out.State}, &deprovisionOut)
+	assert.Empty(t, deprovisionOut.Diagnostics.Errors)
+	assert.Equal(t, []string{otherKeychain, "/Users/wendy/Library/Keychains/login.keychain-db"}, security.searchList)
+}
+
+func TestTempKeychainProvisionerDeprovisionResolvedPath(t *testing.T) {
+	security := &fakeSecurity{
+		searchList: []string{"/private/var/folders/tmp/shell-plugin.keychain-db", "/Users/wendy/Library/Keychains/login.keychain-db"},
+	}
+
+	var out sdk.DeprovisionOutput
+	fakeTempKeychain(security, "").Deprovision(context.Background(), sdk.DeprovisionInput{
+		State: map[string]string{"temp-keychain|": "/var/folders/tmp/shell-plugin.keychain-db"},
+	}, &out)
+	assert.Empty(t, out.Diagnostics.Errors)
+	assert.Equal(t, []string{"/Users/wendy/Library/Keychains/login.keychain-db"}, security.searchList)
+}
+
+func TestTempKeychainProvisionerErrors(t *testing.T) {
+	itemFields := map[sdk.FieldName]string{fieldname.Password: "hunter2"}
+
+	for name, tc := range map[string]struct {
+		provisioner   func(*fakeSecurity) sdk.Provisioner
+		failOn        string
+		commandLine   []string
+		itemFields    map[sdk.FieldName]string
+		expectedError string
+	}{
+		"other platform": {
+			provisioner: func(s *fakeSecurity) sdk.Provisioner {
+				p := fakeTempKeychain(s, "")
+				p.goos = "linux"
+				return p
+			},
+			itemFields:    itemFields,
+			expectedError: "temporary keychains are only supported on macOS, not on linux",
+		},
+		"missing field": {
+			provisioner: func(s *fakeSecurity) sdk.Provisioner {
+				return fakeTempKeychain(s, "", KeychainItem{Service: "example", Password: fieldname.Token})
+			},
+			itemFields:    itemFields,
+			expectedError: "no value present in the item for field 'Token'",
+		},
+		"invalid service": {
+			provisioner: func(s *fakeSecurity) sdk.Provisioner {
+				return fakeTempKeychain(s, "", KeychainItem{Service: `example" -w "x`, Password: fieldname.Password})
+			},
+			itemFields:    itemFields,
+			expectedError: `keychain service 'example" -w "x' must not contain quotes, backslashes or line breaks`,
+		},
+		"item not added": {
+			provisioner: func(s *fakeSecurity) sdk.Provisioner {
+				return fakeTempKeychain(s, "", KeychainItem{Service: "example", Password: fieldname.Password})
+			},
+			failOn:        "find-generic-password",
+			itemFields:    itemFields,
+			expectedError: "adding Password to temporary keychain: exit status 1",
+		},
+		"executable not found": {
+			provisioner: func(s *fakeSecurity) sdk.Provisioner {
+				return fakeTempKeychain(s, "", KeychainItem{Service: "example", Password: fieldname.Password})
+			},
+			commandLine:   []string{"vpnctl"},
+			itemFields:    itemFields,
+			expectedError: "restricting access to the temporary keychain: executable vpnctl not found in PATH",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			commandLine := tc.commandLine
+			if commandLine == nil {
+				commandLine = []string{filepath.Join(fakeExecutable(t, "vpnctl"), "vpnctl")}
+			}
+			security := &fakeSecurity{failOn: tc.failOn}
+			out := sdk.ProvisionOutput{Environment: make(map[string]string), CommandLine: commandLine}
+			tc.provisioner(security).Provision(context.Background(), sdk.ProvisionInput{
+				TempDir:    "/tmp",
+				ItemFields: tc.itemFields,
+			}, &out)
+
+			require.Len(t, out.Diagnostics.Errors, 1)
+			assert.Equal(t, tc.expectedError, out.Diagnostics.Errors[0].Message)
+			assert.Empty(t, out.Environment)
+		})
+	}
+}
+
+func TestTempKeychainProvisionerDryRun(t *testing.T) {
+	security := &fakeSecurity{}
+	out := sdk.ProvisionOutput{Environment: make(map[string]string)}
+	fakeTempKeychain(security, "KEYCHAIN_PATH", KeychainItem{Service: "example", Password: fieldname.Password}).Provision(context.Background(), sdk.ProvisionInput{
+		TempDir:    "/tmp",
+		DryRun:     true,
+		ItemFields: map[sdk.FieldName]string{fieldname.Password: "hunter2"},
+	}, &out)
+
+	assert.Empty(t, out.Diagnostics.Errors)
+	assert.Equal(t, []string{fmt.Sprintf("Create temporary keychain /tmp/%s with 1 item(s) and add it to the keychain search list", tempKeychainName(os.Getpid()))}, out.Plan)
+	assert.Empty(t, security.args)
+}
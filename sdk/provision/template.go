@@ -0,0 +1,55 @@
+package provision
+
+import (
+	"bytes"
+	"encoding/base64"
+	"sort"
+	"text/template"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// fieldTemplateData makes all item fields available to templates by their field name, e.g. "{{ .Token }}". Field
+// names that contain spaces can be referenced with index, e.g. `{{ index . "API Key" }}`.
+func fieldTemplateData(in sdk.ProvisionInput) map[string]string {
+	data := make(map[string]string)
+	for fieldName, value := range in.ItemFields {
+		data[fieldName.String()] = value
+	}
+	return data
+}
+
+// templateFuncs contains the functions that are available to templates in addition to the built-in ones:
+//   - base64: encodes a string with standard base64 encoding, e.g. `{{ base64 (printf "%s:%s" .Username .Password) }}`
+var templateFuncs = template.FuncMap{
+	"base64": func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	},
+}
+
+// executeTemplate parses and executes the specified template string, with templateFuncs available. Referencing a key
+// that's not present in the data results in an error.
+func executeTemplate(name string, tmplStr string, data any) (string, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(tmplStr)
+	if err != nil {
+		return "", err
+	}
+
+	var result bytes.Buffer
+	err = tmpl.Execute(&result, data)
+	if err != nil {
+		return "", err
+	}
+
+	return result.String(), nil
+}
+
+// sortedKeys returns the keys of the specified map in sorted order, which is useful to get deterministic output.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
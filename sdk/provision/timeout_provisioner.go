@@ -0,0 +1,150 @@
+package provision
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// TimeoutProvisioner enforces a deadline on the provision step of another provisioner.
+type TimeoutProvisioner struct {
+	sdk.Provisioner
+
+	provisioner sdk.Provisioner
+	timeout     time.Duration
+}
+
+// WithTimeout wraps the specified provisioner so that its provision step fails if it takes longer than the specified
+// timeout. This is useful for provisioners that perform network calls, such as token exchanges, which could otherwise
+// hang indefinitely. The wrapped provisioner gets a context with the deadline set, so it can abort in time. If it
+// doesn't, its output is discarded and it gets deprovisioned in the background once it finishes, so that partially
+// provisioned resources still get cleaned up.
+//
+// If the wrapped provisioner implements sdk.Refresher, so does the returned provisioner. Refreshes get a context with
+// the same timeout.
+func WithTimeout(provisioner sdk.Provisioner, timeout time.Duration) sdk.Provisioner {
+	withTimeout := TimeoutProvisioner{
+		provisioner: provisioner,
+		timeout:     timeout,
+	}
+	if refresher, ok := provisioner.(sdk.Refresher); ok {
+		return refreshingTimeoutProvisioner{TimeoutProvisioner: withTimeout, refresher: refresher}
+	}
+	return withTimeout
+}
+
+// refreshingTimeoutProvisioner is a TimeoutProvisioner that forwards refreshes to the wrapped provisioner. It's a
+// separate type so that only wrappers of provisioners that implement sdk.Refresher implement it as well.
+type refreshingTimeoutProvisioner struct {
+	TimeoutProvisioner
+
+	refresher sdk.Refresher
+}
+
+func (p refreshingTimeoutProvisioner) Refresh(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	p.refresher.Refresh(ctx, in, out)
+}
+
+func (p TimeoutProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	// Let the wrapped provisioner write to a copy of the output, since it may keep running after the deadline.
+	scratch := cloneProvisionOutput(*out)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.provisioner.Provision(ctx, in, &scratch)
+	}()
+
+	finished := false
+	select {
+	case <-done:
+		finished = true
+	case <-ctx.Done():
+		// The wrapped provisioner may have finished right at the deadline.
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+	}
+
+	// Provisioners that abort because of the deadline typically report the context error, so only keep their output if
+	// they succeeded.
+	if finished && (ctx.Err() == nil || len(scratch.Diagnostics.Errors) == 0) {
+		*out = scratch
+		return
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		out.AddError(fmt.Errorf("provisioning %s timed out after %s", p.provisioner.Description(), p.timeout))
+	} else {
+		out.AddError(fmt.Errorf("provisioning %s got canceled", p.provisioner.Description()))
+	}
+
+	deprovision := func() {
+		p.provisioner.Deprovision(context.Background(), sdk.DeprovisionInput{
+			HomeDir: in.HomeDir,
+			TempDir: in.TempDir,
+			DryRun:  in.DryRun,
+			State:   scratch.State,
+		}, &sdk.DeprovisionOutput{})
+	}
+	if finished {
+		deprovision()
+	} else {
+		go func() {
+			<-done
+			deprovision()
+		}()
+	}
+}
+
+func (p TimeoutProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	p.provisioner.Deprovision(ctx, in, out)
+}
+
+func (p TimeoutProvisioner) Description() string {
+	return p.provisioner.Description()
+}
+
+// cloneProvisionOutput returns a deep copy of the specified provision output.
+func cloneProvisionOutput(out sdk.ProvisionOutput) sdk.ProvisionOutput {
+	clone := out
+	clone.Environment = cloneMap(out.Environment)
+	clone.CommandLine = cloneSlice(out.CommandLine)
+	clone.SensitiveArgIndexes = cloneSlice(out.SensitiveArgIndexes)
+	clone.Files = cloneMap(out.Files)
+	clone.Directories = cloneMap(out.Directories)
+	clone.Stdin = cloneSlice(out.Stdin)
+	clone.Cache.Puts = cloneMap(out.Cache.Puts)
+	clone.Cache.Removes = cloneSlice(out.Cache.Removes)
+	clone.Plan = cloneSlice(out.Plan)
+	clone.State = cloneMap(out.State)
+	clone.Diagnostics.Errors = cloneSlice(out.Diagnostics.Errors)
+	clone.Diagnostics.Warnings = cloneSlice(out.Diagnostics.Warnings)
+	return clone
+}
+
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	if m == nil {
+		return nil
+	}
+	clone := make(map[K]V, len(m))
+	for k, v := range m {
+		clone[k] = v
+	}
+	return clone
+}
+
+func cloneSlice[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
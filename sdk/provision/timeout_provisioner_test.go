@@ -0,0 +1,103 @@
+package provision
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowProvisioner records state and then takes the specified delay to finish provisioning, optionally ignoring
+// context cancellation.
+type slowProvisioner struct {
+	sdk.Provisioner
+
+	delay        time.Duration
+	ignoreCancel bool
+	deprovisions chan map[string]string
+}
+
+func (p slowProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	out.AddState("slow|resource", "created")
+
+	if p.ignoreCancel {
+		time.Sleep(p.delay)
+	} else {
+		select {
+		case <-time.After(p.delay):
+		case <-ctx.Done():
+			out.AddError(ctx.Err())
+			return
+		}
+	}
+	out.AddEnvVar("SESSION_TOKEN", "token")
+}
+
+func (p slowProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	p.deprovisions <- in.State
+}
+
+func (p slowProvisioner) Description() string {
+	return "session token"
+}
+
+func TestWithTimeout(t *testing.T) {
+	t.Run("finishes in time", func(t *testing.T) {
+		out := sdk.ProvisionOutput{Environment: make(map[string]string)}
+		WithTimeout(slowProvisioner{delay: time.Millisecond}, time.Second).Provision(context.Background(), sdk.ProvisionInput{}, &out)
+
+		assert.Empty(t, out.Diagnostics.Errors)
+		assert.Equal(t, map[string]string{"SESSION_TOKEN": "token"}, out.Environment)
+		assert.Equal(t, map[string]string{"slow|resource": "created"}, out.State)
+	})
+
+	for name, ignoreCancel := range map[string]bool{
+		"respects cancellation": false,
+		"ignores cancellation":  true,
+	} {
+		t.Run(name, func(t *testing.T) {
+			deprovisions := make(chan map[string]string, 1)
+			p := WithTimeout(slowProvisioner{delay: 200 * time.Millisecond, ignoreCancel: ignoreCancel, deprovisions: deprovisions}, 10*time.Millisecond)
+
+			out := sdk.ProvisionOutput{Environment: make(map[string]string)}
+			start := time.Now()
+			p.Provision(context.Background(), sdk.ProvisionInput{}, &out)
+
+			assert.Less(t, time.Since(start), 150*time.Millisecond)
+			assert.Equal(t, []sdk.Error{{Message: "provisioning session token timed out after 10ms"}}, out.Diagnostics.Errors)
+			assert.Empty(t, out.Environment)
+			assert.Empty(t, out.State)
+
+			// The partially provisioned state still gets deprovisioned.
+			select {
+			case state := <-deprovisions:
+				assert.Equal(t, map[string]string{"slow|resource": "created"}, state)
+			case <-time.After(time.Second):
+				require.Fail(t, "wrapped provisioner did not get deprovisioned")
+			}
+		})
+	}
+}
+
+func TestWithTimeoutRefresh(t *testing.T) {
+	_, ok := WithTimeout(slowProvisioner{}, time.Second).(sdk.Refresher)
+	assert.False(t, ok, "wrapping a provisioner without refresher should not add one")
+
+	var deadline time.Time
+	source := func(ctx context.Context, in sdk.ProvisionInput) ([]byte, time.Time, error) {
+		deadline, _ = ctx.Deadline()
+		return []byte("token"), time.Now().Add(time.Hour), nil
+	}
+	refresher, ok := WithTimeout(ExpiringTokenFile(source, time.Minute), time.Minute).(sdk.Refresher)
+	require.True(t, ok, "wrapping a provisioner with refresher should keep it")
+
+	out := sdk.ProvisionOutput{Files: make(map[string]sdk.OutputFile)}
+	refresher.Refresh(context.Background(), sdk.ProvisionInput{TempDir: "/tmp"}, &out)
+	assert.Empty(t, out.Diagnostics.Errors)
+	assert.Equal(t, []byte("token"), out.Files["/tmp/token"].Contents)
+	assert.False(t, out.NextRefresh.IsZero())
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second, "the refresh should get the timeout")
+}
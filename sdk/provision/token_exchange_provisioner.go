@@ -0,0 +1,136 @@
+package provision
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// tokenExchangeExpiryMargin is how long before their expiry cached exchanged credentials stop being used, so that
+// they don't expire while the executable is running.
+const tokenExchangeExpiryMargin = time.Minute
+
+// ExchangeFunc exchanges the fields of the item, such as a long-lived key or refresh token, for short-lived
+// credentials. It returns the derived fields, mapped by field name, and when they expire. A zero expiry means the
+// derived fields should not be cached.
+type ExchangeFunc func(ctx context.Context, fields map[string]string) (derived map[string]string, expiresAt time.Time, err error)
+
+// TokenExchangeProvisioner exchanges the stored credential for a short-lived one before provisioning it.
+type TokenExchangeProvisioner struct {
+	sdk.Provisioner
+
+	platform   string
+	exchange   ExchangeFunc
+	downstream sdk.Provisioner
+	now        func() time.Time
+}
+
+// TokenExchange creates a TokenExchangeProvisioner that exchanges the item fields for short-lived credentials with the
+// specified platform, e.g. "Vault" or "AWS STS", and then provisions them using the downstream provisioner. The
+// downstream provisioner gets called with a copy of the item fields, in which the derived fields are added or
+// replace the original ones. For example, to provision an OAuth access token derived from a refresh token:
+// `TokenExchange("Example", refreshAccessToken, EnvVars(map[string]sdk.FieldName{"EXAMPLE_TOKEN": "Access Token"}))`
+//
+// The derived fields get stored in the encrypted cache until shortly before they expire, so the exchange doesn't have
+// to happen on every run. The cache entry is specific to the item fields, so that changing or switching the
+// credential leads to a new exchange.
+func TokenExchange(platform string, exchange ExchangeFunc, downstream sdk.Provisioner) sdk.Provisioner {
+	return TokenExchangeProvisioner{
+		platform:   platform,
+		exchange:   exchange,
+		downstream: downstream,
+		now:        time.Now,
+	}
+}
+
+func (p TokenExchangeProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	key := p.cacheKey(in.ItemFields)
+
+	var derived map[string]string
+	if entry, ok := in.Cache[key]; ok && p.now().Before(entry.ExpiresAt.Add(-tokenExchangeExpiryMargin)) {
+		if !in.Cache.Get(key, &derived) {
+			derived = nil
+		}
+	}
+
+	if in.DryRun {
+		if derived == nil {
+			out.AddPlan(fmt.Sprintf("Exchange the credential with %s, then: %s", p.platform, p.downstream.Description()))
+			return
+		}
+		out.AddPlan(fmt.Sprintf("Use the credential exchanged with %s earlier, which is still valid", p.platform))
+		p.downstream.Provision(ctx, withDerivedFields(in, derived), out)
+		return
+	}
+
+	if derived == nil {
+		fields := make(map[string]string, len(in.ItemFields))
+		for fieldName, value := range in.ItemFields {
+			fields[fieldName.String()] = value
+		}
+
+		var expiresAt time.Time
+		var err error
+		derived, expiresAt, err = p.exchange(ctx, fields)
+		if err != nil {
+			out.AddError(fmt.Errorf("exchanging credential with %s: %s", p.platform, err))
+			return
+		}
+
+		if !expiresAt.IsZero() {
+			if out.Cache.Puts == nil {
+				out.Cache.Puts = make(map[string]sdk.CacheEntry)
+			}
+			if err := out.Cache.Put(key, derived, expiresAt); err != nil {
+				out.AddWarning(fmt.Sprintf("caching credential exchanged with %s: %s", p.platform, err))
+			}
+		}
+	}
+
+	p.downstream.Provision(ctx, withDerivedFields(in, derived), out)
+}
+
+// withDerivedFields returns a copy of the provision input in which the derived fields are added to the item fields.
+func withDerivedFields(in sdk.ProvisionInput, derived map[string]string) sdk.ProvisionInput {
+	itemFields := make(map[sdk.FieldName]string, len(in.ItemFields)+len(derived))
+	for fieldName, value := range in.ItemFields {
+		itemFields[fieldName] = value
+	}
+	for fieldName, value := range derived {
+		itemFields[sdk.FieldName(fieldName)] = value
+	}
+	in.ItemFields = itemFields
+	return in
+}
+
+func (p TokenExchangeProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	// Leave the cache untouched, so that it can be used by consecutive runs.
+	p.downstream.Deprovision(ctx, in, out)
+}
+
+func (p TokenExchangeProvisioner) Description() string {
+	return fmt.Sprintf("%s (exchanged with %s)", p.downstream.Description(), p.platform)
+}
+
+// cacheKey returns the key of the cache entry for the exchanged credential, which contains a hash of the item fields,
+// so that credentials exchanged for other item fields don't get used.
+func (p TokenExchangeProvisioner) cacheKey(itemFields map[sdk.FieldName]string) string {
+	fieldNames := make([]string, 0, len(itemFields))
+	for fieldName := range itemFields {
+		fieldNames = append(fieldNames, fieldName.String())
+	}
+	sort.Strings(fieldNames)
+
+	// Prefix all names and values with their length, so that different fields can't produce the same input.
+	hash := sha256.New()
+	for _, fieldName := range fieldNames {
+		value := itemFields[sdk.FieldName(fieldName)]
+		fmt.Fprintf(hash, "%d:%s%d:%s", len(fieldName), fieldName, len(value), value)
+	}
+	return fmt.Sprintf("token-exchange|%s|%s", p.platform, hex.EncodeToString(hash.Sum(nil)))
+}
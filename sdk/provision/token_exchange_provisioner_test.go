@@ -0,0 +1,124 @@
+package provision
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenExchangeProvisioner(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	exchanges := 0
+	exchange := func(ctx context.Context, fields map[string]string) (map[string]string, time.Time, error) {
+		exchanges++
+		assert.Equal(t, map[string]string{"Token": "refresh-token", "Host": "example.com"}, fields)
+		return map[string]string{"Access Token": "access-token", "Token": "replaced"}, now.Add(time.Hour), nil
+	}
+
+	p := TokenExchange("Example", exchange, EnvVars(map[string]sdk.FieldName{
+		"EXAMPLE_ACCESS_TOKEN": "Access Token",
+		"EXAMPLE_TOKEN":        fieldname.Token,
+		"EXAMPLE_HOST":         fieldname.Host,
+	})).(TokenExchangeProvisioner)
+	p.now = func() time.Time { return now }
+
+	cache := sdk.CacheState{}
+	itemFields := map[sdk.FieldName]string{
+		fieldname.Token: "refresh-token",
+		fieldname.Host:  "example.com",
+	}
+	run := func() sdk.ProvisionOutput {
+		out := sdk.ProvisionOutput{Environment: make(map[string]string)}
+		p.Provision(context.Background(), sdk.ProvisionInput{
+			Cache:      cache,
+			ItemFields: itemFields,
+		}, &out)
+		require.Empty(t, out.Diagnostics.Errors)
+		for key, entry := range out.Cache.Puts {
+			cache[key] = entry
+		}
+		return out
+	}
+
+	expectedEnv := map[string]string{
+		"EXAMPLE_ACCESS_TOKEN": "access-token",
+		"EXAMPLE_TOKEN":        "replaced",
+		"EXAMPLE_HOST":         "example.com",
+	}
+
+	out := run()
+	assert.Equal(t, 1, exchanges)
+	assert.Equal(t, expectedEnv, out.Environment)
+	assert.Equal(t, now.Add(time.Hour), cache[p.cacheKey(itemFields)].ExpiresAt)
+
+	// Uses the cached credential while it's valid.
+	now = now.Add(50 * time.Minute)
+	out = run()
+	assert.Equal(t, 1, exchanges)
+	assert.Equal(t, expectedEnv, out.Environment)
+	assert.Empty(t, out.Cache.Puts)
+
+	// Exchanges again shortly before the cached credential expires.
+	now = now.Add(9*time.Minute + 30*time.Second)
+	out = run()
+	assert.Equal(t, 2, exchanges)
+	assert.Equal(t, expectedEnv, out.Environment)
+}
+
+func TestTokenExchangeProvisionerOtherItemFields(t *testing.T) {
+	now := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := TokenExchange("Example", func(ctx context.Context, fields map[string]string) (map[string]string, time.Time, error) {
+		return map[string]string{"Access Token": "access-for-" + fields["Token"]}, now.Add(time.Hour), nil
+	}, EnvVars(map[string]sdk.FieldName{"EXAMPLE_ACCESS_TOKEN": "Access Token"})).(TokenExchangeProvisioner)
+	p.now = func() time.Time { return now }
+
+	cache := sdk.CacheState{}
+	for _, token := range []string{"first-token", "second-token"} {
+		out := sdk.ProvisionOutput{Environment: make(map[string]string)}
+		p.Provision(context.Background(), sdk.ProvisionInput{
+			Cache:      cache,
+			ItemFields: map[sdk.FieldName]string{fieldname.Token: token},
+		}, &out)
+		require.Empty(t, out.Diagnostics.Errors)
+		for key, entry := range out.Cache.Puts {
+			cache[key] = entry
+		}
+
+		// The credential exchanged for the first token must not be used for the second one.
+		assert.Equal(t, map[string]string{"EXAMPLE_ACCESS_TOKEN": "access-for-" + token}, out.Environment)
+	}
+	assert.Len(t, cache, 2)
+	assert.NotEqual(t,
+		p.cacheKey(map[sdk.FieldName]string{"ab": "c"}),
+		p.cacheKey(map[sdk.FieldName]string{"a": "bc"}),
+	)
+}
+
+func TestTokenExchangeProvisionerWithoutExpiry(t *testing.T) {
+	p := TokenExchange("Example", func(ctx context.Context, fields map[string]string) (map[string]string, time.Time, error) {
+		return map[string]string{"Access Token": "access-token"}, time.Time{}, nil
+	}, EnvVars(map[string]sdk.FieldName{"EXAMPLE_ACCESS_TOKEN": "Access Token"}))
+
+	out := sdk.ProvisionOutput{Environment: make(map[string]string)}
+	p.Provision(context.Background(), sdk.ProvisionInput{ItemFields: map[sdk.FieldName]string{}}, &out)
+	assert.Empty(t, out.Diagnostics.Errors)
+	assert.Equal(t, map[string]string{"EXAMPLE_ACCESS_TOKEN": "access-token"}, out.Environment)
+	assert.Empty(t, out.Cache.Puts)
+}
+
+func TestTokenExchangeProvisionerError(t *testing.T) {
+	p := TokenExchange("Vault", func(ctx context.Context, fields map[string]string) (map[string]string, time.Time, error) {
+		return nil, time.Time{}, errors.New("dial tcp 127.0.0.1:8200: connect: connection refused")
+	}, EnvVars(map[string]sdk.FieldName{"VAULT_TOKEN": fieldname.Token}))
+
+	out := sdk.ProvisionOutput{Environment: make(map[string]string)}
+	p.Provision(context.Background(), sdk.ProvisionInput{ItemFields: map[sdk.FieldName]string{fieldname.Token: "role-secret"}}, &out)
+	assert.Equal(t, []sdk.Error{{Message: "exchanging credential with Vault: dial tcp 127.0.0.1:8200: connect: connection refused"}}, out.Diagnostics.Errors)
+	assert.Empty(t, out.Environment)
+}
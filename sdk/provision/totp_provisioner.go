@@ -0,0 +1,231 @@
+package provision
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+)
+
+// TOTPProvisioner provisions a time-based one-time password (RFC 6238), derived from a TOTP secret stored in the item.
+type TOTPProvisioner struct {
+	sdk.Provisioner
+
+	secretField  sdk.FieldName
+	digits       int
+	period       time.Duration
+	algorithm    string
+	envVarName   string
+	argTemplates []string
+	now          func() time.Time
+}
+
+// TOTPOption can be used to influence the behavior of the TOTP provisioner.
+type TOTPOption func(*TOTPProvisioner)
+
+// TOTP creates a TOTPProvisioner that computes the current one-time password from the TOTP secret stored in the
+// specified field, e.g. fieldname.MFASecret. The secret can either be a base32-encoded key or an otpauth:// URI.
+// By default, codes consist of 6 digits, use a 30 second period, and use SHA1. Use TOTPAsEnvVar or TOTPAsArgs to
+// specify how the code gets passed to the executable.
+func TOTP(secretField sdk.FieldName, opts ...TOTPOption) sdk.Provisioner {
+	p := TOTPProvisioner{
+		secretField: secretField,
+		digits:      6,
+		period:      30 * time.Second,
+		algorithm:   "SHA1",
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
+
+// TOTPAsEnvVar can be used to provision the one-time password as the specified environment variable.
+func TOTPAsEnvVar(envVarName string) TOTPOption {
+	return func(p *TOTPProvisioner) {
+		p.envVarName = envVarName
+	}
+}
+
+// TOTPAsArgs can be used to add the one-time password to the command line. The code is available as "{{ .Code }}"
+// in each arg. For example: `TOTPAsArgs("--mfa-code", "{{ .Code }}")`.
+func TOTPAsArgs(argTemplates ...string) TOTPOption {
+	return func(p *TOTPProvisioner) {
+		p.argTemplates = argTemplates
+	}
+}
+
+// TOTPDigits can be used to change the number of digits of the one-time password. Defaults to 6.
+func TOTPDigits(digits int) TOTPOption {
+	return func(p *TOTPProvisioner) {
+		p.digits = digits
+	}
+}
+
+// TOTPPeriod can be used to change how long each one-time password is valid. Defaults to 30 seconds.
+func TOTPPeriod(period time.Duration) TOTPOption {
+	return func(p *TOTPProvisioner) {
+		p.period = period
+	}
+}
+
+// TOTPAlgorithm can be used to change the HMAC algorithm. Supported values: "SHA1", "SHA256", "SHA512".
+// Defaults to "SHA1".
+func TOTPAlgorithm(algorithm string) TOTPOption {
+	return func(p *TOTPProvisioner) {
+		p.algorithm = algorithm
+	}
+}
+
+func (p TOTPProvisioner) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	secret, ok := in.ItemFields[p.secretField]
+	if !ok {
+		out.AddError(fmt.Errorf("no value present in the item for field '%s'", p.secretField))
+		return
+	}
+
+	var code string
+	if in.DryRun {
+		code = sdk.ConcealedValue(fieldname.OneTimePassword)
+		out.AddPlan(fmt.Sprintf("Provision one-time password derived from %s", p.secretField))
+	} else {
+		var err error
+		code, err = p.generate(secret, p.now())
+		if err != nil {
+			out.AddError(fmt.Errorf("generating one-time password from field '%s': %s", p.secretField, err))
+			return
+		}
+	}
+
+	if p.envVarName != "" {
+		out.AddEnvVar(p.envVarName, code)
+	}
+
+	if len(p.argTemplates) > 0 {
+		tmplData := struct{ Code string }{
+			Code: code,
+		}
+
+		args := make([]string, len(p.argTemplates))
+		for i, tmplStr := range p.argTemplates {
+			arg, err := executeTemplate("arg", tmplStr, tmplData)
+			if err != nil {
+				out.AddError(err)
+				return
+			}
+			args[i] = arg
+		}
+
+		out.AddSensitiveArgs(args...)
+	}
+}
+
+func (p TOTPProvisioner) Deprovision(ctx context.Context, in sdk.DeprovisionInput, out *sdk.DeprovisionOutput) {
+	// Nothing to do here: one-time passwords expire by themselves.
+}
+
+func (p TOTPProvisioner) Description() string {
+	return fmt.Sprintf("Provision one-time password derived from %s", p.secretField)
+}
+
+// generate computes the one-time password for the specified time, as described in RFC 6238.
+func (p TOTPProvisioner) generate(secret string, t time.Time) (string, error) {
+	digits, period, algorithm := p.digits, p.period, p.algorithm
+
+	// Parameters set in an otpauth:// URI take precedence over the configured ones.
+	if strings.HasPrefix(secret, "otpauth://") {
+		uri, err := url.Parse(secret)
+		if err != nil {
+			return "", fmt.Errorf("invalid otpauth URI: %s", err)
+		}
+
+		query := uri.Query()
+		secret = query.Get("secret")
+		if value := query.Get("digits"); value != "" {
+			if digits, err = strconv.Atoi(value); err != nil {
+				return "", fmt.Errorf("invalid digits in otpauth URI: %s", value)
+			}
+		}
+		if value := query.Get("period"); value != "" {
+			seconds, err := strconv.Atoi(value)
+			if err != nil {
+				return "", fmt.Errorf("invalid period in otpauth URI: %s", value)
+			}
+			period = time.Duration(seconds) * time.Second
+		}
+		if value := query.Get("algorithm"); value != "" {
+			algorithm = value
+		}
+	}
+
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	var newHash func() hash.Hash
+	switch strings.ToUpper(algorithm) {
+	case "SHA1":
+		newHash = sha1.New
+	case "SHA256":
+		newHash = sha256.New
+	case "SHA512":
+		newHash = sha512.New
+	default:
+		return "", fmt.Errorf("unsupported algorithm: %s", algorithm)
+	}
+
+	if digits < 1 || digits > 10 {
+		return "", fmt.Errorf("unsupported number of digits: %d", digits)
+	}
+
+	if period < time.Second {
+		return "", fmt.Errorf("unsupported period: %s", period)
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(t.Unix()/int64(period/time.Second)))
+
+	mac := hmac.New(newHash, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation, see RFC 4226 section 5.3.
+	offset := sum[len(sum)-1] & 0x0f
+	value := uint64(binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff)
+
+	modulo := uint64(1)
+	for i := 0; i < digits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%modulo), nil
+}
+
+// decodeTOTPSecret decodes a base32-encoded TOTP secret, ignoring case, spaces, and padding.
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	normalized := strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	normalized = strings.TrimRight(normalized, "=")
+	if normalized == "" {
+		return nil, fmt.Errorf("secret is empty")
+	}
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(normalized)
+	if err != nil {
+		return nil, fmt.Errorf("secret is not valid base32: %s", err)
+	}
+	return key, nil
+}
@@ -0,0 +1,80 @@
+package provision
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	rfc6238SecretSHA1   = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	rfc6238SecretSHA256 = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZA===="
+	rfc6238SecretSHA512 = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNA="
+)
+
+// TestTOTPRFC6238Vectors uses the test vectors from RFC 6238, Appendix B.
+func TestTOTPRFC6238Vectors(t *testing.T) {
+	cases := []struct {
+		unix      int64
+		algorithm string
+		secret    string
+		expected  string
+	}{
+		{59, "SHA1", rfc6238SecretSHA1, "94287082"},
+		{59, "SHA256", rfc6238SecretSHA256, "46119246"},
+		{59, "SHA512", rfc6238SecretSHA512, "90693936"},
+		{1111111109, "SHA1", rfc6238SecretSHA1, "07081804"},
+		{1111111109, "SHA256", rfc6238SecretSHA256, "68084774"},
+		{1111111109, "SHA512", rfc6238SecretSHA512, "25091201"},
+		{1234567890, "SHA1", rfc6238SecretSHA1, "89005924"},
+		{1234567890, "SHA256", rfc6238SecretSHA256, "91819424"},
+		{1234567890, "SHA512", rfc6238SecretSHA512, "93441116"},
+		{20000000000, "SHA1", rfc6238SecretSHA1, "65353130"},
+		{20000000000, "SHA256", rfc6238SecretSHA256, "77737706"},
+		{20000000000, "SHA512", rfc6238SecretSHA512, "47863826"},
+	}
+
+	for _, c := range cases {
+		p := TOTP(fieldname.MFASecret, TOTPDigits(8), TOTPAlgorithm(c.algorithm)).(TOTPProvisioner)
+		code, err := p.generate(c.secret, time.Unix(c.unix, 0))
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, code, "%s at %d", c.algorithm, c.unix)
+	}
+}
+
+func TestTOTPProvisioner(t *testing.T) {
+	provision := func(p sdk.Provisioner, secret string) sdk.ProvisionOutput {
+		totp := p.(TOTPProvisioner)
+		totp.now = func() time.Time { return time.Unix(1111111109, 0) }
+
+		out := sdk.ProvisionOutput{
+			Environment: make(map[string]string),
+			Files:       make(map[string]sdk.OutputFile),
+			CommandLine: []string{"aws"},
+		}
+		totp.Provision(context.Background(), sdk.ProvisionInput{
+			ItemFields: map[sdk.FieldName]string{
+				fieldname.MFASecret: secret,
+			},
+		}, &out)
+		return out
+	}
+
+	out := provision(TOTP(fieldname.MFASecret, TOTPAsEnvVar("MFA_CODE")), rfc6238SecretSHA1)
+	assert.Equal(t, map[string]string{"MFA_CODE": "081804"}, out.Environment)
+
+	out = provision(TOTP(fieldname.MFASecret, TOTPAsArgs("--token-code", "{{ .Code }}")), "gezd gnbv gy3t qojq gezd gnbv gy3t qojq")
+	assert.Equal(t, []string{"aws", "--token-code", "081804"}, out.CommandLine)
+	assert.Equal(t, []string{"aws", "***", "***"}, out.MaskedCommandLine())
+
+	out = provision(TOTP(fieldname.MFASecret, TOTPAsEnvVar("MFA_CODE")), "otpauth://totp/Example:wendy?secret="+rfc6238SecretSHA1+"&digits=8&period=30")
+	assert.Equal(t, map[string]string{"MFA_CODE": "07081804"}, out.Environment)
+
+	out = provision(TOTP(fieldname.MFASecret, TOTPAsEnvVar("MFA_CODE")), "not-base32!")
+	assert.Empty(t, out.Environment)
+	assert.Equal(t, []sdk.Error{{Message: "generating one-time password from field 'MFA Secret': secret is not valid base32: illegal base32 data at input byte 3"}}, out.Diagnostics.Errors)
+}
@@ -3,7 +3,12 @@ package sdk
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
 )
 
@@ -22,6 +27,18 @@ type Provisioner interface {
 	Deprovision(ctx context.Context, input DeprovisionInput, output *DeprovisionOutput)
 }
 
+// Refresher can optionally be implemented by provisioners that provision short-lived credentials, so that these can be
+// refreshed while the executable is running. Refresh gets called at the time set in ProvisionOutput.NextRefresh, first
+// by Provision and after that by the previous call to Refresh.
+//
+// Since the environment and command line of a running process can't change, only the Files of the provision output get
+// applied after refreshing, so refreshable credentials should be provisioned as files.
+type Refresher interface {
+	// Refresh refreshes the provisioned credentials and sets out.NextRefresh to when it should be called next. Leaving it
+	// zero means that no further refreshes are needed. Adding an error to the output stops refreshing.
+	Refresh(ctx context.Context, in ProvisionInput, out *ProvisionOutput)
+}
+
 // ProvisionInput contains info that provisioners can use to provision credentials.
 type ProvisionInput struct {
 	// HomeDir is the path to current user's home directory.
@@ -31,21 +48,85 @@ type ProvisionInput struct {
 	// This directory will automatically be deleted after the executable exits.
 	TempDir string
 
-	// DryRun can be used to opt out
+	// OS is the operating system the executable runs on. Supported values: "darwin", "linux", "windows"
+	OS string
+
+	// ConfigDir is the user's config directory: $XDG_CONFIG_HOME if set, and otherwise ~/.config on Linux,
+	// ~/Library/Application Support on macOS, and %AppData% on Windows. Use FromConfigDir to build paths in it, which
+	// also resolves the directory if it's not set.
+	ConfigDir string
+
+	// CacheDir is the user's cache directory: $XDG_CACHE_HOME if set, and otherwise ~/.cache on Linux,
+	// ~/Library/Caches on macOS, and %LocalAppData% on Windows. Use FromCacheDir to build paths in it, which also
+	// resolves the directory if it's not set.
+	CacheDir string
+
+	// DryRun indicates that the provisioner should only describe what it would provision, without touching any real
+	// secrets or making any changes to the system. In a dry run, provisioners populate the provision output with
+	// placeholders instead of (possibly sensitive) values, see ConcealedValue, and describe what would happen in Plan.
 	DryRun bool
 
 	// Cache can contain data that got added in the provision step from previous runs for this credential.
 	Cache CacheState
 
-	// ItemFields contains the field names and their corresponding (sensitive) values.
+	// CredentialName is the name of the credential type that is being provisioned. When an executable uses multiple
+	// credentials, the provisioner of each credential gets called with its own input, see ProvisionCredentials.
+	CredentialName CredentialName
+
+	// ItemFields contains the field names and their corresponding (sensitive) values. Only the fields of the
+	// credential that is being provisioned are included.
 	ItemFields map[FieldName]string
+
+	// RequiredFields contains the names of the fields of the credential that are not optional, so that provisioners
+	// can report a required field that's missing from the item instead of skipping it. If it's empty, all fields are
+	// treated as optional.
+	RequiredFields []FieldName
+
+	// FieldDefaults contains the default values of the fields of the credential that have one, which provisioners can
+	// use through FieldValueOrDefault when the item doesn't have the field.
+	FieldDefaults map[FieldName]string
+
+	// FieldSets contains the alternative sets of fields of the credential, if it has any, e.g. a token or a username
+	// and password. Provisioners only provision the fields of the set that the item uses, see FieldSet and UsesField.
+	FieldSets [][]FieldName
+
+	// DefaultTTL is how long the credential is typically valid for, if its credential type is expirable. Provisioners
+	// that cache derived credentials can use it when they're not configured with a TTL themselves.
+	DefaultTTL time.Duration
+
+	// ParentEnvVarNames contains the names (not the values) of the environment variables that are already set in the
+	// environment that the executable gets started from.
+	ParentEnvVarNames []string
+
+	// ParentEnvVarValues contains the values of the environment variables listed in ParentEnvVarsWithValues, if they
+	// are set in the environment that the executable gets started from, so that provisioners can build upon them. The
+	// values of other environment variables are not passed, since they may be sensitive.
+	ParentEnvVarValues map[string]string
 }
 
+// ParentEnvVarsWithValues contains the names of the environment variables whose values the host passes to
+// provisioners in ProvisionInput.ParentEnvVarValues.
+var ParentEnvVarsWithValues = []string{"PATH", "KUBECONFIG"}
+
 // DeprovisionInput contains info that provisioners can use to deprovision credentials.
 type DeprovisionInput struct {
 	HomeDir string
 	TempDir string
 	DryRun  bool
+
+	// State contains the state that got added to the provision output in the provision step of this execution.
+	State map[string]string
+}
+
+// ConcealedFileContents is the placeholder for the contents of files provisioned in a dry run.
+const ConcealedFileContents = "<concealed:file contents>"
+
+// MaskedValue is the placeholder for sensitive command-line args whenever the command line gets displayed.
+const MaskedValue = "***"
+
+// ConcealedValue returns the placeholder for the value of the specified field in a dry run, e.g. "<concealed:Token>".
+func ConcealedValue(fieldName FieldName) string {
+	return fmt.Sprintf("<concealed:%s>", fieldName)
 }
 
 // ProvisionOutput contains the sensitive values that the Provisioner outputs.
@@ -58,16 +139,52 @@ type ProvisionOutput struct {
 	// line that will be executed.
 	CommandLine []string
 
+	// SensitiveArgIndexes contains the indexes of the args in CommandLine that contain sensitive values and should be
+	// masked wherever the command line gets displayed.
+	SensitiveArgIndexes []int
+
 	// Files can be used to provision credentials as files. The result of this will be automatically written to disk and deleted when the executable
 	// exits. The expected mapping is: absolute file path to (possibly sensitive) file contents.
 	Files map[string]OutputFile
 
+	// Directories can be used to declare directories that files get provisioned in, to control their permissions. Missing
+	// parent directories of files that are not declared here get created with 0700. Directories only get removed when the
+	// executable exits if they got created for this execution and are empty. The expected mapping is: absolute directory
+	// path to directory info.
+	Directories map[string]OutputDirectory
+
+	// WorkingDirectory can be used to run the executable from a different directory than the one the user ran the command
+	// from, e.g. a directory in the temp dir containing generated config files. Note that relative paths in the command
+	// line, including the ones the user passed, then get resolved relative to this directory. Provisioners should therefore
+	// only add absolute paths to the command line. The directory must exist by the time the executable runs.
+	WorkingDirectory string
+
+	// Stdin can be used to provision credentials through the executable's standard input. The result of this will be piped
+	// to the executable before the user's own stdin gets forwarded. This is useful for executables that read secrets from
+	// stdin, such as `docker login --password-stdin`. The contents are sensitive and never get logged, not even in dry-run.
+	Stdin []byte
+
 	// Cache can be used to make data generated in this provision step available to the provision step of consecutive runs for this credential.
 	// The data added to the cache will be encrypted and stored locally on disk, so it can be used to store sensitive data. To access the cached
 	// data from previous runs, use Cache on ProvisionInput.
 	Cache CacheOperations
 
-	// Diagnostics can be used to report errors.
+	// Plan contains human-readable descriptions of what the provisioner would do. This gets populated in dry runs and
+	// must never contain sensitive values.
+	Plan []string
+
+	// State can be used to pass data from the provision step to the deprovision step of the same execution, e.g. the
+	// location of resources that got created outside of the temp dir and need to be cleaned up. It gets handed back
+	// verbatim as State on DeprovisionInput. Since all provisioners of an execution share the same state, keys should be
+	// prefixed with something that identifies the provisioner. Values may get stored by the host in plaintext, so
+	// they must not contain sensitive data.
+	State map[string]string
+
+	// NextRefresh can be set by provisioners that implement Refresher to schedule the next refresh of the provisioned
+	// credentials while the executable is running, both from Provision and from Refresh.
+	NextRefresh time.Time
+
+	// Diagnostics can be used to report errors and warnings.
 	Diagnostics Diagnostics
 }
 
@@ -78,6 +195,23 @@ type DeprovisionOutput struct {
 // OutputFile contains the sensitive file info and contents that the provisioner outputs.
 type OutputFile struct {
 	Contents []byte
+
+	// (Optional) The permissions to write the file with. Defaults to 0600.
+	FileMode fs.FileMode
+
+	// Whether access to the file should be restricted as much as the platform allows, so that only the current user's
+	// processes can access it. On Unix, this strips all group and other permission bits from FileMode.
+	OnlyAllowCurrentProcess bool
+}
+
+// OutputDirectory contains the info of a directory that files get provisioned in.
+type OutputDirectory struct {
+	// (Optional) The permissions to create the directory with. Defaults to 0700.
+	FileMode fs.FileMode
+
+	// Whether access to the directory should be restricted as much as the platform allows, so that only the current
+	// user's processes can access it. On Unix, this strips all group and other permission bits from FileMode.
+	OnlyAllowCurrentProcess bool
 }
 
 // CacheState represents the state of the encrypted cache for a given plugin and item.
@@ -102,11 +236,105 @@ func (out *ProvisionOutput) AddEnvVar(name string, value string) {
 	out.Environment[name] = value
 }
 
-// AddArgs can be used to add additional arguments to the command line of the provision output.
+// AddArgs can be used to add additional arguments to the command line of the provision output. These args are
+// considered non-sensitive. Use AddSensitiveArgs for args that contain secrets.
 func (out *ProvisionOutput) AddArgs(args ...string) {
 	out.CommandLine = append(out.CommandLine, args...)
 }
 
+// AddSensitiveArgs can be used to add additional arguments containing sensitive values to the command line of the
+// provision output. These args will be masked wherever the command line gets displayed.
+func (out *ProvisionOutput) AddSensitiveArgs(args ...string) {
+	out.InsertSensitiveArgs(len(out.CommandLine), args...)
+}
+
+// MaskedCommandLine returns a copy of the command line in which all sensitive args are replaced by MaskedValue. Use this
+// whenever the command line gets displayed, e.g. in debug logs or dry-run output. If no args were marked as
+// sensitive, the command line is returned as is.
+func (out ProvisionOutput) MaskedCommandLine() []string {
+	masked := make([]string, len(out.CommandLine))
+	copy(masked, out.CommandLine)
+	for _, index := range out.SensitiveArgIndexes {
+		if index >= 0 && index < len(masked) {
+			masked[index] = MaskedValue
+		}
+	}
+	return masked
+}
+
+// InsertArgs can be used to insert additional arguments into the command line of the provision output at the specified index.
+func (out *ProvisionOutput) InsertArgs(index int, args ...string) {
+	if index < 0 || index > len(out.CommandLine) {
+		index = len(out.CommandLine)
+	}
+
+	commandLine := make([]string, 0, len(out.CommandLine)+len(args))
+	commandLine = append(commandLine, out.CommandLine[:index]...)
+	commandLine = append(commandLine, args...)
+	commandLine = append(commandLine, out.CommandLine[index:]...)
+	out.CommandLine = commandLine
+
+	// Keep pointing at the same args after shifting them.
+	for i, sensitiveIndex := range out.SensitiveArgIndexes {
+		if sensitiveIndex >= index {
+			out.SensitiveArgIndexes[i] = sensitiveIndex + len(args)
+		}
+	}
+}
+
+// InsertSensitiveArgs can be used to insert additional arguments containing sensitive values into the command line of
+// the provision output at the specified index. These args will be masked wherever the command line gets displayed.
+func (out *ProvisionOutput) InsertSensitiveArgs(index int, args ...string) {
+	if index < 0 || index > len(out.CommandLine) {
+		index = len(out.CommandLine)
+	}
+
+	out.InsertArgs(index, args...)
+	for i := range args {
+		out.SensitiveArgIndexes = append(out.SensitiveArgIndexes, index+i)
+	}
+}
+
+// SetWorkingDir can be used to set the directory that the executable runs from. If another provisioner already set a
+// different working directory, an error gets reported, since the executable can only run from one directory.
+func (out *ProvisionOutput) SetWorkingDir(path string) {
+	if out.WorkingDirectory != "" && out.WorkingDirectory != path {
+		out.AddError(fmt.Errorf("conflicting working directories: %s and %s", out.WorkingDirectory, path))
+		return
+	}
+	out.WorkingDirectory = path
+}
+
+// CheckWorkingDir returns an error if a working directory is set that does not exist or is not a directory. This should
+// be called right before running the executable, after the provisioned files and directories have been written.
+func (out *ProvisionOutput) CheckWorkingDir() error {
+	if out.WorkingDirectory == "" {
+		return nil
+	}
+
+	info, err := os.Stat(out.WorkingDirectory)
+	if err != nil {
+		return fmt.Errorf("invalid working directory: %s", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("invalid working directory: %s is not a directory", out.WorkingDirectory)
+	}
+	return nil
+}
+
+// AddState can be used to pass data from the provision step to the deprovision step of the same execution.
+func (out *ProvisionOutput) AddState(key string, value string) {
+	if out.State == nil {
+		out.State = make(map[string]string)
+	}
+	out.State[key] = value
+}
+
+// AddStdin can be used to add (possibly sensitive) contents to the standard input of the executable.
+func (out *ProvisionOutput) AddStdin(contents []byte) {
+	out.Stdin = append(out.Stdin, contents...)
+}
+
 // AddSecretFile can be used to add a file containing secrets to the provision output.
 func (out *ProvisionOutput) AddSecretFile(path string, contents []byte) {
 	out.AddFile(path, OutputFile{
@@ -121,17 +349,225 @@ func (out *ProvisionOutput) AddNonSecretFile(path string, contents []byte) {
 	})
 }
 
+// AddSecretFileFromField can be used to add a file to the provision output that contains the value of the specified
+// field, restricted to the current user. If the field is not present in the item, an error is reported instead.
+func (out *ProvisionOutput) AddSecretFileFromField(path string, in ProvisionInput, fieldName FieldName) {
+	value, ok := in.FieldValue(fieldName)
+	if !ok {
+		out.AddError(fmt.Errorf("no value present in the item for field '%s'", fieldName))
+		return
+	}
+
+	out.AddFile(path, OutputFile{
+		Contents:                []byte(value),
+		FileMode:                0600,
+		OnlyAllowCurrentProcess: true,
+	})
+}
+
+// AddExecutableScript can be used to add an executable script to the bin dir in the temp dir, for credentials that
+// can only be consumed through a helper program, such as GIT_ASKPASS or an AWS credential_process. The bin dir gets
+// prepended to PATH, so the executable can find the script by its name. Returns the path of the script.
+func (out *ProvisionOutput) AddExecutableScript(in ProvisionInput, name string, contents []byte) string {
+	binDir := in.FromTempDir("bin")
+	path := filepath.Join(binDir, name)
+
+	out.AddDirectory(binDir, OutputDirectory{
+		FileMode:                0700,
+		OnlyAllowCurrentProcess: true,
+	})
+	out.AddFile(path, OutputFile{
+		Contents:                contents,
+		FileMode:                0700,
+		OnlyAllowCurrentProcess: true,
+	})
+	out.PrependPath(in, binDir)
+	return path
+}
+
+// PrependPath can be used to put the specified directory in front of the PATH of the executable, so that executables
+// in it take precedence. The directory gets prepended to the PATH provisioned so far, or else to the PATH of the
+// environment that the executable gets started from, as passed by the host in ProvisionInput.ParentEnvVarValues. If
+// neither is known, an error is reported, since replacing the PATH would break the executable. If the directory was
+// already on the PATH, it gets moved to the front.
+func (out *ProvisionOutput) PrependPath(in ProvisionInput, dir string) {
+	name, path, ok := in.parentEnvVar("PATH")
+	if !ok {
+		name = "PATH"
+	}
+	// A PATH that got provisioned so far takes precedence, under whichever name it got provisioned.
+	for envVarName, value := range out.Environment {
+		if envVarName == name || (in.goos() == "windows" && strings.EqualFold(envVarName, "PATH")) {
+			name, path, ok = envVarName, value, true
+			break
+		}
+	}
+	if !ok {
+		out.AddError(fmt.Errorf("can't prepend %s to the PATH, because the PATH of the environment that the executable gets started from is unknown", dir))
+		return
+	}
+
+	separator := ':'
+	if in.goos() == "windows" {
+		separator = ';'
+	}
+	out.AddEnvVar(name, prependPathList(path, dir, separator))
+}
+
+// prependPathList puts the directory in front of the list of paths with the specified separator.
+func prependPathList(list string, dir string, separator rune) string {
+	result := []string{dir}
+	if list != "" {
+		for _, entry := range strings.Split(list, string(separator)) {
+			if entry != dir {
+				result = append(result, entry)
+			}
+		}
+	}
+	return strings.Join(result, string(separator))
+}
+
 // AddFile can be used to add a file to the provision output.
 func (out *ProvisionOutput) AddFile(path string, file OutputFile) {
 	out.Files[path] = file
 }
 
+// AddDirectory can be used to declare a directory that files get provisioned in, to control its permissions.
+func (out *ProvisionOutput) AddDirectory(path string, dir OutputDirectory) {
+	if out.Directories == nil {
+		out.Directories = make(map[string]OutputDirectory)
+	}
+	out.Directories[path] = dir
+}
+
 // AddError can be used to report an error to the provision output. If the provision output contains one
 // or more errors, provisioning is considered failed.
 func (out *ProvisionOutput) AddError(err error) {
 	out.Diagnostics.Errors = append(out.Diagnostics.Errors, Error{err.Error()})
 }
 
+// AddWarning can be used to report a warning to the provision output. Warnings don't cause provisioning to fail.
+func (out *ProvisionOutput) AddWarning(message string) {
+	out.Diagnostics.Warnings = append(out.Diagnostics.Warnings, Warning{message})
+}
+
+// AddError can be used to report an error to the deprovision output.
+func (out *DeprovisionOutput) AddError(err error) {
+	out.Diagnostics.Errors = append(out.Diagnostics.Errors, Error{err.Error()})
+}
+
+// AddPlan can be used to describe what the provisioner would do in a dry run. The description must not contain
+// sensitive values.
+func (out *ProvisionOutput) AddPlan(description string) {
+	out.Plan = append(out.Plan, description)
+}
+
+// Concealed returns a copy of the provision input in which the values of all item fields are replaced by placeholders.
+// This can be used to resolve what would be provisioned in a dry run, without touching any real secrets.
+func (in *ProvisionInput) Concealed() ProvisionInput {
+	concealed := *in
+	concealed.ItemFields = make(map[FieldName]string, len(in.ItemFields))
+	for fieldName := range in.ItemFields {
+		concealed.ItemFields[fieldName] = ConcealedValue(fieldName)
+	}
+	return concealed
+}
+
+// FieldValue returns the value of the field with the specified name, and whether it's present in the item.
+func (in *ProvisionInput) FieldValue(fieldName FieldName) (string, bool) {
+	value, ok := in.ItemFields[fieldName]
+	return value, ok
+}
+
+// FieldValueOrDefault returns the value of the field with the specified name, or its default value if it's not present
+// in the item, see FieldDefaults. It returns false if the field has neither.
+func (in *ProvisionInput) FieldValueOrDefault(fieldName FieldName) (string, bool) {
+	if value, ok := in.ItemFields[fieldName]; ok {
+		return value, true
+	}
+	value, ok := in.FieldDefaults[fieldName]
+	return value, ok
+}
+
+// IsRequiredField returns whether the field with the specified name is required for the credential, see RequiredFields.
+func (in *ProvisionInput) IsRequiredField(fieldName FieldName) bool {
+	for _, requiredField := range in.RequiredFields {
+		if requiredField == fieldName {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldSet returns the fields of the first of the FieldSets that the item has all fields of, or nil if there is none.
+func (in *ProvisionInput) FieldSet() []FieldName {
+	for _, fieldSet := range in.FieldSets {
+		complete := true
+		for _, fieldName := range fieldSet {
+			if _, ok := in.ItemFields[fieldName]; !ok {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			return fieldSet
+		}
+	}
+	return nil
+}
+
+// UsesField returns whether the field with the specified name should be provisioned, which is the case for all fields,
+// except for those that only occur in field sets other than the one that the item uses, see FieldSet.
+func (in *ProvisionInput) UsesField(fieldName FieldName) bool {
+	for _, name := range in.FieldSet() {
+		if name == fieldName {
+			return true
+		}
+	}
+	for _, fieldSet := range in.FieldSets {
+		for _, name := range fieldSet {
+			if name == fieldName {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// HasParentEnvVar returns whether the environment variable with the specified name is already set in the
+// environment that the executable gets started from.
+func (in *ProvisionInput) HasParentEnvVar(name string) bool {
+	for _, parentEnvVarName := range in.ParentEnvVarNames {
+		if parentEnvVarName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ParentEnvVarValue returns the value of the environment variable with the specified name in the environment that the
+// executable gets started from. This is only known for the environment variables in ParentEnvVarsWithValues.
+func (in *ProvisionInput) ParentEnvVarValue(name string) (value string, ok bool) {
+	_, value, ok = in.parentEnvVar(name)
+	return value, ok
+}
+
+// parentEnvVar looks up the environment variable with the specified name in ParentEnvVarValues, and also returns the
+// name it's set under. On Windows, names are case-insensitive, e.g. PATH is usually set as "Path".
+func (in *ProvisionInput) parentEnvVar(name string) (actualName string, value string, ok bool) {
+	if value, ok := in.ParentEnvVarValues[name]; ok {
+		return name, value, true
+	}
+	if in.goos() == "windows" {
+		for actualName, value := range in.ParentEnvVarValues {
+			if strings.EqualFold(actualName, name) {
+				return actualName, value, true
+			}
+		}
+	}
+	return "", "", false
+}
+
 // FromHomeDir returns a path with the user's home directory prepended.
 func (in *ProvisionInput) FromHomeDir(path ...string) string {
 	return filepath.Join(append([]string{in.HomeDir}, path...)...)
@@ -142,6 +578,36 @@ func (in *ProvisionInput) FromTempDir(path ...string) string {
 	return filepath.Join(append([]string{in.TempDir}, path...)...)
 }
 
+// FromConfigDir returns a path with the user's config directory prepended. This is ConfigDir as set by the host, or
+// if it's not set: $XDG_CONFIG_HOME if set, and otherwise ~/.config on Linux, ~/Library/Application Support on macOS,
+// and %AppData% on Windows.
+func (in *ProvisionInput) FromConfigDir(path ...string) string {
+	dir := in.ConfigDir
+	if dir == "" {
+		dir = userConfigDir(in.goos(), os.Getenv, in.HomeDir)
+	}
+	return filepath.Join(append([]string{dir}, path...)...)
+}
+
+// FromCacheDir returns a path with the user's cache directory prepended. This is CacheDir as set by the host, or if
+// it's not set: $XDG_CACHE_HOME if set, and otherwise ~/.cache on Linux, ~/Library/Caches on macOS, and
+// %LocalAppData% on Windows.
+func (in *ProvisionInput) FromCacheDir(path ...string) string {
+	dir := in.CacheDir
+	if dir == "" {
+		dir = userCacheDir(in.goos(), os.Getenv, in.HomeDir)
+	}
+	return filepath.Join(append([]string{dir}, path...)...)
+}
+
+// goos returns the OS of the input, falling back to the OS the plugin runs on.
+func (in *ProvisionInput) goos() string {
+	if in.OS != "" {
+		return in.OS
+	}
+	return runtime.GOOS
+}
+
 // Get returns the cached value at the specified key if it exists. The data can be returned either as a []byte
 // or unmarshaled as JSON.
 func (c CacheState) Get(key string, out any) (ok bool) {
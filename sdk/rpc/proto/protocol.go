@@ -56,17 +56,21 @@ func (p ProvisionerID) String() string {
 }
 
 // GetPluginResponse augments schema.Plugin with information about which credentials have the (optional) Importer set
-// and which executables have the (optional) NeedsAuth field set. This is stored separately because these fields are
-// all set to nil before sending the schema.Plugin over RPC.
+// and which executables have the (optional) NeedsAuth and SelectCredentials fields set. This is stored separately
+// because these fields are all set to nil before sending the schema.Plugin over RPC.
 type GetPluginResponse struct {
 	schema.Plugin
 	// CredentialHasImporter contains a true value for all credentials that have their Importer field set.
 	CredentialHasImporter map[CredentialID]bool
 	// ExecutableHasNeedAuth contains a true value for all executables that have their NeedsAuth field set.
 	ExecutableHasNeedAuth map[ExecutableID]bool
+	// ExecutableHasSelector contains a true value for all executables that have their SelectCredentials field set.
+	ExecutableHasSelector map[ExecutableID]bool
 	// CredentialUsageHasProvisioner contains a true value for all CredentialUsage objects that have their Provisioner
 	// field set.
 	CredentialUsageHasProvisioner map[CredentialUsageID]bool
+	// ProvisionerHasRefresher contains a true value for all provisioners that implement sdk.Refresher.
+	ProvisionerHasRefresher map[ProvisionerID]bool
 }
 
 // ImportCredentialRequest augments sdk.ImportInput with a CredentialID so Import() can be called over RPC.
@@ -90,8 +94,15 @@ type DeprovisionCredentialRequest struct {
 	sdk.DeprovisionOutput
 }
 
-// ExecutableNeedsAuthRequest augments sdk.NeedsAuthenticationInput with the ID of an executable so NeedsAuth() can be
-// called over RPC. ExecutableID resembles the slice index of the executable in schema.Plugin.
+// RefreshCredentialRequest augments sdk.ProvisionInput with a ProvisionerID so Refresh() can be called over RPC.
+type RefreshCredentialRequest struct {
+	ProvisionerID
+	sdk.ProvisionInput
+	sdk.ProvisionOutput
+}
+
+// ExecutableNeedsAuthRequest augments sdk.NeedsAuthenticationInput with the ID of an executable so NeedsAuth() and
+// SelectCredentials() can be called over RPC. ExecutableID resembles the slice index of the executable in schema.Plugin.
 type ExecutableNeedsAuthRequest struct {
 	ExecutableID
 	sdk.NeedsAuthenticationInput
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"runtime/debug"
+	"time"
 
 	"github.com/1Password/shell-plugins/sdk"
 	"github.com/1Password/shell-plugins/sdk/rpc/proto"
@@ -31,6 +32,7 @@ type RPCServer struct {
 	importers    map[proto.CredentialID]sdk.Importer
 	provisioners map[proto.ProvisionerID]sdk.Provisioner
 	needsAuth    map[proto.ExecutableID]sdk.NeedsAuthentication
+	selectors    map[proto.ExecutableID]sdk.CredentialSelector
 }
 
 func newServer(p schema.Plugin) *RPCServer {
@@ -38,6 +40,7 @@ func newServer(p schema.Plugin) *RPCServer {
 		importers:    map[proto.CredentialID]sdk.Importer{},
 		provisioners: map[proto.ProvisionerID]sdk.Provisioner{},
 		needsAuth:    map[proto.ExecutableID]sdk.NeedsAuthentication{},
+		selectors:    map[proto.ExecutableID]sdk.CredentialSelector{},
 	}
 
 	// Remove all functions and interfaces from schema.Plugin and store them in the respective maps.
@@ -48,6 +51,8 @@ func newServer(p schema.Plugin) *RPCServer {
 	for i := range p.Executables {
 		s.needsAuth[proto.ExecutableID(i)] = p.Executables[i].NeedsAuth
 		p.Executables[i].NeedsAuth = nil
+		s.selectors[proto.ExecutableID(i)] = p.Executables[i].SelectCredentials
+		p.Executables[i].SelectCredentials = nil
 		for usageID, credentialUse := range p.Executables[i].Uses {
 			executableID := proto.ExecutableID(i)
 			s.provisioners[proto.ProvisionerID{
@@ -83,12 +88,17 @@ func (t *RPCServer) GetPlugin(_ int, resp *proto.GetPluginResponse) error {
 	*resp = proto.GetPluginResponse{
 		CredentialHasImporter:         map[proto.CredentialID]bool{},
 		ExecutableHasNeedAuth:         map[proto.ExecutableID]bool{},
+		ExecutableHasSelector:         map[proto.ExecutableID]bool{},
 		CredentialUsageHasProvisioner: map[proto.CredentialUsageID]bool{},
+		ProvisionerHasRefresher:       map[proto.ProvisionerID]bool{},
 		Plugin:                        t.p,
 	}
 	for executableID, needsAuth := range t.needsAuth {
 		resp.ExecutableHasNeedAuth[executableID] = needsAuth != nil
 	}
+	for executableID, selector := range t.selectors {
+		resp.ExecutableHasSelector[executableID] = selector != nil
+	}
 	for credentialID, importer := range t.importers {
 		resp.CredentialHasImporter[credentialID] = importer != nil
 	}
@@ -96,6 +106,8 @@ func (t *RPCServer) GetPlugin(_ int, resp *proto.GetPluginResponse) error {
 		if !provisionerID.IsDefaultProvisioner {
 			resp.CredentialUsageHasProvisioner[provisionerID.CredentialUsage] = provisioner != nil
 		}
+		_, hasRefresher := provisioner.(sdk.Refresher)
+		resp.ProvisionerHasRefresher[provisionerID] = hasRefresher
 	}
 
 	return nil
@@ -115,6 +127,34 @@ func (t *RPCServer) ExecutableNeedsAuth(req proto.ExecutableNeedsAuthRequest, re
 	return nil
 }
 
+// ExecutableExplainNeedsAuth is a remote version of the Explain method of the NeedsAuth function in
+// schema.Executable, so that the rules that decided whether a command needs authentication can be shown for debugging.
+func (t *RPCServer) ExecutableExplainNeedsAuth(req proto.ExecutableNeedsAuthRequest, resp *[]sdk.RuleTrace) error {
+	needsAuth, ok := t.needsAuth[req.ExecutableID]
+	if !ok || needsAuth == nil {
+		return &errFunctionFieldNotSet{
+			objName:  req.ExecutableID.String(),
+			funcName: "NeedsAuth",
+		}
+	}
+	*resp = needsAuth.ExplainInput(req.NeedsAuthenticationInput)
+	return nil
+}
+
+// ExecutableSelectCredentials is a remote version of the SelectCredentials function in schema.Executable.
+// The call is forwarded to Executables[req.ExecutableID].SelectCredentials of the original plugin.
+func (t *RPCServer) ExecutableSelectCredentials(req proto.ExecutableNeedsAuthRequest, resp *[]sdk.CredentialName) error {
+	selector, ok := t.selectors[req.ExecutableID]
+	if !ok || selector == nil {
+		return &errFunctionFieldNotSet{
+			objName:  req.ExecutableID.String(),
+			funcName: "SelectCredentials",
+		}
+	}
+	*resp = selector(req.NeedsAuthenticationInput)
+	return nil
+}
+
 // CredentialImport is a remote version of the Import() function in schema.CredentialType.
 // The call is forwarded to the Import() function of the credential identified by req.CredentialID.
 func (t *RPCServer) CredentialImport(req proto.ImportCredentialRequest, resp *sdk.ImportOutput) error {
@@ -140,6 +180,12 @@ func (t *RPCServer) CredentialImport(req proto.ImportCredentialRequest, resp *sd
 	}
 	*resp = req.ImportOutput
 	importer(context.Background(), req.ImportInput, resp)
+	t.p.ValidateCandidates(resp)
+	if int(req.CredentialID) < len(t.p.Credentials) {
+		t.p.Credentials[req.CredentialID].FillDefaultValues(resp)
+	}
+	resp.RemoveExisting(req.ImportInput.ExistingItems)
+	resp.SortByConfidence()
 	return nil
 }
 
@@ -169,7 +215,11 @@ func (t *RPCServer) CredentialProvisionerProvision(req proto.ProvisionCredential
 	if err != nil {
 		return err
 	}
+	t.addCredentialFields(req.ProvisionerID, &req.ProvisionInput)
 	*resp = req.ProvisionOutput
+	if !t.checkFieldValues(req.ProvisionerID, req.ProvisionInput, resp) {
+		return nil
+	}
 	provisioner.Provision(context.Background(), req.ProvisionInput, resp)
 	return nil
 }
@@ -193,6 +243,40 @@ func (t *RPCServer) CredentialProvisionerDeprovision(req proto.DeprovisionCreden
 	return nil
 }
 
+// CredentialProvisionerRefresh is a remote version of the Refresh() method of the sdk.Refresher interface. The call
+// is forwarded to the Refresh() function of the Provisioner of the credential identified by req.ProvisionerID, if it
+// implements sdk.Refresher.
+func (t *RPCServer) CredentialProvisionerRefresh(req proto.RefreshCredentialRequest, resp *sdk.ProvisionOutput) error {
+	defer func() {
+		if err := recover(); err != nil {
+			diagnostics := getPanicDiagnostics(err)
+			resp.Diagnostics = diagnostics
+		}
+	}()
+	provisioner, err := t.getProvisioner(req.ProvisionerID)
+	if err != nil {
+		return err
+	}
+	refresher, ok := provisioner.(sdk.Refresher)
+	if !ok {
+		return &errFunctionFieldNotSet{
+			objName:  req.ProvisionerID.String(),
+			funcName: "Refresh",
+		}
+	}
+	t.addCredentialFields(req.ProvisionerID, &req.ProvisionInput)
+	*resp = req.ProvisionOutput
+	if !t.checkFieldValues(req.ProvisionerID, req.ProvisionInput, resp) {
+		return nil
+	}
+	refresher.Refresh(context.Background(), req.ProvisionInput, resp)
+	if len(resp.Diagnostics.Errors) > 0 {
+		// Errors stop refreshing.
+		resp.NextRefresh = time.Time{}
+	}
+	return nil
+}
+
 func (t *RPCServer) getProvisioner(provisionerID proto.ProvisionerID) (sdk.Provisioner, error) {
 	provisioner, ok := t.provisioners[provisionerID]
 	if !ok || provisioner == nil {
@@ -204,6 +288,74 @@ func (t *RPCServer) getProvisioner(provisionerID proto.ProvisionerID) (sdk.Provi
 	return provisioner, nil
 }
 
+// provisionedCredential returns the credential type that the provisioner provisions, if that credential type is part
+// of this plugin. Provisioners of credential usages that refer to a credential of another plugin get nil.
+func (t *RPCServer) provisionedCredential(provisionerID proto.ProvisionerID) *schema.CredentialType {
+	if provisionerID.IsDefaultProvisioner {
+		if int(provisionerID.Credential) < len(t.p.Credentials) {
+			return &t.p.Credentials[provisionerID.Credential]
+		}
+		return nil
+	}
+
+	usageID := provisionerID.CredentialUsage
+	if int(usageID.Executable) >= len(t.p.Executables) || usageID.Usage >= len(t.p.Executables[usageID.Executable].Uses) {
+		return nil
+	}
+	usage := t.p.Executables[usageID.Executable].Uses[usageID.Usage]
+	if usage.Plugin != "" && usage.Plugin != t.p.Name {
+		return nil
+	}
+	for i := range t.p.Credentials {
+		if t.p.Credentials[i].Name == usage.Name {
+			return &t.p.Credentials[i]
+		}
+	}
+	return nil
+}
+
+// checkFieldValues reports an error for each item field with a value that the provisioned credential doesn't allow,
+// and if the item doesn't have all fields of any of the field sets of the credential. It returns whether the item
+// fields can be provisioned.
+func (t *RPCServer) checkFieldValues(provisionerID proto.ProvisionerID, in sdk.ProvisionInput, out *sdk.ProvisionOutput) bool {
+	credential := t.provisionedCredential(provisionerID)
+	if credential == nil {
+		return true
+	}
+	errs := credential.CheckFieldValues(in.ItemFields)
+	if _, err := credential.FieldSetFor(in.ItemFields); err != nil {
+		errs = append(errs, err)
+	}
+	for _, err := range errs {
+		out.AddError(err)
+	}
+	return len(errs) == 0
+}
+
+// addCredentialFields sets the name, the required fields, the default values of the fields, the field sets and the
+// default TTL of the provisioned credential on the provision input, unless the client already set them.
+func (t *RPCServer) addCredentialFields(provisionerID proto.ProvisionerID, in *sdk.ProvisionInput) {
+	credential := t.provisionedCredential(provisionerID)
+	if credential == nil {
+		return
+	}
+	if in.CredentialName == "" {
+		in.CredentialName = credential.Name
+	}
+	if in.RequiredFields == nil {
+		in.RequiredFields = credential.RequiredFields()
+	}
+	if in.FieldDefaults == nil {
+		in.FieldDefaults = credential.FieldDefaults()
+	}
+	if in.FieldSets == nil {
+		in.FieldSets = credential.FieldSets
+	}
+	if in.DefaultTTL == 0 {
+		in.DefaultTTL = credential.DefaultTTL
+	}
+}
+
 func getPanicDiagnostics(err any) sdk.Diagnostics {
 	caughtPanic := fmt.Errorf("locally built plugin panicked: %s\nstack trace:\n%s", err, string(debug.Stack()))
 	return sdk.Diagnostics{Errors: []sdk.Error{{Message: caughtPanic.Error()}}}
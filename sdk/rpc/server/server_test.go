@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/rpc/proto"
+	"github.com/1Password/shell-plugins/sdk/schema"
+	"github.com/1Password/shell-plugins/sdk/schema/credname"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inputRecorder is a provisioner that records the input it gets called with.
+type inputRecorder struct {
+	sdk.Provisioner
+
+	in *sdk.ProvisionInput
+}
+
+func (p inputRecorder) Provision(ctx context.Context, in sdk.ProvisionInput, out *sdk.ProvisionOutput) {
+	*p.in = in
+}
+
+func (p inputRecorder) Description() string {
+	return "Record the provision input"
+}
+
+func TestCredentialProvisionerProvisionSetsCredentialName(t *testing.T) {
+	var defaultIn, usageIn sdk.ProvisionInput
+	s := newServer(schema.Plugin{
+		Name: "example",
+		Credentials: []schema.CredentialType{
+			{
+				Name: credname.APIKey,
+				Fields: []schema.CredentialField{
+					{Name: fieldname.APIKey, Secret: true},
+				},
+				DefaultProvisioner: inputRecorder{in: &defaultIn},
+			},
+		},
+		Executables: []schema.Executable{
+			{
+				Name: "Example CLI",
+				Uses: []schema.CredentialUsage{
+					{Name: credname.APIKey, Provisioner: inputRecorder{in: &usageIn}},
+				},
+			},
+		},
+	})
+	itemFields := map[sdk.FieldName]string{fieldname.APIKey: "s3cret"}
+
+	for _, c := range []struct {
+		id proto.ProvisionerID
+		in *sdk.ProvisionInput
+	}{
+		{id: proto.ProvisionerID{IsDefaultProvisioner: true, Credential: 0}, in: &defaultIn},
+		{id: proto.ProvisionerID{CredentialUsage: proto.CredentialUsageID{Executable: 0, Usage: 0}}, in: &usageIn},
+	} {
+		var out sdk.ProvisionOutput
+		err := s.CredentialProvisionerProvision(proto.ProvisionCredentialRequest{
+			ProvisionerID:  c.id,
+			ProvisionInput: sdk.ProvisionInput{ItemFields: itemFields},
+		}, &out)
+		require.NoError(t, err)
+		require.Empty(t, out.Diagnostics.Errors)
+
+		assert.Equal(t, credname.APIKey, c.in.CredentialName, c.id.String())
+		assert.Equal(t, []sdk.FieldName{fieldname.APIKey}, c.in.RequiredFields, c.id.String())
+	}
+}
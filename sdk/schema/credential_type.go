@@ -3,6 +3,10 @@ package schema
 import (
 	"fmt"
 	"net/url"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/1Password/shell-plugins/sdk"
 )
@@ -15,6 +19,12 @@ type CredentialType struct {
 	// The field(s) on this credential type.
 	Fields []CredentialField
 
+	// (Optional) Alternative sets of fields, for credentials that can consist of different fields, e.g.
+	// [[Token], [Username, Password]] for a platform that accepts either a token or a username and password. Items
+	// need all fields of at least one set, and only the fields of the first such set get provisioned. The fields in
+	// the sets can't be optional, and each set needs a secret field.
+	FieldSets [][]sdk.FieldName
+
 	// (Optional) A URL to the documentation about this credential type.
 	DocsURL *url.URL
 
@@ -26,6 +36,23 @@ type CredentialType struct {
 
 	// The default provisioner to use for this credential if the executable doesn't override it.
 	DefaultProvisioner sdk.Provisioner
+
+	// (Optional) Whether import candidates for this credential type get the default values of fields that they lack
+	// filled in. Leave this off if the default is not a safe guess for credentials found on the system, e.g. because
+	// a missing region in a config file means something else to the CLI.
+	ImportDefaultValues bool
+
+	// (Optional) Whether credentials of this type expire, like session tokens, SSO tokens or STS credentials, so that
+	// users can be warned about expired credentials and refresh flows can be started.
+	Expirable bool
+
+	// (Optional) How long credentials of this type are typically valid for, if the credential itself doesn't say. Only
+	// for expirable credential types.
+	DefaultTTL time.Duration
+
+	// (Optional) The name of the non-secret field that stores when the credential expires, as an RFC 3339 timestamp.
+	// Only for expirable credential types.
+	ExpiryField sdk.FieldName
 }
 
 // CredentialField provides the schema of a single field on a credential type.
@@ -43,11 +70,217 @@ type CredentialField struct {
 	// Whether this field is secret and should be concealed where possible.
 	Secret bool
 
-	// Whether this field is optional.
+	// Whether this field is optional. Items can leave out optional fields, while provisioners report an error when a
+	// required field is missing. A credential type needs at least one secret field that's not optional, or one in each
+	// of its field sets.
 	Optional bool
 
 	// (Optional) Describes how values of this field look like, such as the length, charset, etc.
 	Composition *ValueComposition
+
+	// (Optional) The value to use when the item doesn't have this field, e.g. "us-east-1" for a region or "5432" for a
+	// port. Only non-secret fields can have a default value. A required field with a default value never counts as
+	// missing when provisioning.
+	DefaultValue string
+
+	// (Optional) The values that this field can have, for fields with a closed set of values, like a region or an API
+	// version. Provisioning fails if the item has a value outside of this list, and import candidates with such a
+	// value get a warning. The default value, if set, has to be one of them.
+	AllowedValues []string
+
+	// (Optional) Whether values outside of AllowedValues are accepted as well, for when the list only contains the
+	// common values, e.g. the public regions of a platform that also has private ones.
+	AllowOther bool
+}
+
+// CheckAllowedValue returns an error if the field has allowed values and the value is not one of them, unless the field
+// allows other values. Empty values are not checked. The value is left out of the error if the field is secret.
+func (f CredentialField) CheckAllowedValue(value string) error {
+	if value == "" || len(f.AllowedValues) == 0 || f.AllowOther || containsString(f.AllowedValues, value) {
+		return nil
+	}
+	if f.Secret {
+		return fmt.Errorf("is not one of the allowed values: %s", strings.Join(f.AllowedValues, ", "))
+	}
+	return fmt.Errorf("is '%s', which is not one of the allowed values: %s", value, strings.Join(f.AllowedValues, ", "))
+}
+
+// CheckFieldValues returns an error for each of the item fields that has a value that the field doesn't allow, see
+// CredentialField.AllowedValues.
+func (c CredentialType) CheckFieldValues(itemFields map[sdk.FieldName]string) []error {
+	var errs []error
+	for _, field := range c.Fields {
+		value, ok := itemFields[field.Name]
+		if !ok {
+			continue
+		}
+		if err := field.CheckAllowedValue(value); err != nil {
+			errs = append(errs, fmt.Errorf("value of field '%s' %s", field.Name, err))
+		}
+	}
+	return errs
+}
+
+// RequiredFields returns the names of the fields that are not optional, e.g. to set as
+// sdk.ProvisionInput.RequiredFields. Fields in field sets are left out, since they're only required for items that use
+// their set.
+func (c CredentialType) RequiredFields() []sdk.FieldName {
+	var required []sdk.FieldName
+	for _, field := range c.Fields {
+		if !field.Optional && !c.isInFieldSet(field.Name) {
+			required = append(required, field.Name)
+		}
+	}
+	return required
+}
+
+// FieldSetFor returns the first of the field sets that the item has all fields of. It returns an error if there is no
+// such set, and nil if the credential type has no field sets.
+func (c CredentialType) FieldSetFor(itemFields map[sdk.FieldName]string) ([]sdk.FieldName, error) {
+	if len(c.FieldSets) == 0 {
+		return nil, nil
+	}
+	in := sdk.ProvisionInput{ItemFields: itemFields, FieldSets: c.FieldSets}
+	if fieldSet := in.FieldSet(); fieldSet != nil {
+		return fieldSet, nil
+	}
+
+	var alternatives []string
+	for _, fieldSet := range c.FieldSets {
+		alternatives = append(alternatives, describeFieldSet(fieldSet))
+	}
+	return nil, fmt.Errorf("the item needs either %s", strings.Join(alternatives, ", or "))
+}
+
+// isInFieldSet returns whether the field with the specified name is part of any of the field sets.
+func (c CredentialType) isInFieldSet(fieldName sdk.FieldName) bool {
+	for _, fieldSet := range c.FieldSets {
+		for _, name := range fieldSet {
+			if name == fieldName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// describeFieldSet returns the field names of the set, e.g. "Username and Password".
+func describeFieldSet(fieldSet []sdk.FieldName) string {
+	names := make([]string, len(fieldSet))
+	for i, name := range fieldSet {
+		names[i] = name.String()
+	}
+	if len(names) <= 1 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// fieldSetsCheck checks that the field sets, if defined, are not empty and only contain required fields of the
+// credential type, each of them once, and that none of the sets includes another one, since it would be ambiguous
+// which of them an item uses. The problems are included in the description.
+func fieldSetsCheck(c CredentialType) ValidationCheck {
+	check := ValidationCheck{
+		Code:        CodeCredentialInvalidFieldSets,
+		Description: "If defined, the field sets are not empty, only contain required fields of the credential type, and don't include each other",
+		Assertion:   true,
+		Severity:    ValidationSeverityError,
+	}
+
+	var problems []string
+	for i, fieldSet := range c.FieldSets {
+		if len(fieldSet) == 0 {
+			problems = append(problems, "empty field set")
+			continue
+		}
+		var names []string
+		for _, name := range fieldSet {
+			names = append(names, name.String())
+			field := c.Field(name.String())
+			if field == nil {
+				problems = append(problems, fmt.Sprintf("unknown field %s", name))
+			} else if field.Optional {
+				problems = append(problems, fmt.Sprintf("optional field %s", name))
+			}
+		}
+		if len(duplicateStrings(names)) > 0 {
+			problems = append(problems, fmt.Sprintf("field set %s lists a field more than once", describeFieldSet(fieldSet)))
+		}
+		for j, other := range c.FieldSets {
+			if i == j || len(other) == 0 || (len(other) == len(fieldSet) && j < i) {
+				continue
+			}
+			if includesFieldSet(fieldSet, other) {
+				problems = append(problems, fmt.Sprintf("field set %s includes field set %s", describeFieldSet(fieldSet), describeFieldSet(other)))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		check.Description += ": " + strings.Join(uniqueStrings(problems), ", ")
+		check.Assertion = false
+	}
+	return check
+}
+
+// includesFieldSet returns whether all fields of other are part of fieldSet.
+func includesFieldSet(fieldSet []sdk.FieldName, other []sdk.FieldName) bool {
+	for _, name := range other {
+		found := false
+		for _, n := range fieldSet {
+			if n == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// FieldDefaults returns the default values of the fields that have one, e.g. to set as
+// sdk.ProvisionInput.FieldDefaults.
+func (c CredentialType) FieldDefaults() map[sdk.FieldName]string {
+	var defaults map[sdk.FieldName]string
+	for _, field := range c.Fields {
+		if field.DefaultValue == "" {
+			continue
+		}
+		if defaults == nil {
+			defaults = make(map[sdk.FieldName]string)
+		}
+		defaults[field.Name] = field.DefaultValue
+	}
+	return defaults
+}
+
+// FillDefaultValues adds the default values of fields to the import candidates for this credential type that lack
+// them, if ImportDefaultValues is enabled. Candidates without a credential type set are considered to be for this
+// credential type.
+func (c CredentialType) FillDefaultValues(out *sdk.ImportOutput) {
+	if !c.ImportDefaultValues {
+		return
+	}
+	defaults := c.FieldDefaults()
+	for _, attempt := range out.Attempts {
+		for i := range attempt.Candidates {
+			candidate := &attempt.Candidates[i]
+			if candidate.CredentialType != "" && candidate.CredentialType != c.Name {
+				continue
+			}
+			for fieldName, value := range defaults {
+				if _, ok := candidate.Fields[fieldName]; ok {
+					continue
+				}
+				if candidate.Fields == nil {
+					candidate.Fields = make(map[sdk.FieldName]string)
+				}
+				candidate.Fields[fieldName] = value
+			}
+		}
+	}
 }
 
 func (c CredentialType) Field(name string) *CredentialField {
@@ -80,37 +313,228 @@ type Charset struct {
 	Specific  []rune
 }
 
-func (c CredentialType) Validate() (bool, ValidationReport) {
+// MismatchKind identifies how a value doesn't conform to a ValueComposition.
+type MismatchKind string
+
+const (
+	// MismatchPrefix means that the value doesn't start with the prefix.
+	MismatchPrefix MismatchKind = "prefix"
+
+	// MismatchLength means that the value doesn't have the length.
+	MismatchLength MismatchKind = "length"
+
+	// MismatchCharset means that the value contains characters outside of the charset.
+	MismatchCharset MismatchKind = "charset"
+
+	// MismatchMissingCharset means that the value contains none of the characters of a class in the charset, e.g. no
+	// digits at all while the charset has digits.
+	MismatchMissingCharset MismatchKind = "missing_charset"
+)
+
+// Mismatch describes a single way in which a value doesn't conform to a ValueComposition.
+type Mismatch struct {
+	Kind MismatchKind
+
+	// Message describes the mismatch without revealing the value, e.g. "is 10 characters long instead of 12".
+	Message string
+
+	// Characters contains the characters outside of the charset for a MismatchCharset, in order of appearance.
+	Characters []rune
+}
+
+func (m Mismatch) Error() string {
+	return m.Message
+}
+
+// Validate returns all the ways in which the value doesn't conform to the composition: if it doesn't start with the
+// prefix, doesn't have the length, contains characters outside of the charset, or contains none of the characters of
+// one of the classes of the charset, like uppercase letters or digits. The length counts characters, not bytes, and
+// includes the prefix. The charset applies to the part of the value after the prefix. Sub-fields that are not set, like
+// a zero Length or an empty Charset, are not checked.
+func (v ValueComposition) Validate(value string) []Mismatch {
+	var mismatches []Mismatch
+	rest := value
+	if v.Prefix != "" {
+		if strings.HasPrefix(value, v.Prefix) {
+			rest = value[len(v.Prefix):]
+		} else {
+			mismatches = append(mismatches, Mismatch{
+				Kind:    MismatchPrefix,
+				Message: fmt.Sprintf("does not start with '%s'", v.Prefix),
+			})
+		}
+	}
+
+	if length := utf8.RuneCountInString(value); v.Length > 0 && length != v.Length {
+		mismatches = append(mismatches, Mismatch{
+			Kind:    MismatchLength,
+			Message: fmt.Sprintf("is %d characters long instead of %d", length, v.Length),
+		})
+	}
+
+	if v.Charset.isEmpty() {
+		return mismatches
+	}
+
+	var outside []rune
+	for _, r := range rest {
+		if !v.Charset.Contains(r) && !containsRune(outside, r) {
+			outside = append(outside, r)
+		}
+	}
+	if len(outside) > 0 {
+		mismatches = append(mismatches, Mismatch{
+			Kind:       MismatchCharset,
+			Message:    fmt.Sprintf("contains characters other than %s", v.Charset.describe()),
+			Characters: outside,
+		})
+	}
+
+	if rest == "" {
+		return mismatches
+	}
+	for _, class := range v.Charset.classes() {
+		if strings.IndexFunc(rest, class.Contains) < 0 {
+			mismatches = append(mismatches, Mismatch{
+				Kind:    MismatchMissingCharset,
+				Message: fmt.Sprintf("contains no %s", class.describe()),
+			})
+		}
+	}
+	return mismatches
+}
+
+// Matches returns whether the value conforms to the composition, see Validate.
+func (v ValueComposition) Matches(value string) bool {
+	return len(v.Validate(value)) == 0
+}
+
+// Check returns an error describing the first way in which the value doesn't conform to the composition, see
+// Validate.
+func (v ValueComposition) Check(value string) error {
+	if mismatches := v.Validate(value); len(mismatches) > 0 {
+		return mismatches[0]
+	}
+	return nil
+}
+
+// Contains returns whether the character is part of the charset. Symbols are ASCII punctuation and symbol characters.
+func (c Charset) Contains(r rune) bool {
+	switch {
+	case c.Uppercase && r >= 'A' && r <= 'Z':
+		return true
+	case c.Lowercase && r >= 'a' && r <= 'z':
+		return true
+	case c.Digits && r >= '0' && r <= '9':
+		return true
+	case c.Symbols && r <= unicode.MaxASCII && (unicode.IsPunct(r) || unicode.IsSymbol(r)):
+		return true
+	}
+	for _, specific := range c.Specific {
+		if r == specific {
+			return true
+		}
+	}
+	return false
+}
+
+// classes returns a charset for each class of characters that's part of this charset. Specific characters are not a
+// class, because values don't need to contain all of them.
+func (c Charset) classes() []Charset {
+	var classes []Charset
+	if c.Uppercase {
+		classes = append(classes, Charset{Uppercase: true})
+	}
+	if c.Lowercase {
+		classes = append(classes, Charset{Lowercase: true})
+	}
+	if c.Digits {
+		classes = append(classes, Charset{Digits: true})
+	}
+	if c.Symbols {
+		classes = append(classes, Charset{Symbols: true})
+	}
+	return classes
+}
+
+func containsRune(runes []rune, r rune) bool {
+	for _, rr := range runes {
+		if rr == r {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Charset) isEmpty() bool {
+	return !c.Uppercase && !c.Lowercase && !c.Digits && !c.Symbols && len(c.Specific) == 0
+}
+
+// describe returns the characters in the charset in words, e.g. "uppercase letters, digits and '-'".
+func (c Charset) describe() string {
+	var parts []string
+	if c.Uppercase {
+		parts = append(parts, "uppercase letters")
+	}
+	if c.Lowercase {
+		parts = append(parts, "lowercase letters")
+	}
+	if c.Digits {
+		parts = append(parts, "digits")
+	}
+	if c.Symbols {
+		parts = append(parts, "symbols")
+	}
+	for _, r := range c.Specific {
+		parts = append(parts, fmt.Sprintf("'%c'", r))
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return strings.Join(parts[:len(parts)-1], ", ") + " and " + parts[len(parts)-1]
+}
+
+func (c CredentialType) Validate(opts ...ValidationOption) (bool, ValidationReport) {
+	settings := newValidationSettings("credential", opts)
 	report := ValidationReport{
 		Heading: fmt.Sprintf("Credential: %s", c.Name),
 		Checks:  []ValidationCheck{},
 	}
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialMissingName,
 		Description: "Has name set",
 		Assertion:   c.Name != "",
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialNameNotTitleCase,
 		Description: "Name is using title case",
 		Assertion:   IsTitleCaseString(c.Name.String()),
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialMissingDocsURL,
 		Description: "Has documentation URL set",
 		Assertion:   c.DocsURL != nil,
 		Severity:    ValidationSeverityWarning,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialMissingManagementURL,
 		Description: "Has management URL set",
 		Assertion:   c.ManagementURL != nil,
 		Severity:    ValidationSeverityWarning,
 	})
 
+	report.AddCheck(urlCheck(CodeCredentialMalformedDocsURL, "documentation URL", c.DocsURL))
+
+	report.AddCheck(urlCheck(CodeCredentialMalformedManagementURL, "management URL", c.ManagementURL))
+
 	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialNoFields,
 		Description: "Has at least 1 field",
 		Assertion:   len(c.Fields) > 0,
 		Severity:    ValidationSeverityError,
@@ -121,80 +545,218 @@ func (c CredentialType) Validate() (bool, ValidationReport) {
 	allFieldsInTitleCase := true
 	allCompositionsValid := true
 	hasSecretField := false
-	for _, f := range c.Fields {
+	var secretFieldsWithDefault []string
+	var fieldsWithDisallowedDefault []string
+
+	// The paths of the first field that fails each of the checks below.
+	var namePath, descriptionPath, titleCasePath, compositionPath, secretDefaultsPath, allowedDefaultsPath string
+	firstField := func(path *string, index int) {
+		if *path == "" {
+			*path = fieldPath(settings.path, index)
+		}
+	}
+
+	for i, f := range c.Fields {
 		if f.Name == "" {
 			allFieldsHaveNameSet = false
+			firstField(&namePath, i)
 		}
 		if f.MarkdownDescription == "" {
 			allFieldsHaveDescriptionSet = false
+			firstField(&descriptionPath, i)
 		}
 		if !IsTitleCaseString(f.Name.String()) {
 			allFieldsInTitleCase = false
+			firstField(&titleCasePath, i)
 		}
 		comp := f.Composition
 		if comp != nil {
 			cs := comp.Charset
-			if !cs.Lowercase && !cs.Uppercase && !cs.Digits && !cs.Symbols && len(cs.Specific) == 0 {
+			if cs.isEmpty() {
 				allCompositionsValid = false
+				firstField(&compositionPath, i)
 			}
 		}
-		if f.Secret {
+		if f.Secret && !f.Optional && !c.isInFieldSet(f.Name) {
 			hasSecretField = true
 		}
-
+		if f.Secret && f.DefaultValue != "" {
+			secretFieldsWithDefault = append(secretFieldsWithDefault, f.Name.String())
+			firstField(&secretDefaultsPath, i)
+		}
+		if f.DefaultValue != "" && len(f.AllowedValues) > 0 && !containsString(f.AllowedValues, f.DefaultValue) {
+			fieldsWithDisallowedDefault = append(fieldsWithDisallowedDefault, f.Name.String())
+			firstField(&allowedDefaultsPath, i)
+		}
 	}
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialFieldMissingName,
+		Path:        namePath,
 		Description: "All fields have name set",
 		Assertion:   allFieldsHaveNameSet,
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialFieldNameNotTitleCase,
+		Path:        titleCasePath,
 		Description: "All field names are using title case",
 		Assertion:   allFieldsInTitleCase,
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialFieldMissingDescription,
+		Path:        descriptionPath,
 		Description: "All fields have a description set",
 		Assertion:   allFieldsHaveDescriptionSet,
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialInvalidComposition,
+		Path:        compositionPath,
 		Description: "All specified value compositions are valid",
 		Assertion:   allCompositionsValid,
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
-		Description: "Has at least 1 field that is secret",
-		Assertion:   hasSecretField,
+		Code:        CodeCredentialNoRequiredSecretField,
+		Description: "Has at least 1 field that is secret and not optional",
+		Assertion:   hasSecretField || c.hasSecretFieldInEachFieldSet(),
+		Severity:    ValidationSeverityError,
+	})
+
+	secretDefaultsDescription := "Secret fields have no default value"
+	if len(secretFieldsWithDefault) > 0 {
+		secretDefaultsDescription += ": " + strings.Join(secretFieldsWithDefault, ", ")
+	}
+	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialSecretFieldDefault,
+		Path:        secretDefaultsPath,
+		Description: secretDefaultsDescription,
+		Assertion:   len(secretFieldsWithDefault) == 0,
+		Severity:    ValidationSeverityError,
+	})
+
+	allowedDefaultsDescription := "Default values are one of the allowed values of their field"
+	if len(fieldsWithDisallowedDefault) > 0 {
+		allowedDefaultsDescription += ": " + strings.Join(fieldsWithDisallowedDefault, ", ")
+	}
+	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialDisallowedDefault,
+		Path:        allowedDefaultsPath,
+		Description: allowedDefaultsDescription,
+		Assertion:   len(fieldsWithDisallowedDefault) == 0,
+		Severity:    ValidationSeverityError,
+	})
+
+	report.AddCheck(fieldSetsCheck(c))
+
+	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialExpiryNotExpirable,
+		Description: "Only expirable credential types have a default TTL or expiry field set",
+		Assertion:   c.Expirable || (c.DefaultTTL == 0 && c.ExpiryField == ""),
+		Severity:    ValidationSeverityError,
+	})
+
+	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialNegativeDefaultTTL,
+		Description: "Default TTL is not negative",
+		Assertion:   c.DefaultTTL >= 0,
+		Severity:    ValidationSeverityError,
+	})
+
+	expiryField := c.Field(c.ExpiryField.String())
+	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialInvalidExpiryField,
+		Description: "If set, the expiry field is one of the non-secret fields",
+		Assertion:   c.ExpiryField == "" || (expiryField != nil && !expiryField.Secret),
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialDuplicateFieldNames,
 		Description: "Has no duplicate field names",
 		Assertion:   c.hasNoDuplicateFieldNames(),
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialMissingProvisioner,
 		Description: "Has a provisioner set",
 		Assertion:   c.DefaultProvisioner != nil,
 		Severity:    ValidationSeverityError,
 	})
 
+	noProvisioner, isNoProvisioner := c.DefaultProvisioner.(noProvisioner)
 	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialMissingNoProvisioningReason,
+		Description: "If the provisioner intentionally provisions nothing, it has a reason set",
+		Assertion:   !isNoProvisioner || noProvisioner.NoProvisioningReason() != "",
+		Severity:    ValidationSeverityError,
+	})
+
+	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialInvalidProvisioner,
+		Description: "Provisioner configuration is valid",
+		Assertion:   isValidProvisioner(c.DefaultProvisioner),
+		Severity:    ValidationSeverityError,
+	})
+
+	report.AddCheck(ValidationCheck{
+		Code:        CodeCredentialMissingImporter,
 		Description: "Has an importer set",
 		Assertion:   c.Importer != nil,
 		Severity:    ValidationSeverityWarning,
 	})
 
+	settings.finish(&report)
 	return report.IsValid(), report
 }
 
+// hasSecretFieldInEachFieldSet returns whether the credential type has field sets and each of them contains a secret
+// field, so that every item has a secret, whichever set it uses.
+func (c CredentialType) hasSecretFieldInEachFieldSet() bool {
+	if len(c.FieldSets) == 0 {
+		return false
+	}
+	for _, fieldSet := range c.FieldSets {
+		hasSecret := false
+		for _, name := range fieldSet {
+			if field := c.Field(name.String()); field != nil && field.Secret {
+				hasSecret = true
+				break
+			}
+		}
+		if !hasSecret {
+			return false
+		}
+	}
+	return true
+}
+
+// noProvisioner is implemented by provisioners that intentionally provision nothing, like provision.None.
+type noProvisioner interface {
+	NoProvisioningReason() string
+}
+
+// validatingProvisioner is implemented by provisioners that can check their own configuration, like
+// provision.EnvVarsWithOptions.
+type validatingProvisioner interface {
+	Validate() error
+}
+
+// isValidProvisioner returns false if the provisioner checks its own configuration and found it to be invalid.
+func isValidProvisioner(provisioner sdk.Provisioner) bool {
+	if p, ok := provisioner.(validatingProvisioner); ok {
+		return p.Validate() == nil
+	}
+	return true
+}
+
 func (c CredentialType) hasNoDuplicateFieldNames() bool {
 	allFieldNames := make(map[string]struct{})
 	for _, f := range c.Fields {
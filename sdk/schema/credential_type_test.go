@@ -0,0 +1,369 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/credname"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueCompositionCheck(t *testing.T) {
+	composition := ValueComposition{
+		Length: 12,
+		Prefix: "sk_",
+		Charset: Charset{
+			Lowercase: true,
+			Digits:    true,
+			Specific:  []rune{'-'},
+		},
+	}
+
+	for _, scenario := range []struct {
+		description string
+		value       string
+		expected    string
+	}{
+		{description: "conforming", value: "sk_abc-12345"},
+		{description: "wrong prefix", value: "pk_abc-12345", expected: "does not start with 'sk_'"},
+		{description: "wrong length", value: "sk_abc-123", expected: "is 10 characters long instead of 12"},
+		{description: "character outside of charset", value: "sk_ABC-12345", expected: "contains characters other than lowercase letters, digits and '-'"},
+	} {
+		t.Run(scenario.description, func(t *testing.T) {
+			err := composition.Check(scenario.value)
+			if scenario.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, scenario.expected)
+			}
+		})
+	}
+}
+
+func TestValueCompositionValidate(t *testing.T) {
+	for _, scenario := range []struct {
+		description string
+		composition ValueComposition
+		value       string
+		expected    []Mismatch
+	}{
+		{
+			description: "conforming",
+			composition: ValueComposition{Length: 12, Prefix: "sk_", Charset: Charset{Lowercase: true, Digits: true}},
+			value:       "sk_abc123def",
+		},
+		{
+			description: "empty composition",
+			composition: ValueComposition{},
+			value:       "anything goes ✓",
+		},
+		{
+			description: "only length set",
+			composition: ValueComposition{Length: 4},
+			value:       "a-_!",
+		},
+		{
+			description: "only prefix set",
+			composition: ValueComposition{Prefix: "glpat-"},
+			value:       "glpat-anything",
+		},
+		{
+			description: "missing prefix",
+			composition: ValueComposition{Prefix: "glpat-"},
+			value:       "ghp_anything",
+			expected:    []Mismatch{{Kind: MismatchPrefix, Message: "does not start with 'glpat-'"}},
+		},
+		{
+			description: "wrong length",
+			composition: ValueComposition{Length: 12},
+			value:       "abc",
+			expected:    []Mismatch{{Kind: MismatchLength, Message: "is 3 characters long instead of 12"}},
+		},
+		{
+			description: "length includes prefix",
+			composition: ValueComposition{Length: 6, Prefix: "sk_"},
+			value:       "abc",
+			expected: []Mismatch{
+				{Kind: MismatchPrefix, Message: "does not start with 'sk_'"},
+				{Kind: MismatchLength, Message: "is 3 characters long instead of 6"},
+			},
+		},
+		{
+			description: "characters outside of the charset",
+			composition: ValueComposition{Charset: Charset{Lowercase: true, Digits: true}},
+			value:       "abc-123_def-4",
+			expected: []Mismatch{
+				{Kind: MismatchCharset, Message: "contains characters other than lowercase letters and digits", Characters: []rune{'-', '_'}},
+			},
+		},
+		{
+			description: "charset doesn't apply to the prefix",
+			composition: ValueComposition{Prefix: "dop_v1_", Charset: Charset{Lowercase: true, Digits: true}},
+			value:       "dop_v1_abc123",
+		},
+		{
+			description: "charset applies to the whole value if the prefix is missing",
+			composition: ValueComposition{Prefix: "sk_", Charset: Charset{Lowercase: true}},
+			value:       "pk_abc",
+			expected: []Mismatch{
+				{Kind: MismatchPrefix, Message: "does not start with 'sk_'"},
+				{Kind: MismatchCharset, Message: "contains characters other than lowercase letters", Characters: []rune{'_'}},
+			},
+		},
+		{
+			description: "specific characters",
+			composition: ValueComposition{Charset: Charset{Digits: true, Specific: []rune{'-'}}},
+			value:       "1234-5678",
+		},
+		{
+			description: "missing class of the charset",
+			composition: ValueComposition{Charset: Charset{Uppercase: true, Digits: true}},
+			value:       "ABCDEFGH",
+			expected:    []Mismatch{{Kind: MismatchMissingCharset, Message: "contains no digits"}},
+		},
+		{
+			description: "specific characters don't have to be present",
+			composition: ValueComposition{Charset: Charset{Lowercase: true, Specific: []rune{'-', '.'}}},
+			value:       "abcdef",
+		},
+		{
+			description: "all mismatches",
+			composition: ValueComposition{Length: 10, Prefix: "tkn_", Charset: Charset{Uppercase: true, Digits: true}},
+			value:       "tkn_abc",
+			expected: []Mismatch{
+				{Kind: MismatchLength, Message: "is 7 characters long instead of 10"},
+				{Kind: MismatchCharset, Message: "contains characters other than uppercase letters and digits", Characters: []rune{'a', 'b', 'c'}},
+				{Kind: MismatchMissingCharset, Message: "contains no uppercase letters"},
+				{Kind: MismatchMissingCharset, Message: "contains no digits"},
+			},
+		},
+		{
+			description: "empty value",
+			composition: ValueComposition{Length: 4, Charset: Charset{Digits: true}},
+			value:       "",
+			expected:    []Mismatch{{Kind: MismatchLength, Message: "is 0 characters long instead of 4"}},
+		},
+		{
+			description: "length of unicode value counts characters",
+			composition: ValueComposition{Length: 5},
+			value:       "héllo",
+		},
+		{
+			description: "wrong length of unicode value",
+			composition: ValueComposition{Length: 5},
+			value:       "日本語",
+			expected:    []Mismatch{{Kind: MismatchLength, Message: "is 3 characters long instead of 5"}},
+		},
+		{
+			description: "non-ASCII letters are outside of the charset",
+			composition: ValueComposition{Charset: Charset{Uppercase: true, Lowercase: true}},
+			value:       "ÄbCÉdé",
+			expected: []Mismatch{
+				{Kind: MismatchCharset, Message: "contains characters other than uppercase letters and lowercase letters", Characters: []rune{'Ä', 'É', 'é'}},
+			},
+		},
+		{
+			description: "non-ASCII symbols are outside of the charset",
+			composition: ValueComposition{Charset: Charset{Lowercase: true, Symbols: true}},
+			value:       "abc+€",
+			expected: []Mismatch{
+				{Kind: MismatchCharset, Message: "contains characters other than lowercase letters and symbols", Characters: []rune{'€'}},
+			},
+		},
+		{
+			description: "specific unicode characters",
+			composition: ValueComposition{Length: 4, Charset: Charset{Digits: true, Specific: []rune{'€'}}},
+			value:       "€123",
+		},
+		{
+			description: "unicode prefix",
+			composition: ValueComposition{Length: 6, Prefix: "🔑_", Charset: Charset{Lowercase: true}},
+			value:       "🔑_abcd",
+		},
+	} {
+		t.Run(scenario.description, func(t *testing.T) {
+			mismatches := scenario.composition.Validate(scenario.value)
+			assert.Equal(t, scenario.expected, mismatches)
+			assert.Equal(t, len(scenario.expected) == 0, scenario.composition.Matches(scenario.value))
+
+			err := scenario.composition.Check(scenario.value)
+			if len(scenario.expected) == 0 {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, scenario.expected[0].Message)
+			}
+		})
+	}
+}
+
+func TestCharsetContains(t *testing.T) {
+	charset := Charset{Uppercase: true, Symbols: true}
+
+	assert.True(t, charset.Contains('A'))
+	assert.True(t, charset.Contains('_'))
+	assert.True(t, charset.Contains('+'))
+	assert.False(t, charset.Contains('a'))
+	assert.False(t, charset.Contains('1'))
+	assert.False(t, charset.Contains('€'))
+}
+
+func TestCredentialTypeCheckFieldValues(t *testing.T) {
+	credential := CredentialType{
+		Fields: []CredentialField{
+			{Name: fieldname.APIKey, Secret: true, AllowedValues: []string{"key_1", "key_2"}},
+			{Name: fieldname.APIHost, AllowedValues: []string{"datadoghq.com", "datadoghq.eu"}},
+			{Name: fieldname.Region, AllowedValues: []string{"us-east-1", "eu-west-1"}, AllowOther: true},
+			{Name: fieldname.Host},
+		},
+	}
+
+	for _, scenario := range []struct {
+		description string
+		itemFields  map[sdk.FieldName]string
+		expected    []string
+	}{
+		{
+			description: "valid values",
+			itemFields:  map[sdk.FieldName]string{fieldname.APIKey: "key_1", fieldname.APIHost: "datadoghq.eu", fieldname.Host: "anything"},
+		},
+		{
+			description: "invalid value",
+			itemFields:  map[sdk.FieldName]string{fieldname.APIKey: "key_1", fieldname.APIHost: "datadog.eu"},
+			expected:    []string{"value of field 'API Host' is 'datadog.eu', which is not one of the allowed values: datadoghq.com, datadoghq.eu"},
+		},
+		{
+			description: "invalid value of secret field",
+			itemFields:  map[sdk.FieldName]string{fieldname.APIKey: "key_3"},
+			expected:    []string{"value of field 'API Key' is not one of the allowed values: key_1, key_2"},
+		},
+		{
+			description: "empty values",
+			itemFields:  map[sdk.FieldName]string{fieldname.APIKey: "", fieldname.APIHost: ""},
+		},
+		{
+			description: "missing values",
+			itemFields:  map[sdk.FieldName]string{},
+		},
+		{
+			description: "other value if allowed",
+			itemFields:  map[sdk.FieldName]string{fieldname.Region: "ap-southeast-2"},
+		},
+	} {
+		t.Run(scenario.description, func(t *testing.T) {
+			var errs []string
+			for _, err := range credential.CheckFieldValues(scenario.itemFields) {
+				errs = append(errs, err.Error())
+			}
+			assert.Equal(t, scenario.expected, errs)
+		})
+	}
+}
+
+func TestCredentialTypeFieldDefaults(t *testing.T) {
+	credential := CredentialType{
+		Fields: []CredentialField{
+			{Name: fieldname.Password, Secret: true},
+			{Name: fieldname.Host, Optional: true, DefaultValue: "localhost"},
+			{Name: fieldname.Port, DefaultValue: "5432"},
+			{Name: fieldname.Database, Optional: true},
+		},
+	}
+	assert.Equal(t, map[sdk.FieldName]string{fieldname.Host: "localhost", fieldname.Port: "5432"}, credential.FieldDefaults())
+	assert.Equal(t, []sdk.FieldName{fieldname.Password, fieldname.Port}, credential.RequiredFields())
+	assert.Nil(t, CredentialType{Fields: []CredentialField{{Name: fieldname.Token}}}.FieldDefaults())
+}
+
+func TestCredentialTypeFieldSetFor(t *testing.T) {
+	credential := CredentialType{
+		Fields: []CredentialField{
+			{Name: fieldname.Token, Secret: true},
+			{Name: fieldname.Username},
+			{Name: fieldname.Password, Secret: true},
+			{Name: fieldname.Host, Optional: true},
+		},
+		FieldSets: [][]sdk.FieldName{{fieldname.Token}, {fieldname.Username, fieldname.Password}},
+	}
+	assert.Empty(t, credential.RequiredFields())
+
+	fieldSet, err := credential.FieldSetFor(map[sdk.FieldName]string{fieldname.Token: "tkn_123", fieldname.Host: "registry.example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, []sdk.FieldName{fieldname.Token}, fieldSet)
+
+	fieldSet, err = credential.FieldSetFor(map[sdk.FieldName]string{fieldname.Username: "wendy", fieldname.Password: "hunter2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []sdk.FieldName{fieldname.Username, fieldname.Password}, fieldSet)
+
+	// The first set wins if the item has the fields of both.
+	fieldSet, err = credential.FieldSetFor(map[sdk.FieldName]string{fieldname.Token: "tkn_123", fieldname.Username: "wendy", fieldname.Password: "hunter2"})
+	assert.NoError(t, err)
+	assert.Equal(t, []sdk.FieldName{fieldname.Token}, fieldSet)
+
+	_, err = credential.FieldSetFor(map[sdk.FieldName]string{fieldname.Username: "wendy"})
+	assert.EqualError(t, err, "the item needs either Token, or Username and Password")
+
+	fieldSet, err = CredentialType{Fields: []CredentialField{{Name: fieldname.Token}}}.FieldSetFor(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, fieldSet)
+}
+
+func TestCredentialTypeFillDefaultValues(t *testing.T) {
+	credential := CredentialType{
+		Name: credname.DatabaseCredentials,
+		Fields: []CredentialField{
+			{Name: fieldname.Password, Secret: true},
+			{Name: fieldname.Host, Optional: true, DefaultValue: "localhost"},
+			{Name: fieldname.Port, DefaultValue: "5432"},
+		},
+	}
+	newOutput := func() *sdk.ImportOutput {
+		out := &sdk.ImportOutput{}
+		attempt := out.NewAttempt(sdk.ImportSource{})
+		attempt.AddCandidate(sdk.ImportCandidate{Fields: map[sdk.FieldName]string{fieldname.Password: "hunter2", fieldname.Host: "db.example.com"}})
+		attempt.AddCandidateFor(credname.APIKey, sdk.ImportCandidate{Fields: map[sdk.FieldName]string{fieldname.APIKey: "key"}})
+		return out
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		out := newOutput()
+		credential.FillDefaultValues(out)
+		assert.Equal(t, newOutput().AllCandidates(), out.AllCandidates())
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		credential.ImportDefaultValues = true
+		out := newOutput()
+		credential.FillDefaultValues(out)
+
+		candidates := out.AllCandidates()
+		assert.Equal(t, map[sdk.FieldName]string{fieldname.Password: "hunter2", fieldname.Host: "db.example.com", fieldname.Port: "5432"}, candidates[0].Fields)
+		assert.Equal(t, map[sdk.FieldName]string{fieldname.APIKey: "key"}, candidates[1].Fields)
+	})
+}
+
+func TestCredentialTypeExpirySerialization(t *testing.T) {
+	// The plugin schema gets sent to the client with gob, see rpc/server.
+	credential := CredentialType{
+		Name: credname.AccessKey,
+		Fields: []CredentialField{
+			{Name: fieldname.SessionToken, Secret: true},
+			{Name: "Expiration"},
+		},
+		Expirable:   true,
+		DefaultTTL:  12 * time.Hour,
+		ExpiryField: "Expiration",
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(credential))
+
+	var decoded CredentialType
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+	assert.True(t, decoded.Expirable)
+	assert.Equal(t, 12*time.Hour, decoded.DefaultTTL)
+	assert.Equal(t, sdk.FieldName("Expiration"), decoded.ExpiryField)
+}
@@ -48,3 +48,13 @@ func ListAll() []sdk.CredentialName {
 		UserLogin,
 	}
 }
+
+// IsRegistered returns whether the credential name is one of the credential type names of this package.
+func IsRegistered(name sdk.CredentialName) bool {
+	for _, registered := range ListAll() {
+		if name == registered {
+			return true
+		}
+	}
+	return false
+}
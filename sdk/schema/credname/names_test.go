@@ -1,10 +1,15 @@
 package credname
 
 import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
 	"testing"
 
 	"github.com/1Password/shell-plugins/sdk"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGettingCredentialIDsFromNames(t *testing.T) {
@@ -68,3 +73,28 @@ func TestGettingCredentialIDsFromNames(t *testing.T) {
 		assert.Equal(t, expectedIDs[i], name.ID().String())
 	}
 }
+
+func TestListAllContainsAllNames(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "names.go", nil, 0)
+	require.NoError(t, err)
+
+	var constants []sdk.CredentialName
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.CONST {
+			for _, spec := range gen.Specs {
+				call := spec.(*ast.ValueSpec).Values[0].(*ast.CallExpr)
+				value, err := strconv.Unquote(call.Args[0].(*ast.BasicLit).Value)
+				require.NoError(t, err)
+				constants = append(constants, sdk.CredentialName(value))
+			}
+		}
+	}
+
+	assert.ElementsMatch(t, constants, ListAll())
+}
+
+func TestIsRegistered(t *testing.T) {
+	assert.True(t, IsRegistered("API Token"))
+	assert.False(t, IsRegistered("Api Token"))
+	assert.False(t, IsRegistered(""))
+}
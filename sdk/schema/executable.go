@@ -3,6 +3,7 @@ package schema
 import (
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
 
 	"github.com/1Password/shell-plugins/sdk"
@@ -12,6 +13,10 @@ type Executable struct {
 	// The entrypoint of the command that should be executed, e.g. ["aws"] or ["stripe"].
 	Runs []string
 
+	// (Optional) Alternative entrypoints under which the same executable gets installed, in the same form as Runs,
+	// e.g. [["fdfind"]] for fd, which is called like that on Debian and Ubuntu.
+	Aliases [][]string
+
 	// The display name of the executable, e.g. "AWS CLI".
 	Name string
 
@@ -23,6 +28,24 @@ type Executable struct {
 
 	// (Optional) Whether the executable needs authentication for certain args.
 	NeedsAuth sdk.NeedsAuthentication
+
+	// (Optional) Which of the credentials in `Uses` to provision for certain args, for executables that need different
+	// credentials for different commands. By default, all of them are provisioned.
+	SelectCredentials sdk.CredentialSelector
+
+	// (Optional) The oldest version of the executable that the plugin works with, as a semantic version, e.g. "2.20.0"
+	// for an executable that only reads a credential from an environment variable since that version. Users with an
+	// older version get a warning when the credentials get provisioned.
+	MinimumVersion string
+
+	// (Optional) The command that prints the installed version of the executable, e.g. ["gh", "version"]. Defaults to
+	// the executable command with "--version" appended. Only used if MinimumVersion is set.
+	VersionCommand []string
+
+	// (Optional) A regular expression that finds the version in the output of VersionCommand, on stdout or stderr. If
+	// it has a capture group, the version is the text of the first group. Defaults to the first semantic version in the
+	// output.
+	VersionPattern string
 }
 
 type CredentialUsage struct {
@@ -51,6 +74,11 @@ type CredentialUsage struct {
 
 	// Whether this credential is needed for the executable to run. If set to true, the executable cannot run without provisioning this credential.
 	Optional bool
+
+	// (Optional) How strongly this credential is preferred over the other credentials of the executable, for when the
+	// user has items for more than one of them, e.g. a fine-grained token over a classic one. Items for usages with a
+	// higher preference are suggested first. Usages that set a preference can't share it with another usage.
+	Preference int
 }
 
 type CredentialSelection struct {
@@ -62,48 +90,68 @@ type CredentialSelection struct {
 	AllowMultiple bool
 }
 
-func (e Executable) Validate() (bool, ValidationReport) {
+func (e Executable) Validate(opts ...ValidationOption) (bool, ValidationReport) {
+	settings := newValidationSettings("executable", opts)
 	report := ValidationReport{
 		Heading: fmt.Sprintf("Executable: %s", e.Name),
 		Checks:  []ValidationCheck{},
 	}
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodeExecutableMissingName,
 		Description: "Has name set",
 		Assertion:   e.Name != "",
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodeExecutableMissingDocsURL,
 		Description: "Has documentation URL set",
 		Assertion:   e.DocsURL != nil,
 		Severity:    ValidationSeverityWarning,
 	})
 
+	report.AddCheck(urlCheck(CodeExecutableMalformedDocsURL, "documentation URL", e.DocsURL))
+
 	report.AddCheck(ValidationCheck{
+		Code:        CodeExecutableMissingNeedsAuth,
 		Description: "Has specified which commands need authentication",
 		Assertion:   e.NeedsAuth != nil,
 		Severity:    ValidationSeverityWarning,
 	})
 
+	report.AddCheck(needsAuthCheck(CodeExecutableInvalidNeedsAuth, e.NeedsAuth))
+
+	report.AddCheck(credentialSelectorCheck(e))
+
 	report.AddCheck(ValidationCheck{
+		Code:        CodeExecutableMissingCommand,
 		Description: "Has executable command set",
 		Assertion:   len(e.Runs) > 0,
 		Severity:    ValidationSeverityError,
 	})
 
+	report.AddCheck(aliasesCheck(e))
+
+	report.AddCheck(preferencesCheck(e))
+
+	report.AddCheck(minimumVersionCheck(e))
+
 	report.AddCheck(ValidationCheck{
+		Code:        CodeExecutableNoCredentialUsages,
 		Description: "Has a credential type defined",
 		Assertion:   len(e.Uses) > 0,
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodeExecutableDuplicateCredentialUsages,
 		Description: "Credential usage definitions are uniquely identifiable inside an executable",
 		Assertion:   AreCredentialUsagesUniquelyIdentifiable(e),
 		Severity:    ValidationSeverityError,
 	})
 
+	settings.finish(&report)
 	return report.IsValid(), report
 }
 
@@ -111,31 +159,233 @@ func (e Executable) Command() string {
 	return strings.Join(e.Runs, " ")
 }
 
-func (c CredentialUsage) Validate() (bool, ValidationReport) {
+// Commands returns the command of the executable, followed by the commands of its aliases.
+func (e Executable) Commands() []string {
+	commands := []string{e.Command()}
+	for _, alias := range e.Aliases {
+		commands = append(commands, strings.Join(alias, " "))
+	}
+	return commands
+}
+
+// aliasesCheck checks that the aliases of the executable are set and that they differ from each other and from the
+// command of the executable.
+func aliasesCheck(e Executable) ValidationCheck {
+	check := ValidationCheck{
+		Code:        CodeExecutableInvalidAliases,
+		Description: "If defined, the aliases are set and differ from each other and from the executable command",
+		Assertion:   true,
+		Severity:    ValidationSeverityError,
+	}
+	for _, alias := range e.Aliases {
+		if len(alias) == 0 {
+			check.Assertion = false
+		}
+	}
+	if duplicates := duplicateStrings(e.Commands()); len(duplicates) > 0 {
+		check.Description += ": duplicates: " + strings.Join(duplicates, ", ")
+		check.Assertion = false
+	}
+	return check
+}
+
+// UsagesByPreference returns the credential usages of the executable ordered by their preference, from high to low.
+// Usages with the same preference keep the order in which they're defined.
+func (e Executable) UsagesByPreference() []CredentialUsage {
+	usages := make([]CredentialUsage, len(e.Uses))
+	copy(usages, e.Uses)
+	sort.SliceStable(usages, func(i, j int) bool {
+		return usages[i].Preference > usages[j].Preference
+	})
+	return usages
+}
+
+// preferencesCheck checks that no two credential usages that set a preference have the same one, because it would be
+// ambiguous which of them to suggest first.
+func preferencesCheck(e Executable) ValidationCheck {
+	check := ValidationCheck{
+		Code:        CodeExecutableTiedPreferences,
+		Description: "Credential usages that set a preference have distinct preferences",
+		Assertion:   true,
+		Severity:    ValidationSeverityError,
+	}
+	var tied []string
+	for i, usage := range e.Uses {
+		if usage.Preference == 0 {
+			continue
+		}
+		for j, other := range e.Uses {
+			if i != j && other.Preference == usage.Preference {
+				tied = append(tied, usage.ID())
+				break
+			}
+		}
+	}
+	if len(tied) > 0 {
+		check.Description += ": tied: " + strings.Join(tied, ", ")
+		check.Assertion = false
+	}
+	return check
+}
+
+// DuplicateCommands returns the commands that are used by more than one executable of the plugins, including the
+// aliases of the executables. Executables have to be unique across plugins for the shell hooks to find their plugin.
+func DuplicateCommands(plugins ...Plugin) []string {
+	var commands []string
+	for _, p := range plugins {
+		for _, e := range p.Executables {
+			commands = append(commands, uniqueStrings(e.Commands())...)
+		}
+	}
+	return duplicateStrings(commands)
+}
+
+func (c CredentialUsage) Validate(opts ...ValidationOption) (bool, ValidationReport) {
+	settings := newValidationSettings("usage", opts)
 	report := ValidationReport{
 		Heading: fmt.Sprintf("Credential usage %s", c.ID()),
 		Checks:  []ValidationCheck{},
 	}
 	report.AddCheck(ValidationCheck{
+		Code:        CodeUsageMissingCredentialName,
 		Description: "If defined, a credential reference must have at least a `Name`",
 		Assertion:   c.Name != "" || (c.Plugin == "" && c.Provisioner == nil),
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodeUsageIncompleteSelection,
 		Description: "If defined, a credential selection must have its `ID` and `IncludeAllCredentials` set",
 		Assertion:   c.SelectFrom == nil || (c.SelectFrom.ID != "" && c.SelectFrom.IncludeAllCredentials),
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodeUsageReferenceAndSelection,
 		Description: "Credential usage has either a credential reference or selection defined, but not both",
 		Assertion:   (c.SelectFrom != nil || c.Name != "") && !(c.SelectFrom != nil && c.Name != ""),
 		Severity:    ValidationSeverityError,
 	})
+
+	report.AddCheck(ValidationCheck{
+		Code:        CodeUsageInvalidProvisioner,
+		Description: "If defined, the provisioner configuration is valid",
+		Assertion:   isValidProvisioner(c.Provisioner),
+		Severity:    ValidationSeverityError,
+	})
+
+	report.AddCheck(needsAuthCheck(CodeUsageInvalidNeedsAuth, c.NeedsAuth))
+
+	settings.finish(&report)
 	return report.IsValid(), report
 }
 
+// needsAuthCheck checks that the NeedsAuth rule, if defined, has no configuration errors, such as an invalid regular
+// expression. The errors are included in the description, so that they show up in the validation report.
+func needsAuthCheck(code ValidationCode, needsAuth sdk.NeedsAuthentication) ValidationCheck {
+	check := ValidationCheck{
+		Code:        code,
+		Description: "If defined, the rules for which commands need authentication are valid",
+		Assertion:   true,
+		Severity:    ValidationSeverityError,
+	}
+	if needsAuth == nil {
+		return check
+	}
+	if err := needsAuth.Validate(); err != nil {
+		check.Description += ": " + err.Error()
+		check.Assertion = false
+	}
+	return check
+}
+
+// credentialSelectorCheck checks that the SelectCredentials selector of the executable, if defined, only selects
+// credentials that the executable uses, and that its rules have no configuration errors.
+func credentialSelectorCheck(e Executable) ValidationCheck {
+	check := ValidationCheck{
+		Code:        CodeExecutableInvalidCredentialSelector,
+		Description: "If defined, the credential selection only selects credentials the executable uses",
+		Assertion:   true,
+		Severity:    ValidationSeverityError,
+	}
+	if e.SelectCredentials == nil {
+		return check
+	}
+
+	credentials, err := e.SelectCredentials.Validate()
+	var unknown []string
+	for _, credential := range credentials {
+		if !e.usesCredential(credential) {
+			unknown = append(unknown, credential.String())
+		}
+	}
+	if len(unknown) > 0 {
+		check.Description += fmt.Sprintf(": not used: %s", strings.Join(unknown, ", "))
+		check.Assertion = false
+	}
+	if err != nil {
+		check.Description += ": " + err.Error()
+		check.Assertion = false
+	}
+	return check
+}
+
+// UsagesToProvision returns the credential usages to provision, given the credentials selected by SelectCredentials
+// for the command args. Without selected credentials, that's all of them. Otherwise, it's the usages of the selected
+// credentials and the usages that let the user select from a list of credentials, which can't be selected by name.
+func (e Executable) UsagesToProvision(selected []sdk.CredentialName) []CredentialUsage {
+	if len(selected) == 0 {
+		return e.Uses
+	}
+
+	var usages []CredentialUsage
+	for _, usage := range e.Uses {
+		if usage.SelectFrom != nil {
+			usages = append(usages, usage)
+			continue
+		}
+		for _, credential := range selected {
+			if usage.Name == credential {
+				usages = append(usages, usage)
+				break
+			}
+		}
+	}
+	return usages
+}
+
+// usesCredential returns whether the executable uses the credential with the specified name, of any plugin.
+func (e Executable) usesCredential(credential sdk.CredentialName) bool {
+	for _, usage := range e.Uses {
+		if usage.Name == credential {
+			return true
+		}
+	}
+	return false
+}
+
+// ProvisionerFor returns the provisioner to use for the specified credential type in this usage: the usage's own
+// Provisioner if set, or the DefaultProvisioner of the credential type otherwise.
+func (c CredentialUsage) ProvisionerFor(credentialType CredentialType) sdk.Provisioner {
+	if c.Provisioner != nil {
+		return c.Provisioner
+	}
+	return credentialType.DefaultProvisioner
+}
+
+// CredentialProvisioner returns the sdk.CredentialProvisioner to pass to sdk.ProvisionCredentials for this usage, with
+// the provisioner from ProvisionerFor and the required fields, field defaults and field sets of the credential type.
+// Usages of credentials of other plugins can pass an empty credential type.
+func (c CredentialUsage) CredentialProvisioner(credentialType CredentialType) sdk.CredentialProvisioner {
+	return sdk.CredentialProvisioner{
+		Credential:     c.Name,
+		Provisioner:    c.ProvisionerFor(credentialType),
+		RequiredFields: credentialType.RequiredFields(),
+		FieldDefaults:  credentialType.FieldDefaults(),
+		FieldSets:      credentialType.FieldSets,
+	}
+}
+
 // ID returns the identifier of this credential usage at the scope of its executable
 func (c CredentialUsage) ID() string {
 	if c.Name != "" {
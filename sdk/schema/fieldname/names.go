@@ -30,10 +30,12 @@ const (
 	DefaultRegion   = sdk.FieldName("Default Region")
 	Deployment      = sdk.FieldName("Deployment")
 	Email           = sdk.FieldName("Email")
+	Encrypted       = sdk.FieldName("Encrypted")
 	Endpoint        = sdk.FieldName("Endpoint")
 	Host            = sdk.FieldName("Host")
 	HostAddress     = sdk.FieldName("Host Address")
 	Key             = sdk.FieldName("Key")
+	MFASecret       = sdk.FieldName("MFA Secret")
 	MFASerial       = sdk.FieldName("MFA Serial")
 	Mode            = sdk.FieldName("Mode")
 	Namespace       = sdk.FieldName("Namespace")
@@ -50,6 +52,8 @@ const (
 	Region          = sdk.FieldName("Region")
 	Secret          = sdk.FieldName("Secret")
 	SecretAccessKey = sdk.FieldName("Secret Access Key")
+	SessionToken    = sdk.FieldName("Session Token")
+	StartURL        = sdk.FieldName("Start URL")
 	Subdomain       = sdk.FieldName("Subdomain")
 	Token           = sdk.FieldName("Token")
 	URL             = sdk.FieldName("URL")
@@ -65,6 +69,7 @@ func ListAll() []sdk.FieldName {
 		APIKey,
 		APIKeyID,
 		APISecret,
+		APIUrl,
 		AccessKeyID,
 		AccessToken,
 		Account,
@@ -85,10 +90,13 @@ func ListAll() []sdk.FieldName {
 		Database,
 		DefaultRegion,
 		Deployment,
+		Email,
+		Encrypted,
 		Endpoint,
 		Host,
 		HostAddress,
 		Key,
+		MFASecret,
 		MFASerial,
 		Mode,
 		Namespace,
@@ -105,10 +113,24 @@ func ListAll() []sdk.FieldName {
 		Region,
 		Secret,
 		SecretAccessKey,
+		SessionToken,
+		StartURL,
+		Subdomain,
 		Token,
 		URL,
 		User,
+		UserAccessToken,
 		Username,
 		Website,
 	}
 }
+
+// IsRegistered returns whether the field name is one of the field names of this package.
+func IsRegistered(name sdk.FieldName) bool {
+	for _, registered := range ListAll() {
+		if name == registered {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package fieldname
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAllContainsAllNames(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "names.go", nil, 0)
+	require.NoError(t, err)
+
+	var constants []sdk.FieldName
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.CONST {
+			for _, spec := range gen.Specs {
+				call := spec.(*ast.ValueSpec).Values[0].(*ast.CallExpr)
+				value, err := strconv.Unquote(call.Args[0].(*ast.BasicLit).Value)
+				require.NoError(t, err)
+				constants = append(constants, sdk.FieldName(value))
+			}
+		}
+	}
+
+	assert.ElementsMatch(t, constants, ListAll())
+}
+
+func TestIsRegistered(t *testing.T) {
+	assert.True(t, IsRegistered("Token"))
+	assert.False(t, IsRegistered("Tokens"))
+	assert.False(t, IsRegistered(""))
+}
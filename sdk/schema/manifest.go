@@ -0,0 +1,248 @@
+package schema
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+)
+
+// ManifestSchemaVersion is the version of the format of Manifest. It gets incremented on every change to the format
+// that existing consumers can't handle, like a removed or renamed property.
+const ManifestSchemaVersion = 1
+
+// Manifest is a machine-readable description of a plugin, for tooling like docs generation that needs to know what a
+// plugin provides without running it. It's a plain data structure with stable JSON property names and ordering.
+// Importers, provisioners and rules are functions, so they are described by their description instead, if they have
+// one.
+type Manifest struct {
+	SchemaVersion int                      `json:"schema_version"`
+	Name          string                   `json:"name"`
+	Platform      PlatformManifest         `json:"platform"`
+	Credentials   []CredentialTypeManifest `json:"credentials"`
+	Executables   []ExecutableManifest     `json:"executables"`
+}
+
+// PlatformManifest describes a PlatformInfo in a Manifest.
+type PlatformManifest struct {
+	Name     string `json:"name"`
+	Homepage string `json:"homepage,omitempty"`
+}
+
+// CredentialTypeManifest describes a CredentialType in a Manifest.
+type CredentialTypeManifest struct {
+	Name                string                    `json:"name"`
+	Fields              []CredentialFieldManifest `json:"fields"`
+	FieldSets           [][]string                `json:"field_sets,omitempty"`
+	DocsURL             string                    `json:"docs_url,omitempty"`
+	ManagementURL       string                    `json:"management_url,omitempty"`
+	HasImporter         bool                      `json:"has_importer"`
+	DefaultProvisioner  string                    `json:"default_provisioner,omitempty"`
+	ImportDefaultValues bool                      `json:"import_default_values,omitempty"`
+	Expirable           bool                      `json:"expirable,omitempty"`
+	DefaultTTL          string                    `json:"default_ttl,omitempty"`
+	ExpiryField         string                    `json:"expiry_field,omitempty"`
+}
+
+// CredentialFieldManifest describes a CredentialField in a Manifest.
+type CredentialFieldManifest struct {
+	Name                string                    `json:"name"`
+	AlternativeNames    []string                  `json:"alternative_names,omitempty"`
+	MarkdownDescription string                    `json:"markdown_description"`
+	Secret              bool                      `json:"secret"`
+	Optional            bool                      `json:"optional"`
+	Composition         *ValueCompositionManifest `json:"composition,omitempty"`
+	DefaultValue        string                    `json:"default_value,omitempty"`
+	AllowedValues       []string                  `json:"allowed_values,omitempty"`
+	AllowOther          bool                      `json:"allow_other,omitempty"`
+}
+
+// ValueCompositionManifest describes a ValueComposition in a Manifest. The specific characters of the charset are
+// listed as a string.
+type ValueCompositionManifest struct {
+	Length  int    `json:"length,omitempty"`
+	Prefix  string `json:"prefix,omitempty"`
+	Charset struct {
+		Uppercase bool   `json:"uppercase"`
+		Lowercase bool   `json:"lowercase"`
+		Digits    bool   `json:"digits"`
+		Symbols   bool   `json:"symbols"`
+		Specific  string `json:"specific,omitempty"`
+	} `json:"charset"`
+}
+
+// ExecutableManifest describes an Executable in a Manifest. NeedsAuth is the description of the rule, or "custom rule"
+// for a rule without a description. It's empty if every command needs authentication.
+type ExecutableManifest struct {
+	Name              string                    `json:"name"`
+	Runs              []string                  `json:"runs"`
+	Aliases           [][]string                `json:"aliases,omitempty"`
+	DocsURL           string                    `json:"docs_url,omitempty"`
+	NeedsAuth         string                    `json:"needs_auth,omitempty"`
+	SelectCredentials bool                      `json:"select_credentials,omitempty"`
+	MinimumVersion    string                    `json:"minimum_version,omitempty"`
+	VersionCommand    []string                  `json:"version_command,omitempty"`
+	VersionPattern    string                    `json:"version_pattern,omitempty"`
+	Uses              []CredentialUsageManifest `json:"uses"`
+}
+
+// CredentialUsageManifest describes a CredentialUsage in a Manifest.
+type CredentialUsageManifest struct {
+	Name        string                       `json:"name,omitempty"`
+	Plugin      string                       `json:"plugin,omitempty"`
+	Description string                       `json:"description,omitempty"`
+	SelectFrom  *CredentialSelectionManifest `json:"select_from,omitempty"`
+	Provisioner string                       `json:"provisioner,omitempty"`
+	NeedsAuth   string                       `json:"needs_auth,omitempty"`
+	Optional    bool                         `json:"optional,omitempty"`
+	Preference  int                          `json:"preference,omitempty"`
+}
+
+// CredentialSelectionManifest describes a CredentialSelection in a Manifest.
+type CredentialSelectionManifest struct {
+	ID                    string `json:"id"`
+	IncludeAllCredentials bool   `json:"include_all_credentials"`
+	AllowMultiple         bool   `json:"allow_multiple"`
+}
+
+// ToManifest converts the plugin to its Manifest.
+func (p Plugin) ToManifest() Manifest {
+	m := Manifest{
+		SchemaVersion: ManifestSchemaVersion,
+		Name:          p.Name,
+		Platform: PlatformManifest{
+			Name:     p.Platform.Name,
+			Homepage: urlString(p.Platform.Homepage),
+		},
+		Credentials: []CredentialTypeManifest{},
+		Executables: []ExecutableManifest{},
+	}
+	for _, c := range p.Credentials {
+		m.Credentials = append(m.Credentials, c.ToManifest())
+	}
+	for _, e := range p.Executables {
+		m.Executables = append(m.Executables, e.ToManifest())
+	}
+	return m
+}
+
+// ToManifest converts the credential type to its description in a Manifest.
+func (c CredentialType) ToManifest() CredentialTypeManifest {
+	m := CredentialTypeManifest{
+		Name:                c.Name.String(),
+		Fields:              []CredentialFieldManifest{},
+		DocsURL:             urlString(c.DocsURL),
+		ManagementURL:       urlString(c.ManagementURL),
+		HasImporter:         c.Importer != nil,
+		DefaultProvisioner:  provisionerDescription(c.DefaultProvisioner),
+		ImportDefaultValues: c.ImportDefaultValues,
+		Expirable:           c.Expirable,
+		DefaultTTL:          durationString(c.DefaultTTL),
+		ExpiryField:         c.ExpiryField.String(),
+	}
+	for _, f := range c.Fields {
+		m.Fields = append(m.Fields, f.ToManifest())
+	}
+	for _, fieldSet := range c.FieldSets {
+		names := []string{}
+		for _, name := range fieldSet {
+			names = append(names, name.String())
+		}
+		m.FieldSets = append(m.FieldSets, names)
+	}
+	return m
+}
+
+// ToManifest converts the credential field to its description in a Manifest.
+func (f CredentialField) ToManifest() CredentialFieldManifest {
+	m := CredentialFieldManifest{
+		Name:                f.Name.String(),
+		AlternativeNames:    f.AlternativeNames,
+		MarkdownDescription: f.MarkdownDescription,
+		Secret:              f.Secret,
+		Optional:            f.Optional,
+		DefaultValue:        f.DefaultValue,
+		AllowedValues:       f.AllowedValues,
+		AllowOther:          f.AllowOther,
+	}
+	if f.Composition != nil {
+		composition := &ValueCompositionManifest{
+			Length: f.Composition.Length,
+			Prefix: f.Composition.Prefix,
+		}
+		composition.Charset.Uppercase = f.Composition.Charset.Uppercase
+		composition.Charset.Lowercase = f.Composition.Charset.Lowercase
+		composition.Charset.Digits = f.Composition.Charset.Digits
+		composition.Charset.Symbols = f.Composition.Charset.Symbols
+		composition.Charset.Specific = string(f.Composition.Charset.Specific)
+		m.Composition = composition
+	}
+	return m
+}
+
+// ToManifest converts the executable to its description in a Manifest.
+func (e Executable) ToManifest() ExecutableManifest {
+	m := ExecutableManifest{
+		Name:              e.Name,
+		Runs:              e.Runs,
+		Aliases:           e.Aliases,
+		DocsURL:           urlString(e.DocsURL),
+		NeedsAuth:         needsAuthDescription(e.NeedsAuth),
+		SelectCredentials: e.SelectCredentials != nil,
+		MinimumVersion:    e.MinimumVersion,
+		VersionCommand:    e.VersionCommand,
+		VersionPattern:    e.VersionPattern,
+		Uses:              []CredentialUsageManifest{},
+	}
+	for _, u := range e.Uses {
+		usage := CredentialUsageManifest{
+			Name:        u.Name.String(),
+			Plugin:      u.Plugin,
+			Description: u.Description,
+			Provisioner: provisionerDescription(u.Provisioner),
+			NeedsAuth:   needsAuthDescription(u.NeedsAuth),
+			Optional:    u.Optional,
+			Preference:  u.Preference,
+		}
+		if u.SelectFrom != nil {
+			usage.SelectFrom = &CredentialSelectionManifest{
+				ID:                    u.SelectFrom.ID,
+				IncludeAllCredentials: u.SelectFrom.IncludeAllCredentials,
+				AllowMultiple:         u.SelectFrom.AllowMultiple,
+			}
+		}
+		m.Uses = append(m.Uses, usage)
+	}
+	return m
+}
+
+func urlString(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
+
+func durationString(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+func provisionerDescription(provisioner sdk.Provisioner) string {
+	if provisioner == nil {
+		return ""
+	}
+	return provisioner.Description()
+}
+
+func needsAuthDescription(needsAuth sdk.NeedsAuthentication) string {
+	if needsAuth == nil {
+		return ""
+	}
+	if description := needsAuth.Description(); description != "" {
+		return description
+	}
+	return "custom rule"
+}
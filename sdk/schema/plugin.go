@@ -4,6 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/credname"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
 )
 
 // Plugin provides the schema for a single shell plugin. A plugin focuses on a single platform
@@ -31,86 +37,201 @@ type PlatformInfo struct {
 	Homepage *url.URL
 }
 
-func (p Plugin) Validate() (bool, ValidationReport) {
+func (p Plugin) Validate(opts ...ValidationOption) (bool, ValidationReport) {
+	settings := newValidationSettings("plugin", opts)
 	report := ValidationReport{
 		Heading: fmt.Sprintf("Plugin: %s", p.Name),
 		Checks:  []ValidationCheck{},
 	}
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodePluginMissingName,
 		Description: "Has plugin name set",
 		Assertion:   p.Name != "",
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodePluginNameNotLowercase,
 		Description: "Plugin name only using lowercase characters or digits",
 		Assertion:   ContainsLowercaseLettersOrDigits(p.Name),
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodePluginNameTooLong,
 		Description: "Plugin name not longer than 20 characters",
 		Assertion:   len(p.Name) <= 20,
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodePluginMissingPlatformName,
 		Description: "Has platform name set",
 		Assertion:   p.Platform.Name != "",
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodePluginMissingHomepage,
 		Description: "Has platform homepage URL set",
 		Assertion:   p.Platform.Homepage != nil,
 		Severity:    ValidationSeverityError,
 	})
 
+	report.AddCheck(urlCheck(CodePluginMalformedHomepage, "platform homepage URL", p.Platform.Homepage))
+
 	report.AddCheck(ValidationCheck{
+		Code:        CodePluginEmpty,
 		Description: "Has a credential type or executable defined",
 		Assertion:   len(p.Credentials) > 0 || len(p.Executables) > 0,
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodePluginMultipleCredentials,
 		Description: "Has no more than one credential type defined. Plugins with multiple credential types are not supported yet",
 		Assertion:   len(p.Credentials) <= 1,
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodePluginUnknownCredentialReference,
 		Description: "Credentials referenced in executables are included in the same plugin definition",
 		Assertion:   CredentialReferencesInCredentialList(p),
 		Severity:    ValidationSeverityError,
 	})
 
 	report.AddCheck(ValidationCheck{
+		Code:        CodePluginDuplicateCredentials,
 		Description: "Credentials are uniquely identifiable inside a plugin",
 		Assertion:   NoDuplicateCredentials(p),
 		Severity:    ValidationSeverityError,
 	})
 
+	report.AddCheck(registeredNamesCheck(p))
+
+	commandsDescription := "Executable commands and aliases are unique inside a plugin"
+	duplicateCommands := DuplicateCommands(p)
+	if len(duplicateCommands) > 0 {
+		commandsDescription += ": duplicates: " + strings.Join(duplicateCommands, ", ")
+	}
+	report.AddCheck(ValidationCheck{
+		Code:        CodePluginDuplicateExecutableCommands,
+		Description: commandsDescription,
+		Assertion:   len(duplicateCommands) == 0,
+		Severity:    ValidationSeverityError,
+	})
+
+	settings.finish(&report)
 	return report.IsValid(), report
 }
 
-func (p Plugin) DeepValidate() []ValidationReport {
+// registeredNamesCheck checks that the names of all credential types and their fields are registered in the credname
+// and fieldname packages, so that plugins name the same things the same way. The paths of the unregistered names are
+// included in the description, in the format plugin/credential or plugin/credential/field.
+func registeredNamesCheck(p Plugin) ValidationCheck {
+	var unregistered []string
+	for _, credential := range p.Credentials {
+		if !credname.IsRegistered(credential.Name) {
+			unregistered = append(unregistered, fmt.Sprintf("%s/%s", p.Name, credential.Name))
+		}
+		for _, field := range credential.Fields {
+			if !fieldname.IsRegistered(field.Name) {
+				unregistered = append(unregistered, fmt.Sprintf("%s/%s/%s", p.Name, credential.Name, field.Name))
+			}
+		}
+	}
+
+	check := ValidationCheck{
+		Code:        CodePluginUnregisteredNames,
+		Description: "Credential and field names are registered in the credname and fieldname packages",
+		Assertion:   len(unregistered) == 0,
+		Severity:    ValidationSeverityError,
+	}
+	if len(unregistered) > 0 {
+		check.Description += ": not registered: " + strings.Join(unregistered, ", ")
+	}
+	return check
+}
+
+// ValidateCandidates checks the import candidates that were added for another credential type using
+// sdk.ImportAttempt.AddCandidateFor. Candidates for a credential type that's not part of this plugin, or with fields
+// that don't match the fields of their credential type, are removed and reported as an error on their attempt.
+func (p Plugin) ValidateCandidates(out *sdk.ImportOutput) {
+	for _, attempt := range out.Attempts {
+		var valid []sdk.ImportCandidate
+		for _, candidate := range attempt.Candidates {
+			if candidate.CredentialType == "" {
+				valid = append(valid, candidate)
+				continue
+			}
+			if err := p.validateCandidate(candidate); err != nil {
+				attempt.AddError(err)
+				continue
+			}
+			valid = append(valid, candidate)
+		}
+		attempt.Candidates = valid
+	}
+}
+
+// validateCandidate returns an error if the credential type of the candidate is not part of this plugin, or if the
+// candidate has fields that the credential type doesn't have, is missing any of its required fields, or doesn't have
+// all fields of any of its field sets.
+func (p Plugin) validateCandidate(candidate sdk.ImportCandidate) error {
+	var credential *CredentialType
+	for i := range p.Credentials {
+		if p.Credentials[i].Name == candidate.CredentialType {
+			credential = &p.Credentials[i]
+			break
+		}
+	}
+	if credential == nil {
+		return fmt.Errorf("import candidate is for credential type '%s', which is not part of plugin '%s'", candidate.CredentialType, p.Name)
+	}
+
+	fieldNames := make([]string, 0, len(candidate.Fields))
+	for fieldName := range candidate.Fields {
+		fieldNames = append(fieldNames, fieldName.String())
+	}
+	sort.Strings(fieldNames)
+	for _, fieldName := range fieldNames {
+		if credential.Field(fieldName) == nil {
+			return fmt.Errorf("import candidate for credential type '%s' has field '%s', which that credential type doesn't have", candidate.CredentialType, fieldName)
+		}
+	}
+	for _, fieldName := range credential.RequiredFields() {
+		if _, ok := candidate.Fields[fieldName]; !ok {
+			return fmt.Errorf("import candidate for credential type '%s' is missing required field '%s'", candidate.CredentialType, fieldName)
+		}
+	}
+	if _, err := credential.FieldSetFor(candidate.Fields); err != nil {
+		return fmt.Errorf("import candidate for credential type '%s' has none of its field sets: %s", candidate.CredentialType, err)
+	}
+	return nil
+}
+
+// DeepValidate validates the plugin and all of its credential types, executables and credential usages, and returns
+// their reports. The paths of the checks point to the validated part of the plugin, e.g. "plugin.executables[1]".
+func (p Plugin) DeepValidate(opts ...ValidationOption) []ValidationReport {
 	var reports []ValidationReport
 
-	_, pluginReport := p.Validate()
+	_, pluginReport := p.Validate(opts...)
 	reports = append(reports, pluginReport)
 
-	for _, cred := range p.Credentials {
-		_, credReport := cred.Validate()
+	for i, cred := range p.Credentials {
+		_, credReport := cred.Validate(append(opts, atPath(fmt.Sprintf("plugin.credentials[%d]", i)))...)
 		reports = append(reports, credReport)
 	}
 
-	for _, exe := range p.Executables {
-		_, exeReport := exe.Validate()
+	for i, exe := range p.Executables {
+		exePath := fmt.Sprintf("plugin.executables[%d]", i)
+		_, exeReport := exe.Validate(append(opts, atPath(exePath))...)
 		reports = append(reports, exeReport)
 
-		for _, usage := range exe.Uses {
-			_, usageReport := usage.Validate()
+		for j, usage := range exe.Uses {
+			_, usageReport := usage.Validate(append(opts, atPath(fmt.Sprintf("%s.uses[%d]", exePath, j)))...)
 			usageReport.Heading = fmt.Sprintf("Executable %s: %s", exe.Name, usageReport.Heading)
 			reports = append(reports, usageReport)
 		}
@@ -0,0 +1,120 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/schema/credname"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPluginValidateCandidates(t *testing.T) {
+	plugin := Plugin{
+		Name: "test",
+		Credentials: []CredentialType{
+			{
+				Name: credname.APIKey,
+				Fields: []CredentialField{
+					{Name: fieldname.APIKey},
+				},
+			},
+			{
+				Name: credname.AuthToken,
+				Fields: []CredentialField{
+					{Name: fieldname.Token},
+					{Name: fieldname.Region, Optional: true},
+				},
+			},
+			{
+				Name: credname.RegistryCredentials,
+				Fields: []CredentialField{
+					{Name: fieldname.Token, Secret: true},
+					{Name: fieldname.Username},
+					{Name: fieldname.Password, Secret: true},
+				},
+				FieldSets: [][]sdk.FieldName{{fieldname.Token}, {fieldname.Username, fieldname.Password}},
+			},
+		},
+	}
+
+	apiKey := sdk.ImportCandidate{Fields: map[sdk.FieldName]string{fieldname.APIKey: "key"}}
+
+	for _, scenario := range []struct {
+		description        string
+		candidate          sdk.ImportCandidate
+		expectedCandidates []sdk.ImportCandidate
+		expectedErrors     []sdk.Error
+	}{
+		{
+			description: "other credential type of the plugin",
+			candidate: sdk.ImportCandidate{
+				Fields:         map[sdk.FieldName]string{fieldname.Token: "token"},
+				CredentialType: credname.AuthToken,
+			},
+			expectedCandidates: []sdk.ImportCandidate{apiKey, {
+				Fields:         map[sdk.FieldName]string{fieldname.Token: "token"},
+				CredentialType: credname.AuthToken,
+			}},
+		},
+		{
+			description: "credential type not part of the plugin",
+			candidate: sdk.ImportCandidate{
+				Fields:         map[sdk.FieldName]string{fieldname.Key: "secret"},
+				CredentialType: credname.SecretKey,
+			},
+			expectedCandidates: []sdk.ImportCandidate{apiKey},
+			expectedErrors:     []sdk.Error{{Message: "import candidate is for credential type 'Secret Key', which is not part of plugin 'test'"}},
+		},
+		{
+			description: "field that the credential type doesn't have",
+			candidate: sdk.ImportCandidate{
+				Fields:         map[sdk.FieldName]string{fieldname.Token: "token", fieldname.Password: "password"},
+				CredentialType: credname.AuthToken,
+			},
+			expectedCandidates: []sdk.ImportCandidate{apiKey},
+			expectedErrors:     []sdk.Error{{Message: "import candidate for credential type 'Auth Token' has field 'Password', which that credential type doesn't have"}},
+		},
+		{
+			description: "missing required field",
+			candidate: sdk.ImportCandidate{
+				Fields:         map[sdk.FieldName]string{fieldname.Region: "eu"},
+				CredentialType: credname.AuthToken,
+			},
+			expectedCandidates: []sdk.ImportCandidate{apiKey},
+			expectedErrors:     []sdk.Error{{Message: "import candidate for credential type 'Auth Token' is missing required field 'Token'"}},
+		},
+		{
+			description: "second field set",
+			candidate: sdk.ImportCandidate{
+				Fields:         map[sdk.FieldName]string{fieldname.Username: "wendy", fieldname.Password: "hunter2"},
+				CredentialType: credname.RegistryCredentials,
+			},
+			expectedCandidates: []sdk.ImportCandidate{apiKey, {
+				Fields:         map[sdk.FieldName]string{fieldname.Username: "wendy", fieldname.Password: "hunter2"},
+				CredentialType: credname.RegistryCredentials,
+			}},
+		},
+		{
+			description: "incomplete field set",
+			candidate: sdk.ImportCandidate{
+				Fields:         map[sdk.FieldName]string{fieldname.Username: "wendy"},
+				CredentialType: credname.RegistryCredentials,
+			},
+			expectedCandidates: []sdk.ImportCandidate{apiKey},
+			expectedErrors:     []sdk.Error{{Message: "import candidate for credential type 'Registry Credentials' has none of its field sets: the item needs either Token, or Username and Password"}},
+		},
+	} {
+		t.Run(scenario.description, func(t *testing.T) {
+			out := sdk.ImportOutput{}
+			attempt := out.NewAttempt(sdk.ImportSource{})
+			attempt.AddCandidate(apiKey)
+			attempt.AddCandidateFor(scenario.candidate.CredentialType, sdk.ImportCandidate{Fields: scenario.candidate.Fields})
+
+			plugin.ValidateCandidates(&out)
+
+			assert.Equal(t, scenario.expectedCandidates, out.AllCandidates())
+			assert.Equal(t, scenario.expectedErrors, out.Errors())
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package schema
 
 import (
+	"fmt"
+	"net/url"
 	"regexp"
 	"strings"
 )
@@ -18,7 +20,7 @@ func (vr *ValidationReport) IsValid() bool {
 	isValid := true
 
 	for _, check := range vr.Checks {
-		if !check.Assertion {
+		if !check.Assertion && !check.Suppressed {
 			isValid = false
 			break
 		}
@@ -29,7 +31,7 @@ func (vr *ValidationReport) IsValid() bool {
 
 func (vr *ValidationReport) HasErrors() bool {
 	for _, check := range vr.Checks {
-		if !check.Assertion && check.Severity == ValidationSeverityError {
+		if !check.Assertion && !check.Suppressed && check.Severity == ValidationSeverityError {
 			return true
 		}
 	}
@@ -38,12 +40,37 @@ func (vr *ValidationReport) HasErrors() bool {
 }
 
 type ValidationCheck struct {
+	// Code identifies the check, e.g. "CRED001_MissingDocsURL". Unlike the description, it doesn't change between
+	// releases, so it can be used to suppress a check or to group failures.
+	Code ValidationCode
+	// Path points to the part of the plugin that got checked, e.g. "plugin.credentials[0].fields[2]". For checks that
+	// fail because of specific fields, it points to the first of those fields.
+	Path string
 	// Description explains what we want to validate
 	Description string
 	// Assertion
 	Assertion bool
 	// Severity is "warning" for Optional fields that are not passed and "error" for Required fields
 	Severity ValidationSeverity
+	// Suppressed is true if the check failed, but its code was passed to WithSuppressedChecks. Suppressed checks don't
+	// make the report invalid.
+	Suppressed bool
+}
+
+// String returns the check in the format "[code] path: description", with "(suppressed)" appended to suppressed checks.
+func (c ValidationCheck) String() string {
+	var s string
+	if c.Code != "" {
+		s += fmt.Sprintf("[%s] ", c.Code)
+	}
+	if c.Path != "" {
+		s += c.Path + ": "
+	}
+	s += c.Description
+	if c.Suppressed {
+		s += " (suppressed)"
+	}
+	return s
 }
 
 type ValidationSeverity string
@@ -53,6 +80,70 @@ const (
 	ValidationSeverityError   ValidationSeverity = "error"
 )
 
+// ValidationOption can be used to configure how a schema gets validated.
+type ValidationOption func(*validationSettings)
+
+// validationSettings holds the settings of a validation.
+type validationSettings struct {
+	path       string
+	suppressed []ValidationCode
+}
+
+// WithSuppressedChecks suppresses the failures of the checks with the specified codes, for plugins with documented
+// exceptions. A code can be passed in full, e.g. "CRED001_MissingDocsURL", or by its prefix, e.g. "CRED001".
+func WithSuppressedChecks(codes ...ValidationCode) ValidationOption {
+	return func(s *validationSettings) {
+		s.suppressed = append(s.suppressed, codes...)
+	}
+}
+
+// atPath sets the path of the validated part of the plugin, for when it gets validated as part of the whole plugin.
+func atPath(path string) ValidationOption {
+	return func(s *validationSettings) {
+		s.path = path
+	}
+}
+
+// newValidationSettings applies the options to the settings of a validation of the part of the plugin at the specified
+// path, e.g. "credential", unless the options set another path.
+func newValidationSettings(path string, opts []ValidationOption) validationSettings {
+	settings := validationSettings{path: path}
+	for _, opt := range opts {
+		opt(&settings)
+	}
+	return settings
+}
+
+// finish sets the path of the checks of the report that don't have their own path and marks the failed checks that
+// are suppressed.
+func (s validationSettings) finish(report *ValidationReport) {
+	for i := range report.Checks {
+		check := &report.Checks[i]
+		if check.Path == "" {
+			check.Path = s.path
+		}
+		if !check.Assertion && s.isSuppressed(check.Code) {
+			check.Suppressed = true
+		}
+	}
+}
+
+// isSuppressed returns whether the check with the specified code is suppressed, either by its full code or by its
+// prefix.
+func (s validationSettings) isSuppressed(code ValidationCode) bool {
+	for _, suppressed := range s.suppressed {
+		if suppressed != "" && (code == suppressed || code.Prefix() == suppressed) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldPath returns the path of the field at the specified index of the credential type at the specified path.
+func fieldPath(credentialPath string, index int) string {
+	return fmt.Sprintf("%s.fields[%d]", credentialPath, index)
+}
+
 func IsTitleCaseWord(word string) bool {
 	words := strings.Split(word, " ")
 	if len(words) > 1 {
@@ -98,7 +189,8 @@ func ContainsLowercaseLettersOrDigits(str string) bool {
 func CredentialReferencesInCredentialList(plugin Plugin) bool {
 	for _, executable := range plugin.Executables {
 		for _, execCredential := range executable.Uses {
-			if execCredential.Name != "" {
+			// Credentials of other plugins are defined in their own plugin definition.
+			if execCredential.Name != "" && execCredential.Plugin == "" {
 				found := false
 				for _, credential := range plugin.Credentials {
 					if execCredential.Name == credential.Name {
@@ -147,3 +239,77 @@ func IsStringSliceASet(slice []string) bool {
 
 	return true
 }
+
+// urlCheck checks that the URL, if set, is a real https URL rather than a leftover of the plugin scaffolding: that it
+// uses https, has a host with a dot in it, and doesn't contain template syntax or "TODO". The problems are included
+// in the description. It's a warning, so that plugins with intentionally unusual URLs still pass.
+func urlCheck(code ValidationCode, name string, u *url.URL) ValidationCheck {
+	check := ValidationCheck{
+		Code:        code,
+		Description: fmt.Sprintf("If set, the %s is a well-formed https URL", name),
+		Assertion:   true,
+		Severity:    ValidationSeverityWarning,
+	}
+	if u == nil {
+		return check
+	}
+
+	var problems []string
+	if u.Scheme != "https" {
+		problems = append(problems, "doesn't use https")
+	}
+	if !strings.Contains(u.Hostname(), ".") {
+		problems = append(problems, "has no host with a dot in it")
+	}
+	raw := u.String()
+	if unescaped, err := url.PathUnescape(raw); err == nil {
+		raw = unescaped
+	}
+	if strings.Contains(raw, "{{") || strings.Contains(raw, "}}") {
+		problems = append(problems, "contains template leftovers")
+	}
+	if strings.Contains(raw, "TODO") {
+		problems = append(problems, "contains TODO")
+	}
+
+	if len(problems) > 0 {
+		check.Description += ": " + strings.Join(problems, ", ")
+		check.Assertion = false
+	}
+	return check
+}
+
+// duplicateStrings returns the strings that occur more than once in the slice, in order of their second occurrence.
+func duplicateStrings(slice []string) []string {
+	var seen, duplicates []string
+	for _, s := range slice {
+		if containsString(seen, s) {
+			if !containsString(duplicates, s) {
+				duplicates = append(duplicates, s)
+			}
+			continue
+		}
+		seen = append(seen, s)
+	}
+	return duplicates
+}
+
+// uniqueStrings returns the slice without the strings that already occurred earlier in it.
+func uniqueStrings(slice []string) []string {
+	var unique []string
+	for _, s := range slice {
+		if !containsString(unique, s) {
+			unique = append(unique, s)
+		}
+	}
+	return unique
+}
+
+func containsString(slice []string, s string) bool {
+	for _, ss := range slice {
+		if ss == s {
+			return true
+		}
+	}
+	return false
+}
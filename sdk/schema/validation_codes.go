@@ -0,0 +1,82 @@
+package schema
+
+import "strings"
+
+// ValidationCode identifies a validation check, in the format "<prefix>_<name>", e.g. "CRED001_MissingDocsURL". The
+// prefix consists of the kind of schema that gets checked and a number. Codes never change or get reused once
+// released, so that suppressions and tooling that groups failures keep working.
+type ValidationCode string
+
+// Prefix returns the part of the code before the underscore, e.g. "CRED001".
+func (c ValidationCode) Prefix() ValidationCode {
+	prefix, _, _ := strings.Cut(string(c), "_")
+	return ValidationCode(prefix)
+}
+
+// Codes of the checks of Plugin.Validate.
+const (
+	CodePluginMissingName                 ValidationCode = "PLUG001_MissingName"
+	CodePluginNameNotLowercase            ValidationCode = "PLUG002_NameNotLowercase"
+	CodePluginNameTooLong                 ValidationCode = "PLUG003_NameTooLong"
+	CodePluginMissingPlatformName         ValidationCode = "PLUG004_MissingPlatformName"
+	CodePluginMissingHomepage             ValidationCode = "PLUG005_MissingHomepage"
+	CodePluginMalformedHomepage           ValidationCode = "PLUG006_MalformedHomepage"
+	CodePluginEmpty                       ValidationCode = "PLUG007_Empty"
+	CodePluginMultipleCredentials         ValidationCode = "PLUG008_MultipleCredentials"
+	CodePluginUnknownCredentialReference  ValidationCode = "PLUG009_UnknownCredentialReference"
+	CodePluginDuplicateCredentials        ValidationCode = "PLUG010_DuplicateCredentials"
+	CodePluginUnregisteredNames           ValidationCode = "PLUG011_UnregisteredNames"
+	CodePluginDuplicateExecutableCommands ValidationCode = "PLUG012_DuplicateExecutableCommands"
+)
+
+// Codes of the checks of CredentialType.Validate.
+const (
+	CodeCredentialMissingDocsURL              ValidationCode = "CRED001_MissingDocsURL"
+	CodeCredentialMissingManagementURL        ValidationCode = "CRED002_MissingManagementURL"
+	CodeCredentialMalformedDocsURL            ValidationCode = "CRED003_MalformedDocsURL"
+	CodeCredentialMalformedManagementURL      ValidationCode = "CRED004_MalformedManagementURL"
+	CodeCredentialMissingName                 ValidationCode = "CRED005_MissingName"
+	CodeCredentialNameNotTitleCase            ValidationCode = "CRED006_NameNotTitleCase"
+	CodeCredentialNoFields                    ValidationCode = "CRED007_NoFields"
+	CodeCredentialFieldMissingName            ValidationCode = "CRED008_FieldMissingName"
+	CodeCredentialFieldNameNotTitleCase       ValidationCode = "CRED009_FieldNameNotTitleCase"
+	CodeCredentialFieldMissingDescription     ValidationCode = "CRED010_FieldMissingDescription"
+	CodeCredentialInvalidComposition          ValidationCode = "CRED011_InvalidComposition"
+	CodeCredentialNoRequiredSecretField       ValidationCode = "CRED012_NoRequiredSecretField"
+	CodeCredentialSecretFieldDefault          ValidationCode = "CRED013_SecretFieldDefault"
+	CodeCredentialDisallowedDefault           ValidationCode = "CRED014_DisallowedDefault"
+	CodeCredentialExpiryNotExpirable          ValidationCode = "CRED015_ExpiryNotExpirable"
+	CodeCredentialNegativeDefaultTTL          ValidationCode = "CRED016_NegativeDefaultTTL"
+	CodeCredentialInvalidExpiryField          ValidationCode = "CRED017_InvalidExpiryField"
+	CodeCredentialDuplicateFieldNames         ValidationCode = "CRED018_DuplicateFieldNames"
+	CodeCredentialMissingProvisioner          ValidationCode = "CRED019_MissingProvisioner"
+	CodeCredentialMissingNoProvisioningReason ValidationCode = "CRED020_MissingNoProvisioningReason"
+	CodeCredentialInvalidProvisioner          ValidationCode = "CRED021_InvalidProvisioner"
+	CodeCredentialMissingImporter             ValidationCode = "CRED022_MissingImporter"
+	CodeCredentialInvalidFieldSets            ValidationCode = "CRED023_InvalidFieldSets"
+)
+
+// Codes of the checks of Executable.Validate.
+const (
+	CodeExecutableMissingName               ValidationCode = "EXEC001_MissingName"
+	CodeExecutableMissingDocsURL            ValidationCode = "EXEC002_MissingDocsURL"
+	CodeExecutableMalformedDocsURL          ValidationCode = "EXEC003_MalformedDocsURL"
+	CodeExecutableMissingNeedsAuth          ValidationCode = "EXEC004_MissingNeedsAuth"
+	CodeExecutableInvalidNeedsAuth          ValidationCode = "EXEC005_InvalidNeedsAuth"
+	CodeExecutableInvalidCredentialSelector ValidationCode = "EXEC006_InvalidCredentialSelector"
+	CodeExecutableMissingCommand            ValidationCode = "EXEC007_MissingCommand"
+	CodeExecutableInvalidAliases            ValidationCode = "EXEC008_InvalidAliases"
+	CodeExecutableTiedPreferences           ValidationCode = "EXEC009_TiedPreferences"
+	CodeExecutableInvalidMinimumVersion     ValidationCode = "EXEC010_InvalidMinimumVersion"
+	CodeExecutableNoCredentialUsages        ValidationCode = "EXEC011_NoCredentialUsages"
+	CodeExecutableDuplicateCredentialUsages ValidationCode = "EXEC012_DuplicateCredentialUsages"
+)
+
+// Codes of the checks of CredentialUsage.Validate.
+const (
+	CodeUsageMissingCredentialName ValidationCode = "USE001_MissingCredentialName"
+	CodeUsageIncompleteSelection   ValidationCode = "USE002_IncompleteSelection"
+	CodeUsageReferenceAndSelection ValidationCode = "USE003_ReferenceAndSelection"
+	CodeUsageInvalidProvisioner    ValidationCode = "USE004_InvalidProvisioner"
+	CodeUsageInvalidNeedsAuth      ValidationCode = "USE005_InvalidNeedsAuth"
+)
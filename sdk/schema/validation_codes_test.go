@@ -0,0 +1,204 @@
+package schema
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/needsauth"
+	"github.com/1Password/shell-plugins/sdk/schema/credname"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
+	"github.com/stretchr/testify/assert"
+)
+
+func codes(report ValidationReport) []ValidationCode {
+	var codes []ValidationCode
+	for _, c := range report.Checks {
+		codes = append(codes, c.Code)
+	}
+	return codes
+}
+
+// TestValidationCodes locks down the codes of the checks, since suppressions and tooling depend on them. Codes must
+// never change once released: add a new code for a new check instead.
+func TestValidationCodes(t *testing.T) {
+	_, pluginReport := Plugin{}.Validate()
+	_, credentialReport := CredentialType{}.Validate()
+	_, executableReport := Executable{}.Validate()
+	_, usageReport := CredentialUsage{}.Validate()
+
+	assert.Equal(t, []ValidationCode{
+		"PLUG001_MissingName",
+		"PLUG002_NameNotLowercase",
+		"PLUG003_NameTooLong",
+		"PLUG004_MissingPlatformName",
+		"PLUG005_MissingHomepage",
+		"PLUG006_MalformedHomepage",
+		"PLUG007_Empty",
+		"PLUG008_MultipleCredentials",
+		"PLUG009_UnknownCredentialReference",
+		"PLUG010_DuplicateCredentials",
+		"PLUG011_UnregisteredNames",
+		"PLUG012_DuplicateExecutableCommands",
+	}, codes(pluginReport))
+
+	assert.Equal(t, []ValidationCode{
+		"CRED005_MissingName",
+		"CRED006_NameNotTitleCase",
+		"CRED001_MissingDocsURL",
+		"CRED002_MissingManagementURL",
+		"CRED003_MalformedDocsURL",
+		"CRED004_MalformedManagementURL",
+		"CRED007_NoFields",
+		"CRED008_FieldMissingName",
+		"CRED009_FieldNameNotTitleCase",
+		"CRED010_FieldMissingDescription",
+		"CRED011_InvalidComposition",
+		"CRED012_NoRequiredSecretField",
+		"CRED013_SecretFieldDefault",
+		"CRED014_DisallowedDefault",
+		"CRED023_InvalidFieldSets",
+		"CRED015_ExpiryNotExpirable",
+		"CRED016_NegativeDefaultTTL",
+		"CRED017_InvalidExpiryField",
+		"CRED018_DuplicateFieldNames",
+		"CRED019_MissingProvisioner",
+		"CRED020_MissingNoProvisioningReason",
+		"CRED021_InvalidProvisioner",
+		"CRED022_MissingImporter",
+	}, codes(credentialReport))
+
+	assert.Equal(t, []ValidationCode{
+		"EXEC001_MissingName",
+		"EXEC002_MissingDocsURL",
+		"EXEC003_MalformedDocsURL",
+		"EXEC004_MissingNeedsAuth",
+		"EXEC005_InvalidNeedsAuth",
+		"EXEC006_InvalidCredentialSelector",
+		"EXEC007_MissingCommand",
+		"EXEC008_InvalidAliases",
+		"EXEC009_TiedPreferences",
+		"EXEC010_InvalidMinimumVersion",
+		"EXEC011_NoCredentialUsages",
+		"EXEC012_DuplicateCredentialUsages",
+	}, codes(executableReport))
+
+	assert.Equal(t, []ValidationCode{
+		"USE001_MissingCredentialName",
+		"USE002_IncompleteSelection",
+		"USE003_ReferenceAndSelection",
+		"USE004_InvalidProvisioner",
+		"USE005_InvalidNeedsAuth",
+	}, codes(usageReport))
+
+	format := regexp.MustCompile(`^[A-Z]+\d{3}_[A-Z][A-Za-z]+$`)
+	var all []string
+	for _, report := range []ValidationReport{pluginReport, credentialReport, executableReport, usageReport} {
+		for _, code := range codes(report) {
+			assert.Regexp(t, format, string(code))
+			all = append(all, string(code.Prefix()))
+		}
+	}
+	assert.Empty(t, duplicateStrings(all))
+}
+
+func TestValidationCodePrefix(t *testing.T) {
+	assert.Equal(t, ValidationCode("CRED001"), CodeCredentialMissingDocsURL.Prefix())
+	assert.Equal(t, ValidationCode("CRED001"), ValidationCode("CRED001").Prefix())
+}
+
+func TestWithSuppressedChecks(t *testing.T) {
+	executable := Executable{
+		Runs:      []string{"example"},
+		NeedsAuth: needsauth.NotForHelpOrVersion(),
+		Uses:      []CredentialUsage{{Name: credname.APIToken}},
+	}
+
+	valid, report := executable.Validate()
+	assert.False(t, valid)
+	assert.True(t, report.HasErrors())
+
+	for name, suppressed := range map[string][]ValidationCode{
+		"by prefix":    {"EXEC001", "EXEC002"},
+		"by full code": {CodeExecutableMissingName, CodeExecutableMissingDocsURL},
+	} {
+		t.Run(name, func(t *testing.T) {
+			valid, report := executable.Validate(WithSuppressedChecks(suppressed...))
+			assert.True(t, valid)
+			assert.False(t, report.HasErrors())
+
+			var suppressedCodes []ValidationCode
+			for _, c := range report.Checks {
+				if c.Suppressed {
+					assert.False(t, c.Assertion)
+					suppressedCodes = append(suppressedCodes, c.Code)
+				}
+			}
+			assert.Equal(t, []ValidationCode{CodeExecutableMissingName, CodeExecutableMissingDocsURL}, suppressedCodes)
+		})
+	}
+
+	// Passing checks are not marked as suppressed, and codes are not matched on a partial prefix.
+	_, report = executable.Validate(WithSuppressedChecks("EXEC007", "EXEC00"))
+	for _, c := range report.Checks {
+		if c.Code == CodeExecutableMissingCommand || c.Code == CodeExecutableMissingName {
+			assert.False(t, c.Suppressed)
+		}
+	}
+}
+
+func TestValidationPaths(t *testing.T) {
+	plugin := Plugin{
+		Name:     "example",
+		Platform: PlatformInfo{Name: "Example", Homepage: sdk.URL("https://example.com")},
+		Credentials: []CredentialType{
+			{
+				Name: credname.APIToken,
+				Fields: []CredentialField{
+					{Name: fieldname.Token, MarkdownDescription: "Token used to authenticate to Example.", Secret: true},
+					{Name: fieldname.APIHost},
+					{Name: fieldname.Username},
+				},
+			},
+		},
+		Executables: []Executable{
+			{Name: "Example CLI", Runs: []string{"example"}, Uses: []CredentialUsage{{Name: credname.APIToken}}},
+		},
+	}
+
+	paths := map[ValidationCode]string{}
+	for _, report := range plugin.DeepValidate() {
+		for _, c := range report.Checks {
+			paths[c.Code] = c.Path
+		}
+	}
+
+	assert.Equal(t, "plugin", paths[CodePluginMissingName])
+	assert.Equal(t, "plugin.credentials[0]", paths[CodeCredentialMissingDocsURL])
+	assert.Equal(t, "plugin.credentials[0].fields[1]", paths[CodeCredentialFieldMissingDescription])
+	assert.Equal(t, "plugin.credentials[0]", paths[CodeCredentialFieldMissingName])
+	assert.Equal(t, "plugin.executables[0]", paths[CodeExecutableMissingDocsURL])
+	assert.Equal(t, "plugin.executables[0].uses[0]", paths[CodeUsageInvalidNeedsAuth])
+
+	_, report := plugin.Credentials[0].Validate()
+	for _, c := range report.Checks {
+		if c.Code == CodeCredentialFieldMissingDescription {
+			assert.Equal(t, "credential.fields[1]", c.Path)
+		}
+	}
+}
+
+func TestValidationCheckString(t *testing.T) {
+	check := ValidationCheck{
+		Code:        CodeCredentialMissingDocsURL,
+		Path:        "plugin.credentials[0]",
+		Description: "Has documentation URL set",
+		Severity:    ValidationSeverityWarning,
+	}
+	assert.Equal(t, "[CRED001_MissingDocsURL] plugin.credentials[0]: Has documentation URL set", check.String())
+
+	check.Suppressed = true
+	assert.Equal(t, "[CRED001_MissingDocsURL] plugin.credentials[0]: Has documentation URL set (suppressed)", check.String())
+
+	assert.Equal(t, "Has name set", ValidationCheck{Description: "Has name set"}.String())
+}
@@ -1,10 +1,21 @@
 package schema
 
 import (
+	"bytes"
+	"encoding/gob"
 	"fmt"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/1Password/shell-plugins/sdk/needsauth"
+	"github.com/1Password/shell-plugins/sdk/provision"
+	"github.com/1Password/shell-plugins/sdk/schema/credname"
+	"github.com/1Password/shell-plugins/sdk/schema/fieldname"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsTitleCaseWord(t *testing.T) {
@@ -136,6 +147,109 @@ func TestPluginValidateEachReportFieldHasError(t *testing.T) {
 	assert.False(t, c.Assertion, fmt.Sprintf("\"%s\" validation is erroneous", c.Description))
 }
 
+func TestPluginValidateRegisteredNames(t *testing.T) {
+	for name, tc := range map[string]struct {
+		credentials []CredentialType
+		expected    bool
+		description string
+	}{
+		"registered": {
+			credentials: []CredentialType{{
+				Name:   credname.APIToken,
+				Fields: []CredentialField{{Name: fieldname.Token}, {Name: fieldname.Host}},
+			}},
+			expected:    true,
+			description: "Credential and field names are registered in the credname and fieldname packages",
+		},
+		"unregistered credential and field names": {
+			credentials: []CredentialType{{
+				Name:   sdk.CredentialName("Magic Token"),
+				Fields: []CredentialField{{Name: fieldname.Token}, {Name: sdk.FieldName("Magic Word")}},
+			}},
+			expected:    false,
+			description: "Credential and field names are registered in the credname and fieldname packages: not registered: test/Magic Token, test/Magic Token/Magic Word",
+		},
+		"unregistered field name": {
+			credentials: []CredentialType{{
+				Name:   credname.APIToken,
+				Fields: []CredentialField{{Name: sdk.FieldName("Tokens")}},
+			}},
+			expected:    false,
+			description: "Credential and field names are registered in the credname and fieldname packages: not registered: test/API Token/Tokens",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, report := Plugin{Name: "test", Credentials: tc.credentials}.Validate()
+
+			var found bool
+			for _, c := range report.Checks {
+				if strings.HasPrefix(c.Description, "Credential and field names are registered") {
+					found = true
+					assert.Equal(t, tc.expected, c.Assertion)
+					assert.Equal(t, tc.description, c.Description)
+				}
+			}
+			assert.True(t, found)
+		})
+	}
+}
+
+func TestURLCheck(t *testing.T) {
+	for _, tc := range []struct {
+		url         string
+		expected    bool
+		description string
+	}{
+		{url: "", expected: true, description: "If set, the documentation URL is a well-formed https URL"},
+		{url: "https://developer.example.com/docs/api-keys", expected: true, description: "If set, the documentation URL is a well-formed https URL"},
+		{url: "https://example.com/docs/todo-lists", expected: true, description: "If set, the documentation URL is a well-formed https URL"},
+		{url: "http://example.com/docs/api-keys", expected: false, description: "If set, the documentation URL is a well-formed https URL: doesn't use https"},
+		{url: "https://localhost/docs", expected: false, description: "If set, the documentation URL is a well-formed https URL: has no host with a dot in it"},
+		{url: "https:///docs", expected: false, description: "If set, the documentation URL is a well-formed https URL: has no host with a dot in it"},
+		{url: "example.com/docs", expected: false, description: "If set, the documentation URL is a well-formed https URL: doesn't use https, has no host with a dot in it"},
+		{url: "https://example.com/docs/{{ .CredentialNameSnakeCase }}", expected: false, description: "If set, the documentation URL is a well-formed https URL: contains template leftovers"},
+		{url: "https://example.com/TODO", expected: false, description: "If set, the documentation URL is a well-formed https URL: contains TODO"},
+		{url: "https://example.com/docs?page=TODO", expected: false, description: "If set, the documentation URL is a well-formed https URL: contains TODO"},
+		{url: "http://localhost/{{.Name}}/TODO", expected: false, description: "If set, the documentation URL is a well-formed https URL: doesn't use https, has no host with a dot in it, contains template leftovers, contains TODO"},
+	} {
+		t.Run(tc.url, func(t *testing.T) {
+			var u *url.URL
+			if tc.url != "" {
+				u = sdk.URL(tc.url)
+			}
+			check := urlCheck(CodeCredentialMalformedDocsURL, "documentation URL", u)
+			assert.Equal(t, tc.expected, check.Assertion)
+			assert.Equal(t, tc.description, check.Description)
+			assert.Equal(t, ValidationSeverityWarning, check.Severity)
+		})
+	}
+}
+
+func TestValidateURLs(t *testing.T) {
+	todo := sdk.URL("https://example.com/TODO")
+	descriptions := func(report ValidationReport) []string {
+		var failing []string
+		for _, c := range report.Checks {
+			if !c.Assertion && strings.HasPrefix(c.Description, "If set, the") && strings.Contains(c.Description, "URL") {
+				failing = append(failing, c.Description)
+			}
+		}
+		return failing
+	}
+
+	_, report := CredentialType{DocsURL: todo, ManagementURL: sdk.URL("http://console.example.com")}.Validate()
+	assert.Equal(t, []string{
+		"If set, the documentation URL is a well-formed https URL: contains TODO",
+		"If set, the management URL is a well-formed https URL: doesn't use https",
+	}, descriptions(report))
+
+	_, report = Executable{DocsURL: todo}.Validate()
+	assert.Equal(t, []string{"If set, the documentation URL is a well-formed https URL: contains TODO"}, descriptions(report))
+
+	_, report = Plugin{Platform: PlatformInfo{Homepage: todo}}.Validate()
+	assert.Equal(t, []string{"If set, the platform homepage URL is a well-formed https URL: contains TODO"}, descriptions(report))
+}
+
 func TestIsStringSliceASet(t *testing.T) {
 	testCases := []struct {
 		slice     []string
@@ -165,3 +279,578 @@ func TestIsStringSliceASet(t *testing.T) {
 		assert.Equal(t, tc.assertion, IsStringSliceASet(tc.slice))
 	}
 }
+
+func TestCredentialTypeValidateProvisioner(t *testing.T) {
+	findCheck := func(report ValidationReport, description string) ValidationCheck {
+		for _, c := range report.Checks {
+			if c.Description == description {
+				return c
+			}
+		}
+		t.Fatalf("no check found with description %q", description)
+		return ValidationCheck{}
+	}
+
+	cases := map[string]struct {
+		provisioner       sdk.Provisioner
+		hasProvisioner    bool
+		noProvisionReason bool
+	}{
+		"nil": {
+			provisioner:       nil,
+			hasProvisioner:    false,
+			noProvisionReason: true,
+		},
+		"env vars": {
+			provisioner:       provision.EnvVars(map[string]sdk.FieldName{"TOKEN": fieldname.Token}),
+			hasProvisioner:    true,
+			noProvisionReason: true,
+		},
+		"none with reason": {
+			provisioner:       provision.None("the CLI resolves op:// references itself"),
+			hasProvisioner:    true,
+			noProvisionReason: true,
+		},
+		"none without reason": {
+			provisioner:       provision.None(""),
+			hasProvisioner:    true,
+			noProvisionReason: false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, report := CredentialType{DefaultProvisioner: tc.provisioner}.Validate()
+
+			assert.Equal(t, tc.hasProvisioner, findCheck(report, "Has a provisioner set").Assertion)
+			assert.Equal(t, tc.noProvisionReason, findCheck(report, "If the provisioner intentionally provisions nothing, it has a reason set").Assertion)
+		})
+	}
+}
+
+func TestCredentialTypeValidateRequiredSecretField(t *testing.T) {
+	hasRequiredSecret := func(fields []CredentialField) bool {
+		_, report := CredentialType{Fields: fields}.Validate()
+		for _, c := range report.Checks {
+			if c.Description == "Has at least 1 field that is secret and not optional" {
+				return c.Assertion
+			}
+		}
+		t.Fatal("no check found for secret fields")
+		return false
+	}
+
+	assert.True(t, hasRequiredSecret([]CredentialField{
+		{Name: fieldname.Token, Secret: true},
+		{Name: fieldname.Region, Optional: true},
+	}))
+	assert.False(t, hasRequiredSecret([]CredentialField{
+		{Name: fieldname.Username},
+		{Name: fieldname.Password, Secret: true, Optional: true},
+	}))
+	assert.False(t, hasRequiredSecret([]CredentialField{
+		{Name: fieldname.Username},
+	}))
+}
+
+func TestCredentialTypeValidateFieldSets(t *testing.T) {
+	fields := []CredentialField{
+		{Name: fieldname.Token, Secret: true},
+		{Name: fieldname.Username},
+		{Name: fieldname.Password, Secret: true},
+		{Name: fieldname.Host, Optional: true},
+	}
+	for name, tc := range map[string]struct {
+		fieldSets         [][]sdk.FieldName
+		expected          bool
+		description       string
+		hasRequiredSecret bool
+	}{
+		"valid": {
+			fieldSets:         [][]sdk.FieldName{{fieldname.Token}, {fieldname.Username, fieldname.Password}},
+			expected:          true,
+			description:       "If defined, the field sets are not empty, only contain required fields of the credential type, and don't include each other",
+			hasRequiredSecret: true,
+		},
+		"shared field": {
+			fieldSets:         [][]sdk.FieldName{{fieldname.Username, fieldname.Token}, {fieldname.Username, fieldname.Password}},
+			expected:          true,
+			description:       "If defined, the field sets are not empty, only contain required fields of the credential type, and don't include each other",
+			hasRequiredSecret: true,
+		},
+		"set without secret": {
+			fieldSets:         [][]sdk.FieldName{{fieldname.Token}, {fieldname.Username}, {fieldname.Password}},
+			expected:          true,
+			description:       "If defined, the field sets are not empty, only contain required fields of the credential type, and don't include each other",
+			hasRequiredSecret: false,
+		},
+		"empty set": {
+			fieldSets:   [][]sdk.FieldName{{fieldname.Token}, {}},
+			expected:    false,
+			description: "If defined, the field sets are not empty, only contain required fields of the credential type, and don't include each other: empty field set",
+		},
+		"unknown and optional fields": {
+			fieldSets:   [][]sdk.FieldName{{fieldname.Token}, {fieldname.Username, fieldname.Password, fieldname.Host, fieldname.APIKey}},
+			expected:    false,
+			description: "If defined, the field sets are not empty, only contain required fields of the credential type, and don't include each other: optional field Host, unknown field API Key",
+		},
+		"field listed twice": {
+			fieldSets:   [][]sdk.FieldName{{fieldname.Token, fieldname.Token}},
+			expected:    false,
+			description: "If defined, the field sets are not empty, only contain required fields of the credential type, and don't include each other: field set Token and Token lists a field more than once",
+		},
+		"set includes another": {
+			fieldSets:   [][]sdk.FieldName{{fieldname.Password}, {fieldname.Username, fieldname.Password}},
+			expected:    false,
+			description: "If defined, the field sets are not empty, only contain required fields of the credential type, and don't include each other: field set Username and Password includes field set Password",
+		},
+		"same set twice": {
+			fieldSets:   [][]sdk.FieldName{{fieldname.Token}, {fieldname.Token}},
+			expected:    false,
+			description: "If defined, the field sets are not empty, only contain required fields of the credential type, and don't include each other: field set Token includes field set Token",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, report := CredentialType{Fields: fields, FieldSets: tc.fieldSets}.Validate()
+
+			var found bool
+			for _, c := range report.Checks {
+				if strings.HasPrefix(c.Description, "If defined, the field sets") {
+					found = true
+					assert.Equal(t, tc.expected, c.Assertion)
+					assert.Equal(t, tc.description, c.Description)
+				}
+				if tc.expected && c.Code == CodeCredentialNoRequiredSecretField {
+					assert.Equal(t, tc.hasRequiredSecret, c.Assertion)
+				}
+			}
+			assert.True(t, found)
+		})
+	}
+}
+
+func TestCredentialTypeValidateSecretDefaults(t *testing.T) {
+	check := func(fields []CredentialField) ValidationCheck {
+		_, report := CredentialType{Fields: fields}.Validate()
+		for _, c := range report.Checks {
+			if strings.HasPrefix(c.Description, "Secret fields have no default value") {
+				return c
+			}
+		}
+		t.Fatal("no check found for default values of secret fields")
+		return ValidationCheck{}
+	}
+
+	c := check([]CredentialField{
+		{Name: fieldname.Password, Secret: true},
+		{Name: fieldname.Port, DefaultValue: "5432"},
+	})
+	assert.True(t, c.Assertion)
+
+	c = check([]CredentialField{
+		{Name: fieldname.Password, Secret: true, DefaultValue: "postgres"},
+		{Name: fieldname.Port, DefaultValue: "5432"},
+	})
+	assert.False(t, c.Assertion)
+	assert.Equal(t, "Secret fields have no default value: Password", c.Description)
+}
+
+func TestCredentialTypeValidateAllowedDefaults(t *testing.T) {
+	check := func(fields []CredentialField) ValidationCheck {
+		_, report := CredentialType{Fields: fields}.Validate()
+		for _, c := range report.Checks {
+			if strings.HasPrefix(c.Description, "Default values are one of the allowed values of their field") {
+				return c
+			}
+		}
+		t.Fatal("no check found for default values of fields with allowed values")
+		return ValidationCheck{}
+	}
+
+	assert.True(t, check([]CredentialField{
+		{Name: fieldname.Region, AllowedValues: []string{"us-east-1", "eu-west-1"}, DefaultValue: "us-east-1"},
+		{Name: fieldname.Host, DefaultValue: "localhost"},
+	}).Assertion)
+
+	c := check([]CredentialField{
+		{Name: fieldname.Region, AllowedValues: []string{"us-east-1", "eu-west-1"}, DefaultValue: "us-east1"},
+		{Name: fieldname.APIHost, AllowedValues: []string{"datadoghq.com"}, AllowOther: true, DefaultValue: "datadoghq.eu"},
+	})
+	assert.False(t, c.Assertion)
+	assert.Equal(t, "Default values are one of the allowed values of their field: Region, API Host", c.Description)
+}
+
+func TestCredentialTypeValidateExpiry(t *testing.T) {
+	fields := []CredentialField{
+		{Name: fieldname.Token, Secret: true},
+		{Name: fieldname.SessionToken, Secret: true},
+		{Name: fieldname.Region},
+	}
+	expiryChecks := []string{
+		"Only expirable credential types have a default TTL or expiry field set",
+		"Default TTL is not negative",
+		"If set, the expiry field is one of the non-secret fields",
+	}
+	failingChecks := func(c CredentialType) []string {
+		c.Fields = fields
+		_, report := c.Validate()
+		var failing []string
+		for _, check := range report.Checks {
+			for _, description := range expiryChecks {
+				if check.Description == description && !check.Assertion {
+					failing = append(failing, check.Description)
+				}
+			}
+		}
+		return failing
+	}
+
+	assert.Empty(t, failingChecks(CredentialType{}))
+	assert.Empty(t, failingChecks(CredentialType{Expirable: true}))
+	assert.Empty(t, failingChecks(CredentialType{Expirable: true, DefaultTTL: time.Hour, ExpiryField: fieldname.Region}))
+
+	assert.Equal(t, []string{"Only expirable credential types have a default TTL or expiry field set"}, failingChecks(CredentialType{DefaultTTL: time.Hour}))
+	assert.Equal(t, []string{"Only expirable credential types have a default TTL or expiry field set"}, failingChecks(CredentialType{ExpiryField: fieldname.Region}))
+	assert.Equal(t, []string{"Default TTL is not negative"}, failingChecks(CredentialType{Expirable: true, DefaultTTL: -time.Hour}))
+	assert.Equal(t, []string{"If set, the expiry field is one of the non-secret fields"}, failingChecks(CredentialType{Expirable: true, ExpiryField: fieldname.SessionToken}))
+	assert.Equal(t, []string{"If set, the expiry field is one of the non-secret fields"}, failingChecks(CredentialType{Expirable: true, ExpiryField: fieldname.Host}))
+}
+
+func TestCredentialTypeValidateProvisionerConfiguration(t *testing.T) {
+	for name, tc := range map[string]struct {
+		provisioner sdk.Provisioner
+		expected    bool
+	}{
+		"no validation": {
+			provisioner: provision.EnvVars(map[string]sdk.FieldName{"TOKEN": fieldname.Token}),
+			expected:    true,
+		},
+		"known encoding": {
+			provisioner: provision.EnvVarsWithOptions(map[string]provision.EnvVarSpec{
+				"TOKEN": {Field: fieldname.Token, Encoding: provision.EncodingBase64},
+			}),
+			expected: true,
+		},
+		"unknown encoding": {
+			provisioner: provision.EnvVarsWithOptions(map[string]provision.EnvVarSpec{
+				"TOKEN": {Field: fieldname.Token, Encoding: "base32"},
+			}),
+			expected: false,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, report := CredentialType{DefaultProvisioner: tc.provisioner}.Validate()
+			for _, c := range report.Checks {
+				if c.Description == "Provisioner configuration is valid" {
+					assert.Equal(t, tc.expected, c.Assertion)
+				}
+			}
+
+			_, usageReport := CredentialUsage{Name: "API Token", Provisioner: tc.provisioner}.Validate()
+			assert.Equal(t, tc.expected, usageReport.IsValid())
+		})
+	}
+}
+
+func TestExecutableValidateNeedsAuth(t *testing.T) {
+	for name, tc := range map[string]struct {
+		needsAuth   sdk.NeedsAuthentication
+		expected    bool
+		description string
+	}{
+		"not set": {
+			needsAuth:   nil,
+			expected:    true,
+			description: "If defined, the rules for which commands need authentication are valid",
+		},
+		"valid": {
+			needsAuth:   needsauth.IfAll(needsauth.NotForHelpOrVersion(), needsauth.NotMatchingRegexp(`(^| )--local( |$)`)),
+			expected:    true,
+			description: "If defined, the rules for which commands need authentication are valid",
+		},
+		"invalid regexp nested in rules": {
+			needsAuth: needsauth.IfAll(
+				needsauth.NotWithoutArgs(),
+				needsauth.IfAny(needsauth.ForCommand("deploy"), needsauth.MatchingRegexp(`^s3 (ls`)),
+			),
+			expected:    false,
+			description: "If defined, the rules for which commands need authentication are valid: invalid regular expression in needsauth.MatchingRegexp: error parsing regexp: missing closing ): `^s3 (ls`",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, report := Executable{NeedsAuth: tc.needsAuth}.Validate()
+			_, usageReport := CredentialUsage{Name: "API Token", NeedsAuth: tc.needsAuth}.Validate()
+
+			for _, r := range []ValidationReport{report, usageReport} {
+				var found bool
+				for _, c := range r.Checks {
+					if strings.HasPrefix(c.Description, "If defined, the rules for which commands need authentication are valid") {
+						found = true
+						assert.Equal(t, tc.expected, c.Assertion)
+						assert.Equal(t, tc.description, c.Description)
+					}
+				}
+				assert.True(t, found)
+			}
+		})
+	}
+}
+
+func TestExecutableValidateSelectCredentials(t *testing.T) {
+	uses := []CredentialUsage{{Name: "Publish Token"}, {Name: "Read Token", Plugin: "registry"}, {SelectFrom: &CredentialSelection{ID: "other", IncludeAllCredentials: true}}}
+
+	for name, tc := range map[string]struct {
+		selector    sdk.CredentialSelector
+		expected    bool
+		description string
+	}{
+		"not set": {
+			selector:    nil,
+			expected:    true,
+			description: "If defined, the credential selection only selects credentials the executable uses",
+		},
+		"valid": {
+			selector: needsauth.SelectCredentials(
+				needsauth.UseCredential("Publish Token").ForCommands("publish"),
+				needsauth.UseCredential("Read Token").ForCommands("install"),
+			),
+			expected:    true,
+			description: "If defined, the credential selection only selects credentials the executable uses",
+		},
+		"credential not used": {
+			selector: needsauth.SelectCredentials(
+				needsauth.UseCredential("Publish Token").ForCommands("publish"),
+				needsauth.UseCredential("Access Token").ForCommands("whoami"),
+				needsauth.UseCredential("API Key"),
+			),
+			expected:    false,
+			description: "If defined, the credential selection only selects credentials the executable uses: not used: Access Token, API Key",
+		},
+		"invalid rule": {
+			selector: needsauth.SelectCredentials(
+				needsauth.UseCredential("Publish Token").When(needsauth.MatchingRegexp(`^publish (`)),
+			),
+			expected:    false,
+			description: "If defined, the credential selection only selects credentials the executable uses: invalid regular expression in needsauth.MatchingRegexp: error parsing regexp: missing closing ): `^publish (`",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, report := Executable{Uses: uses, SelectCredentials: tc.selector}.Validate()
+
+			var found bool
+			for _, c := range report.Checks {
+				if strings.HasPrefix(c.Description, "If defined, the credential selection") {
+					found = true
+					assert.Equal(t, tc.expected, c.Assertion)
+					assert.Equal(t, tc.description, c.Description)
+				}
+			}
+			assert.True(t, found)
+		})
+	}
+}
+
+func TestExecutableValidateAliases(t *testing.T) {
+	for name, tc := range map[string]struct {
+		aliases     [][]string
+		expected    bool
+		description string
+	}{
+		"not set": {
+			aliases:     nil,
+			expected:    true,
+			description: "If defined, the aliases are set and differ from each other and from the executable command",
+		},
+		"valid": {
+			aliases:     [][]string{{"fdfind"}, {"fd-find"}},
+			expected:    true,
+			description: "If defined, the aliases are set and differ from each other and from the executable command",
+		},
+		"empty alias": {
+			aliases:     [][]string{{"fdfind"}, {}},
+			expected:    false,
+			description: "If defined, the aliases are set and differ from each other and from the executable command",
+		},
+		"same as the executable command": {
+			aliases:     [][]string{{"fd"}},
+			expected:    false,
+			description: "If defined, the aliases are set and differ from each other and from the executable command: duplicates: fd",
+		},
+		"duplicate aliases": {
+			aliases:     [][]string{{"fdfind"}, {"fd-find"}, {"fdfind"}},
+			expected:    false,
+			description: "If defined, the aliases are set and differ from each other and from the executable command: duplicates: fdfind",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, report := Executable{Runs: []string{"fd"}, Aliases: tc.aliases}.Validate()
+
+			var found bool
+			for _, c := range report.Checks {
+				if strings.HasPrefix(c.Description, "If defined, the aliases") {
+					found = true
+					assert.Equal(t, tc.expected, c.Assertion)
+					assert.Equal(t, tc.description, c.Description)
+				}
+			}
+			assert.True(t, found)
+		})
+	}
+}
+
+func TestDuplicateCommands(t *testing.T) {
+	terraform := Plugin{Executables: []Executable{{Runs: []string{"terraform"}}}}
+	opentofu := Plugin{Executables: []Executable{{Runs: []string{"tofu"}, Aliases: [][]string{{"opentofu"}}}}}
+	fork := Plugin{Executables: []Executable{{Runs: []string{"terraform-fork"}, Aliases: [][]string{{"terraform"}, {"tofu"}}}}}
+
+	assert.Empty(t, DuplicateCommands(terraform, opentofu))
+	assert.Equal(t, []string{"terraform", "tofu"}, DuplicateCommands(terraform, opentofu, fork))
+
+	// Duplicates within an executable are reported by its own validation.
+	assert.Empty(t, DuplicateCommands(Plugin{Executables: []Executable{{Runs: []string{"fd"}, Aliases: [][]string{{"fd"}}}}}))
+}
+
+func TestPluginValidateUniqueCommands(t *testing.T) {
+	plugin := Plugin{
+		Name: "fd",
+		Executables: []Executable{
+			{Runs: []string{"fd"}, Aliases: [][]string{{"fdfind"}}},
+			{Runs: []string{"fdfind"}},
+		},
+	}
+	_, report := plugin.Validate()
+
+	var found bool
+	for _, c := range report.Checks {
+		if strings.HasPrefix(c.Description, "Executable commands and aliases are unique inside a plugin") {
+			found = true
+			assert.False(t, c.Assertion)
+			assert.Equal(t, "Executable commands and aliases are unique inside a plugin: duplicates: fdfind", c.Description)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestExecutableAliasesSerialization(t *testing.T) {
+	// The plugin schema gets sent to the client with gob, see rpc/server.
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(Executable{Runs: []string{"bat"}, Aliases: [][]string{{"batcat"}}}))
+
+	var decoded Executable
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+	assert.Equal(t, []string{"bat", "batcat"}, decoded.Commands())
+}
+
+func TestExecutableValidateMinimumVersion(t *testing.T) {
+	for name, tc := range map[string]struct {
+		minimumVersion string
+		versionPattern string
+		expected       bool
+		description    string
+	}{
+		"not set": {
+			expected:    true,
+			description: "If defined, the minimum version is a valid semantic version and the version pattern is a valid regular expression",
+		},
+		"valid": {
+			minimumVersion: "2.20.0-rc.1",
+			versionPattern: `gh version (\S+)`,
+			expected:       true,
+			description:    "If defined, the minimum version is a valid semantic version and the version pattern is a valid regular expression",
+		},
+		"invalid version": {
+			minimumVersion: "2.x",
+			expected:       false,
+			description:    "If defined, the minimum version is a valid semantic version and the version pattern is a valid regular expression: '2.x' is not a valid semantic version",
+		},
+		"invalid pattern": {
+			minimumVersion: "2.20",
+			versionPattern: `version (`,
+			expected:       false,
+			description:    "If defined, the minimum version is a valid semantic version and the version pattern is a valid regular expression: error parsing regexp: missing closing ): `version (`",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, report := Executable{Runs: []string{"gh"}, MinimumVersion: tc.minimumVersion, VersionPattern: tc.versionPattern}.Validate()
+
+			var found bool
+			for _, c := range report.Checks {
+				if strings.HasPrefix(c.Description, "If defined, the minimum version") {
+					found = true
+					assert.Equal(t, tc.expected, c.Assertion)
+					assert.Equal(t, tc.description, c.Description)
+				}
+			}
+			assert.True(t, found)
+		})
+	}
+}
+
+func TestExecutableValidatePreferences(t *testing.T) {
+	for name, tc := range map[string]struct {
+		uses        []CredentialUsage
+		expected    bool
+		description string
+	}{
+		"not set": {
+			uses:        []CredentialUsage{{Name: credname.PersonalAccessToken}, {Name: credname.AccessToken}},
+			expected:    true,
+			description: "Credential usages that set a preference have distinct preferences",
+		},
+		"distinct": {
+			uses:        []CredentialUsage{{Name: credname.PersonalAccessToken, Preference: 1}, {Name: credname.AccessToken, Preference: 2}, {Name: credname.APIKey}},
+			expected:    true,
+			description: "Credential usages that set a preference have distinct preferences",
+		},
+		"tied": {
+			uses:        []CredentialUsage{{Name: credname.PersonalAccessToken, Preference: 1}, {Name: credname.AccessToken, Preference: 1}, {Name: credname.APIKey}},
+			expected:    false,
+			description: "Credential usages that set a preference have distinct preferences: tied: personal_access_token, access_token",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, report := Executable{Uses: tc.uses}.Validate()
+
+			var found bool
+			for _, c := range report.Checks {
+				if strings.HasPrefix(c.Description, "Credential usages that set a preference") {
+					found = true
+					assert.Equal(t, tc.expected, c.Assertion)
+					assert.Equal(t, tc.description, c.Description)
+				}
+			}
+			assert.True(t, found)
+		})
+	}
+}
+
+func TestExecutableUsagesByPreference(t *testing.T) {
+	classic := CredentialUsage{Name: credname.PersonalAccessToken}
+	fineGrained := CredentialUsage{Name: credname.AccessToken, Preference: 2}
+	app := CredentialUsage{Name: credname.AppToken, Preference: 1}
+	other := CredentialUsage{SelectFrom: &CredentialSelection{ID: "other", IncludeAllCredentials: true}}
+	executable := Executable{Uses: []CredentialUsage{classic, fineGrained, other, app}}
+
+	assert.Equal(t, []CredentialUsage{fineGrained, app, classic, other}, executable.UsagesByPreference())
+	assert.Equal(t, []CredentialUsage{classic, fineGrained, other, app}, executable.Uses, "the usages themselves are not reordered")
+
+	// The order of the usages and their preferences survive the serialization to the client, which uses gob, so that
+	// the client gets the same order.
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(executable))
+	var decoded Executable
+	require.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+	assert.Equal(t, executable.Uses, decoded.Uses)
+	assert.Equal(t, executable.UsagesByPreference(), decoded.UsagesByPreference())
+}
+
+func TestExecutableUsagesToProvision(t *testing.T) {
+	publish := CredentialUsage{Name: "Publish Token"}
+	read := CredentialUsage{Name: "Read Token", Plugin: "registry"}
+	other := CredentialUsage{SelectFrom: &CredentialSelection{ID: "other", IncludeAllCredentials: true}}
+	executable := Executable{Uses: []CredentialUsage{publish, read, other}}
+
+	assert.Equal(t, []CredentialUsage{publish, read, other}, executable.UsagesToProvision(nil))
+	assert.Equal(t, []CredentialUsage{publish, other}, executable.UsagesToProvision([]sdk.CredentialName{"Publish Token"}))
+	assert.Equal(t, []CredentialUsage{publish, read, other}, executable.UsagesToProvision([]sdk.CredentialName{"Read Token", "Publish Token"}))
+}
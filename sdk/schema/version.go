@@ -0,0 +1,169 @@
+package schema
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"golang.org/x/mod/semver"
+)
+
+// versionCommandTimeout is how long the version command of an executable gets to print its version.
+const versionCommandTimeout = 5 * time.Second
+
+// defaultVersionPattern matches the first semantic version in the output of a version command, with or without a "v"
+// prefix, e.g. "2.20.1" in "gh version 2.20.1 (2022-12-07)". The patch version may be omitted.
+var defaultVersionPattern = regexp.MustCompile(`\bv?(\d+\.\d+(?:\.\d+)?(?:-[0-9A-Za-z.-]+)?)`)
+
+// Version is a semantic version in its canonical form, e.g. "v2.20.0" or "v1.0.0-rc.1". Use ParseVersion to create
+// one. Build metadata is dropped, since it doesn't affect the order of versions.
+type Version string
+
+// ParseVersion parses a semantic version, with or without a "v" prefix. The minor and patch version may be omitted,
+// so "2.20" parses as "v2.20.0".
+func ParseVersion(version string) (Version, error) {
+	normalized := strings.TrimSpace(version)
+	if !strings.HasPrefix(normalized, "v") {
+		normalized = "v" + normalized
+	}
+	if !semver.IsValid(normalized) {
+		return "", fmt.Errorf("'%s' is not a valid semantic version", version)
+	}
+	return Version(semver.Canonical(normalized)), nil
+}
+
+// Compare returns -1, 0 or +1 depending on whether v is older than, the same as or newer than other. Pre-release
+// versions are older than the release they precede, e.g. "v2.20.0-rc.1" is older than "v2.20.0".
+func (v Version) Compare(other Version) int {
+	return semver.Compare(string(v), string(other))
+}
+
+// AtLeast returns whether v is the same as or newer than minimum.
+func (v Version) AtLeast(minimum Version) bool {
+	return v.Compare(minimum) >= 0
+}
+
+// String returns the version without its "v" prefix, e.g. "2.20.0", since that's how most CLIs print it.
+func (v Version) String() string {
+	return strings.TrimPrefix(string(v), "v")
+}
+
+// ExtractVersion finds the version in the output of a version command, which can contain a banner around it, e.g.
+// "gh version 2.20.1 (2022-12-07)". If the pattern has a capture group, the version is the text of the first group,
+// otherwise it's the whole match. Without a pattern, the first semantic version in the output is used.
+func ExtractVersion(output string, pattern *regexp.Regexp) (Version, error) {
+	if pattern == nil {
+		pattern = defaultVersionPattern
+	}
+	match := pattern.FindStringSubmatch(output)
+	if match == nil {
+		return "", fmt.Errorf("no version found in output '%s'", strings.TrimSpace(output))
+	}
+	if len(match) > 1 {
+		return ParseVersion(match[1])
+	}
+	return ParseVersion(match[0])
+}
+
+// DetectVersion runs the version command of the executable and extracts the installed version from its output. The
+// output on stdout and stderr is combined, since some CLIs print their version banner on stderr. The executable is
+// resolved from the PATH and gets killed if it doesn't print its version within 5 seconds.
+func (e Executable) DetectVersion(ctx context.Context) (Version, error) {
+	cmd := e.versionCommand()
+	if len(cmd) == 0 {
+		return "", errors.New("no version command specified")
+	}
+	var pattern *regexp.Regexp
+	if e.VersionPattern != "" {
+		var err error
+		pattern, err = regexp.Compile(e.VersionPattern)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	path, err := exec.LookPath(cmd[0])
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, versionCommandTimeout)
+	defer cancel()
+
+	var output bytes.Buffer
+	command := exec.CommandContext(ctx, path, cmd[1:]...)
+	command.Stdout = &output
+	command.Stderr = &output
+	if err := command.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("'%s' did not finish within %s", strings.Join(cmd, " "), versionCommandTimeout)
+		}
+		return "", fmt.Errorf("'%s' failed: %w", strings.Join(cmd, " "), err)
+	}
+	return ExtractVersion(output.String(), pattern)
+}
+
+// CheckMinimumVersion detects the installed version of the executable and adds a warning to the diagnostics if it's
+// older than the MinimumVersion of the executable, so that the user knows why provisioning might not work. If the
+// version can't be detected, that's added as a note instead. Nothing is run if the executable has no minimum version.
+func (e Executable) CheckMinimumVersion(ctx context.Context, diagnostics *sdk.Diagnostics) {
+	if e.MinimumVersion == "" {
+		return
+	}
+	minimum, err := ParseVersion(e.MinimumVersion)
+	if err != nil {
+		diagnostics.Notes = append(diagnostics.Notes, sdk.Note{Message: fmt.Sprintf("could not check the version of %s: %s", e.Command(), err)})
+		return
+	}
+	installed, err := e.DetectVersion(ctx)
+	if err != nil {
+		diagnostics.Notes = append(diagnostics.Notes, sdk.Note{Message: fmt.Sprintf("could not detect the version of %s: %s", e.Command(), err)})
+		return
+	}
+	if !installed.AtLeast(minimum) {
+		diagnostics.Warnings = append(diagnostics.Warnings, sdk.Warning{
+			Message: fmt.Sprintf("your %s is %s, the %s plugin needs %s or newer", e.Command(), installed, e.Name, minimum),
+		})
+	}
+}
+
+// versionCommand returns the VersionCommand of the executable, or the executable command with "--version" appended.
+func (e Executable) versionCommand() []string {
+	if len(e.VersionCommand) > 0 {
+		return e.VersionCommand
+	}
+	if len(e.Runs) == 0 {
+		return nil
+	}
+	return append(append([]string{}, e.Runs...), "--version")
+}
+
+// minimumVersionCheck checks that the minimum version of the executable, if defined, is a valid semantic version and
+// that the pattern to find the version in the output of the version command is a valid regular expression.
+func minimumVersionCheck(e Executable) ValidationCheck {
+	check := ValidationCheck{
+		Code:        CodeExecutableInvalidMinimumVersion,
+		Description: "If defined, the minimum version is a valid semantic version and the version pattern is a valid regular expression",
+		Assertion:   true,
+		Severity:    ValidationSeverityError,
+	}
+	if e.MinimumVersion != "" {
+		if _, err := ParseVersion(e.MinimumVersion); err != nil {
+			check.Description += ": " + err.Error()
+			check.Assertion = false
+		}
+	}
+	if e.VersionPattern != "" {
+		if _, err := regexp.Compile(e.VersionPattern); err != nil {
+			check.Description += ": " + err.Error()
+			check.Assertion = false
+		}
+	}
+	return check
+}
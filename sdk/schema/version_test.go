@@ -0,0 +1,191 @@
+package schema
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+
+	"github.com/1Password/shell-plugins/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// installStubExecutable puts an executable with the specified shell script on the PATH.
+func installStubExecutable(t *testing.T, name string, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("stub executables are shell scripts")
+	}
+
+	binDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, name), []byte("#!/bin/sh\n"+script+"\n"), 0700))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestParseVersion(t *testing.T) {
+	for version, expected := range map[string]Version{
+		"2.20.0":             "v2.20.0",
+		"v2.20.0":            "v2.20.0",
+		"2.20":               "v2.20.0",
+		"2":                  "v2.0.0",
+		" 1.0.0\n":           "v1.0.0",
+		"1.0.0-rc.1":         "v1.0.0-rc.1",
+		"1.0.0-beta+exp.sha": "v1.0.0-beta",
+	} {
+		t.Run(version, func(t *testing.T) {
+			parsed, err := ParseVersion(version)
+			require.NoError(t, err)
+			assert.Equal(t, expected, parsed)
+		})
+	}
+
+	for _, version := range []string{"", "latest", "2.x", "1.2.3.4", "01.2.3", "vv1.2.3"} {
+		t.Run(version, func(t *testing.T) {
+			_, err := ParseVersion(version)
+			assert.EqualError(t, err, "'"+version+"' is not a valid semantic version")
+		})
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	for _, tc := range []struct {
+		older string
+		newer string
+	}{
+		{older: "2.1.0", newer: "2.20.0"},
+		{older: "2.20.0", newer: "2.20.1"},
+		{older: "1.99.99", newer: "2.0.0"},
+		{older: "2.20.0-rc.1", newer: "2.20.0"},
+		{older: "2.20.0-alpha", newer: "2.20.0-beta"},
+		{older: "2.20.0-rc.2", newer: "2.20.0-rc.10"},
+		{older: "2.19.9", newer: "2.20.0-rc.1"},
+	} {
+		t.Run(tc.older+" < "+tc.newer, func(t *testing.T) {
+			older, err := ParseVersion(tc.older)
+			require.NoError(t, err)
+			newer, err := ParseVersion(tc.newer)
+			require.NoError(t, err)
+
+			assert.Equal(t, -1, older.Compare(newer))
+			assert.Equal(t, 1, newer.Compare(older))
+			assert.Equal(t, 0, older.Compare(older))
+			assert.False(t, older.AtLeast(newer))
+			assert.True(t, newer.AtLeast(older))
+			assert.True(t, newer.AtLeast(newer))
+		})
+	}
+}
+
+func TestVersionString(t *testing.T) {
+	version, err := ParseVersion("v2.20.0-rc.1")
+	require.NoError(t, err)
+	assert.Equal(t, "2.20.0-rc.1", version.String())
+}
+
+func TestExtractVersion(t *testing.T) {
+	for name, tc := range map[string]struct {
+		output   string
+		pattern  *regexp.Regexp
+		expected Version
+	}{
+		"plain version": {
+			output:   "2.20.1\n",
+			expected: "v2.20.1",
+		},
+		"banner": {
+			output:   "gh version 2.20.1 (2022-12-07)\nhttps://github.com/cli/cli/releases/tag/v2.20.1\n",
+			expected: "v2.20.1",
+		},
+		"v prefix": {
+			output:   "Terraform v1.3.6\non darwin_arm64\n",
+			expected: "v1.3.6",
+		},
+		"pre-release": {
+			output:   "example-cli v3.0.0-beta.2 (build 1234)",
+			expected: "v3.0.0-beta.2",
+		},
+		"without patch version": {
+			output:   "tool 1.4",
+			expected: "v1.4.0",
+		},
+		"pattern with capture group": {
+			output:   "Python 3.11.1\npip 22.3.1 from /usr/lib/python3/site-packages",
+			pattern:  regexp.MustCompile(`pip (\S+)`),
+			expected: "v22.3.1",
+		},
+		"pattern without capture group": {
+			output:   "aws-cli/2.9.8 Python/3.9.11 Darwin/22.2.0",
+			pattern:  regexp.MustCompile(`\d+\.\d+\.\d+`),
+			expected: "v2.9.8",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			version, err := ExtractVersion(tc.output, tc.pattern)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, version)
+		})
+	}
+
+	_, err := ExtractVersion("unknown command: --version\n", nil)
+	assert.EqualError(t, err, "no version found in output 'unknown command: --version'")
+
+	_, err = ExtractVersion("version: latest", regexp.MustCompile(`version: (\S+)`))
+	assert.EqualError(t, err, "'latest' is not a valid semantic version")
+}
+
+func TestExecutableDetectVersion(t *testing.T) {
+	installStubExecutable(t, "stub-cli", `[ "$1" = "--version" ] && echo "stub-cli version 2.1.0" >&2`)
+	version, err := Executable{Runs: []string{"stub-cli"}}.DetectVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Version("v2.1.0"), version)
+
+	installStubExecutable(t, "other-cli", `[ "$1" = "version" ] && echo "other-cli 1.0.0, API 3.2.0"`)
+	version, err = Executable{
+		Runs:           []string{"other-cli"},
+		VersionCommand: []string{"other-cli", "version"},
+		VersionPattern: `API (\S+)`,
+	}.DetectVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, Version("v3.2.0"), version)
+
+	installStubExecutable(t, "failing-cli", `exit 2`)
+	_, err = Executable{Runs: []string{"failing-cli"}}.DetectVersion(context.Background())
+	assert.EqualError(t, err, "'failing-cli --version' failed: exit status 2")
+}
+
+func TestExecutableCheckMinimumVersion(t *testing.T) {
+	installStubExecutable(t, "stub-cli", `echo "stub-cli version 2.1.0"`)
+
+	for name, tc := range map[string]struct {
+		executable Executable
+		expected   sdk.Diagnostics
+	}{
+		"no minimum version": {
+			executable: Executable{Name: "Stub CLI", Runs: []string{"stub-cli"}},
+		},
+		"new enough": {
+			executable: Executable{Name: "Stub CLI", Runs: []string{"stub-cli"}, MinimumVersion: "2.1"},
+		},
+		"too old": {
+			executable: Executable{Name: "Stub CLI", Runs: []string{"stub-cli"}, MinimumVersion: "2.20.0"},
+			expected: sdk.Diagnostics{
+				Warnings: []sdk.Warning{{Message: "your stub-cli is 2.1.0, the Stub CLI plugin needs 2.20.0 or newer"}},
+			},
+		},
+		"not installed": {
+			executable: Executable{Name: "Missing CLI", Runs: []string{"missing-cli-for-version-check"}, MinimumVersion: "2.20.0"},
+			expected: sdk.Diagnostics{
+				Notes: []sdk.Note{{Message: `could not detect the version of missing-cli-for-version-check: exec: "missing-cli-for-version-check": executable file not found in $PATH`}},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var diagnostics sdk.Diagnostics
+			tc.executable.CheckMinimumVersion(context.Background(), &diagnostics)
+			assert.Equal(t, tc.expected, diagnostics)
+		})
+	}
+}